@@ -1,20 +1,61 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"godb/engine"
 	"godb/web"
+	"io/fs"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
-	server := web.NewServer(":8080")
+	dataFile := flag.String("data", "", "file the database is loaded from on startup and saved to on shutdown")
+	flag.Parse()
+
+	// Resume from the data file when it exists
+	db := engine.NewDatabase()
+	if *dataFile != "" {
+		loaded, err := engine.LoadFromFile(*dataFile)
+		switch {
+		case err == nil:
+			db = loaded
+		case !errors.Is(err, fs.ErrNotExist):
+			log.Fatalf("Failed to load %s: %v", *dataFile, err)
+		}
+	}
+
+	logger := engine.NewStdLogger(os.Stderr, engine.LevelInfo)
+	server := web.NewServerWithDB(db, web.Config{Addr: ":8080", DataFile: *dataFile, Logger: logger})
 
 	// Initialize database schema
 	if err := server.Initialize(); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Start server
-	if err := server.Start(); err != nil {
-		log.Fatalf("Server failed: %v", err)
+	errs := make(chan error, 1)
+	go func() { errs <- server.Start() }()
+
+	select {
+	case err := <-errs:
+		if err != nil {
+			log.Fatalf("Server failed: %v", err)
+		}
+	case <-ctx.Done():
+		// Give in-flight requests a few seconds, then save and exit
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Fatalf("Shutdown failed: %v", err)
+		}
 	}
 }
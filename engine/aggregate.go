@@ -0,0 +1,150 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Aggregate represents an aggregate function call in a SELECT
+type Aggregate struct {
+	Func     string // "COUNT", "SUM", "AVG", "MIN", "MAX"
+	Column   string // "*" for COUNT(*)
+	Distinct bool   // COUNT(DISTINCT column) counts each non-NULL value once
+	Alias    string // Output name given with AS, if any
+}
+
+// Name returns the output column name for the aggregate (e.g. "count", "avg_age",
+// "count_distinct_user_id")
+// An alias replaces the generated name
+func (a Aggregate) Name() string {
+	if a.Alias != "" {
+		return a.Alias
+	}
+	fn := strings.ToLower(a.Func)
+	if a.Column == "*" || a.Column == "" {
+		return fn
+	}
+	if a.Distinct {
+		fn += "_distinct"
+	}
+	return fmt.Sprintf("%s_%s", fn, a.Column)
+}
+
+// String renders the call as written, e.g. "COUNT(DISTINCT user_id) AS authors"
+func (a Aggregate) String() string {
+	arg := a.Column
+	if a.Distinct {
+		arg = "DISTINCT " + arg
+	}
+	call := fmt.Sprintf("%s(%s)", strings.ToUpper(a.Func), arg)
+	if a.Alias != "" {
+		call += " AS " + a.Alias
+	}
+	return call
+}
+
+// validateAggregate checks that an aggregate can be computed over a table
+func (t *Table) validateAggregate(agg Aggregate) error {
+	fn := strings.ToUpper(agg.Func)
+	switch fn {
+	case "COUNT", "SUM", "AVG", "MIN", "MAX":
+	default:
+		return ErrInvalidAggregate{Func: agg.Func, Column: agg.Column}
+	}
+
+	// DISTINCT only changes the result of COUNT, and * has nothing to be distinct over
+	if agg.Distinct && (fn != "COUNT" || agg.Column == "*") {
+		return ErrInvalidAggregate{Func: agg.Func, Column: agg.Column, Distinct: true}
+	}
+
+	if agg.Column == "*" {
+		if fn != "COUNT" {
+			return ErrInvalidAggregate{Func: agg.Func, Column: agg.Column}
+		}
+		return nil
+	}
+
+	col, ok := t.column(agg.Column)
+	if !ok {
+		return ErrColumnNotFound{TableName: t.name, ColumnName: agg.Column}
+	}
+
+	if (fn == "SUM" || fn == "AVG") && col.Type != TypeInt {
+		return ErrInvalidAggregate{Func: agg.Func, Column: agg.Column, Type: col.Type}
+	}
+
+	return nil
+}
+
+// aggregateRows computes aggregates over rows and returns a single result row
+func aggregateRows(rows []Row, aggregates []Aggregate) Row {
+	result := make(Row)
+	for _, agg := range aggregates {
+		result.Set(agg.Name(), computeAggregate(rows, agg))
+	}
+	return result
+}
+
+// computeAggregate computes a single aggregate, skipping NULL values
+// Aggregates other than COUNT return nil over an empty set
+func computeAggregate(rows []Row, agg Aggregate) interface{} {
+	fn := strings.ToUpper(agg.Func)
+
+	if fn == "COUNT" && agg.Column == "*" {
+		return len(rows)
+	}
+
+	count := 0
+	sum := 0
+	var extreme interface{}
+	var seen map[interface{}]bool
+	if agg.Distinct {
+		seen = make(map[interface{}]bool)
+	}
+
+	for _, row := range rows {
+		value, ok := row.Get(agg.Column)
+		if !ok || value == nil {
+			continue
+		}
+		if seen != nil {
+			if seen[value] {
+				continue
+			}
+			seen[value] = true
+		}
+		count++
+
+		switch fn {
+		case "SUM", "AVG":
+			if v, ok := value.(int); ok {
+				sum += v
+			}
+		case "MIN":
+			if extreme == nil || compareValues(value, extreme) < 0 {
+				extreme = value
+			}
+		case "MAX":
+			if extreme == nil || compareValues(value, extreme) > 0 {
+				extreme = value
+			}
+		}
+	}
+
+	switch fn {
+	case "COUNT":
+		return count
+	case "SUM":
+		if count == 0 {
+			return nil
+		}
+		return sum
+	case "AVG":
+		if count == 0 {
+			return nil
+		}
+		return float64(sum) / float64(count)
+	default:
+		return extreme
+	}
+}
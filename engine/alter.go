@@ -0,0 +1,336 @@
+package engine
+
+import (
+	"fmt"
+	"maps"
+)
+
+// AddColumn appends a column to a table's schema
+// Existing rows get NULL for the new column, so a NOT NULL column or a
+// PRIMARY KEY can only be added while the table is empty. UNIQUE and
+// PRIMARY KEY columns are indexed
+func (db *Database) AddColumn(tableName string, col Column) error {
+	if err := db.addColumn(tableName, col); err != nil {
+		return err
+	}
+	db.notifySchemaChange(SchemaEvent{Table: tableName, Kind: SchemaAlter, Column: col.Name})
+	return nil
+}
+
+// addColumn does the work of AddColumn under its locks
+func (db *Database) addColumn(tableName string, col Column) error {
+	table, err := db.GetTable(tableName)
+	if err != nil {
+		return err
+	}
+
+	// Parents of a new foreign key are read-locked before db.mu to respect the locking order
+	unlock := lockTables([]*Table{table}, db.parentsOf([]Column{col}))
+	defer unlock()
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if err := table.checkDropped(); err != nil {
+		return err
+	}
+	if err := db.validateNewColumn(table, col); err != nil {
+		return err
+	}
+
+	// The schema slice is replaced rather than appended to, since callers of
+	// Schema may still hold the old one
+	schema := make([]Column, len(table.schema), len(table.schema)+1)
+	copy(schema, table.schema)
+	table.schema = append(schema, col)
+
+	// Rows are shared with earlier Rows snapshots, so backfill copies
+	for i, row := range table.rows {
+		row = row.Copy()
+		row.Set(col.Name, nil)
+		table.rows[i] = row
+	}
+
+	if col.PrimaryKey {
+		table.primaryKey = col.Name
+	}
+	if col.AutoIncrement {
+		table.autoInc = col.Name
+	}
+	if col.PrimaryKey || col.Unique {
+		table.indexes[col.Name] = NewIndex(col.Name) // Every existing value is NULL
+	}
+
+	return nil
+}
+
+// validateNewColumn checks that a column can be added to a table
+// Callers must hold the table lock and db.mu
+func (db *Database) validateNewColumn(table *Table, col Column) error {
+	invalid := func(reason string) error {
+		return ErrInvalidColumnDefinition{TableName: table.name, ColumnName: col.Name, Reason: reason}
+	}
+
+	if table.hasColumn(col.Name) {
+		return invalid("column already exists")
+	}
+
+	populated := len(table.rows) > 0
+	if col.PrimaryKey {
+		if table.primaryKey != "" {
+			return ErrMultiplePrimaryKeys{TableName: table.name}
+		}
+		if populated {
+			return invalid("cannot add a PRIMARY KEY to a table with rows")
+		}
+	}
+	if col.NotNull && populated {
+		return invalid("cannot add a NOT NULL column without a default to a table with rows")
+	}
+	if col.AutoIncrement && (col.Type != TypeInt || !col.PrimaryKey) {
+		return invalid("AUTOINCREMENT requires an INT PRIMARY KEY")
+	}
+
+	schema := append(append([]Column{}, table.schema...), col)
+	if col.References != nil {
+		if err := db.validateForeignKey(table.name, schema, col); err != nil {
+			return err
+		}
+	}
+
+	if err := validateCheck(table.name, schema, col); err != nil {
+		return err
+	}
+	if col.Check != nil {
+		// Existing rows get NULL for the new column, which the check must accept
+		for _, row := range table.rows {
+			row = row.Copy()
+			row.Set(col.Name, nil)
+			if !satisfiesCheck(row, col.Check) {
+				return invalid("existing rows do not satisfy the CHECK constraint")
+			}
+		}
+	}
+
+	return nil
+}
+
+// RenameTable renames a table and repoints the foreign keys that reference it
+// The table moves to a new Table value: handles obtained from GetTable before
+// the rename fail with ErrTableNotFound, as if the table had been dropped
+func (db *Database) RenameTable(oldName, newName string) error {
+	if err := db.renameTable(oldName, newName); err != nil {
+		return err
+	}
+	db.notifySchemaChange(SchemaEvent{Table: newName, Kind: SchemaRename, From: oldName})
+	return nil
+}
+
+// renameTable does the work of RenameTable under its locks
+func (db *Database) renameTable(oldName, newName string) error {
+	table, err := db.GetTable(oldName)
+	if err != nil {
+		return err
+	}
+
+	// Referencing tables are write-locked since their schemas are rewritten
+	refs := db.referencing(oldName)
+	write := []*Table{table}
+	for _, ref := range refs {
+		write = append(write, ref.child)
+	}
+	unlock := lockTables(write, nil)
+	defer unlock()
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	// Another rename or drop may have won the race while the lock was awaited
+	if db.tables[oldName] != table {
+		return ErrTableNotFound{TableName: oldName}
+	}
+	if _, exists := db.tables[newName]; exists {
+		return ErrTableAlreadyExists{TableName: newName}
+	}
+
+	// A table created with a foreign key while the locks were awaited is not locked
+	locked := make(map[*Table]bool, len(write))
+	for _, t := range write {
+		locked[t] = true
+	}
+	for _, ref := range db.referencesTo(oldName) {
+		if !locked[ref.child] {
+			return fmt.Errorf("table '%s' gained a foreign key from '%s' during the rename", oldName, ref.child.name)
+		}
+	}
+
+	renamed := &Table{
+		name:       newName,
+		schema:     renameReferences(table.schema, oldName, newName),
+		rows:       table.rows,
+		primaryKey: table.primaryKey,
+		indexes:    table.indexes,
+		ordered:    table.ordered,
+		analysis:   table.analysis,
+		autoInc:    table.autoInc,
+		nextID:     table.nextID,
+		db:         table.db,
+		deferred:   table.deferred,
+	}
+	for _, ref := range refs {
+		if ref.child != table {
+			ref.child.schema = renameReferences(ref.child.schema, oldName, newName)
+		}
+	}
+
+	delete(db.tables, oldName)
+	db.tables[newName] = renamed
+	table.dropped = true
+	return nil
+}
+
+// renameReferences returns a copy of a schema whose foreign keys to oldName
+// reference newName instead
+// The schema and its ForeignKey values are copied, since callers of Schema may still hold them
+func renameReferences(schema []Column, oldName, newName string) []Column {
+	renamed := make([]Column, len(schema))
+	for i, col := range schema {
+		if col.References != nil && col.References.Table == oldName {
+			fk := *col.References
+			fk.Table = newName
+			col.References = &fk
+		}
+		renamed[i] = col
+	}
+	return renamed
+}
+
+// RenameColumn renames a column of a table
+// Rows, indexes, statistics, CHECK constraints and the foreign keys of other
+// tables that reference the column all follow the new name
+func (db *Database) RenameColumn(tableName, oldName, newName string) error {
+	if err := db.renameColumn(tableName, oldName, newName); err != nil {
+		return err
+	}
+	db.notifySchemaChange(SchemaEvent{Table: tableName, Kind: SchemaRename, Column: newName, From: oldName})
+	return nil
+}
+
+// renameColumn does the work of RenameColumn under its locks
+func (db *Database) renameColumn(tableName, oldName, newName string) error {
+	table, err := db.GetTable(tableName)
+	if err != nil {
+		return err
+	}
+
+	// Referencing tables are write-locked since their schemas are rewritten
+	write := []*Table{table}
+	for _, ref := range db.referencing(tableName) {
+		write = append(write, ref.child)
+	}
+	unlock := lockTables(write, nil)
+	defer unlock()
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if err := table.checkDropped(); err != nil {
+		return err
+	}
+	if !table.hasColumn(oldName) {
+		return ErrColumnNotFound{TableName: tableName, ColumnName: oldName}
+	}
+	if table.hasColumn(newName) {
+		return ErrInvalidColumnDefinition{TableName: tableName, ColumnName: newName, Reason: "column already exists"}
+	}
+
+	locked := make(map[*Table]bool, len(write))
+	for _, t := range write {
+		locked[t] = true
+	}
+	refs := db.referencesTo(tableName)
+	for _, ref := range refs {
+		if !locked[ref.child] {
+			return fmt.Errorf("table '%s' gained a foreign key from '%s' during the rename", tableName, ref.child.name)
+		}
+	}
+
+	rename := func(column string) string {
+		if column == oldName {
+			return newName
+		}
+		return column
+	}
+
+	// The schema slice and rows are replaced rather than modified, since
+	// callers of Schema and earlier Rows snapshots may still hold them
+	schema := renameReferencedColumn(table.schema, tableName, oldName, newName)
+	for i, col := range schema {
+		schema[i].Name = rename(col.Name)
+		schema[i].Check = col.Check.withColumns(rename)
+	}
+	table.schema = schema
+
+	for i, row := range table.rows {
+		if value, ok := row.Get(oldName); ok {
+			row = row.Copy()
+			delete(row, oldName)
+			row.Set(newName, value)
+			table.rows[i] = row
+		}
+	}
+
+	table.primaryKey = rename(table.primaryKey)
+	table.autoInc = rename(table.autoInc)
+	// Indexes move to the new name and are rebuilt in place, so existing
+	// *Index handles stay valid
+	if idx, ok := table.indexes[oldName]; ok {
+		delete(table.indexes, oldName)
+		idx.column = newName
+		table.indexes[newName] = idx
+	}
+	if table.deferred[oldName] {
+		delete(table.deferred, oldName)
+		table.deferred[newName] = true
+	}
+	if idx, ok := table.ordered[oldName]; ok {
+		delete(table.ordered, oldName)
+		idx.column = newName
+		table.ordered[newName] = idx
+	}
+	table.rebuildIndexes()
+
+	if table.analysis != nil {
+		if cardinality, ok := table.analysis.Cardinality[oldName]; ok {
+			analysis := *table.analysis
+			analysis.Cardinality = maps.Clone(table.analysis.Cardinality)
+			delete(analysis.Cardinality, oldName)
+			analysis.Cardinality[newName] = cardinality
+			table.analysis = &analysis
+		}
+	}
+
+	// A table referencing its own column was rewritten with its schema above
+	for _, ref := range refs {
+		if ref.child != table && ref.column.References.Column == oldName {
+			ref.child.schema = renameReferencedColumn(ref.child.schema, tableName, oldName, newName)
+		}
+	}
+	return nil
+}
+
+// renameReferencedColumn returns a copy of a schema whose foreign keys to
+// tableName.oldName reference newName instead
+// ForeignKey values are copied, since callers of Schema may still hold them
+func renameReferencedColumn(schema []Column, tableName, oldName, newName string) []Column {
+	renamed := make([]Column, len(schema))
+	for i, col := range schema {
+		if fk := col.References; fk != nil && fk.Table == tableName && fk.Column == oldName {
+			copied := *fk
+			copied.Column = newName
+			col.References = &copied
+		}
+		renamed[i] = col
+	}
+	return renamed
+}
@@ -0,0 +1,237 @@
+package engine
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ArchiveVersion is the format version written by SaveArchive
+// LoadArchive rejects archives written by a newer version
+const ArchiveVersion = 1
+
+// archive is the document stored, gzip-compressed, by SaveArchive
+type archive struct {
+	Version int            `json:"version"`
+	Tables  []archiveTable `json:"tables"`
+}
+
+// archiveTable holds one table's schema, index metadata and rows
+type archiveTable struct {
+	Name           string   `json:"name"`
+	Columns        []Column `json:"columns"`
+	Indexes        []string `json:"indexes,omitempty"`         // Hash indexes not implied by the schema
+	OrderedIndexes []string `json:"ordered_indexes,omitempty"` // Columns with an ordered index
+	NextID         int      `json:"next_id"`
+	Rows           []Row    `json:"rows"`
+}
+
+// SaveArchive writes every table's schema, rows and index metadata to w as a
+// single versioned, gzip-compressed JSON document for LoadArchive
+// All tables are read-locked together, so the archive is a consistent snapshot
+func (db *Database) SaveArchive(w io.Writer) error {
+	db.mu.RLock()
+	tables := make([]*Table, 0, len(db.tables))
+	for _, table := range db.tables {
+		tables = append(tables, table)
+	}
+	db.mu.RUnlock()
+
+	unlock := lockTables(nil, tables)
+	doc := archive{Version: ArchiveVersion}
+	for _, table := range orderByReferences(tables) {
+		if table.dropped {
+			continue
+		}
+		doc.Tables = append(doc.Tables, table.archive())
+	}
+	unlock()
+
+	zw := gzip.NewWriter(w)
+	if err := json.NewEncoder(zw).Encode(doc); err != nil {
+		zw.Close()
+		return err
+	}
+	return zw.Close()
+}
+
+// SaveToFile writes an archive of the database to a file, as SaveArchive does
+// The archive is written to a temporary file in the same directory that then
+// replaces path, so a failed save leaves any earlier archive intact
+func (db *Database) SaveToFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if err := db.SaveArchive(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadFromFile reads a database from an archive file written by SaveToFile
+func LoadFromFile(path string) (*Database, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadArchive(f)
+}
+
+// archive captures the table for SaveArchive
+// Callers must hold the table lock
+func (t *Table) archive() archiveTable {
+	result := archiveTable{
+		Name:    t.name,
+		Columns: t.schema,
+		NextID:  t.nextID,
+		Rows:    append([]Row{}, t.rows...), // Rows are replaced, never modified in place
+	}
+	for column := range t.indexes {
+		if !t.implicitIndex(column) {
+			result.Indexes = append(result.Indexes, column)
+		}
+	}
+	for column := range t.ordered {
+		result.OrderedIndexes = append(result.OrderedIndexes, column)
+	}
+	sort.Strings(result.Indexes)
+	sort.Strings(result.OrderedIndexes)
+	return result
+}
+
+// LoadArchive reads an archive written by SaveArchive into a new database
+func LoadArchive(r io.Reader) (*Database, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive: %v", err)
+	}
+	defer zr.Close()
+
+	decoder := json.NewDecoder(zr)
+	decoder.UseNumber() // Keep integers exact until the column type is known
+	var doc archive
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid archive: %v", err)
+	}
+	if doc.Version < 1 || doc.Version > ArchiveVersion {
+		return nil, ErrUnsupportedArchive{Version: doc.Version}
+	}
+
+	db := NewDatabase()
+	for _, saved := range doc.Tables {
+		if err := db.loadTable(saved); err != nil {
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// loadTable recreates a saved table
+// The rows came from a database that enforced its constraints, so they are
+// only converted back to their column types, not validated again
+func (db *Database) loadTable(saved archiveTable) error {
+	for i, col := range saved.Columns {
+		check, err := archivedCondition(col.Name, col.Check)
+		if err != nil {
+			return err
+		}
+		saved.Columns[i].Check = check
+	}
+
+	table, err := db.createTable(saved.Name, saved.Columns, TableOptions{})
+	if err != nil {
+		return err
+	}
+
+	types := make(map[string]ColumnType, len(saved.Columns))
+	for _, col := range saved.Columns {
+		types[col.Name] = col.Type
+	}
+
+	for _, row := range saved.Rows {
+		for col, value := range row {
+			colType, ok := types[col]
+			if !ok {
+				return ErrColumnNotFound{TableName: saved.Name, ColumnName: col}
+			}
+			if row[col], err = archivedValue(col, colType, value); err != nil {
+				return err
+			}
+		}
+		table.addRow(row)
+	}
+	table.nextID = saved.NextID
+
+	for _, column := range saved.Indexes {
+		if err := table.CreateIndex(column); err != nil {
+			return err
+		}
+	}
+	for _, column := range saved.OrderedIndexes {
+		if err := table.CreateOrderedIndex(column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// archivedCondition converts the literals of a condition decoded from JSON to Go values
+func archivedCondition(column string, cond *Condition) (*Condition, error) {
+	if cond == nil {
+		return nil, nil
+	}
+
+	var err error
+	restored := *cond
+	if restored.Left, err = archivedCondition(column, cond.Left); err != nil {
+		return nil, err
+	}
+	if restored.Right, err = archivedCondition(column, cond.Right); err != nil {
+		return nil, err
+	}
+	if restored.Value, err = archivedValue(column, "", cond.Value); err != nil {
+		return nil, err
+	}
+	if restored.High, err = archivedValue(column, "", cond.High); err != nil {
+		return nil, err
+	}
+	if cond.Arith != nil {
+		arith := *cond.Arith
+		if arith.Operand, err = archivedValue(column, "", arith.Operand); err != nil {
+			return nil, err
+		}
+		restored.Arith = &arith
+	}
+	return &restored, nil
+}
+
+// archivedValue converts a value decoded from JSON to the Go value stored for a column type
+// DATE values are saved as RFC 3339 strings
+func archivedValue(column string, colType ColumnType, value interface{}) (interface{}, error) {
+	if colType == TypeDate && value != nil {
+		return coerceDate(column, value)
+	}
+	number, ok := value.(json.Number)
+	if !ok {
+		return value, nil
+	}
+	if n, err := number.Int64(); err == nil {
+		return int(n), nil
+	}
+	if colType == TypeInt {
+		return nil, ErrInvalidValue{Column: column, Expected: string(colType), Got: value}
+	}
+	return number.Float64()
+}
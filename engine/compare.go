@@ -0,0 +1,64 @@
+package engine
+
+// Equal reports whether two tables hold the same content: columns with the
+// same names and types in the same order, and equal rows in any order
+// Constraints, indexes and the table names are not compared, so a table can be
+// checked against its copy in another database or a snapshot
+func (t *Table) Equal(other *Table) bool {
+	if t == other {
+		return true
+	}
+	schema, rows := t.contents()
+	otherSchema, otherRows := other.contents()
+
+	if len(schema) != len(otherSchema) || len(rows) != len(otherRows) {
+		return false
+	}
+	orderBy := make([]OrderBy, len(schema))
+	for i, col := range schema {
+		if col.Name != otherSchema[i].Name || col.Type != otherSchema[i].Type {
+			return false
+		}
+		orderBy[i] = OrderBy{Column: col.Name}
+	}
+
+	// Sorting on every column lines up equal rows
+	sortRows(rows, orderBy)
+	sortRows(otherRows, orderBy)
+	for i, row := range rows {
+		if !row.Equal(otherRows[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// contents returns the table's schema and a copy of its row slice
+// Each table is locked on its own, so comparing two tables never holds both locks
+func (t *Table) contents() ([]Column, []Row) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	rows := make([]Row, len(t.rows))
+	copy(rows, t.rows)
+	return t.schema, rows
+}
+
+// TablesEqual reports whether two databases have tables of the same names
+// holding the same content, as compared by Table.Equal
+func (db *Database) TablesEqual(other *Database) bool {
+	names := db.ListTables()
+	if len(names) != len(other.ListTables()) {
+		return false
+	}
+	for _, name := range names {
+		table, err := db.GetTable(name)
+		if err != nil {
+			return false
+		}
+		otherTable, err := other.GetTable(name)
+		if err != nil || !table.Equal(otherTable) {
+			return false
+		}
+	}
+	return true
+}
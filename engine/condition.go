@@ -0,0 +1,423 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Condition represents a WHERE clause condition
+// Compound conditions use Operator "AND" or "OR" and combine Left and Right;
+// "NOT" negates Left
+type Condition struct {
+	Column   string
+	Operator string // "=", "!=" (or "<>"), ">", "<", ">=", "<=", "LIKE", "ILIKE", "BETWEEN", "IS NULL", "IS NOT NULL", "AND", "OR", "NOT"
+	Value    interface{}
+	High     interface{} // Inclusive upper bound of BETWEEN; Value holds the lower bound
+	Escape   rune        // Escape character of LIKE and ILIKE, 0 for none
+	Arith    *Arithmetic // Optional arithmetic applied to the column value before comparing
+	Left     *Condition  // Left operand of AND/OR, or the operand of NOT
+	Right    *Condition  // Right operand of AND/OR
+}
+
+// And combines two conditions with AND
+func And(left, right *Condition) *Condition {
+	return &Condition{Operator: "AND", Left: left, Right: right}
+}
+
+// Or combines two conditions with OR
+func Or(left, right *Condition) *Condition {
+	return &Condition{Operator: "OR", Left: left, Right: right}
+}
+
+// Not negates a condition
+func Not(cond *Condition) *Condition {
+	return &Condition{Operator: "NOT", Left: cond}
+}
+
+// IsCompound reports whether the condition combines or negates other conditions
+func (c *Condition) IsCompound() bool {
+	return c.Operator == "AND" || c.Operator == "OR" || c.Operator == "NOT"
+}
+
+// IsNullCheck reports whether the condition is IS NULL or IS NOT NULL
+func (c *Condition) IsNullCheck() bool {
+	return c.Operator == "IS NULL" || c.Operator == "IS NOT NULL"
+}
+
+// withColumns returns a copy of the condition with every column name passed through rename
+func (c *Condition) withColumns(rename func(string) string) *Condition {
+	if c == nil {
+		return nil
+	}
+	copied := *c
+	if c.IsCompound() {
+		copied.Left = c.Left.withColumns(rename)
+		copied.Right = c.Right.withColumns(rename)
+	} else {
+		copied.Column = rename(c.Column)
+	}
+	return &copied
+}
+
+// Columns returns the names of the columns the condition references, walking
+// every leaf of an AND, OR or NOT tree
+func (c *Condition) Columns() []string {
+	var names []string
+	c.withColumns(func(column string) string {
+		names = append(names, column)
+		return column
+	})
+	return names
+}
+
+// String renders the condition in SQL-like form
+func (c *Condition) String() string {
+	if c.Operator == "NOT" {
+		return fmt.Sprintf("NOT %s", c.Left)
+	}
+	if c.IsCompound() {
+		return fmt.Sprintf("(%s %s %s)", c.Left, c.Operator, c.Right)
+	}
+
+	column := c.Column
+	if c.Arith != nil {
+		column = fmt.Sprintf("%s %s %v", c.Column, c.Arith.Operator, c.Arith.Operand)
+	}
+	if (c.Operator == "LIKE" || c.Operator == "ILIKE") && c.Escape != 0 {
+		return fmt.Sprintf("%s %s %s ESCAPE %s", column, c.Operator, formatValue(c.Value), formatValue(string(c.Escape)))
+	}
+	if c.Operator == "BETWEEN" {
+		return fmt.Sprintf("%s BETWEEN %s AND %s", column, formatValue(c.Value), formatValue(c.High))
+	}
+	if c.IsNullCheck() {
+		return fmt.Sprintf("%s %s", column, c.Operator)
+	}
+	return fmt.Sprintf("%s %s %s", column, c.Operator, formatValue(c.Value))
+}
+
+// formatValue renders a literal value in SQL-like form
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return "'" + v + "'"
+	case time.Time:
+		return "'" + FormatDate(v) + "'"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// Arithmetic represents an arithmetic operation on a column value, e.g. id % 2
+// Integer operands use integer arithmetic, so 7 / 2 evaluates to 3
+type Arithmetic struct {
+	Operator string // "+", "-", "*", "/", "%"
+	Operand  interface{}
+}
+
+// Results of a condition under SQL's three-valued logic
+const (
+	logicFalse = iota - 1
+	logicUnknown
+	logicTrue
+)
+
+// evaluateCondition checks if a row satisfies a condition
+func evaluateCondition(row Row, cond *Condition) bool {
+	return evaluateLogic(row, cond) == logicTrue
+}
+
+// evaluateLogic evaluates a condition under three-valued logic
+// A comparison with NULL is unknown, and so is its negation, so NOT email = 'x'
+// matches no more rows with a NULL email than email = 'x' does
+func evaluateLogic(row Row, cond *Condition) int {
+	switch cond.Operator {
+	case "AND":
+		left := evaluateLogic(row, cond.Left)
+		if left == logicFalse {
+			return left
+		}
+		return min(left, evaluateLogic(row, cond.Right))
+	case "OR":
+		left := evaluateLogic(row, cond.Left)
+		if left == logicTrue {
+			return left
+		}
+		return max(left, evaluateLogic(row, cond.Right))
+	case "NOT":
+		return -evaluateLogic(row, cond.Left)
+	}
+
+	if value, _ := row.Get(cond.Column); value == nil && !cond.IsNullCheck() {
+		return logicUnknown
+	}
+	if evaluatePredicate(row, cond) {
+		return logicTrue
+	}
+	return logicFalse
+}
+
+// evaluatePredicate checks if a row satisfies a single comparison
+func evaluatePredicate(row Row, cond *Condition) bool {
+	value, ok := row.Get(cond.Column)
+	if ok && cond.Arith != nil {
+		value, ok = applyArithmetic(value, cond.Arith)
+	}
+
+	// A missing column, an explicit NULL and failed arithmetic are all NULL,
+	// which only IS NULL matches; comparing NULL with anything is never true
+	isNull := !ok || value == nil
+	if cond.IsNullCheck() {
+		return isNull == (cond.Operator == "IS NULL")
+	}
+	if isNull {
+		return false
+	}
+
+	switch cond.Operator {
+	case "=":
+		return valuesEqual(value, cond.Value)
+	case "!=", "<>":
+		return !valuesEqual(value, cond.Value)
+	case ">":
+		return compareValues(value, cond.Value) > 0
+	case "<":
+		return compareValues(value, cond.Value) < 0
+	case ">=":
+		return compareValues(value, cond.Value) >= 0
+	case "<=":
+		return compareValues(value, cond.Value) <= 0
+	case "BETWEEN":
+		return orderable(value, cond.Value) && orderable(value, cond.High) &&
+			compareValues(value, cond.Value) >= 0 && compareValues(value, cond.High) <= 0
+	case "LIKE", "ILIKE":
+		s, ok := value.(string)
+		pattern, isString := cond.Value.(string)
+		return ok && isString && matchLike(s, pattern, cond.Escape, cond.Operator == "ILIKE")
+	default:
+		return false
+	}
+}
+
+// applyArithmetic applies an arithmetic operation to an integer value
+// Division and modulo by zero, and non-integer operands, yield no value
+func applyArithmetic(value interface{}, arith *Arithmetic) (interface{}, bool) {
+	a, ok := value.(int)
+	if !ok {
+		return nil, false
+	}
+	b, ok := arith.Operand.(int)
+	if !ok {
+		return nil, false
+	}
+
+	switch arith.Operator {
+	case "+":
+		return a + b, true
+	case "-":
+		return a - b, true
+	case "*":
+		return a * b, true
+	case "/":
+		if b == 0 {
+			return nil, false
+		}
+		return a / b, true
+	case "%":
+		if b == 0 {
+			return nil, false
+		}
+		return a % b, true
+	default:
+		return nil, false
+	}
+}
+
+// compareValues compares two values for ordering
+// Ints and floats compare numerically with each other, false sorts before
+// true, and dates compare with dates or date strings. Values of different
+// kinds compare as equal
+func compareValues(a, b interface{}) int {
+	if at, bt, ok := dateOperands(a, b); ok {
+		return at.Compare(bt)
+	}
+
+	if ai, ok := a.(int); ok {
+		if bi, ok := b.(int); ok {
+			if ai < bi {
+				return -1
+			} else if ai > bi {
+				return 1
+			}
+			return 0
+		}
+	}
+
+	if af, ok := toFloat(a); ok {
+		if bf, ok := toFloat(b); ok {
+			if af < bf {
+				return -1
+			} else if af > bf {
+				return 1
+			}
+			return 0
+		}
+	}
+
+	switch av := a.(type) {
+	case string:
+		if bv, ok := b.(string); ok {
+			if av < bv {
+				return -1
+			} else if av > bv {
+				return 1
+			}
+			return 0
+		}
+	case bool:
+		if bv, ok := b.(bool); ok {
+			if !av && bv {
+				return -1
+			} else if av && !bv {
+				return 1
+			}
+			return 0
+		}
+	}
+	return 0
+}
+
+// valuesEqual reports whether two non-NULL values are equal
+// Ints and floats compare numerically, so 10 equals 10.0, and a date equals
+// a string naming the same instant
+func valuesEqual(a, b interface{}) bool {
+	if at, bt, ok := dateOperands(a, b); ok {
+		return at.Equal(bt)
+	}
+
+	if af, ok := toFloat(a); ok {
+		if bf, ok := toFloat(b); ok {
+			return af == bf
+		}
+	}
+	return a == b
+}
+
+// orderable reports whether two values can be ordered against each other
+func orderable(a, b interface{}) bool {
+	if _, _, ok := dateOperands(a, b); ok {
+		return true
+	}
+	if _, ok := toFloat(a); ok {
+		_, ok = toFloat(b)
+		return ok
+	}
+	switch a.(type) {
+	case string:
+		_, ok := b.(string)
+		return ok
+	case bool:
+		_, ok := b.(bool)
+		return ok
+	}
+	return false
+}
+
+// toFloat converts a numeric value to float64
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// likeToken is a compiled element of a LIKE pattern
+type likeToken struct {
+	wildcard rune // '%' or '_' for wildcards, 0 for a literal
+	char     rune
+}
+
+// compileLike splits a LIKE pattern into literals and wildcards
+// A character following the escape character is always taken literally
+func compileLike(pattern string, escape rune) []likeToken {
+	var tokens []likeToken
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case escape != 0 && r == escape && i+1 < len(runes):
+			i++
+			tokens = append(tokens, likeToken{char: runes[i]})
+		case r == '%' || r == '_':
+			tokens = append(tokens, likeToken{wildcard: r})
+		default:
+			tokens = append(tokens, likeToken{char: r})
+		}
+	}
+	return tokens
+}
+
+// matchLike reports whether s matches a SQL LIKE pattern
+// % matches any run of characters and _ matches exactly one. Matching is
+// case-sensitive unless fold is set, as it is for ILIKE.
+// With a non-zero escape character, escaped % and _ match themselves
+func matchLike(s, pattern string, escape rune, fold bool) bool {
+	str, pat := []rune(s), compileLike(pattern, escape)
+	if fold && !hasWildcard(pat) {
+		// A pattern without wildcards is a case-insensitive equality
+		return strings.EqualFold(s, literal(pat))
+	}
+
+	same := func(a, b rune) bool {
+		return a == b || (fold && strings.EqualFold(string(a), string(b)))
+	}
+	si, pi := 0, 0
+	star, mark := -1, 0
+
+	for si < len(str) {
+		switch {
+		case pi < len(pat) && (pat[pi].wildcard == '_' || (pat[pi].wildcard == 0 && same(pat[pi].char, str[si]))):
+			si++
+			pi++
+		case pi < len(pat) && pat[pi].wildcard == '%':
+			// Remember the wildcard and first try matching it against nothing
+			star, mark = pi, si
+			pi++
+		case star >= 0:
+			// Backtrack: let the last % swallow one more character
+			mark++
+			si, pi = mark, star+1
+		default:
+			return false
+		}
+	}
+
+	for pi < len(pat) && pat[pi].wildcard == '%' {
+		pi++
+	}
+	return pi == len(pat)
+}
+
+// hasWildcard reports whether a compiled LIKE pattern contains % or _
+func hasWildcard(pat []likeToken) bool {
+	for _, token := range pat {
+		if token.wildcard != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// literal returns the text matched by a compiled LIKE pattern without wildcards
+func literal(pat []likeToken) string {
+	runes := make([]rune, len(pat))
+	for i, token := range pat {
+		runes[i] = token.char
+	}
+	return string(runes)
+}
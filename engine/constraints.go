@@ -1,5 +1,12 @@
 package engine
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // ColumnType represents the data type of a column
 type ColumnType string
 
@@ -7,29 +14,50 @@ const (
 	TypeInt    ColumnType = "INT"
 	TypeString ColumnType = "STRING"
 	TypeBool   ColumnType = "BOOL"
+	TypeDate   ColumnType = "DATE" // Held as a time.Time in UTC
 )
 
 // Column represents a table column with its schema
 type Column struct {
-	Name       string
-	Type       ColumnType
-	PrimaryKey bool
-	Unique     bool
-	NotNull    bool
+	Name          string
+	Type          ColumnType
+	PrimaryKey    bool
+	Unique        bool
+	NotNull       bool
+	AutoIncrement bool        // Generate sequential values when omitted on insert
+	References    *ForeignKey // Parent column this column must match, if any
+	Check         *Condition  // Condition every row must satisfy, if any
+}
+
+// ForeignKey describes the parent column referenced by a column
+type ForeignKey struct {
+	Table           string
+	Column          string
+	OnDeleteCascade bool // Delete referencing rows with the parent instead of rejecting the delete
 }
 
 // ConstraintChecker validates constraints on rows
 type ConstraintChecker struct {
-	table *Table
+	table  *Table
+	lookup func(name string) (*Table, error) // Resolves parent tables of foreign keys
 }
 
 // NewConstraintChecker creates a new constraint checker for a table
 func NewConstraintChecker(table *Table) *ConstraintChecker {
-	return &ConstraintChecker{table: table}
+	checker := &ConstraintChecker{table: table}
+	if table.db != nil {
+		checker.lookup = table.db.GetTable
+	}
+	return checker
 }
 
 // ValidateInsert checks if a row can be inserted without violating constraints
 func (c *ConstraintChecker) ValidateInsert(row Row) error {
+	// Check value types
+	if err := c.validateTypes(row); err != nil {
+		return err
+	}
+
 	// Check primary key constraint
 	if c.table.primaryKey != "" {
 		pkValue, hasPK := row.Get(c.table.primaryKey)
@@ -77,17 +105,37 @@ func (c *ConstraintChecker) ValidateInsert(row Row) error {
 		}
 	}
 
+	if err := c.validateChecks(row); err != nil {
+		return err
+	}
+
+	// Check foreign key constraints
+	for _, col := range c.table.schema {
+		if col.References != nil {
+			value, _ := row.Get(col.Name)
+			if err := c.validateReference(col, value); err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
 
 // ValidateUpdate checks if a row can be updated without violating constraints
 func (c *ConstraintChecker) ValidateUpdate(oldRow, newRow Row) error {
+	// Check value types
+	if err := c.validateTypes(newRow); err != nil {
+		return err
+	}
+
 	// Check primary key constraint (if primary key is being changed)
 	if c.table.primaryKey != "" {
 		oldPK, _ := oldRow.Get(c.table.primaryKey)
 		newPK, _ := newRow.Get(c.table.primaryKey)
 
 		// If primary key changed, check for duplicates
+		// The types were checked above, so == compares values of the same type
 		if oldPK != newPK && c.table.hasPrimaryKeyValue(newPK) {
 			return ErrPrimaryKeyViolation{
 				TableName: c.table.name,
@@ -127,5 +175,160 @@ func (c *ConstraintChecker) ValidateUpdate(oldRow, newRow Row) error {
 		}
 	}
 
+	if err := c.validateChecks(newRow); err != nil {
+		return err
+	}
+
+	// Check foreign key constraints (if a referencing value is being changed)
+	for _, col := range c.table.schema {
+		if col.References != nil {
+			oldValue, _ := oldRow.Get(col.Name)
+			newValue, _ := newRow.Get(col.Name)
+			if oldValue != newValue {
+				if err := c.validateReference(col, newValue); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateReference checks that a foreign key value exists in the parent table
+// NULL references nothing and is always allowed
+func (c *ConstraintChecker) validateReference(col Column, value interface{}) error {
+	if value == nil || c.lookup == nil {
+		return nil
+	}
+
+	parent, err := c.lookup(col.References.Table)
+	if err != nil {
+		return err
+	}
+
+	if !parent.hasUniqueValue(col.References.Column, value) {
+		return ErrForeignKeyViolation{
+			TableName: c.table.name,
+			Column:    col.Name,
+			Value:     value,
+			Reason:    fmt.Sprintf("no matching row in '%s.%s'", col.References.Table, col.References.Column),
+		}
+	}
+	return nil
+}
+
+// validateChecks checks the row against every CHECK constraint
+func (c *ConstraintChecker) validateChecks(row Row) error {
+	for _, col := range c.table.schema {
+		if col.Check != nil && !satisfiesCheck(row, col.Check) {
+			value, _ := row.Get(col.Name)
+			return ErrCheckViolation{
+				TableName: c.table.name,
+				Column:    col.Name,
+				Check:     col.Check.String(),
+				Value:     value,
+			}
+		}
+	}
+	return nil
+}
+
+// validateCheck checks that a column's CHECK constraint only references columns of the schema
+func validateCheck(tableName string, schema []Column, col Column) error {
+	if col.Check == nil {
+		return nil
+	}
+	for _, name := range col.Check.Columns() {
+		if !hasColumn(schema, name) {
+			return ErrInvalidColumnDefinition{
+				TableName:  tableName,
+				ColumnName: col.Name,
+				Reason:     fmt.Sprintf("CHECK references unknown column '%s'", name),
+			}
+		}
+	}
+	return nil
+}
+
+// hasColumn reports whether the schema has a column with the given name
+func hasColumn(schema []Column, name string) bool {
+	for _, col := range schema {
+		if col.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// satisfiesCheck reports whether a row passes a CHECK condition
+// As in SQL, a check that is unknown because it compares a NULL passes;
+// only a false check fails
+func satisfiesCheck(row Row, check *Condition) bool {
+	return evaluateLogic(row, check) != logicFalse
+}
+
+// validateTypes checks that every non-nil value matches its column's declared type
+// nil values are left to the NOT NULL checks
+func (c *ConstraintChecker) validateTypes(row Row) error {
+	for _, col := range c.table.schema {
+		value, hasValue := row.Get(col.Name)
+		if !hasValue || value == nil {
+			continue
+		}
+
+		if !matchesType(col.Type, value) {
+			return ErrInvalidValue{
+				Column:   col.Name,
+				Expected: string(col.Type),
+				Got:      value,
+			}
+		}
+	}
 	return nil
 }
+
+// matchesType checks if a Go value is valid for a column type
+func matchesType(colType ColumnType, value interface{}) bool {
+	switch colType {
+	case TypeInt:
+		_, ok := value.(int)
+		return ok
+	case TypeString:
+		_, ok := value.(string)
+		return ok
+	case TypeBool:
+		_, ok := value.(bool)
+		return ok
+	case TypeDate:
+		_, ok := value.(time.Time)
+		return ok
+	default:
+		return true
+	}
+}
+
+// CoerceValue converts text, such as a form field, to the Go value stored for a column type
+// INT accepts decimal integers, BOOL accepts true/false, 1/0, yes/no and
+// on/off in any case, and DATE accepts what ParseDate does. Anything else is
+// rejected with ErrInvalidValue
+func CoerceValue(column string, colType ColumnType, text string) (interface{}, error) {
+	switch colType {
+	case TypeInt:
+		if n, err := strconv.Atoi(text); err == nil {
+			return n, nil
+		}
+	case TypeBool:
+		switch strings.ToLower(text) {
+		case "true", "1", "yes", "on":
+			return true, nil
+		case "false", "0", "no", "off":
+			return false, nil
+		}
+	case TypeDate:
+		return coerceDate(column, text)
+	default:
+		return text, nil
+	}
+	return nil, ErrInvalidValue{Column: column, Expected: string(colType), Got: text}
+}
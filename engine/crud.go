@@ -1,64 +1,374 @@
 package engine
 
-// Condition represents a WHERE clause condition
-type Condition struct {
-	Column   string
-	Operator string // "=", "!=", ">", "<", ">=", "<="
-	Value    interface{}
-}
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
 
 // Insert adds a new row to a table
 func (db *Database) Insert(tableName string, row Row) error {
+	_, _, err := db.insert(tableName, row)
+	return err
+}
+
+// InsertWithKey adds a new row to a table and returns its primary key value,
+// including keys generated by an AUTOINCREMENT column
+func (db *Database) InsertWithKey(tableName string, row Row) (interface{}, error) {
+	table, stored, err := db.insert(tableName, row)
+	if err != nil {
+		return nil, err
+	}
+
+	key, _ := stored.Get(table.PrimaryKey())
+	return key, nil
+}
+
+// InsertReturning adds a new row to a table and returns a copy of the row as
+// stored, including any value generated by an AUTOINCREMENT column
+func (db *Database) InsertReturning(tableName string, row Row) (Row, error) {
+	_, stored, err := db.insert(tableName, row)
+	if err != nil {
+		return nil, err
+	}
+	return stored.Copy(), nil
+}
+
+// insert adds a new row to a table and returns the table and the stored row
+func (db *Database) insert(tableName string, row Row) (*Table, Row, error) {
 	table, err := db.GetTable(tableName)
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+
+	unlock := db.lockForInsert(table)
+	defer unlock()
+
+	if err := table.checkDropped(); err != nil {
+		return nil, nil, err
+	}
+
+	db.metrics.inserts.Add(1)
+	// A rejected row must not use up an auto-increment value
+	nextID := table.nextID
+	if row, err = table.prepareInsert(row); err != nil {
+		table.nextID = nextID
+		db.recordViolation(tableName, err)
+		return nil, nil, err
 	}
 
 	// Validate constraints
 	checker := NewConstraintChecker(table)
 	if err := checker.ValidateInsert(row); err != nil {
-		return err
+		table.nextID = nextID
+		db.recordViolation(tableName, err)
+		return nil, nil, err
 	}
 
 	// Add row to table
 	table.addRow(row)
+	db.record(Operation{Op: "insert", Table: tableName, Rows: []Row{row}})
+
+	return table, row, nil
+}
+
+// InsertMany adds several rows to a table as a single all-or-nothing operation
+// If any row violates a constraint, none of the rows are kept and no
+// auto-increment values are used up
+func (db *Database) InsertMany(tableName string, rows []Row) (int, error) {
+	table, err := db.GetTable(tableName)
+	if err != nil {
+		return 0, err
+	}
+
+	unlock := db.lockForInsert(table)
+	defer unlock()
+
+	if err := table.checkDropped(); err != nil {
+		return 0, err
+	}
+
+	db.metrics.inserts.Add(1)
+	checker := NewConstraintChecker(table)
+	start := len(table.rows)
+	nextID := table.nextID
+
+	for _, row := range rows {
+		row, err := table.prepareInsert(row)
+		if err == nil {
+			// Earlier rows of the batch are already in the table, so duplicates
+			// within the batch are caught as well
+			err = checker.ValidateInsert(row)
+		}
+		if err != nil {
+			table.truncateRows(start)
+			table.nextID = nextID
+			db.recordViolation(tableName, err)
+			return 0, err
+		}
+		table.addRow(row)
+	}
+
+	db.record(Operation{Op: "insert", Table: tableName, Rows: table.rows[start:]})
+	return len(rows), nil
+}
+
+// InsertValues adds a row given its values in schema column order, as in
+// INSERT INTO users VALUES (1, 'Bob')
+func (db *Database) InsertValues(tableName string, values []interface{}) error {
+	_, err := db.InsertManyValues(tableName, [][]interface{}{values})
+	return err
+}
+
+// InsertManyValues adds several rows given as values in schema column order
+// Every tuple must have one value per column; like InsertMany, either all
+// rows are inserted or none are
+func (db *Database) InsertManyValues(tableName string, tuples [][]interface{}) (int, error) {
+	table, err := db.GetTable(tableName)
+	if err != nil {
+		return 0, err
+	}
+
+	schema := table.Schema()
+	rows := make([]Row, len(tuples))
+	for i, values := range tuples {
+		if len(values) != len(schema) {
+			return 0, ErrValueCount{TableName: tableName, Expected: len(schema), Got: len(values)}
+		}
+		rows[i] = make(Row, len(schema))
+		for j, col := range schema {
+			rows[i][col.Name] = values[j]
+		}
+	}
+	return db.InsertMany(tableName, rows)
+}
+
+// BulkInsert adds several rows to a table as a single all-or-nothing operation
+// Unlike InsertMany, every row is validated before any is added, so a failed
+// batch leaves nothing to roll back. Duplicate keys within the batch are
+// detected up front; rows cannot reference other rows of the same batch
+func (db *Database) BulkInsert(tableName string, rows []Row) error {
+	table, err := db.GetTable(tableName)
+	if err != nil {
+		return err
+	}
+
+	unlock := db.lockForInsert(table)
+	defer unlock()
+
+	if err := table.checkDropped(); err != nil {
+		return err
+	}
+
+	db.metrics.inserts.Add(1)
+	nextID := table.nextID
+	prepared, err := table.validateBatch(rows)
+	if err != nil {
+		table.nextID = nextID
+		db.recordViolation(tableName, err)
+		return err
+	}
+
+	table.rows = slices.Grow(table.rows, len(prepared))
+	for _, row := range prepared {
+		table.addRow(row)
+	}
+	db.record(Operation{Op: "insert", Table: tableName, Rows: prepared})
 	return nil
 }
 
+// validateBatch assigns auto-increment values to a batch of rows and checks
+// them against the table and against each other
+// Callers must hold the table lock
+func (t *Table) validateBatch(rows []Row) ([]Row, error) {
+	checker := NewConstraintChecker(t)
+	seen := make(map[string]map[interface{}]bool)
+	for _, col := range t.schema {
+		if col.PrimaryKey || col.Unique {
+			seen[col.Name] = make(map[interface{}]bool, len(rows))
+		}
+	}
+
+	prepared := make([]Row, len(rows))
+	for i, row := range rows {
+		row, err := t.prepareInsert(row)
+		if err != nil {
+			return nil, err
+		}
+		if err := checker.ValidateInsert(row); err != nil {
+			return nil, err
+		}
+
+		for column, values := range seen {
+			value, ok := row.Get(column)
+			if !ok || value == nil {
+				continue
+			}
+			if values[value] {
+				if column == t.primaryKey {
+					return nil, ErrPrimaryKeyViolation{TableName: t.name, Key: column, Value: value}
+				}
+				return nil, ErrUniqueViolation{TableName: t.name, Column: column, Value: value}
+			}
+			values[value] = true
+		}
+		prepared[i] = row
+	}
+	return prepared, nil
+}
+
 // Select retrieves rows from a table with optional filtering
 func (db *Database) Select(tableName string, columns []string, condition *Condition) ([]Row, error) {
+	return db.Query(Query{
+		Table:     tableName,
+		Columns:   columns,
+		Condition: condition,
+	})
+}
+
+// Exists reports whether any row of a table matches the condition
+// It stops at the first match and copies no rows, so it is cheaper than
+// Select when only the presence of a row matters. A nil condition matches any row
+func (db *Database) Exists(tableName string, condition *Condition) (bool, error) {
 	table, err := db.GetTable(tableName)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
 
-	// Get candidate rows
-	var candidateIndices []int
-	useIndex := false
+	table.mu.RLock()
+	defer table.mu.RUnlock()
+
+	if err := table.checkDropped(); err != nil {
+		return false, err
+	}
+
+	if err := checkDateLiterals([]*Table{table}, condition); err != nil {
+		return false, err
+	}
+
+	db.metrics.selects.Add(1)
+	return table.exists(condition), nil
+}
+
+// exists reports whether any row matches the condition, using an index when possible
+// Callers must hold the table lock
+func (t *Table) exists(condition *Condition) bool {
+	path := t.chooseAccessPath(condition)
+	if !path.indexed() {
+		for i, row := range t.rows {
+			if condition == nil || evaluateCondition(row, condition) {
+				t.metrics().recordScan(false, i+1)
+				return true
+			}
+		}
+		t.metrics().recordScan(false, len(t.rows))
+		return false
+	}
+
+	candidates := path.candidates()
+	for i, pos := range candidates {
+		if evaluateCondition(t.rows[pos], condition) {
+			t.metrics().recordScan(true, i+1)
+			return true
+		}
+	}
+	t.metrics().recordScan(true, len(candidates))
+	return false
+}
+
+// Scan calls fn with each row of a table that matches the condition, in rowid
+// order, until fn returns false
+// Unlike Select, rows are handed over one at a time rather than collected, and
+// fn receives a copy it may keep. An index answers the condition when one
+// applies, as in Select. The table is read-locked for the whole scan, so fn must
+// not modify the database. A nil condition matches every row; the scan is
+// aborted with ErrQueryTimeout once the query timeout passes
+func (db *Database) Scan(tableName string, condition *Condition, fn func(Row) bool) error {
+	table, err := db.GetTable(tableName)
+	if err != nil {
+		return err
+	}
+
+	table.mu.RLock()
+	defer table.mu.RUnlock()
+
+	if err := table.checkDropped(); err != nil {
+		return err
+	}
+
+	if err := checkDateLiterals([]*Table{table}, condition); err != nil {
+		return err
+	}
+
+	db.metrics.selects.Add(1)
+	return table.scan(condition, db.newDeadline(), fn)
+}
+
+// scan calls fn with each matching row until it returns false
+// Only the rows visited before stopping are counted as scanned
+// Callers must hold the table lock
+func (t *Table) scan(condition *Condition, dl deadline, fn func(Row) bool) error {
+	path := t.chooseAccessPath(condition)
+	var candidates []int
+	total := len(t.rows)
+	if path.indexed() {
+		candidates = path.candidates()
+		total = len(candidates)
+	}
+
+	visited := 0
+	defer func() { t.metrics().recordScan(path.indexed(), visited) }()
+
+	for visited < total {
+		if err := dl.check(visited); err != nil {
+			return err
+		}
+		pos := visited
+		if path.indexed() {
+			pos = candidates[visited]
+		}
+		visited++
 
-	// Try to use index if condition is on an indexed column with equality
-	if condition != nil && condition.Operator == "=" {
-		if idx, hasIdx := table.GetIndex(condition.Column); hasIdx {
-			candidateIndices = idx.Lookup(condition.Value)
-			useIndex = true
+		row := t.rows[pos]
+		if condition != nil && !evaluateCondition(row, condition) {
+			continue
+		}
+		if !fn(row.Copy()) {
+			return nil
 		}
 	}
+	return nil
+}
+
+// filterRows returns the rows matching a condition, using an index when possible
+// Rows come back in rowid order. The scan is aborted with ErrQueryTimeout once the deadline passes
+func (t *Table) filterRows(condition *Condition, dl deadline) ([]Row, error) {
+	// Get candidate rows
+	var candidateIndices []int
 
-	// If no index used, scan all rows
-	if !useIndex {
-		candidateIndices = make([]int, len(table.rows))
-		for i := range table.rows {
+	// Use an index for a predicate on an indexed column if possible
+	path := t.chooseAccessPath(condition)
+	if path.indexed() {
+		candidateIndices = path.candidates()
+	} else {
+		// If no index used, scan all rows
+		candidateIndices = make([]int, len(t.rows))
+		for i := range t.rows {
 			candidateIndices[i] = i
 		}
 	}
+	t.metrics().recordScan(path.indexed(), len(candidateIndices))
 
 	// Filter rows based on condition
 	var results []Row
-	for _, idx := range candidateIndices {
-		if idx >= len(table.rows) {
+	for i, idx := range candidateIndices {
+		if err := dl.check(i); err != nil {
+			return nil, err
+		}
+		if idx >= len(t.rows) {
 			continue // Skip invalid indices
 		}
-		row := table.rows[idx]
+		row := t.rows[idx]
 
 		// Apply condition if present
 		if condition != nil {
@@ -67,9 +377,7 @@ func (db *Database) Select(tableName string, columns []string, condition *Condit
 			}
 		}
 
-		// Project columns
-		resultRow := projectRow(row, columns, table.schema)
-		results = append(results, resultRow)
+		results = append(results, row)
 	}
 
 	return results, nil
@@ -77,13 +385,57 @@ func (db *Database) Select(tableName string, columns []string, condition *Condit
 
 // Update modifies rows in a table that match the condition
 func (db *Database) Update(tableName string, updates Row, condition *Condition) (int, error) {
+	updated, err := db.update(tableName, updates, condition)
+	return len(updated), err
+}
+
+// UpdateReturning modifies rows like Update and returns copies of the updated
+// rows with their new values, in table order
+// Rows are updated one at a time, so on a constraint violation the rows updated
+// before it are returned along with the error
+func (db *Database) UpdateReturning(tableName string, updates Row, condition *Condition) ([]Row, error) {
+	updated, err := db.update(tableName, updates, condition)
+	for i, row := range updated {
+		updated[i] = row.Copy()
+	}
+	return updated, err
+}
+
+// update modifies the rows matching the condition and returns them as stored
+func (db *Database) update(tableName string, updates Row, condition *Condition) ([]Row, error) {
 	table, err := db.GetTable(tableName)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+
+	unlock := db.lockForUpdate(table)
+	defer unlock()
+
+	if err := table.checkDropped(); err != nil {
+		return nil, err
+	}
+
+	db.metrics.updates.Add(1)
+	table.metrics().recordScan(false, len(table.rows))
+
+	if updates, err = table.coerceDates(updates); err != nil {
+		db.recordViolation(tableName, err)
+		return nil, err
+	}
+	if err := checkDateLiterals([]*Table{table}, condition); err != nil {
+		return nil, err
 	}
 
 	checker := NewConstraintChecker(table)
-	rowsAffected := 0
+	refs := db.referencing(tableName)
+
+	// Rows updated before a failure stay updated, so they are logged either way
+	changed := Operation{Op: "update", Table: tableName}
+	defer func() {
+		if len(changed.Positions) > 0 {
+			db.record(changed)
+		}
+	}()
 
 	// Find rows to update
 	for i := 0; i < len(table.rows); i++ {
@@ -102,91 +454,255 @@ func (db *Database) Update(tableName string, updates Row, condition *Condition)
 
 		// Validate constraints
 		if err := checker.ValidateUpdate(row, newRow); err != nil {
-			return rowsAffected, err
+			db.recordViolation(tableName, err)
+			return changed.Rows, err
+		}
+
+		// Referenced values cannot change while child rows point at them
+		for _, ref := range refs {
+			oldValue, _ := row.Get(ref.column.References.Column)
+			newValue, _ := newRow.Get(ref.column.References.Column)
+			if oldValue != newValue {
+				if err := db.checkReferenced(table, []reference{ref}, []Row{row}, false); err != nil {
+					db.recordViolation(tableName, err)
+					return changed.Rows, err
+				}
+			}
 		}
 
 		// Update the row
 		table.updateRow(i, newRow)
-		rowsAffected++
+		changed.Positions = append(changed.Positions, i)
+		changed.Rows = append(changed.Rows, newRow)
+	}
+
+	return changed.Rows, nil
+}
+
+// RowChange describes how an UPDATE would change a single row
+type RowChange struct {
+	Before Row
+	After  Row
+}
+
+// Changed returns the columns whose value differs between Before and After, in the given order
+func (c RowChange) Changed(columns []string) []string {
+	var changed []string
+	for _, col := range columns {
+		before, _ := c.Before.Get(col)
+		after, _ := c.After.Get(col)
+		if before != after {
+			changed = append(changed, col)
+		}
+	}
+	return changed
+}
+
+// PreviewUpdate returns the rows an Update with the same arguments would
+// modify, with their values before and after, without changing the table
+// Constraints are not checked; Update reports violations when it runs
+func (db *Database) PreviewUpdate(tableName string, updates Row, condition *Condition) ([]RowChange, error) {
+	table, err := db.GetTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	table.mu.RLock()
+	defer table.mu.RUnlock()
+
+	if err := table.checkDropped(); err != nil {
+		return nil, err
+	}
+
+	for col := range updates {
+		if !table.hasColumn(col) {
+			return nil, ErrColumnNotFound{TableName: tableName, ColumnName: col}
+		}
+	}
+	// Values are shown as Update would store them
+	if updates, err = table.coerceDates(updates); err != nil {
+		return nil, err
+	}
+	if err := checkDateLiterals([]*Table{table}, condition); err != nil {
+		return nil, err
+	}
+
+	rows, err := table.filterRows(condition, db.newDeadline())
+	if err != nil {
+		return nil, err
 	}
 
-	return rowsAffected, nil
+	changes := make([]RowChange, len(rows))
+	for i, row := range rows {
+		after := row.Copy()
+		for col, val := range updates {
+			after.Set(col, val)
+		}
+		changes[i] = RowChange{Before: row.Copy(), After: after}
+	}
+	return changes, nil
 }
 
 // Delete removes rows from a table that match the condition
 func (db *Database) Delete(tableName string, condition *Condition) (int, error) {
+	removed, err := db.delete(tableName, condition)
+	return len(removed), err
+}
+
+// DeleteReturning removes rows like Delete and returns them, in table order
+// Rows removed from other tables by ON DELETE CASCADE are not included
+func (db *Database) DeleteReturning(tableName string, condition *Condition) ([]Row, error) {
+	return db.delete(tableName, condition)
+}
+
+// delete removes the rows matching the condition and returns them
+func (db *Database) delete(tableName string, condition *Condition) ([]Row, error) {
 	table, err := db.GetTable(tableName)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	rowsAffected := 0
+	unlock := db.lockForDelete(table)
+	defer unlock()
 
-	// Iterate backwards to avoid index issues when deleting
-	for i := len(table.rows) - 1; i >= 0; i-- {
-		row := table.rows[i]
+	if err := table.checkDropped(); err != nil {
+		return nil, err
+	}
+
+	if err := checkDateLiterals([]*Table{table}, condition); err != nil {
+		return nil, err
+	}
+
+	db.metrics.deletes.Add(1)
+	return db.deleteWhere(table, condition)
+}
+
+// Truncate removes every row of a table in one operation, keeping its schema
+// and indexes and restarting the auto-increment counter at 1
+// It is rejected while rows of another table still reference the table
+func (db *Database) Truncate(tableName string) error {
+	table, err := db.GetTable(tableName)
+	if err != nil {
+		return err
+	}
+
+	refs := db.referencing(tableName)
+	children := make([]*Table, 0, len(refs))
+	for _, ref := range refs {
+		children = append(children, ref.child)
+	}
+	unlock := lockTables([]*Table{table}, children)
+	defer unlock()
+
+	if err := table.checkDropped(); err != nil {
+		return err
+	}
+
+	for _, ref := range refs {
+		if ref.child == table {
+			continue
+		}
+		for _, row := range ref.child.rows {
+			if value, _ := row.Get(ref.column.Name); value != nil {
+				err := ErrForeignKeyViolation{
+					TableName: ref.child.name,
+					Column:    ref.column.Name,
+					Value:     value,
+					Reason:    fmt.Sprintf("table '%s' is still referenced", tableName),
+				}
+				db.recordViolation(tableName, err)
+				return err
+			}
+		}
+	}
+
+	db.metrics.deletes.Add(1)
+	table.truncate()
+	db.record(Operation{Op: "truncate", Table: tableName})
+	return nil
+}
+
+// truncate removes every row and restarts the auto-increment counter at 1
+// Callers must hold the table lock
+func (t *Table) truncate() {
+	t.rows = make([]Row, 0)
+	t.rebuildIndexes()
+	t.nextID = 1
+	t.analysis = nil // Statistics describe rows that are gone
+}
 
+// deleteWhere removes the rows of a table that match the condition and returns them
+// Callers must hold the locks taken by lockForDelete
+func (db *Database) deleteWhere(table *Table, condition *Condition) ([]Row, error) {
+	table.metrics().recordScan(false, len(table.rows))
+
+	var positions []int
+	var removed []Row
+	for i, row := range table.rows {
 		// Check if row matches condition
 		if condition != nil && !evaluateCondition(row, condition) {
 			continue
 		}
 
-		// Delete the row
-		table.deleteRow(i)
-		rowsAffected++
+		positions = append(positions, i)
+		removed = append(removed, row)
+	}
+
+	if len(positions) == 0 {
+		return nil, nil
 	}
 
-	return rowsAffected, nil
+	// Delete the rows
+	if err := db.deleteRows(table, positions); err != nil {
+		return nil, err
+	}
+
+	return removed, nil
 }
 
-// evaluateCondition checks if a row satisfies a condition
-func evaluateCondition(row Row, cond *Condition) bool {
-	value, ok := row.Get(cond.Column)
-	if !ok {
-		return false
+// DeleteByKeys removes the rows whose primary key is in keys
+// Each row is located through the primary key index instead of a full scan.
+// The rows are deleted together: if one is still referenced by a foreign
+// key, none are deleted
+func (db *Database) DeleteByKeys(tableName string, keys []interface{}) (int, error) {
+	table, err := db.GetTable(tableName)
+	if err != nil {
+		return 0, err
 	}
+	table.buildDeferredPrimaryKeyIndex()
 
-	switch cond.Operator {
-	case "=":
-		return value == cond.Value
-	case "!=":
-		return value != cond.Value
-	case ">":
-		return compareValues(value, cond.Value) > 0
-	case "<":
-		return compareValues(value, cond.Value) < 0
-	case ">=":
-		return compareValues(value, cond.Value) >= 0
-	case "<=":
-		return compareValues(value, cond.Value) <= 0
-	default:
-		return false
+	unlock := db.lockForDelete(table)
+	defer unlock()
+
+	if err := table.checkDropped(); err != nil {
+		return 0, err
 	}
-}
 
-// compareValues compares two values for ordering
-func compareValues(a, b interface{}) int {
-	switch av := a.(type) {
-	case int:
-		if bv, ok := b.(int); ok {
-			if av < bv {
-				return -1
-			} else if av > bv {
-				return 1
-			}
-			return 0
-		}
-	case string:
-		if bv, ok := b.(string); ok {
-			if av < bv {
-				return -1
-			} else if av > bv {
-				return 1
-			}
-			return 0
+	idx, hasIndex := table.indexes[table.primaryKey]
+	if table.primaryKey == "" || !hasIndex {
+		return 0, ErrNoPrimaryKey{TableName: tableName}
+	}
+	db.metrics.deletes.Add(1)
+
+	// Every key is looked up before anything is removed, so the rows are
+	// deleted, and the indexes rebuilt, once for the whole batch
+	found := make(map[int]bool, len(keys))
+	for _, key := range keys {
+		indices := idx.Lookup(key)
+		table.metrics().recordScan(true, len(indices))
+		if len(indices) > 0 {
+			found[indices[0]] = true
 		}
 	}
-	return 0
+	if len(found) == 0 {
+		return 0, nil
+	}
+
+	positions := slices.Sorted(maps.Keys(found))
+	if err := db.deleteRows(table, positions); err != nil {
+		return 0, err
+	}
+	return len(positions), nil
 }
 
 // projectRow extracts specified columns from a row
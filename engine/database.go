@@ -1,28 +1,157 @@
 package engine
 
-import "sync"
+import (
+	"fmt"
+	"sync"
+	"time"
+)
 
 // Database represents the in-memory database with multiple tables
 type Database struct {
-	tables map[string]*Table
-	mu     sync.RWMutex
+	tables       map[string]*Table
+	mu           sync.RWMutex
+	queryTimeout time.Duration
+	slowQuery    time.Duration // Queries running longer are logged at debug level
+	logger       Logger
+	metrics      metrics
+	oplog        operationLog // Set by SetOperationLog
+
+	schemaListeners []func(SchemaEvent) // Called by notifySchemaChange
 }
 
 // NewDatabase creates a new empty database
 func NewDatabase() *Database {
 	return &Database{
-		tables: make(map[string]*Table),
+		tables:    make(map[string]*Table),
+		slowQuery: defaultSlowQueryThreshold,
+		logger:    nopLogger{},
 	}
 }
 
 // CreateTable creates a new table with the given schema
 func (db *Database) CreateTable(name string, schema []Column) error {
+	return db.CreateTableWithOptions(name, schema, TableOptions{})
+}
+
+// CreateTableWithOptions creates a new table with the given schema and options
+func (db *Database) CreateTableWithOptions(name string, schema []Column, opts TableOptions) error {
 	db.mu.Lock()
-	defer db.mu.Unlock()
+	_, err := db.createTable(name, schema, opts)
+	db.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	db.notifySchemaChange(SchemaEvent{Table: name, Kind: SchemaCreate})
+	return nil
+}
+
+// CreateTableWithRows creates a table and loads rows into it as one atomic operation
+// If the schema is invalid or any row violates a constraint, the table is not created
+func (db *Database) CreateTableWithRows(name string, schema []Column, rows []Row) error {
+	if err := db.createTableWithRows(name, schema, rows); err != nil {
+		return err
+	}
+	db.notifySchemaChange(SchemaEvent{Table: name, Kind: SchemaCreate})
+	return nil
+}
+
+// CopyTable creates a table with the schema, rows, indexes and next
+// auto-increment value of an existing one
+// A foreign key of the source that references the source itself references
+// the copy instead. Later changes to either table do not affect the other
+func (db *Database) CopyTable(source, name string) error {
+	table, err := db.GetTable(source)
+	if err != nil {
+		return err
+	}
+
+	// The copy is taken before the database lock, as Snapshot does, so the
+	// table lock is never awaited while holding it
+	table.mu.RLock()
+	if err := table.checkDropped(); err != nil {
+		table.mu.RUnlock()
+		return err
+	}
+	copied := table.clone()
+	table.mu.RUnlock()
+
+	copied.name = name
+	copied.schema = renameReferences(copied.schema, source, name)
+	copied.db = db
 
+	db.mu.Lock()
 	if _, exists := db.tables[name]; exists {
+		db.mu.Unlock()
 		return ErrTableAlreadyExists{TableName: name}
 	}
+	db.tables[name] = copied
+	if len(copied.rows) > 0 {
+		db.record(Operation{Op: "insert", Table: name, Rows: copied.rows})
+	}
+	db.mu.Unlock()
+
+	db.notifySchemaChange(SchemaEvent{Table: name, Kind: SchemaCreate})
+	return nil
+}
+
+// createTableWithRows does the work of CreateTableWithRows under its locks
+func (db *Database) createTableWithRows(name string, schema []Column, rows []Row) error {
+	// Parent tables are read-locked before db.mu to respect the locking order
+	unlock := lockTables(nil, db.parentsOf(schema))
+	defer unlock()
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	table, err := db.createTable(name, schema, TableOptions{})
+	if err != nil {
+		return err
+	}
+
+	// The database lock is already held, so parent tables are looked up directly
+	checker := NewConstraintChecker(table)
+	checker.lookup = db.table
+
+	for _, row := range rows {
+		row, err := table.prepareInsert(row)
+		if err == nil {
+			err = checker.ValidateInsert(row)
+		}
+		if err != nil {
+			delete(db.tables, name)
+			db.metrics.constraintViolations.Add(1)
+			db.logger.Debug("constraint violation on table '%s': %v", name, err)
+			return err
+		}
+		table.addRow(row)
+	}
+
+	if len(rows) > 0 {
+		db.record(Operation{Op: "insert", Table: name, Rows: table.rows})
+	}
+	return nil
+}
+
+// createTable validates a schema and registers a new empty table
+// Callers must hold db.mu
+func (db *Database) createTable(name string, schema []Column, opts TableOptions) (*Table, error) {
+	if _, exists := db.tables[name]; exists {
+		return nil, ErrTableAlreadyExists{TableName: name}
+	}
+
+	// Column names must be unique
+	seen := make(map[string]bool, len(schema))
+	for _, col := range schema {
+		if seen[col.Name] {
+			return nil, ErrInvalidColumnDefinition{
+				TableName:  name,
+				ColumnName: col.Name,
+				Reason:     "duplicate column name",
+			}
+		}
+		seen[col.Name] = true
+	}
 
 	// Validate only one primary key
 	pkCount := 0
@@ -32,12 +161,40 @@ func (db *Database) CreateTable(name string, schema []Column) error {
 		}
 	}
 	if pkCount > 1 {
-		return ErrMultiplePrimaryKeys{TableName: name}
+		return nil, ErrMultiplePrimaryKeys{TableName: name}
+	}
+
+	// Auto-increment is only supported on an INT primary key
+	for _, col := range schema {
+		if col.AutoIncrement && (col.Type != TypeInt || !col.PrimaryKey) {
+			return nil, ErrInvalidColumnDefinition{
+				TableName:  name,
+				ColumnName: col.Name,
+				Reason:     "AUTOINCREMENT requires an INT PRIMARY KEY",
+			}
+		}
+	}
+
+	// Foreign keys must reference a primary key or unique column of the same type
+	for _, col := range schema {
+		if col.References != nil {
+			if err := db.validateForeignKey(name, schema, col); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// CHECK constraints may only reference columns of the table
+	for _, col := range schema {
+		if err := validateCheck(name, schema, col); err != nil {
+			return nil, err
+		}
 	}
 
-	table := NewTable(name, schema)
+	table := newTableWithOptions(name, schema, opts)
+	table.db = db
 	db.tables[name] = table
-	return nil
+	return table, nil
 }
 
 // GetTable retrieves a table by name
@@ -45,6 +202,12 @@ func (db *Database) GetTable(name string) (*Table, error) {
 	db.mu.RLock()
 	defer db.mu.RUnlock()
 
+	return db.table(name)
+}
+
+// table retrieves a table by name
+// Callers must hold db.mu
+func (db *Database) table(name string) (*Table, error) {
 	table, exists := db.tables[name]
 	if !exists {
 		return nil, ErrTableNotFound{TableName: name}
@@ -53,16 +216,74 @@ func (db *Database) GetTable(name string) (*Table, error) {
 	return table, nil
 }
 
+// RowCount returns the number of rows in a table without copying them
+func (db *Database) RowCount(tableName string) (int, error) {
+	table, err := db.GetTable(tableName)
+	if err != nil {
+		return 0, err
+	}
+
+	return table.Stats().Rows, nil
+}
+
+// Vacuum compacts a table's rows and indexes after deletes; see Table.Vacuum
+func (db *Database) Vacuum(tableName string) (int, error) {
+	table, err := db.GetTable(tableName)
+	if err != nil {
+		return 0, err
+	}
+
+	table.mu.Lock()
+	defer table.mu.Unlock()
+
+	if err := table.checkDropped(); err != nil {
+		return 0, err
+	}
+	return table.vacuum(), nil
+}
+
 // DropTable removes a table from the database
+// The table is write-locked first, so operations already holding it finish
+// before the drop and operations that looked it up earlier fail afterwards
 func (db *Database) DropTable(name string) error {
+	if err := db.dropTable(name); err != nil {
+		return err
+	}
+	db.notifySchemaChange(SchemaEvent{Table: name, Kind: SchemaDrop})
+	return nil
+}
+
+// dropTable does the work of DropTable under its locks
+func (db *Database) dropTable(name string) error {
+	table, err := db.GetTable(name)
+	if err != nil {
+		return err
+	}
+
+	table.mu.Lock()
+	defer table.mu.Unlock()
+
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
-	if _, exists := db.tables[name]; !exists {
+	// Another DropTable may have won the race while the lock was awaited
+	if db.tables[name] != table {
 		return ErrTableNotFound{TableName: name}
 	}
 
+	// A table cannot be dropped while another table references it
+	for _, ref := range db.referencesTo(name) {
+		if ref.child.name != name {
+			return ErrForeignKeyViolation{
+				TableName: ref.child.name,
+				Column:    ref.column.Name,
+				Reason:    fmt.Sprintf("table '%s' is still referenced", name),
+			}
+		}
+	}
+
 	delete(db.tables, name)
+	table.dropped = true
 	return nil
 }
 
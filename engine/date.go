@@ -0,0 +1,151 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+)
+
+// dateLayouts are the text forms accepted for DATE values, tried in order
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05.999999999",
+	time.RFC3339Nano,
+}
+
+// ParseDate parses a DATE value written as 2024-01-15, 2024-01-15 10:30:00 or
+// in RFC 3339 form
+// Values without a zone are taken to be UTC, and all values are returned in UTC
+func ParseDate(text string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date '%s': expected YYYY-MM-DD or YYYY-MM-DD HH:MM:SS", text)
+}
+
+// FormatDate writes a DATE value the way ParseDate reads it back
+// Midnight is written as a bare date
+func FormatDate(t time.Time) string {
+	t = t.UTC()
+	if t.Equal(t.Truncate(24 * time.Hour)) {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02 15:04:05.999999999")
+}
+
+// coerceDate converts a value given for a DATE column to the time.Time stored
+// Strings are parsed with ParseDate; values of other types are returned as
+// they are for the type check to reject
+func coerceDate(column string, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v.UTC(), nil
+	case string:
+		t, err := ParseDate(v)
+		if err != nil {
+			return nil, ErrInvalidValue{Column: column, Expected: string(TypeDate), Got: v}
+		}
+		return t, nil
+	}
+	return value, nil
+}
+
+// coerceDates converts the values of a row's DATE columns to time.Time
+// The caller's row is copied rather than modified when a value changes
+// Callers must hold the table lock
+func (t *Table) coerceDates(row Row) (Row, error) {
+	copied := false
+	for _, col := range t.schema {
+		if col.Type != TypeDate {
+			continue
+		}
+		value, ok := row.Get(col.Name)
+		if !ok || value == nil {
+			continue
+		}
+		coerced, err := coerceDate(col.Name, value)
+		if err != nil {
+			return nil, err
+		}
+		if !copied {
+			row = row.Copy()
+			copied = true
+		}
+		row.Set(col.Name, coerced)
+	}
+	return row, nil
+}
+
+// prepareInsert readies a row for insertion: DATE values are converted and
+// the auto-increment column is filled in
+// Callers must hold the table lock
+func (t *Table) prepareInsert(row Row) (Row, error) {
+	row, err := t.coerceDates(row)
+	if err != nil {
+		return nil, err
+	}
+	return t.assignAutoIncrement(row), nil
+}
+
+// dateOperands returns two values as times when one is a time and the other
+// a time or a string ParseDate accepts, so a DATE column can be compared
+// with a literal such as '2024-01-15'
+func dateOperands(a, b interface{}) (time.Time, time.Time, bool) {
+	at, aok := a.(time.Time)
+	bt, bok := b.(time.Time)
+	switch {
+	case aok && bok:
+		return at, bt, true
+	case aok:
+		if s, ok := b.(string); ok {
+			parsed, err := ParseDate(s)
+			return at, parsed, err == nil
+		}
+	case bok:
+		if s, ok := a.(string); ok {
+			parsed, err := ParseDate(s)
+			return parsed, bt, err == nil
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// checkDateLiterals rejects a string compared with a DATE column that
+// ParseDate does not accept, with the ErrInvalidValue INSERT and UPDATE report,
+// rather than letting the comparison quietly match nothing
+// Callers must hold the tables' locks
+func checkDateLiterals(tables []*Table, c *Condition) error {
+	if c == nil {
+		return nil
+	}
+	if c.IsCompound() {
+		if err := checkDateLiterals(tables, c.Left); err != nil {
+			return err
+		}
+		return checkDateLiterals(tables, c.Right)
+	}
+	if c.IsNullCheck() || c.Operator == "LIKE" || c.Operator == "ILIKE" {
+		return nil
+	}
+
+	res := resolveColumn(tables, c.Column)
+	var col Column
+	for _, table := range tables {
+		if table.name == res.Table {
+			col, _ = table.column(res.Column)
+		}
+	}
+	if col.Type != TypeDate {
+		return nil
+	}
+	for _, value := range []interface{}{c.Value, c.High} {
+		if s, ok := value.(string); ok {
+			if _, err := coerceDate(c.Column, s); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
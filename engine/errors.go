@@ -1,6 +1,9 @@
 package engine
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // ErrTableNotFound is returned when a table does not exist
 type ErrTableNotFound struct {
@@ -90,3 +93,164 @@ type ErrMultiplePrimaryKeys struct {
 func (e ErrMultiplePrimaryKeys) Error() string {
 	return fmt.Sprintf("table '%s' cannot have multiple primary keys", e.TableName)
 }
+
+// ErrInvalidAggregate is returned when an aggregate function cannot be applied to a column
+type ErrInvalidAggregate struct {
+	Func     string
+	Column   string
+	Type     ColumnType
+	Distinct bool
+}
+
+func (e ErrInvalidAggregate) Error() string {
+	if e.Type != "" {
+		return fmt.Sprintf("cannot compute %s over non-numeric column '%s' (%s)", e.Func, e.Column, e.Type)
+	}
+	if e.Distinct {
+		return fmt.Sprintf("invalid aggregate %s(DISTINCT %s): DISTINCT is only supported in COUNT over a column", e.Func, e.Column)
+	}
+	return fmt.Sprintf("invalid aggregate %s(%s)", e.Func, e.Column)
+}
+
+// ErrInvalidFunction is returned when a scalar function cannot be applied to a column
+type ErrInvalidFunction struct {
+	Func   string
+	Column string
+	Type   ColumnType
+}
+
+func (e ErrInvalidFunction) Error() string {
+	if e.Type != "" {
+		return fmt.Sprintf("cannot apply %s to non-string column '%s' (%s)", e.Func, e.Column, e.Type)
+	}
+	return fmt.Sprintf("invalid function %s(%s)", e.Func, e.Column)
+}
+
+// ErrValueCount is returned when a positional INSERT has a different number of
+// values than the table has columns
+type ErrValueCount struct {
+	TableName string
+	Expected  int
+	Got       int
+}
+
+func (e ErrValueCount) Error() string {
+	return fmt.Sprintf("table '%s' has %d column(s) but %d value(s) were supplied", e.TableName, e.Expected, e.Got)
+}
+
+// ErrParamCount is returned when a prepared statement is bound with a different
+// number of arguments than it has placeholders
+type ErrParamCount struct {
+	Expected int
+	Got      int
+}
+
+func (e ErrParamCount) Error() string {
+	return fmt.Sprintf("statement has %d parameter(s) but %d argument(s) were supplied", e.Expected, e.Got)
+}
+
+// ErrNoPrimaryKey is returned when an operation requires a primary key the table does not have
+type ErrNoPrimaryKey struct {
+	TableName string
+}
+
+func (e ErrNoPrimaryKey) Error() string {
+	return fmt.Sprintf("table '%s' has no primary key", e.TableName)
+}
+
+// ErrIndexNotFound is returned when dropping an index that does not exist
+type ErrIndexNotFound struct {
+	TableName  string
+	ColumnName string
+}
+
+func (e ErrIndexNotFound) Error() string {
+	return fmt.Sprintf("no index on column '%s' in table '%s'", e.ColumnName, e.TableName)
+}
+
+// ErrImplicitIndex is returned when dropping the index of a PRIMARY KEY or UNIQUE column
+type ErrImplicitIndex struct {
+	TableName  string
+	ColumnName string
+}
+
+func (e ErrImplicitIndex) Error() string {
+	return fmt.Sprintf("index on '%s.%s' enforces a PRIMARY KEY or UNIQUE constraint and cannot be dropped", e.TableName, e.ColumnName)
+}
+
+// ErrQueryTimeout is returned when a query runs longer than the configured timeout
+type ErrQueryTimeout struct {
+	Timeout time.Duration
+}
+
+func (e ErrQueryTimeout) Error() string {
+	return fmt.Sprintf("query exceeded timeout of %v", e.Timeout)
+}
+
+// ErrInvalidColumnDefinition is returned when a column definition is not valid
+type ErrInvalidColumnDefinition struct {
+	TableName  string
+	ColumnName string
+	Reason     string
+}
+
+func (e ErrInvalidColumnDefinition) Error() string {
+	return fmt.Sprintf("invalid definition for column '%s' in table '%s': %s", e.ColumnName, e.TableName, e.Reason)
+}
+
+// ErrNoAutoIncrement is returned when a table has no auto-increment column
+type ErrNoAutoIncrement struct {
+	TableName string
+}
+
+func (e ErrNoAutoIncrement) Error() string {
+	return fmt.Sprintf("table '%s' has no AUTOINCREMENT column", e.TableName)
+}
+
+// ErrAutoIncrementConflict is returned when an auto-increment start collides with an existing key
+type ErrAutoIncrementConflict struct {
+	TableName string
+	Start     int
+	Existing  int
+}
+
+func (e ErrAutoIncrementConflict) Error() string {
+	return fmt.Sprintf("cannot set AUTO_INCREMENT of table '%s' to %d: key %d already exists",
+		e.TableName, e.Start, e.Existing)
+}
+
+// ErrForeignKeyViolation is returned when a change would break a foreign key reference
+type ErrForeignKeyViolation struct {
+	TableName string
+	Column    string
+	Value     interface{} // Offending value; nil when the violation is not about one value, as for DROP TABLE
+	Reason    string
+}
+
+func (e ErrForeignKeyViolation) Error() string {
+	if e.Value == nil {
+		return fmt.Sprintf("foreign key violation on '%s.%s': %s", e.TableName, e.Column, e.Reason)
+	}
+	return fmt.Sprintf("foreign key violation on '%s.%s' (value: %v): %s", e.TableName, e.Column, e.Value, e.Reason)
+}
+
+// ErrCheckViolation is returned when a row does not satisfy a CHECK constraint
+type ErrCheckViolation struct {
+	TableName string
+	Column    string
+	Check     string
+	Value     interface{}
+}
+
+func (e ErrCheckViolation) Error() string {
+	return fmt.Sprintf("check constraint violation on '%s.%s' (value: %v): CHECK (%s)", e.TableName, e.Column, e.Value, e.Check)
+}
+
+// ErrUnsupportedArchive is returned when an archive has a format version this build cannot read
+type ErrUnsupportedArchive struct {
+	Version int
+}
+
+func (e ErrUnsupportedArchive) Error() string {
+	return fmt.Sprintf("unsupported archive version %d (supported up to %d)", e.Version, ArchiveVersion)
+}
@@ -0,0 +1,56 @@
+package engine
+
+// SchemaChangeKind identifies what happened to a table's schema
+type SchemaChangeKind int
+
+const (
+	SchemaCreate SchemaChangeKind = iota
+	SchemaAlter
+	SchemaDrop
+	SchemaRename
+)
+
+// String returns the kind as a statement name, e.g. "CREATE"
+func (k SchemaChangeKind) String() string {
+	switch k {
+	case SchemaCreate:
+		return "CREATE"
+	case SchemaAlter:
+		return "ALTER"
+	case SchemaDrop:
+		return "DROP"
+	case SchemaRename:
+		return "RENAME"
+	}
+	return "UNKNOWN"
+}
+
+// SchemaEvent describes a change to a table's schema
+type SchemaEvent struct {
+	Table  string
+	Kind   SchemaChangeKind
+	Column string // The added column for SchemaAlter, or the renamed column for SchemaRename
+	From   string // For SchemaRename, the previous name of the column, or of the table when Column is empty
+}
+
+// OnSchemaChange registers a function called after each table is created,
+// altered, renamed or dropped
+// Listeners run on the goroutine that made the change, once the change is
+// applied and every lock is released, so they may query the database
+func (db *Database) OnSchemaChange(fn func(event SchemaEvent)) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.schemaListeners = append(db.schemaListeners, fn)
+}
+
+// notifySchemaChange calls the schema change listeners
+// Callers must not hold any lock
+func (db *Database) notifySchemaChange(event SchemaEvent) {
+	db.mu.RLock()
+	listeners := db.schemaListeners
+	db.mu.RUnlock()
+
+	for _, fn := range listeners {
+		fn(event)
+	}
+}
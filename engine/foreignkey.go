@@ -0,0 +1,207 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+)
+
+// reference is a column of a child table that references a parent table
+type reference struct {
+	child  *Table
+	column Column
+}
+
+// validateForeignKey checks a REFERENCES clause of a table being created
+// Callers must hold db.mu. A table may reference its own columns
+func (db *Database) validateForeignKey(tableName string, schema []Column, col Column) error {
+	fk := col.References
+	invalid := func(reason string) error {
+		return ErrInvalidColumnDefinition{TableName: tableName, ColumnName: col.Name, Reason: reason}
+	}
+
+	parentSchema := schema
+	if fk.Table != tableName {
+		parent, exists := db.tables[fk.Table]
+		if !exists {
+			return invalid(fmt.Sprintf("referenced table '%s' does not exist", fk.Table))
+		}
+		parentSchema = parent.schema
+	}
+
+	for _, parentCol := range parentSchema {
+		if parentCol.Name != fk.Column {
+			continue
+		}
+		if !parentCol.PrimaryKey && !parentCol.Unique {
+			return invalid(fmt.Sprintf("referenced column '%s.%s' must be a PRIMARY KEY or UNIQUE", fk.Table, fk.Column))
+		}
+		if parentCol.Type != col.Type {
+			return invalid(fmt.Sprintf("type %s does not match referenced column '%s.%s' of type %s",
+				col.Type, fk.Table, fk.Column, parentCol.Type))
+		}
+		return nil
+	}
+
+	return invalid(fmt.Sprintf("referenced column '%s.%s' does not exist", fk.Table, fk.Column))
+}
+
+// referencesTo lists the foreign key columns that reference the given table,
+// ordered by child table name. Callers must hold db.mu
+func (db *Database) referencesTo(tableName string) []reference {
+	var refs []reference
+	for _, table := range db.tables {
+		for _, col := range table.schema {
+			if col.References != nil && col.References.Table == tableName {
+				refs = append(refs, reference{child: table, column: col})
+			}
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].child.name != refs[j].child.name {
+			return refs[i].child.name < refs[j].child.name
+		}
+		return refs[i].column.Name < refs[j].column.Name
+	})
+	return refs
+}
+
+// referencing returns the foreign key columns that reference the given table
+func (db *Database) referencing(tableName string) []reference {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.referencesTo(tableName)
+}
+
+// checkReferenced rejects changing or removing a parent value that child rows still reference
+// References with ON DELETE CASCADE are skipped when cascade is true
+func (db *Database) checkReferenced(table *Table, refs []reference, rows []Row, cascade bool) error {
+	for _, ref := range refs {
+		if cascade && ref.column.References.OnDeleteCascade {
+			continue
+		}
+		for _, row := range rows {
+			value, _ := row.Get(ref.column.References.Column)
+			if value != nil && ref.child.hasUniqueValue(ref.column.Name, value) {
+				return ErrForeignKeyViolation{
+					TableName: table.name,
+					Column:    ref.column.References.Column,
+					Value:     value,
+					Reason:    fmt.Sprintf("still referenced by '%s.%s'", ref.child.name, ref.column.Name),
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// deleteRows removes the rows at the given positions while enforcing foreign keys
+// Deletion is rejected if a child row still references one of the rows,
+// unless the reference cascades.
+// Callers must hold the locks taken by lockForDelete
+func (db *Database) deleteRows(table *Table, positions []int) error {
+	refs := db.referencing(table.name)
+
+	rows := make([]Row, len(positions))
+	for i, pos := range positions {
+		rows[i] = table.rows[pos]
+	}
+
+	if err := db.checkReferenced(table, refs, rows, true); err != nil {
+		db.recordViolation(table.name, err)
+		return err
+	}
+	seen := map[*Table]map[int]bool{table: positionSet(positions)}
+	if err := db.checkCascade(refs, rows, seen); err != nil {
+		db.recordViolation(table.name, err)
+		return err
+	}
+
+	table.removeRows(positions)
+	db.record(Operation{Op: "delete", Table: table.name, Positions: positions})
+
+	// Each child table is scanned once for the children of every deleted row
+	for _, ref := range refs {
+		if !ref.column.References.OnDeleteCascade {
+			continue
+		}
+		parents := parentKeys(ref, rows)
+		if len(parents) == 0 {
+			continue
+		}
+
+		ref.child.metrics().recordScan(false, len(ref.child.rows))
+		var children []int
+		for pos, child := range ref.child.rows {
+			if value, _ := child.Get(ref.column.Name); value != nil && parents[indexKey(value)] {
+				children = append(children, pos)
+			}
+		}
+		if len(children) == 0 {
+			continue
+		}
+		if err := db.deleteRows(ref.child, children); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parentKeys returns the non-NULL values a reference points at in the given
+// parent rows, keyed as an index keys them so 1 and 1.0 match
+func parentKeys(ref reference, rows []Row) map[interface{}]bool {
+	parents := make(map[interface{}]bool, len(rows))
+	for _, row := range rows {
+		if value, _ := row.Get(ref.column.References.Column); value != nil {
+			parents[indexKey(value)] = true
+		}
+	}
+	return parents
+}
+
+// checkCascade rejects a delete whose cascade would reach child rows that are
+// still referenced without ON DELETE CASCADE, before anything is removed
+// seen holds the positions of rows already reached, so reference cycles end
+func (db *Database) checkCascade(refs []reference, rows []Row, seen map[*Table]map[int]bool) error {
+	for _, ref := range refs {
+		if !ref.column.References.OnDeleteCascade {
+			continue
+		}
+
+		parents := parentKeys(ref, rows)
+
+		if seen[ref.child] == nil {
+			seen[ref.child] = make(map[int]bool)
+		}
+		var children []Row
+		for pos, child := range ref.child.rows {
+			value, _ := child.Get(ref.column.Name)
+			if value == nil || !parents[indexKey(value)] || seen[ref.child][pos] {
+				continue
+			}
+			seen[ref.child][pos] = true
+			children = append(children, child)
+		}
+		if len(children) == 0 {
+			continue
+		}
+
+		childRefs := db.referencing(ref.child.name)
+		if err := db.checkReferenced(ref.child, childRefs, children, true); err != nil {
+			return err
+		}
+		if err := db.checkCascade(childRefs, children, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// positionSet returns the given row positions as a set
+func positionSet(positions []int) map[int]bool {
+	set := make(map[int]bool, len(positions))
+	for _, pos := range positions {
+		set[pos] = true
+	}
+	return set
+}
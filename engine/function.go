@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ScalarFunc applies a single-argument function to a column of each result row,
+// e.g. UPPER(name)
+type ScalarFunc struct {
+	Func   string // "UPPER", "LOWER", "LENGTH"
+	Column string
+	Alias  string // Output name given with AS, if any
+}
+
+// Name returns the output column name for the function (e.g. "upper_name")
+// An alias replaces the generated name
+func (f ScalarFunc) Name() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return fmt.Sprintf("%s_%s", strings.ToLower(f.Func), f.Column)
+}
+
+// String renders the call as written, e.g. "UPPER(name) AS shout"
+func (f ScalarFunc) String() string {
+	call := fmt.Sprintf("%s(%s)", strings.ToUpper(f.Func), f.Column)
+	if f.Alias != "" {
+		call += " AS " + f.Alias
+	}
+	return call
+}
+
+// ResultType returns the type of the values the function produces
+func (f ScalarFunc) ResultType() ColumnType {
+	if strings.ToUpper(f.Func) == "LENGTH" {
+		return TypeInt
+	}
+	return TypeString
+}
+
+// validateFunction checks that a scalar function can be applied to a column of the table
+// Every supported function takes a STRING argument
+func (t *Table) validateFunction(f ScalarFunc) error {
+	switch strings.ToUpper(f.Func) {
+	case "UPPER", "LOWER", "LENGTH":
+	default:
+		return ErrInvalidFunction{Func: f.Func, Column: f.Column}
+	}
+
+	col, ok := t.column(f.Column)
+	if !ok {
+		return ErrColumnNotFound{TableName: t.name, ColumnName: f.Column}
+	}
+	if col.Type != TypeString {
+		return ErrInvalidFunction{Func: f.Func, Column: f.Column, Type: col.Type}
+	}
+	return nil
+}
+
+// apply evaluates the function on a value; NULL stays NULL
+// LENGTH counts characters, not bytes
+func (f ScalarFunc) apply(value interface{}) interface{} {
+	s, ok := value.(string)
+	if !ok {
+		return nil
+	}
+	switch strings.ToUpper(f.Func) {
+	case "UPPER":
+		return strings.ToUpper(s)
+	case "LOWER":
+		return strings.ToLower(s)
+	case "LENGTH":
+		return utf8.RuneCountInString(s)
+	}
+	return nil
+}
+
+// applyFunctions sets each computed column of a projected result from its source row
+func applyFunctions(result, row Row, functions map[string]ScalarFunc) {
+	for name, f := range functions {
+		value, _ := row.Get(f.Column)
+		result.Set(name, f.apply(value))
+	}
+}
@@ -1,5 +1,7 @@
 package engine
 
+import "math"
+
 // Index represents a hash-based index for a column
 // Maps column value -> list of row indices
 type Index struct {
@@ -15,12 +17,23 @@ func NewIndex(column string) *Index {
 	}
 }
 
+// indexKey returns the key a value is stored under
+// Whole floats are keyed as ints, so an equality lookup for 10.0 finds the
+// rows holding 10 just as a scan would
+func indexKey(value interface{}) interface{} {
+	if f, ok := value.(float64); ok && f == math.Trunc(f) && math.Abs(f) < 1<<62 {
+		return int(f)
+	}
+	return value
+}
+
 // Add adds a row index to the index for a given value
 func (idx *Index) Add(value interface{}, rowIndex int) {
 	if value == nil {
 		return // Don't index nil values
 	}
-	idx.data[value] = append(idx.data[value], rowIndex)
+	key := indexKey(value)
+	idx.data[key] = append(idx.data[key], rowIndex)
 }
 
 // Remove removes a row index from the index for a given value
@@ -29,7 +42,8 @@ func (idx *Index) Remove(value interface{}, rowIndex int) {
 		return
 	}
 
-	indices, exists := idx.data[value]
+	key := indexKey(value)
+	indices, exists := idx.data[key]
 	if !exists {
 		return
 	}
@@ -43,9 +57,9 @@ func (idx *Index) Remove(value interface{}, rowIndex int) {
 	}
 
 	if len(newIndices) == 0 {
-		delete(idx.data, value)
+		delete(idx.data, key)
 	} else {
-		idx.data[value] = newIndices
+		idx.data[key] = newIndices
 	}
 }
 
@@ -54,7 +68,7 @@ func (idx *Index) Lookup(value interface{}) []int {
 	if value == nil {
 		return nil
 	}
-	return idx.data[value]
+	return idx.data[indexKey(value)]
 }
 
 // Update updates the index when a row's value changes
@@ -68,6 +82,19 @@ func (idx *Index) Has(value interface{}) bool {
 	if value == nil {
 		return false
 	}
-	_, exists := idx.data[value]
+	_, exists := idx.data[indexKey(value)]
 	return exists
 }
+
+// Size returns the number of distinct values in the index
+func (idx *Index) Size() int {
+	return len(idx.data)
+}
+
+// Count returns the number of rows with the given value
+func (idx *Index) Count(value interface{}) int {
+	if value == nil {
+		return 0
+	}
+	return len(idx.data[indexKey(value)])
+}
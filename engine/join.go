@@ -1,6 +1,10 @@
 package engine
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 // JoinCondition represents the condition for joining two tables
 type JoinCondition struct {
@@ -8,102 +12,376 @@ type JoinCondition struct {
 	RightColumn string
 }
 
+// JoinType represents the kind of join to perform
+type JoinType string
+
+const (
+	JoinInner JoinType = "INNER"
+	JoinLeft  JoinType = "LEFT"
+	JoinRight JoinType = "RIGHT"
+	JoinFull  JoinType = "FULL"
+)
+
+// keepsLeft reports whether rows joined so far are kept when nothing matches them
+func (t JoinType) keepsLeft() bool {
+	return t == JoinLeft || t == JoinFull
+}
+
+// keepsRight reports whether rows of the joined table are kept when nothing matches them
+func (t JoinType) keepsRight() bool {
+	return t == JoinRight || t == JoinFull
+}
+
+// JoinStep joins one more table onto the rows joined so far
+type JoinStep struct {
+	Type        JoinType
+	Table       string // Table being joined
+	LeftTable   string // Table joined earlier whose column is matched
+	LeftColumn  string
+	RightColumn string // Column of Table matched against LeftTable.LeftColumn
+}
+
 // InnerJoin performs an INNER JOIN between two tables
 func (db *Database) InnerJoin(leftTable, rightTable string, condition JoinCondition, selectColumns []string) ([]Row, error) {
-	// Get both tables
-	left, err := db.GetTable(leftTable)
+	return db.Join(leftTable, []JoinStep{joinStep(leftTable, rightTable, condition, JoinInner)}, nil, selectColumns)
+}
+
+// LeftJoin performs a LEFT OUTER JOIN between two tables
+// Left rows without a match are kept, with the right table's columns set to nil
+func (db *Database) LeftJoin(leftTable, rightTable string, condition JoinCondition, selectColumns []string) ([]Row, error) {
+	return db.Join(leftTable, []JoinStep{joinStep(leftTable, rightTable, condition, JoinLeft)}, nil, selectColumns)
+}
+
+// RightJoin performs a RIGHT OUTER JOIN between two tables
+// Right rows without a match are kept, with the left table's columns set to nil
+func (db *Database) RightJoin(leftTable, rightTable string, condition JoinCondition, selectColumns []string) ([]Row, error) {
+	return db.Join(leftTable, []JoinStep{joinStep(leftTable, rightTable, condition, JoinRight)}, nil, selectColumns)
+}
+
+// FullJoin performs a FULL OUTER JOIN between two tables
+// Rows of either table without a match are kept, with the other table's columns set to nil
+func (db *Database) FullJoin(leftTable, rightTable string, condition JoinCondition, selectColumns []string) ([]Row, error) {
+	return db.Join(leftTable, []JoinStep{joinStep(leftTable, rightTable, condition, JoinFull)}, nil, selectColumns)
+}
+
+// joinStep describes a join between two tables as the single step of a chain
+func joinStep(leftTable, rightTable string, condition JoinCondition, joinType JoinType) JoinStep {
+	return JoinStep{
+		Type:        joinType,
+		Table:       rightTable,
+		LeftTable:   leftTable,
+		LeftColumn:  condition.LeftColumn,
+		RightColumn: condition.RightColumn,
+	}
+}
+
+// Join joins a chain of tables, starting from tableName and joining each step's
+// table against the rows accumulated so far, by index lookups or a hash join
+// Columns of the result are qualified with their table name. Joined rows are
+// kept if they match the condition, and projected to the selected columns; both
+// may name columns qualified or, when only one table has them, bare, and an
+// unknown column is an ErrColumnNotFound. Results are ordered by the first table's primary
+// key, then by each joined table's primary key in turn. Rows a RIGHT or FULL step
+// keeps without a match follow those of the step, in primary key order
+func (db *Database) Join(tableName string, steps []JoinStep, condition *Condition, selectColumns []string) ([]Row, error) {
+	defer db.logSlowQuery(time.Now(), "%s", joinDescription(tableName, steps))
+	db.metrics.selects.Add(1)
+
+	tables, err := db.joinTables(tableName, steps)
 	if err != nil {
 		return nil, err
 	}
 
-	right, err := db.GetTable(rightTable)
+	unlock := lockTables(nil, tables)
+	defer unlock()
+
+	if err := validateJoin(tables, steps); err != nil {
+		return nil, err
+	}
+	condition, err = qualifyCondition(tables, condition)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkDateLiterals(tables, condition); err != nil {
+		return nil, err
+	}
+	selectColumns, err = qualifyColumns(tables, selectColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	dl := db.newDeadline()
+	scanned := 0
 
-	// Verify join columns exist
-	if !left.hasColumn(condition.LeftColumn) {
-		return nil, ErrColumnNotFound{
-			TableName:  leftTable,
-			ColumnName: condition.LeftColumn,
+	// Start from the first table in primary key order so the output does not
+	// depend on the physical row order, which deletes rearrange
+	first := tables[0]
+	first.metrics().recordScan(false, len(first.rows))
+	var results []Row
+	for _, row := range first.rowsByPrimaryKey() {
+		scanned++
+		if err := dl.check(scanned); err != nil {
+			return nil, err
 		}
+		results = append(results, qualifyRow(make(Row), row, first.name))
 	}
 
-	if !right.hasColumn(condition.RightColumn) {
-		return nil, ErrColumnNotFound{
-			TableName:  rightTable,
-			ColumnName: condition.RightColumn,
+	for i, step := range steps {
+		results, err = joinNext(results, tables[:i+1], tables[i+1], step, dl, &scanned)
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	var results []Row
+	filtered := results[:0]
+	for _, row := range results {
+		if condition == nil || evaluateCondition(row, condition) {
+			filtered = append(filtered, projectJoinedRow(row, selectColumns))
+		}
+	}
+	return filtered, nil
+}
 
-	// Check if right table has an index on the join column
-	rightIndex, hasIndex := right.GetIndex(condition.RightColumn)
+// joinTables looks up the first table and the table of every step
+func (db *Database) joinTables(tableName string, steps []JoinStep) ([]*Table, error) {
+	tables := make([]*Table, 0, len(steps)+1)
+	for _, name := range append([]string{tableName}, stepTables(steps)...) {
+		table, err := db.GetTable(name)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// validateJoin checks that every step joins a new table on columns that exist
+// in that table and in a table joined before it
+// Callers must hold the tables' locks
+func validateJoin(tables []*Table, steps []JoinStep) error {
+	joined := map[string]*Table{tables[0].name: tables[0]}
+	for i, step := range steps {
+		right := tables[i+1]
+		if _, ok := joined[right.name]; ok {
+			return fmt.Errorf("table '%s' is joined more than once", right.name)
+		}
+
+		left, ok := joined[step.LeftTable]
+		if !ok {
+			return fmt.Errorf("JOIN condition on '%s' must reference a table joined before it, not '%s'", right.name, step.LeftTable)
+		}
+		if !left.hasColumn(step.LeftColumn) {
+			return ErrColumnNotFound{TableName: left.name, ColumnName: step.LeftColumn}
+		}
+		if !right.hasColumn(step.RightColumn) {
+			return ErrColumnNotFound{TableName: right.name, ColumnName: step.RightColumn}
+		}
+		joined[right.name] = right
+	}
+	return nil
+}
+
+// qualifyCondition rewrites the columns of a condition on joined rows to their
+// qualified names, rejecting columns that are unknown or ambiguous
+// Callers must hold the tables' locks
+func qualifyCondition(tables []*Table, condition *Condition) (*Condition, error) {
+	var err error
+	qualified := condition.withColumns(func(column string) string {
+		if err != nil {
+			return column
+		}
+		var name string
+		if name, err = qualifyColumn(tables, column); err != nil {
+			return column
+		}
+		return name
+	})
+	return qualified, err
+}
+
+// qualifyColumns returns the qualified names of the selected columns of a join,
+// rejecting columns that are unknown or ambiguous
+// Callers must hold the tables' locks
+func qualifyColumns(tables []*Table, columns []string) ([]string, error) {
+	if len(columns) == 0 {
+		return columns, nil
+	}
+	qualified := make([]string, len(columns))
+	for i, column := range columns {
+		name, err := qualifyColumn(tables, column)
+		if err != nil {
+			return nil, err
+		}
+		qualified[i] = name
+	}
+	return qualified, nil
+}
+
+// qualifyColumn resolves a column reference against the joined tables
+// Callers must hold the tables' locks
+func qualifyColumn(tables []*Table, column string) (string, error) {
+	res := resolveColumn(tables, column)
+	switch {
+	case res.Ambiguous:
+		return "", fmt.Errorf("column '%s' is ambiguous: it exists in %s", column, strings.Join(res.Candidates, ", "))
+	case !res.Resolved():
+		tableName := tables[0].name
+		if qualifier, _, ok := strings.Cut(column, "."); ok {
+			tableName = qualifier
+		}
+		return "", ErrColumnNotFound{TableName: tableName, ColumnName: res.Column}
+	}
+	return res.Qualified(), nil
+}
 
-	// Iterate through left table
-	for _, leftRow := range left.rows {
-		leftValue, ok := leftRow.Get(condition.LeftColumn)
-		if !ok || leftValue == nil {
-			continue
+// joinNext joins the rows accumulated so far from the left tables against the
+// next table in the chain
+// Matches are found through the right table's index on the join column or,
+// without one, through a hash table built from a single pass over its rows.
+// Unmatched rows are kept on the sides the step's join type keeps, with the
+// other side's columns set to nil
+// Callers must hold the tables' read locks
+func joinNext(rows []Row, left []*Table, right *Table, step JoinStep, dl deadline, scanned *int) ([]Row, error) {
+	// Check if right table has an index on the join column
+	rightIndex, hasIndex := right.indexes[step.RightColumn]
+	if !hasIndex && (len(rows) > 0 || step.Type.keepsRight()) {
+		var err error
+		if rightIndex, err = buildJoinIndex(right, step.RightColumn, dl, scanned); err != nil {
+			return nil, err
 		}
+	}
+	leftColumn := fmt.Sprintf("%s.%s", step.LeftTable, step.LeftColumn)
+
+	// Row used in place of the right side for unmatched left rows
+	nullRight := make(Row)
+	for _, col := range right.schema {
+		nullRight.Set(col.Name, nil)
+	}
+
+	var results []Row
+	matched := make(map[int]bool)
+	for _, leftRow := range rows {
+		leftValue, ok := leftRow.Get(leftColumn)
 
-		// Find matching rows in right table
+		// Find matching rows in right table (NULL never matches)
 		var matchingRightIndices []int
-		if hasIndex {
-			// Use index for faster lookup
+		if ok && leftValue != nil {
 			matchingRightIndices = rightIndex.Lookup(leftValue)
-		} else {
-			// Linear scan through right table
-			for i, rightRow := range right.rows {
-				rightValue, ok := rightRow.Get(condition.RightColumn)
-				if ok && rightValue == leftValue {
-					matchingRightIndices = append(matchingRightIndices, i)
-				}
+			if hasIndex {
+				right.metrics().recordScan(true, len(matchingRightIndices))
 			}
 		}
 
-		// Create joined rows
+		// Collect matched right rows
+		var rightRows []Row
 		for _, rightIdx := range matchingRightIndices {
 			if rightIdx >= len(right.rows) {
 				continue
 			}
-			rightRow := right.rows[rightIdx]
-			joinedRow := mergeRows(leftRow, rightRow, leftTable, rightTable)
-
-			// Project columns if specified
-			if len(selectColumns) > 0 {
-				projectedRow := make(Row)
-				for _, col := range selectColumns {
-					if value, ok := joinedRow.Get(col); ok {
-						projectedRow.Set(col, value)
-					}
-				}
-				results = append(results, projectedRow)
-			} else {
-				results = append(results, joinedRow)
-			}
+			matched[rightIdx] = true
+			rightRows = append(rightRows, right.rows[rightIdx])
+		}
+
+		right.sortByPrimaryKey(rightRows)
+
+		if len(rightRows) == 0 && step.Type.keepsLeft() {
+			rightRows = []Row{nullRight}
+		}
+
+		// Create joined rows
+		for _, rightRow := range rightRows {
+			results = append(results, qualifyRow(leftRow.Copy(), rightRow, right.name))
 		}
 	}
 
+	if !step.Type.keepsRight() {
+		return results, nil
+	}
+
+	// Keep the right rows nothing matched, with every left column set to nil
+	nullLeft := make(Row)
+	for _, table := range left {
+		for _, col := range table.schema {
+			nullLeft.Set(fmt.Sprintf("%s.%s", table.name, col.Name), nil)
+		}
+	}
+	if hasIndex {
+		right.metrics().recordScan(false, len(right.rows))
+	}
+	var unmatched []Row
+	for i, row := range right.rows {
+		*scanned++
+		if err := dl.check(*scanned); err != nil {
+			return nil, err
+		}
+		if !matched[i] {
+			unmatched = append(unmatched, row)
+		}
+	}
+	right.sortByPrimaryKey(unmatched)
+	for _, row := range unmatched {
+		results = append(results, qualifyRow(nullLeft.Copy(), row, right.name))
+	}
 	return results, nil
 }
 
-// mergeRows combines two rows from different tables, prefixing column names with table names
-func mergeRows(left, right Row, leftTable, rightTable string) Row {
-	result := make(Row)
+// buildJoinIndex hashes a table's rows by a column for the duration of one join
+// Callers must hold the table's read lock
+func buildJoinIndex(table *Table, column string, dl deadline, scanned *int) (*Index, error) {
+	table.metrics().recordScan(false, len(table.rows))
+	index := NewIndex(column)
+	for i, row := range table.rows {
+		*scanned++
+		if err := dl.check(*scanned); err != nil {
+			return nil, err
+		}
+		if value, ok := row.Get(column); ok {
+			index.Add(value, i)
+		}
+	}
+	return index, nil
+}
+
+// stepTables returns the names of the tables joined by the steps, in order
+func stepTables(steps []JoinStep) []string {
+	names := make([]string, len(steps))
+	for i, step := range steps {
+		names[i] = step.Table
+	}
+	return names
+}
+
+// joinDescription summarises a join chain for the slow query log
+func joinDescription(tableName string, steps []JoinStep) string {
+	var b strings.Builder
+	b.WriteString(tableName)
+	for _, step := range steps {
+		fmt.Fprintf(&b, " %s JOIN %s", step.Type, step.Table)
+	}
+	return b.String()
+}
 
-	// Add columns from left table with prefix
-	for col, val := range left {
-		qualifiedCol := fmt.Sprintf("%s.%s", leftTable, col)
-		result.Set(qualifiedCol, val)
+// projectJoinedRow extracts the selected qualified columns from a joined row
+// If selectColumns is empty, the joined row is returned as is
+func projectJoinedRow(joinedRow Row, selectColumns []string) Row {
+	if len(selectColumns) == 0 {
+		return joinedRow
 	}
 
-	// Add columns from right table with prefix
-	for col, val := range right {
-		qualifiedCol := fmt.Sprintf("%s.%s", rightTable, col)
-		result.Set(qualifiedCol, val)
+	projectedRow := make(Row)
+	for _, col := range selectColumns {
+		if value, ok := joinedRow.Get(col); ok {
+			projectedRow.Set(col, value)
+		}
 	}
+	return projectedRow
+}
 
-	return result
+// qualifyRow copies the columns of a table's row into a joined row,
+// prefixing column names with the table name
+func qualifyRow(joined, row Row, tableName string) Row {
+	for col, val := range row {
+		joined.Set(fmt.Sprintf("%s.%s", tableName, col), val)
+	}
+	return joined
 }
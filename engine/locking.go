@@ -0,0 +1,122 @@
+package engine
+
+import "sort"
+
+// Locking order
+//
+// Every operation that needs more than one table lock takes them through
+// lockTables, which acquires them in table name order, so two operations can
+// never wait on each other's tables. Table locks are always taken before
+// db.mu, and db.mu is never held while waiting for a table lock. Unexported
+// Table helpers assume the caller already holds the table's lock.
+
+// lockTables locks the given tables in name order and returns a function releasing them
+// Tables in write are write-locked and the remaining tables read-locked
+func lockTables(write, read []*Table) func() {
+	modes := make(map[*Table]bool) // table -> write lock
+	for _, t := range read {
+		modes[t] = false
+	}
+	for _, t := range write {
+		modes[t] = true
+	}
+
+	tables := make([]*Table, 0, len(modes))
+	for t := range modes {
+		tables = append(tables, t)
+	}
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].name < tables[j].name
+	})
+
+	for _, t := range tables {
+		if modes[t] {
+			t.mu.Lock()
+		} else {
+			t.mu.RLock()
+		}
+	}
+
+	return func() {
+		for i := len(tables) - 1; i >= 0; i-- {
+			if modes[tables[i]] {
+				tables[i].mu.Unlock()
+			} else {
+				tables[i].mu.RUnlock()
+			}
+		}
+	}
+}
+
+// parentsOf returns the tables referenced by foreign keys of the given columns
+func (db *Database) parentsOf(schema []Column) []*Table {
+	var parents []*Table
+	for _, col := range schema {
+		if col.References == nil {
+			continue
+		}
+		if parent, err := db.GetTable(col.References.Table); err == nil {
+			parents = append(parents, parent)
+		}
+	}
+	return parents
+}
+
+// parentsOfTable returns the tables referenced by foreign keys of a table
+// The schema is read under db.mu since AddColumn may be replacing it
+func (db *Database) parentsOfTable(table *Table) []*Table {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	var parents []*Table
+	for _, col := range table.schema {
+		if col.References == nil {
+			continue
+		}
+		if parent, err := db.table(col.References.Table); err == nil {
+			parents = append(parents, parent)
+		}
+	}
+	return parents
+}
+
+// lockForInsert locks a table for inserting rows
+// Parent tables are read-locked so foreign key checks see a stable view
+func (db *Database) lockForInsert(table *Table) func() {
+	return lockTables([]*Table{table}, db.parentsOfTable(table))
+}
+
+// lockForUpdate locks a table for updating rows
+// Parents are read-locked for foreign key checks and children for checks that
+// referenced keys are not changed
+func (db *Database) lockForUpdate(table *Table) func() {
+	read := db.parentsOfTable(table)
+	for _, ref := range db.referencing(table.name) {
+		read = append(read, ref.child)
+	}
+	return lockTables([]*Table{table}, read)
+}
+
+// lockForDelete locks a table for deleting rows
+// Tables reached through ON DELETE CASCADE are write-locked since their rows are
+// deleted too; other referencing tables are read-locked to check for children
+func (db *Database) lockForDelete(table *Table) func() {
+	write := []*Table{table}
+	var read []*Table
+
+	visited := map[*Table]bool{table: true}
+	for i := 0; i < len(write); i++ {
+		for _, ref := range db.referencing(write[i].name) {
+			if !ref.column.References.OnDeleteCascade {
+				read = append(read, ref.child)
+				continue
+			}
+			if !visited[ref.child] {
+				visited[ref.child] = true
+				write = append(write, ref.child)
+			}
+		}
+	}
+
+	return lockTables(write, read)
+}
@@ -0,0 +1,121 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"time"
+)
+
+// defaultSlowQueryThreshold is how long a query may run before it is logged as slow
+const defaultSlowQueryThreshold = 100 * time.Millisecond
+
+// Logger receives diagnostic messages from the database
+// Messages are printf-style format strings with arguments
+type Logger interface {
+	Debug(format string, args ...interface{})
+	Info(format string, args ...interface{})
+	Warn(format string, args ...interface{})
+	Error(format string, args ...interface{})
+}
+
+// LogLevel is the minimum severity a StdLogger writes
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// String returns the level name used as a message prefix
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	default:
+		return "ERROR"
+	}
+}
+
+// StdLogger is a Logger backed by the standard library log package
+type StdLogger struct {
+	logger *log.Logger
+	level  LogLevel
+}
+
+// NewStdLogger creates a logger writing messages at or above level to w
+func NewStdLogger(w io.Writer, level LogLevel) *StdLogger {
+	return &StdLogger{logger: log.New(w, "", log.LstdFlags), level: level}
+}
+
+func (l *StdLogger) Debug(format string, args ...interface{}) { l.log(LevelDebug, format, args...) }
+func (l *StdLogger) Info(format string, args ...interface{})  { l.log(LevelInfo, format, args...) }
+func (l *StdLogger) Warn(format string, args ...interface{})  { l.log(LevelWarn, format, args...) }
+func (l *StdLogger) Error(format string, args ...interface{}) { l.log(LevelError, format, args...) }
+
+// log writes a message if its level is enabled
+func (l *StdLogger) log(level LogLevel, format string, args ...interface{}) {
+	if level < l.level {
+		return
+	}
+	l.logger.Printf("[%s] %s", level, fmt.Sprintf(format, args...))
+}
+
+// nopLogger discards every message
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+
+// SetLogger sets the logger used for slow queries and constraint violations
+// A nil logger discards messages
+func (db *Database) SetLogger(logger Logger) {
+	if logger == nil {
+		logger = nopLogger{}
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.logger = logger
+}
+
+// Logger returns the database's logger
+func (db *Database) Logger() Logger {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.logger
+}
+
+// SetSlowQueryThreshold sets how long a query may run before it is logged at debug level
+// A zero duration disables slow query logging
+func (db *Database) SetSlowQueryThreshold(d time.Duration) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.slowQuery = d
+}
+
+// logSlowQuery logs a query that ran longer than the slow query threshold
+func (db *Database) logSlowQuery(start time.Time, format string, args ...interface{}) {
+	db.mu.RLock()
+	threshold, logger := db.slowQuery, db.logger
+	db.mu.RUnlock()
+
+	elapsed := time.Since(start)
+	if threshold <= 0 || elapsed < threshold {
+		return
+	}
+	logger.Debug("slow query (%v): %s", elapsed, fmt.Sprintf(format, args...))
+}
+
+// recordViolation counts a rejected write and logs it at debug level
+func (db *Database) recordViolation(tableName string, err error) {
+	db.metrics.constraintViolations.Add(1)
+	db.Logger().Debug("constraint violation on table '%s': %v", tableName, err)
+}
@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+// Metrics is a snapshot of the database's operation counters
+type Metrics struct {
+	Selects              int64 // SELECT queries and joins
+	Inserts              int64 // Insert calls, counting a multi-row insert once
+	Updates              int64
+	Deletes              int64
+	ConstraintViolations int64
+	RowsScanned          int64 // Rows examined while filtering and joining
+	IndexHits            int64 // Row lookups served by an index
+	FullScans            int64 // Row lookups that walked the whole table
+}
+
+// WriteTo writes the metrics as "name value" lines
+func (m Metrics) WriteTo(w io.Writer) (int64, error) {
+	n, err := fmt.Fprintf(w,
+		"godb_selects_total %d\n"+
+			"godb_inserts_total %d\n"+
+			"godb_updates_total %d\n"+
+			"godb_deletes_total %d\n"+
+			"godb_constraint_violations_total %d\n"+
+			"godb_rows_scanned_total %d\n"+
+			"godb_index_hits_total %d\n"+
+			"godb_full_scans_total %d\n",
+		m.Selects, m.Inserts, m.Updates, m.Deletes,
+		m.ConstraintViolations, m.RowsScanned, m.IndexHits, m.FullScans)
+	return int64(n), err
+}
+
+// metrics holds the live counters of a database
+// A nil *metrics discards updates so tables outside a database can share code paths
+type metrics struct {
+	selects              atomic.Int64
+	inserts              atomic.Int64
+	updates              atomic.Int64
+	deletes              atomic.Int64
+	constraintViolations atomic.Int64
+	rowsScanned          atomic.Int64
+	indexHits            atomic.Int64
+	fullScans            atomic.Int64
+}
+
+// Metrics returns a snapshot of the operation counters
+func (db *Database) Metrics() Metrics {
+	m := &db.metrics
+	return Metrics{
+		Selects:              m.selects.Load(),
+		Inserts:              m.inserts.Load(),
+		Updates:              m.updates.Load(),
+		Deletes:              m.deletes.Load(),
+		ConstraintViolations: m.constraintViolations.Load(),
+		RowsScanned:          m.rowsScanned.Load(),
+		IndexHits:            m.indexHits.Load(),
+		FullScans:            m.fullScans.Load(),
+	}
+}
+
+// metrics returns the counters of the table's database, if any
+func (t *Table) metrics() *metrics {
+	if t.db == nil {
+		return nil
+	}
+	return &t.db.metrics
+}
+
+// recordScan records how a table was accessed and how many rows were examined
+func (m *metrics) recordScan(indexed bool, rows int) {
+	if m == nil {
+		return
+	}
+	if indexed {
+		m.indexHits.Add(1)
+	} else {
+		m.fullScans.Add(1)
+	}
+	m.rowsScanned.Add(int64(rows))
+}
@@ -0,0 +1,136 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Operation is an entry of the operation log: one change applied to a table
+// Updates and deletes name rows by position, which is the row's rowid, so
+// replaying the entries in order against the same starting rows is exact
+type Operation struct {
+	Op        string `json:"op"` // "insert", "update", "delete" or "truncate"
+	Table     string `json:"table"`
+	Positions []int  `json:"positions,omitempty"` // Rows changed by update or removed by delete
+	Rows      []Row  `json:"rows,omitempty"`      // Rows added by insert, or the new values of updated rows
+}
+
+// operationLog writes operations to the writer set by SetOperationLog
+type operationLog struct {
+	mu      sync.Mutex
+	encoder *json.Encoder // nil when no log is set
+}
+
+// SetOperationLog starts appending every change made by inserts, updates,
+// deletes (including cascades) and truncates to w, one JSON Operation per line
+// Schema changes and Restore are not logged. A nil w stops logging.
+// Entries are written while the changed tables are locked, so they are in the
+// order the changes were applied; a failed write is reported to the logger
+func (db *Database) SetOperationLog(w io.Writer) {
+	db.oplog.mu.Lock()
+	defer db.oplog.mu.Unlock()
+
+	db.oplog.encoder = nil
+	if w != nil {
+		db.oplog.encoder = json.NewEncoder(w)
+	}
+}
+
+// record appends an operation to the operation log, if one is set
+// Callers must hold the lock of the changed table
+func (db *Database) record(op Operation) {
+	db.oplog.mu.Lock()
+	defer db.oplog.mu.Unlock()
+
+	if db.oplog.encoder == nil {
+		return
+	}
+	if err := db.oplog.encoder.Encode(op); err != nil {
+		db.logger.Error("operation log: %v", err)
+	}
+}
+
+// Replay applies the operations of a log written by SetOperationLog in order
+// and returns how many were applied
+// The tables must already exist with the rows they held when logging started,
+// usually none: replaying into tables created from SchemaSQL rebuilds their
+// rows. Entries are applied as logged, without checking constraints again,
+// and auto-increment counters resume after the highest replayed value.
+// Replayed operations are written to this database's own log, if one is set
+func (db *Database) Replay(r io.Reader) (int, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber() // Keep integers exact until they are converted
+
+	applied := 0
+	for {
+		var op Operation
+		err := decoder.Decode(&op)
+		if errors.Is(err, io.EOF) {
+			return applied, nil
+		}
+		if err != nil {
+			return applied, fmt.Errorf("operation log entry %d: %v", applied+1, err)
+		}
+		if err := db.replay(op); err != nil {
+			return applied, fmt.Errorf("operation log entry %d: %w", applied+1, err)
+		}
+		applied++
+	}
+}
+
+// replay applies a single logged operation
+func (db *Database) replay(op Operation) error {
+	table, err := db.GetTable(op.Table)
+	if err != nil {
+		return err
+	}
+
+	table.mu.Lock()
+	defer table.mu.Unlock()
+
+	if err := table.checkDropped(); err != nil {
+		return err
+	}
+	for _, row := range op.Rows {
+		for col, value := range row {
+			column, ok := table.column(col)
+			if !ok {
+				return ErrColumnNotFound{TableName: table.name, ColumnName: col}
+			}
+			if row[col], err = archivedValue(col, column.Type, value); err != nil {
+				return err
+			}
+		}
+	}
+	for _, pos := range op.Positions {
+		if pos < 0 || pos >= len(table.rows) {
+			return fmt.Errorf("table '%s' has no row at position %d", table.name, pos)
+		}
+	}
+
+	switch op.Op {
+	case "insert":
+		for _, row := range op.Rows {
+			table.addRow(table.assignAutoIncrement(row))
+		}
+	case "update":
+		if len(op.Positions) != len(op.Rows) {
+			return fmt.Errorf("update of %d position(s) has %d row(s)", len(op.Positions), len(op.Rows))
+		}
+		for i, pos := range op.Positions {
+			table.updateRow(pos, op.Rows[i])
+		}
+	case "delete":
+		table.removeRows(op.Positions)
+	case "truncate":
+		table.truncate()
+	default:
+		return fmt.Errorf("unknown operation %q", op.Op)
+	}
+
+	db.record(op)
+	return nil
+}
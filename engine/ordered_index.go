@@ -0,0 +1,162 @@
+package engine
+
+import (
+	"sort"
+	"time"
+)
+
+// OrderedIndex keeps a column's values sorted so that range predicates
+// (>, <, >=, <=) can be answered with a binary search instead of a full scan
+// Entries are ordered by value kind, then value, then row index
+type OrderedIndex struct {
+	column  string
+	entries []orderedEntry
+}
+
+// orderedEntry maps a column value to the position of its row
+type orderedEntry struct {
+	value interface{}
+	row   int
+}
+
+// NewOrderedIndex creates a new ordered index for a column
+func NewOrderedIndex(column string) *OrderedIndex {
+	return &OrderedIndex{column: column}
+}
+
+// Add adds a row index to the index for a given value
+func (idx *OrderedIndex) Add(value interface{}, rowIndex int) {
+	if value == nil {
+		return // Don't index nil values
+	}
+
+	entry := orderedEntry{value: value, row: rowIndex}
+	i := idx.search(func(e orderedEntry) bool { return compareEntries(e, entry) > 0 })
+	idx.entries = append(idx.entries, orderedEntry{})
+	copy(idx.entries[i+1:], idx.entries[i:])
+	idx.entries[i] = entry
+}
+
+// Remove removes a row index from the index for a given value
+func (idx *OrderedIndex) Remove(value interface{}, rowIndex int) {
+	if value == nil {
+		return
+	}
+
+	entry := orderedEntry{value: value, row: rowIndex}
+	i := idx.search(func(e orderedEntry) bool { return compareEntries(e, entry) >= 0 })
+	if i < len(idx.entries) && compareEntries(idx.entries[i], entry) == 0 {
+		idx.entries = append(idx.entries[:i], idx.entries[i+1:]...)
+	}
+}
+
+// Update updates the index when a row's value changes
+func (idx *OrderedIndex) Update(oldValue, newValue interface{}, rowIndex int) {
+	idx.Remove(oldValue, rowIndex)
+	idx.Add(newValue, rowIndex)
+}
+
+// Range returns the row indices whose value satisfies "value <operator> bound"
+// for >, <, >= and <=. It returns false when the bound cannot be ordered or
+// the operator is not an inequality, in which case the index cannot be used
+func (idx *OrderedIndex) Range(operator string, bound interface{}) ([]int, bool) {
+	kind := valueKind(bound)
+	if kind == kindOther {
+		return nil, false
+	}
+
+	// Values of the bound's kind form one contiguous, sorted run
+	lo := idx.search(func(e orderedEntry) bool { return valueKind(e.value) >= kind })
+	hi := idx.search(func(e orderedEntry) bool { return valueKind(e.value) > kind })
+	above := func(inclusive bool) int {
+		return lo + sort.Search(hi-lo, func(i int) bool {
+			cmp := compareValues(idx.entries[lo+i].value, bound)
+			return cmp > 0 || (inclusive && cmp == 0)
+		})
+	}
+
+	var start, end int
+	switch operator {
+	case ">":
+		start, end = above(false), hi
+	case ">=":
+		start, end = above(true), hi
+	case "<":
+		start, end = lo, above(true)
+	case "<=":
+		start, end = lo, above(false)
+	default:
+		return nil, false
+	}
+
+	rows := make([]int, 0, end-start)
+	for _, e := range idx.entries[start:end] {
+		rows = append(rows, e.row)
+	}
+
+	// compareValues treats values of different kinds as equal, so >= and <=
+	// match them too; include them to keep results identical to a full scan
+	if operator == ">=" || operator == "<=" {
+		for _, e := range idx.entries[:lo] {
+			rows = append(rows, e.row)
+		}
+		for _, e := range idx.entries[hi:] {
+			rows = append(rows, e.row)
+		}
+	}
+
+	return rows, true
+}
+
+// reset repopulates the index from the given rows
+func (idx *OrderedIndex) reset(rows []Row) {
+	idx.entries = idx.entries[:0]
+	for rowIdx, row := range rows {
+		if value, ok := row.Get(idx.column); ok && value != nil {
+			idx.entries = append(idx.entries, orderedEntry{value: value, row: rowIdx})
+		}
+	}
+	sort.Slice(idx.entries, func(i, j int) bool {
+		return compareEntries(idx.entries[i], idx.entries[j]) < 0
+	})
+}
+
+// search returns the first entry position for which f is true
+func (idx *OrderedIndex) search(f func(e orderedEntry) bool) int {
+	return sort.Search(len(idx.entries), func(i int) bool { return f(idx.entries[i]) })
+}
+
+// Value kinds, in the order they are kept by an ordered index
+const (
+	kindNumber = iota
+	kindString
+	kindBool
+	kindTime
+	kindOther
+)
+
+// valueKind groups values that compareValues can order against each other
+func valueKind(value interface{}) int {
+	switch value.(type) {
+	case int, float64:
+		return kindNumber
+	case string:
+		return kindString
+	case bool:
+		return kindBool
+	case time.Time:
+		return kindTime
+	}
+	return kindOther
+}
+
+// compareEntries orders entries by value kind, then value, then row index
+func compareEntries(a, b orderedEntry) int {
+	if ka, kb := valueKind(a.value), valueKind(b.value); ka != kb {
+		return ka - kb
+	}
+	if cmp := compareValues(a.value, b.value); cmp != 0 {
+		return cmp
+	}
+	return a.row - b.row
+}
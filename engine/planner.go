@@ -0,0 +1,410 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Analysis holds per-column statistics gathered by ANALYZE
+type Analysis struct {
+	RowCount    int
+	Cardinality map[string]int // column -> number of distinct non-null values
+}
+
+// Analyze computes per-column cardinality and stores it on the table
+// The planner uses these statistics to choose between indexes
+func (t *Table) Analyze() Analysis {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	analysis := Analysis{
+		RowCount:    len(t.rows),
+		Cardinality: make(map[string]int, len(t.schema)),
+	}
+
+	for _, col := range t.schema {
+		distinct := make(map[interface{}]bool)
+		for _, row := range t.rows {
+			if value, ok := row.Get(col.Name); ok && value != nil {
+				distinct[value] = true
+			}
+		}
+		analysis.Cardinality[col.Name] = len(distinct)
+	}
+
+	t.analysis = &analysis
+	return analysis
+}
+
+// Analysis returns the statistics from the last ANALYZE, if any
+func (t *Table) Analysis() (Analysis, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.analysis == nil {
+		return Analysis{}, false
+	}
+	return *t.analysis, true
+}
+
+// Analyze refreshes the planner statistics of a table
+func (db *Database) Analyze(tableName string) error {
+	table, err := db.GetTable(tableName)
+	if err != nil {
+		return err
+	}
+	table.Analyze()
+	return nil
+}
+
+// accessPath describes how candidate rows are located
+// Either index is set for an equality lookup, or ordered for a range scan;
+// neither means a full table scan
+type accessPath struct {
+	index    *Index
+	ordered  *OrderedIndex
+	operator string // Inequality answered by the ordered index
+	value    interface{}
+}
+
+// indexed reports whether the path avoids a full table scan
+func (p accessPath) indexed() bool {
+	return p.index != nil || p.ordered != nil
+}
+
+// candidates returns the row positions the path yields, in rowid order
+func (p accessPath) candidates() []int {
+	var positions []int
+	if p.index != nil {
+		positions = append(positions, p.index.Lookup(p.value)...)
+	} else {
+		positions, _ = p.ordered.Range(p.operator, p.value)
+	}
+	// Index entries can fall out of position order after updates
+	sort.Ints(positions)
+	return positions
+}
+
+// chooseAccessPath picks an index for the predicates that every matching
+// row must satisfy (the AND conjuncts of the condition)
+// Equality predicates on a hash index are preferred. With ANALYZE statistics
+// the most selective (highest cardinality) of them wins, otherwise the first
+// indexed predicate in the condition is used. Failing that, the first
+// inequality on a column with an ordered index becomes a range scan
+func (t *Table) chooseAccessPath(condition *Condition) accessPath {
+	var best accessPath
+	bestCardinality := -1
+
+	for _, pred := range conjuncts(condition) {
+		if pred.Arith != nil {
+			continue
+		}
+		if pred.Operator != "=" {
+			if best.ordered == nil && t.rangeScannable(pred) {
+				best.ordered = t.ordered[pred.Column]
+				best.operator = pred.Operator
+				best.value = t.lookupValue(pred)
+			}
+			continue
+		}
+		idx, ok := t.indexes[pred.Column]
+		if !ok {
+			continue
+		}
+
+		cardinality := 0
+		if t.analysis != nil {
+			cardinality = t.analysis.Cardinality[pred.Column]
+		}
+
+		if best.index == nil || cardinality > bestCardinality {
+			best = accessPath{index: idx, value: t.lookupValue(pred)}
+			bestCardinality = cardinality
+		}
+	}
+
+	if best.index != nil {
+		best.ordered = nil
+	}
+	return best
+}
+
+// rangeScannable reports whether a predicate can be answered by an ordered index
+func (t *Table) rangeScannable(pred *Condition) bool {
+	if _, ok := t.ordered[pred.Column]; !ok {
+		return false
+	}
+	switch pred.Operator {
+	case ">", "<", ">=", "<=":
+		return valueKind(t.lookupValue(pred)) != kindOther
+	}
+	return false
+}
+
+// lookupValue returns a predicate's value as it is keyed in the column's indexes
+// Date strings compared with a DATE column are looked up as times
+func (t *Table) lookupValue(pred *Condition) interface{} {
+	if col, ok := t.column(pred.Column); ok && col.Type == TypeDate {
+		if value, err := coerceDate(pred.Column, pred.Value); err == nil {
+			return value
+		}
+	}
+	return pred.Value
+}
+
+// conjuncts flattens the AND-ed predicates of a condition
+// OR conditions are returned whole since neither side must hold on its own
+func conjuncts(condition *Condition) []*Condition {
+	if condition == nil {
+		return nil
+	}
+	if condition.Operator == "AND" {
+		return append(conjuncts(condition.Left), conjuncts(condition.Right)...)
+	}
+	return []*Condition{condition}
+}
+
+// PlanNode is a single step of a query plan
+type PlanNode struct {
+	Op            string // e.g. "IndexScan", "FullScan", "Filter", "Project"
+	Detail        string
+	EstimatedRows int
+	Children      []*PlanNode
+}
+
+// String renders the node as Op(Detail)
+func (n *PlanNode) String() string {
+	if n.Detail == "" {
+		return n.Op
+	}
+	return fmt.Sprintf("%s(%s)", n.Op, n.Detail)
+}
+
+// Plan describes how a query will be executed
+type Plan struct {
+	Root *PlanNode
+}
+
+// String renders the plan from the root down, e.g. "Project(*) -> Filter(...) -> IndexScan(email)"
+func (p *Plan) String() string {
+	var steps []string
+	for node := p.Root; node != nil; {
+		steps = append(steps, node.String())
+		if len(node.Children) == 0 {
+			break
+		}
+		node = node.Children[0]
+	}
+	return strings.Join(steps, " -> ")
+}
+
+// TreeString renders the plan as an indented tree, one node per line
+// Each line shows the node and its estimated row count, e.g.
+//
+//	Project(*) rows=1
+//	└─ Filter(age > 30) rows=1
+//	   └─ IndexScan(users.email = 'a@example.com') rows=1
+func (p *Plan) TreeString() string {
+	var b strings.Builder
+	if p.Root != nil {
+		writeTree(&b, p.Root, "", "")
+	}
+	return b.String()
+}
+
+// writeTree writes node on its own line, then its children below it
+// first prefixes the node's line and rest prefixes the lines of its children
+func writeTree(b *strings.Builder, node *PlanNode, first, rest string) {
+	fmt.Fprintf(b, "%s%s rows=%d\n", first, node.String(), node.EstimatedRows)
+	for i, child := range node.Children {
+		if i == len(node.Children)-1 {
+			writeTree(b, child, rest+"└─ ", rest+"   ")
+		} else {
+			writeTree(b, child, rest+"├─ ", rest+"│  ")
+		}
+	}
+}
+
+// AccessPath returns the scan node at the bottom of the plan
+func (p *Plan) AccessPath() *PlanNode {
+	node := p.Root
+	for node != nil && len(node.Children) > 0 {
+		node = node.Children[0]
+	}
+	return node
+}
+
+// Explain describes how a query would be executed without running it
+func (db *Database) Explain(q Query) (*Plan, error) {
+	q = q.Unqualified()
+	table, err := db.GetTable(q.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	table.mu.RLock()
+	defer table.mu.RUnlock()
+
+	var node *PlanNode
+	path := table.chooseAccessPath(q.Condition)
+	if path.index != nil {
+		node = &PlanNode{
+			Op:            "IndexScan",
+			Detail:        fmt.Sprintf("%s.%s = %s", table.name, path.index.column, formatValue(path.value)),
+			EstimatedRows: path.index.Count(path.value),
+		}
+	} else if path.ordered != nil {
+		node = &PlanNode{
+			Op:            "RangeScan",
+			Detail:        fmt.Sprintf("%s.%s %s %s", table.name, path.ordered.column, path.operator, formatValue(path.value)),
+			EstimatedRows: len(path.candidates()),
+		}
+	} else {
+		node = &PlanNode{
+			Op:            "FullScan",
+			Detail:        table.name,
+			EstimatedRows: len(table.rows),
+		}
+	}
+
+	wrap := func(op, detail string) {
+		node = &PlanNode{Op: op, Detail: detail, EstimatedRows: node.EstimatedRows, Children: []*PlanNode{node}}
+	}
+
+	if q.Condition != nil {
+		wrap("Filter", q.Condition.String())
+	}
+
+	names := make([]string, len(q.Aggregates))
+	for i, agg := range q.Aggregates {
+		names[i] = agg.String()
+	}
+
+	if len(q.GroupBy) > 0 {
+		detail := strings.Join(q.GroupBy, ", ")
+		if len(names) > 0 {
+			detail += ": " + strings.Join(names, ", ")
+		}
+		wrap("GroupBy", detail)
+	} else if len(q.Aggregates) > 0 {
+		wrap("Aggregate", strings.Join(names, ", "))
+		node.EstimatedRows = 1
+		return &Plan{Root: node}, nil
+	}
+
+	if len(q.OrderBy) > 0 {
+		terms := make([]string, len(q.OrderBy))
+		for i, order := range q.OrderBy {
+			terms[i] = order.Column
+			if order.Desc {
+				terms[i] += " DESC"
+			}
+			switch order.Nulls {
+			case NullsFirst:
+				terms[i] += " NULLS FIRST"
+			case NullsLast:
+				terms[i] += " NULLS LAST"
+			}
+		}
+		wrap("Sort", strings.Join(terms, ", "))
+	}
+
+	if len(q.DistinctOn) > 0 {
+		wrap("DistinctOn", strings.Join(q.DistinctOn, ", "))
+	}
+
+	outputs := make([]string, 0, len(q.Columns)+len(names))
+	for _, col := range q.Columns {
+		if f, ok := q.Functions[col]; ok {
+			col = f.String()
+		} else if alias, ok := q.Aliases[col]; ok {
+			col += " AS " + alias
+		}
+		outputs = append(outputs, col)
+	}
+	columns := "*"
+	if outputs = append(outputs, names...); len(outputs) > 0 {
+		columns = strings.Join(outputs, ", ")
+	}
+	wrap("Project", columns)
+
+	if q.Distinct {
+		wrap("Distinct", "")
+	}
+
+	return &Plan{Root: node}, nil
+}
+
+// ExplainJoin describes how a join would be executed without running it
+// The left table drives the join and the right table is probed per left row, through
+// its index on the join column or a hash table built from it
+func (db *Database) ExplainJoin(leftTable, rightTable string, condition JoinCondition, joinType JoinType, selectColumns []string) (*Plan, error) {
+	return db.ExplainJoins(leftTable, []JoinStep{joinStep(leftTable, rightTable, condition, joinType)}, nil, selectColumns)
+}
+
+// ExplainJoins describes how a chain of joins would be executed without running it
+// Each step is a NestedLoopJoin over an index or a HashJoin whose outer side is the
+// result of the steps before it,
+// and the condition filters the joined rows
+func (db *Database) ExplainJoins(tableName string, steps []JoinStep, condition *Condition, selectColumns []string) (*Plan, error) {
+	tables, err := db.joinTables(tableName, steps)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock := lockTables(nil, tables)
+	defer unlock()
+
+	if err := validateJoin(tables, steps); err != nil {
+		return nil, err
+	}
+	condition, err = qualifyCondition(tables, condition)
+	if err != nil {
+		return nil, err
+	}
+	selectColumns, err = qualifyColumns(tables, selectColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	first := tables[0]
+	outer := &PlanNode{Op: "FullScan", Detail: first.name, EstimatedRows: len(first.rows)}
+
+	for i, step := range steps {
+		right := tables[i+1]
+
+		// Without an index the right table is scanned once to build a hash table
+		op := "NestedLoopJoin"
+		var inner *PlanNode
+		if _, ok := right.indexes[step.RightColumn]; ok {
+			inner = &PlanNode{
+				Op:            "IndexLookup",
+				Detail:        fmt.Sprintf("%s.%s", right.name, step.RightColumn),
+				EstimatedRows: 1,
+			}
+		} else {
+			op = "HashJoin"
+			inner = &PlanNode{Op: "FullScan", Detail: right.name, EstimatedRows: len(right.rows)}
+		}
+
+		outer = &PlanNode{
+			Op:            op,
+			Detail:        fmt.Sprintf("%s %s.%s = %s.%s", step.Type, step.LeftTable, step.LeftColumn, right.name, step.RightColumn),
+			EstimatedRows: outer.EstimatedRows,
+			Children:      []*PlanNode{outer, inner},
+		}
+	}
+
+	if condition != nil {
+		outer = &PlanNode{Op: "Filter", Detail: condition.String(), EstimatedRows: outer.EstimatedRows, Children: []*PlanNode{outer}}
+	}
+
+	columns := "*"
+	if len(selectColumns) > 0 {
+		columns = strings.Join(selectColumns, ", ")
+	}
+	root := &PlanNode{Op: "Project", Detail: columns, EstimatedRows: outer.EstimatedRows, Children: []*PlanNode{outer}}
+
+	return &Plan{Root: root}, nil
+}
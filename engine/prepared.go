@@ -0,0 +1,143 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Param is a placeholder for a condition value, written ? in SQL
+// A condition holding Params is a template: bind it with BindParams or run it
+// with ExecutePrepared. Left unbound, a Param is compared like a value that
+// equals nothing, so = matches no rows and != matches every row
+type Param struct {
+	Index int // Zero-based position of the argument that replaces the placeholder
+}
+
+// Params returns the number of arguments needed to bind the condition's placeholders
+func (c *Condition) Params() int {
+	if c == nil {
+		return 0
+	}
+	if c.IsCompound() {
+		return max(c.Left.Params(), c.Right.Params())
+	}
+
+	count := 0
+	for _, value := range []interface{}{c.Value, c.High} {
+		if param, ok := value.(Param); ok {
+			count = max(count, param.Index+1)
+		}
+	}
+	return count
+}
+
+// ExecutePrepared binds args to the placeholders of the query's condition and runs it
+// The query can be built once, e.g. from a statement parsed with Parser.Prepare,
+// and executed any number of times with different arguments
+func (db *Database) ExecutePrepared(q Query, args ...interface{}) ([]Row, error) {
+	condition, err := db.BindParams([]string{q.Table}, q.Condition, args)
+	if err != nil {
+		return nil, err
+	}
+	q.Condition = condition
+	return db.Query(q)
+}
+
+// BindParams returns a copy of a condition over the given tables with every
+// Param replaced by its argument
+// The number of arguments must match the placeholders (ErrParamCount), and each
+// argument must be NULL or have the type of the column it is compared with
+// (ErrInvalidValue); LIKE and ILIKE patterns must be strings. Columns may be
+// qualified, as in the condition of a join
+func (db *Database) BindParams(tableNames []string, condition *Condition, args []interface{}) (*Condition, error) {
+	if expected := condition.Params(); expected != len(args) {
+		return nil, ErrParamCount{Expected: expected, Got: len(args)}
+	}
+	if len(args) == 0 {
+		return condition, nil
+	}
+
+	tables := make([]*Table, 0, len(tableNames))
+	for _, name := range tableNames {
+		table, err := db.GetTable(name)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+
+	unlock := lockTables(nil, tables)
+	defer unlock()
+
+	return bindCondition(tables, condition, args)
+}
+
+// bindCondition substitutes args into a copy of the condition
+// Callers must hold the tables' locks
+func bindCondition(tables []*Table, c *Condition, args []interface{}) (*Condition, error) {
+	if c == nil {
+		return nil, nil
+	}
+	bound := *c
+	if c.IsCompound() {
+		var err error
+		if bound.Left, err = bindCondition(tables, c.Left, args); err != nil {
+			return nil, err
+		}
+		if bound.Right, err = bindCondition(tables, c.Right, args); err != nil {
+			return nil, err
+		}
+		return &bound, nil
+	}
+
+	for _, value := range []*interface{}{&bound.Value, &bound.High} {
+		param, ok := (*value).(Param)
+		if !ok {
+			continue
+		}
+		arg, err := checkParam(tables, c, args[param.Index])
+		if err != nil {
+			return nil, err
+		}
+		*value = arg
+	}
+	return &bound, nil
+}
+
+// checkParam checks that an argument can be compared by a condition and
+// returns the value to bind; date strings compared with a DATE column are
+// bound as times
+// Callers must hold the tables' locks
+func checkParam(tables []*Table, c *Condition, arg interface{}) (interface{}, error) {
+	res := resolveColumn(tables, c.Column)
+	switch {
+	case res.Ambiguous:
+		return nil, fmt.Errorf("column '%s' is ambiguous: it exists in %s", c.Column, strings.Join(res.Candidates, ", "))
+	case !res.Resolved():
+		return nil, ErrColumnNotFound{TableName: tables[0].name, ColumnName: c.Column}
+	}
+	if arg == nil {
+		return nil, nil
+	}
+
+	var col Column
+	for _, table := range tables {
+		if table.name == res.Table {
+			col, _ = table.column(res.Column)
+		}
+	}
+	expected := col.Type
+	if c.Operator == "LIKE" || c.Operator == "ILIKE" {
+		expected = TypeString
+	}
+	if expected == TypeDate {
+		var err error
+		if arg, err = coerceDate(c.Column, arg); err != nil {
+			return nil, err
+		}
+	}
+	if !matchesType(expected, arg) {
+		return nil, ErrInvalidValue{Column: c.Column, Expected: string(expected), Got: arg}
+	}
+	return arg, nil
+}
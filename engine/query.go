@@ -0,0 +1,484 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// NullsOrder controls where NULLs are placed by an ORDER BY term
+type NullsOrder int
+
+const (
+	NullsDefault NullsOrder = iota // Last when ascending, first when descending
+	NullsFirst
+	NullsLast
+)
+
+// OrderBy represents an ORDER BY term; a query sorts by a list of terms
+type OrderBy struct {
+	Column string
+	Desc   bool
+	Nulls  NullsOrder
+}
+
+// nullsFirst reports whether NULLs sort before every other value for this term
+func (o OrderBy) nullsFirst() bool {
+	switch o.Nulls {
+	case NullsFirst:
+		return true
+	case NullsLast:
+		return false
+	}
+	return o.Desc
+}
+
+// Query describes a SELECT against a single table
+type Query struct {
+	Table      string
+	Columns    []string
+	Condition  *Condition
+	Distinct   bool                  // Drop rows whose projected values duplicate an earlier row
+	DistinctOn []string              // Keep the first row per distinct value of these columns
+	OrderBy    []OrderBy             // Sort keys, compared in order until one differs
+	GroupBy    []string              // Compute the aggregates once per distinct value of these columns
+	Aggregates []Aggregate           // Without GroupBy, the result is a single aggregated row
+	Aliases    map[string]string     // Output name for selected columns, keyed by column
+	Functions  map[string]ScalarFunc // Computed columns, keyed by their output name in Columns
+}
+
+// Query runs a SELECT described by q
+// Rows are filtered, sorted, reduced by DISTINCT ON, projected and finally
+// deduplicated by DISTINCT. With GROUP BY, rows are grouped after filtering
+// and each group becomes one row; otherwise aggregates collapse everything
+// into a single row. Selected columns are renamed by their aliases last
+func (db *Database) Query(q Query) ([]Row, error) {
+	defer db.logSlowQuery(time.Now(), "SELECT from %s", q.Table)
+	db.metrics.selects.Add(1)
+	q = q.Unqualified()
+
+	table, err := db.GetTable(q.Table)
+	if err != nil {
+		return nil, err
+	}
+	table.buildDeferredIndexes(q.Condition)
+
+	table.mu.RLock()
+	defer table.mu.RUnlock()
+
+	if err := table.checkDropped(); err != nil {
+		return nil, err
+	}
+
+	for _, col := range append(append(append([]string{}, q.Columns...), q.DistinctOn...), q.GroupBy...) {
+		if _, computed := q.Functions[col]; !computed && !table.hasColumn(col) {
+			return nil, ErrColumnNotFound{TableName: q.Table, ColumnName: col}
+		}
+	}
+	for _, f := range q.Functions {
+		if err := table.validateFunction(f); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := q.validateAliases(); err != nil {
+		return nil, err
+	}
+	if err := checkDateLiterals([]*Table{table}, q.Condition); err != nil {
+		return nil, err
+	}
+
+	if len(q.GroupBy) > 0 {
+		if len(q.Functions) > 0 {
+			return nil, fmt.Errorf("functions cannot be combined with GROUP BY")
+		}
+		results, err := db.groupedQuery(table, q)
+		if err != nil {
+			return nil, err
+		}
+		return renameColumns(results, q.Aliases), nil
+	}
+
+	for _, order := range q.OrderBy {
+		if !table.hasColumn(order.Column) {
+			return nil, ErrColumnNotFound{TableName: q.Table, ColumnName: order.Column}
+		}
+	}
+
+	if len(q.Aggregates) > 0 {
+		if len(q.Columns) > 0 {
+			return nil, fmt.Errorf("cannot mix aggregates and plain columns without GROUP BY")
+		}
+		for _, agg := range q.Aggregates {
+			if err := table.validateAggregate(agg); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	rows, err := table.filterRows(q.Condition, db.newDeadline())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(q.Aggregates) > 0 {
+		return []Row{aggregateRows(rows, q.Aggregates)}, nil
+	}
+
+	if len(q.OrderBy) > 0 {
+		sortRows(rows, q.OrderBy)
+	}
+
+	if len(q.DistinctOn) > 0 {
+		rows = distinctOn(rows, q.DistinctOn)
+	}
+
+	results := make([]Row, 0, len(rows))
+	for _, row := range rows {
+		result := projectRow(row, q.Columns, table.schema)
+		applyFunctions(result, row, q.Functions)
+		results = append(results, result)
+	}
+
+	if q.Distinct {
+		columns := q.Columns
+		if len(columns) == 0 {
+			columns = table.columnNames()
+		}
+		// Keeping the first row of each group preserves the ORDER BY
+		results = distinctOn(results, columns)
+	}
+
+	return renameColumns(results, q.Aliases), nil
+}
+
+// SelectOrdered runs q like Query and also returns the result's column names
+// in a stable order: the selected columns as written, or the schema's
+// declaration order for SELECT *, followed by the aggregates
+func (db *Database) SelectOrdered(q Query) ([]string, []Row, error) {
+	table, err := db.GetTable(q.Table)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := db.Query(q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return q.Unqualified().outputColumns(table.ColumnNames()), rows, nil
+}
+
+// outputColumns returns the names of the columns the query returns, in order
+// tableColumns lists the table's columns in schema order, for SELECT *
+func (q Query) outputColumns(tableColumns []string) []string {
+	columns := append([]string{}, q.Columns...)
+	if len(columns) == 0 && len(q.Aggregates) == 0 {
+		if len(q.GroupBy) > 0 {
+			columns = append(columns, q.GroupBy...)
+		} else {
+			columns = append(columns, tableColumns...)
+		}
+	}
+	for i, col := range columns {
+		if alias, ok := q.Aliases[col]; ok {
+			columns[i] = alias
+		}
+	}
+	for _, agg := range q.Aggregates {
+		columns = append(columns, agg.Name())
+	}
+	return columns
+}
+
+// groupedQuery runs a query with GROUP BY
+// Groups appear in the order their first row was inserted, and NULL forms a
+// group of its own. Callers must hold the table's read lock
+func (db *Database) groupedQuery(table *Table, q Query) ([]Row, error) {
+	if len(q.DistinctOn) > 0 {
+		return nil, fmt.Errorf("DISTINCT ON cannot be combined with GROUP BY")
+	}
+
+	grouped := make(map[string]bool, len(q.GroupBy))
+	for _, col := range q.GroupBy {
+		grouped[col] = true
+	}
+
+	for _, col := range q.Columns {
+		if !grouped[col] {
+			return nil, fmt.Errorf("column '%s' must appear in GROUP BY or be used in an aggregate", col)
+		}
+	}
+	outputs := make(map[string]bool, len(q.GroupBy)+len(q.Aggregates))
+	for col := range grouped {
+		outputs[col] = true
+	}
+	for _, agg := range q.Aggregates {
+		if err := table.validateAggregate(agg); err != nil {
+			return nil, err
+		}
+		outputs[agg.Name()] = true
+	}
+	for _, order := range q.OrderBy {
+		if !outputs[order.Column] {
+			return nil, fmt.Errorf("ORDER BY column '%s' must appear in GROUP BY or name an aggregate", order.Column)
+		}
+	}
+
+	rows, err := table.filterRows(q.Condition, db.newDeadline())
+	if err != nil {
+		return nil, err
+	}
+
+	groups := groupRows(rows, q.GroupBy)
+	results := make([]Row, 0, len(groups))
+	for _, group := range groups {
+		result := aggregateRows(group, q.Aggregates)
+		for _, col := range q.GroupBy {
+			value, _ := group[0].Get(col)
+			result.Set(col, value)
+		}
+		results = append(results, result)
+	}
+
+	if len(q.OrderBy) > 0 {
+		sortRows(results, q.OrderBy)
+	}
+
+	// Only the selected group columns are returned, or all of them for SELECT *
+	if len(q.Columns) > 0 || len(q.Aggregates) > 0 {
+		for _, result := range results {
+			for _, col := range q.GroupBy {
+				if !containsString(q.Columns, col) {
+					delete(result, col)
+				}
+			}
+		}
+	}
+
+	if q.Distinct {
+		columns := append([]string{}, q.Columns...)
+		for _, agg := range q.Aggregates {
+			columns = append(columns, agg.Name())
+		}
+		if len(columns) == 0 {
+			columns = q.GroupBy
+		}
+		results = distinctOn(results, columns)
+	}
+
+	return results, nil
+}
+
+// groupRows partitions rows by the values of the given columns, keeping the
+// groups in order of their first row
+func groupRows(rows []Row, columns []string) [][]Row {
+	index := make(map[string]int)
+	var groups [][]Row
+	for _, row := range rows {
+		key := rowKey(row, columns)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], row)
+	}
+	return groups
+}
+
+// Unqualified returns a copy of the query in which column references qualified
+// with the query's own table, such as users.name, use the bare column name
+// References qualified with any other table are left alone and fail as unknown columns
+func (q Query) Unqualified() Query {
+	strip := func(column string) string {
+		return strings.TrimPrefix(column, q.Table+".")
+	}
+	stripAll := func(columns []string) []string {
+		if columns == nil {
+			return nil
+		}
+		result := make([]string, len(columns))
+		for i, col := range columns {
+			result[i] = strip(col)
+		}
+		return result
+	}
+
+	q.Columns = stripAll(q.Columns)
+	q.DistinctOn = stripAll(q.DistinctOn)
+	q.GroupBy = stripAll(q.GroupBy)
+	q.Condition = q.Condition.withColumns(strip)
+
+	if q.OrderBy != nil {
+		orderBy := make([]OrderBy, len(q.OrderBy))
+		for i, order := range q.OrderBy {
+			order.Column = strip(order.Column)
+			orderBy[i] = order
+		}
+		q.OrderBy = orderBy
+	}
+
+	if q.Aggregates != nil {
+		aggregates := make([]Aggregate, len(q.Aggregates))
+		for i, agg := range q.Aggregates {
+			agg.Column = strip(agg.Column)
+			aggregates[i] = agg
+		}
+		q.Aggregates = aggregates
+	}
+
+	if q.Functions != nil {
+		functions := make(map[string]ScalarFunc, len(q.Functions))
+		for name, f := range q.Functions {
+			f.Column = strip(f.Column)
+			functions[name] = f
+		}
+		q.Functions = functions
+	}
+
+	if q.Aliases != nil {
+		aliases := make(map[string]string, len(q.Aliases))
+		for col, alias := range q.Aliases {
+			aliases[strip(col)] = alias
+		}
+		q.Aliases = aliases
+	}
+
+	return q
+}
+
+// validateAliases checks that aliases name selected columns and that no two
+// output columns end up with the same name
+func (q Query) validateAliases() error {
+	aliased := len(q.Aliases) > 0
+	for _, agg := range q.Aggregates {
+		aliased = aliased || agg.Alias != ""
+	}
+	for _, f := range q.Functions {
+		aliased = aliased || f.Alias != ""
+	}
+	if !aliased {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(q.Columns)+len(q.Aggregates))
+	outputs := make([]string, 0, len(q.Columns)+len(q.Aggregates))
+	for _, col := range q.Columns {
+		if alias, ok := q.Aliases[col]; ok {
+			col = alias
+		}
+		outputs = append(outputs, col)
+	}
+	for _, agg := range q.Aggregates {
+		outputs = append(outputs, agg.Name())
+	}
+	for _, name := range outputs {
+		if seen[name] {
+			return fmt.Errorf("output column '%s' is named more than once", name)
+		}
+		seen[name] = true
+	}
+
+	for col := range q.Aliases {
+		if !containsString(q.Columns, col) {
+			return fmt.Errorf("alias given for column '%s', which is not selected", col)
+		}
+	}
+	return nil
+}
+
+// renameColumns renames the keys of result rows by their aliases
+func renameColumns(rows []Row, aliases map[string]string) []Row {
+	if len(aliases) == 0 {
+		return rows
+	}
+	for i, row := range rows {
+		renamed := make(Row, len(row))
+		for col, value := range row {
+			if alias, ok := aliases[col]; ok {
+				col = alias
+			}
+			renamed[col] = value
+		}
+		rows[i] = renamed
+	}
+	return rows
+}
+
+// containsString reports whether s is in list
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// sortRows sorts rows in place by the given ORDER BY terms
+// Rows are compared by the first term, falling through to the next one on a
+// tie. By default NULLs sort after every other value in ascending order and
+// before them in descending order; NULLS FIRST and NULLS LAST override the
+// placement. Rows arrive in rowid order and the sort is stable, so rows equal
+// on every term always come back in rowid order
+func sortRows(rows []Row, orderBy []OrderBy) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		for _, order := range orderBy {
+			if cmp := compareTerm(rows[i], rows[j], order); cmp != 0 {
+				return cmp < 0
+			}
+		}
+		return false
+	})
+}
+
+// compareTerm compares two rows by a single ORDER BY term, returning a
+// negative number when a sorts first, a positive one when b does, and 0 on a tie
+func compareTerm(a, b Row, order OrderBy) int {
+	x, _ := a.Get(order.Column)
+	y, _ := b.Get(order.Column)
+
+	if x == nil || y == nil {
+		switch {
+		case x == nil && y == nil:
+			return 0
+		case (x == nil) == order.nullsFirst():
+			return -1
+		default:
+			return 1
+		}
+	}
+
+	cmp := compareValues(x, y)
+	if order.Desc {
+		return -cmp
+	}
+	return cmp
+}
+
+// distinctOn keeps the first row for each distinct combination of the given columns
+func distinctOn(rows []Row, columns []string) []Row {
+	seen := make(map[string]bool)
+	result := make([]Row, 0, len(rows))
+	for _, row := range rows {
+		key := rowKey(row, columns)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, row)
+	}
+	return result
+}
+
+// rowKey builds a comparable key from the values of the given columns
+// A missing value and an explicit nil both render as NULL, so all NULLs share a key
+func rowKey(row Row, columns []string) string {
+	parts := make([]string, len(columns))
+	for i, col := range columns {
+		value, _ := row.Get(col)
+		parts[i] = fmt.Sprintf("%T:%#v", value, value)
+	}
+	return strings.Join(parts, "\x00")
+}
@@ -0,0 +1,122 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ColumnResolution describes how a column reference resolves against the queried tables
+type ColumnResolution struct {
+	Reference  string   // The column as written in the query
+	Table      string   // Table the column belongs to, empty if unresolved
+	Column     string   // Column name without table qualifier
+	Ambiguous  bool     // True when several tables have the column
+	Candidates []string // Tables that have the column when ambiguous
+}
+
+// Resolved reports whether the reference maps to exactly one table column
+func (r ColumnResolution) Resolved() bool {
+	return r.Table != ""
+}
+
+// Qualified returns the table-qualified column name
+func (r ColumnResolution) Qualified() string {
+	return fmt.Sprintf("%s.%s", r.Table, r.Column)
+}
+
+// ResolutionReport describes how every column referenced by a query resolves
+type ResolutionReport struct {
+	Tables  []string
+	Columns []ColumnResolution
+	Output  []string // Names of the columns the query will return
+}
+
+// ResolveColumns reports how the select columns and other references (WHERE, ON,
+// ORDER BY, ...) of a query over the given tables resolve
+// A nil selectColumns means all columns
+func (db *Database) ResolveColumns(tableNames []string, selectColumns []string, references []string) (*ResolutionReport, error) {
+	tables := make([]*Table, 0, len(tableNames))
+	for _, name := range tableNames {
+		table, err := db.GetTable(name)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+
+	unlock := lockTables(nil, tables)
+	defer unlock()
+
+	report := &ResolutionReport{Tables: tableNames}
+	joined := len(tables) > 1
+
+	seen := make(map[string]bool)
+	for _, ref := range append(append([]string{}, selectColumns...), references...) {
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		report.Columns = append(report.Columns, resolveColumn(tables, ref))
+	}
+
+	// Work out the output column names
+	if len(selectColumns) == 0 {
+		for _, table := range tables {
+			for _, col := range table.schema {
+				if joined {
+					report.Output = append(report.Output, fmt.Sprintf("%s.%s", table.name, col.Name))
+				} else {
+					report.Output = append(report.Output, col.Name)
+				}
+			}
+		}
+	} else {
+		for _, ref := range selectColumns {
+			res := resolveColumn(tables, ref)
+			if !res.Resolved() {
+				continue
+			}
+			if joined {
+				report.Output = append(report.Output, res.Qualified())
+			} else {
+				report.Output = append(report.Output, res.Column)
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// resolveColumn finds the table a (possibly qualified) column reference belongs to
+func resolveColumn(tables []*Table, ref string) ColumnResolution {
+	res := ColumnResolution{Reference: ref, Column: ref}
+
+	if dot := strings.Index(ref, "."); dot >= 0 {
+		tableName, colName := ref[:dot], ref[dot+1:]
+		res.Column = colName
+		for _, table := range tables {
+			if table.name == tableName && table.hasColumn(colName) {
+				res.Table = table.name
+			}
+		}
+		return res
+	}
+
+	for _, table := range tables {
+		if table.hasColumn(ref) {
+			res.Candidates = append(res.Candidates, table.name)
+		}
+	}
+
+	switch len(res.Candidates) {
+	case 0:
+		// Not found in any table
+	case 1:
+		res.Table = res.Candidates[0]
+		res.Candidates = nil
+	default:
+		res.Ambiguous = true
+	}
+
+	return res
+}
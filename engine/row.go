@@ -23,3 +23,26 @@ func (r Row) Get(column string) (interface{}, bool) {
 func (r Row) Set(column string, value interface{}) {
 	r[column] = value
 }
+
+// Equal reports whether two rows have the same columns holding equal values
+// Ints and floats compare numerically, so 10 equals 10.0. A column set to nil
+// is not the same as a missing column
+func (r Row) Equal(other Row) bool {
+	if len(r) != len(other) {
+		return false
+	}
+	for col, value := range r {
+		otherValue, ok := other[col]
+		switch {
+		case !ok:
+			return false
+		case value == nil || otherValue == nil:
+			if value != otherValue {
+				return false
+			}
+		case !valuesEqual(value, otherValue):
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SchemaSQL returns the CREATE TABLE and CREATE INDEX statements that recreate
+// every table without its data, one statement per line
+// Referenced tables come before the tables that reference them so the output
+// can be replayed in order
+func (db *Database) SchemaSQL() string {
+	db.mu.RLock()
+	tables := make([]*Table, 0, len(db.tables))
+	for _, table := range db.tables {
+		tables = append(tables, table)
+	}
+	db.mu.RUnlock()
+
+	var b strings.Builder
+	for _, table := range orderByReferences(tables) {
+		table.mu.RLock()
+		b.WriteString(table.createSQL())
+		b.WriteString(";\n")
+
+		columns := make([]string, 0, len(table.indexes))
+		for column := range table.indexes {
+			if !table.implicitIndex(column) {
+				columns = append(columns, column)
+			}
+		}
+		table.mu.RUnlock()
+
+		sort.Strings(columns)
+		for _, column := range columns {
+			fmt.Fprintf(&b, "CREATE INDEX ON %s (%s);\n", table.name, column)
+		}
+	}
+	return b.String()
+}
+
+// createSQL renders the CREATE TABLE statement for the table's schema
+// Callers must hold the table lock
+func (t *Table) createSQL() string {
+	defs := make([]string, len(t.schema))
+	for i, col := range t.schema {
+		defs[i] = col.definitionSQL()
+	}
+	return fmt.Sprintf("CREATE TABLE %s (%s)", t.name, strings.Join(defs, ", "))
+}
+
+// definitionSQL renders a column definition as it appears in CREATE TABLE
+func (c Column) definitionSQL() string {
+	parts := append([]string{c.Name, string(c.Type)}, c.Constraints()...)
+	return strings.Join(parts, " ")
+}
+
+// Constraints returns the column's constraints as they appear in CREATE TABLE,
+// e.g. "PRIMARY KEY" or "REFERENCES users(id)"
+func (c Column) Constraints() []string {
+	var parts []string
+	if c.PrimaryKey {
+		parts = append(parts, "PRIMARY KEY")
+	} else if c.NotNull {
+		parts = append(parts, "NOT NULL")
+	}
+	if c.Unique && !c.PrimaryKey {
+		parts = append(parts, "UNIQUE")
+	}
+	if c.AutoIncrement {
+		parts = append(parts, "AUTOINCREMENT")
+	}
+	if fk := c.References; fk != nil {
+		parts = append(parts, fmt.Sprintf("REFERENCES %s(%s)", fk.Table, fk.Column))
+		if fk.OnDeleteCascade {
+			parts = append(parts, "ON DELETE CASCADE")
+		}
+	}
+	if c.Check != nil {
+		parts = append(parts, fmt.Sprintf("CHECK (%s)", c.Check))
+	}
+	return parts
+}
+
+// implicitIndex reports whether the index on a column is created by the schema itself
+func (t *Table) implicitIndex(column string) bool {
+	for _, col := range t.schema {
+		if col.Name == column {
+			return col.PrimaryKey || col.Unique
+		}
+	}
+	return false
+}
+
+// orderByReferences sorts tables by name, then moves each table after the
+// tables its foreign keys reference
+func orderByReferences(tables []*Table) []*Table {
+	sort.Slice(tables, func(i, j int) bool { return tables[i].name < tables[j].name })
+
+	byName := make(map[string]*Table, len(tables))
+	for _, table := range tables {
+		byName[table.name] = table
+	}
+
+	ordered := make([]*Table, 0, len(tables))
+	visited := make(map[string]bool, len(tables))
+	var visit func(table *Table)
+	visit = func(table *Table) {
+		if visited[table.name] {
+			return
+		}
+		visited[table.name] = true
+		for _, col := range table.schema {
+			if col.References == nil {
+				continue
+			}
+			if parent, ok := byName[col.References.Table]; ok {
+				visit(parent)
+			}
+		}
+		ordered = append(ordered, table)
+	}
+	for _, table := range tables {
+		visit(table)
+	}
+	return ordered
+}
@@ -0,0 +1,140 @@
+package engine
+
+import (
+	"maps"
+	"sort"
+)
+
+// Snapshot is a copy of every table in a database at one point in time,
+// taken by Database.Snapshot and applied with Database.Restore
+// It shares no rows or indexes with the database, so later changes to the
+// database never show up in the snapshot
+type Snapshot struct {
+	tables map[string]*Table // Detached copies, never modified
+}
+
+// Tables returns the sorted names of the tables in the snapshot
+func (s *Snapshot) Tables() []string {
+	names := make([]string, 0, len(s.tables))
+	for name := range s.tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Snapshot returns a deep copy of every table's schema, rows and indexes
+// All tables are read-locked together, so the copy is consistent
+func (db *Database) Snapshot() *Snapshot {
+	db.mu.RLock()
+	tables := make([]*Table, 0, len(db.tables))
+	for _, table := range db.tables {
+		tables = append(tables, table)
+	}
+	db.mu.RUnlock()
+
+	unlock := lockTables(nil, tables)
+	defer unlock()
+
+	snapshot := &Snapshot{tables: make(map[string]*Table, len(tables))}
+	for _, table := range tables {
+		if !table.dropped {
+			snapshot.tables[table.name] = table.clone()
+		}
+	}
+	return snapshot
+}
+
+// Restore replaces every table with its copy in the snapshot, dropping tables
+// created since the snapshot was taken
+// The snapshot is copied again, so it can be restored any number of times.
+// Tables looked up before the restore are detached from the database, and
+// later operations on them fail with ErrTableNotFound. Schema listeners see
+// every replaced table dropped and every restored table created
+func (db *Database) Restore(snapshot *Snapshot) {
+	var replaced []*Table
+	var unlock func()
+	for {
+		db.mu.RLock()
+		replaced = make([]*Table, 0, len(db.tables))
+		for _, table := range db.tables {
+			replaced = append(replaced, table)
+		}
+		db.mu.RUnlock()
+
+		unlock = lockTables(replaced, nil)
+		db.mu.Lock()
+		if sameTables(db.tables, replaced) {
+			break
+		}
+		// A table was created or dropped while the locks were awaited
+		db.mu.Unlock()
+		unlock()
+	}
+
+	for _, table := range replaced {
+		table.dropped = true
+	}
+	db.tables = make(map[string]*Table, len(snapshot.tables))
+	for name, saved := range snapshot.tables {
+		table := saved.clone() // The snapshot's tables are never modified, so need no lock
+		table.db = db
+		db.tables[name] = table
+	}
+	db.mu.Unlock()
+	unlock()
+
+	sort.Slice(replaced, func(i, j int) bool { return replaced[i].name < replaced[j].name })
+	for _, table := range replaced {
+		db.notifySchemaChange(SchemaEvent{Table: table.name, Kind: SchemaDrop})
+	}
+	for _, name := range snapshot.Tables() {
+		db.notifySchemaChange(SchemaEvent{Table: name, Kind: SchemaCreate})
+	}
+}
+
+// sameTables reports whether a table registry holds exactly the given tables
+func sameTables(registry map[string]*Table, tables []*Table) bool {
+	if len(registry) != len(tables) {
+		return false
+	}
+	for _, table := range tables {
+		if registry[table.name] != table {
+			return false
+		}
+	}
+	return true
+}
+
+// clone returns a deep copy of the table, detached from its database
+// Callers must hold the table lock
+func (t *Table) clone() *Table {
+	copied := &Table{
+		name:       t.name,
+		schema:     append([]Column(nil), t.schema...),
+		rows:       make([]Row, len(t.rows)),
+		primaryKey: t.primaryKey,
+		indexes:    make(map[string]*Index, len(t.indexes)),
+		ordered:    make(map[string]*OrderedIndex, len(t.ordered)),
+		autoInc:    t.autoInc,
+		nextID:     t.nextID,
+		deferred:   maps.Clone(t.deferred),
+	}
+	for i, row := range t.rows {
+		copied.rows[i] = row.Copy()
+	}
+	for column := range t.indexes {
+		copied.indexes[column] = NewIndex(column)
+	}
+	for column := range t.ordered {
+		copied.ordered[column] = NewOrderedIndex(column)
+	}
+	copied.rebuildIndexes()
+
+	if t.analysis != nil {
+		analysis := *t.analysis
+		analysis.Cardinality = maps.Clone(t.analysis.Cardinality)
+		copied.analysis = &analysis
+	}
+	return copied
+}
@@ -1,29 +1,76 @@
 package engine
 
+import (
+	"sort"
+	"sync"
+)
+
 // Table represents a database table with schema, data, and indexes
+// Rows, indexes and counters are guarded by mu. The schema, primary key and
+// auto-increment column only change in AddColumn and RenameColumn, and a
+// schema's foreign keys also change when RenameTable or RenameColumn rewrites
+// the tables referencing a renamed table or column; all of these hold db.mu and
+// the mu of every table they change.
+// RenameTable moves the table to a new Table value rather than renaming it in place.
+// A row's position in rows acts as its rowid: inserts append and deletes
+// shift later rows down, so position order is always insertion order
 type Table struct {
+	mu         sync.RWMutex
 	name       string
 	schema     []Column
 	rows       []Row
 	primaryKey string
-	indexes    map[string]*Index // column name -> index
+	indexes    map[string]*Index        // column name -> index
+	ordered    map[string]*OrderedIndex // column name -> ordered index
+	analysis   *Analysis                // Statistics from the last ANALYZE
+	autoInc    string                   // Auto-increment column, if any
+	nextID     int                      // Next auto-increment value, never reused
+	db         *Database                // Owning database, used to check foreign keys
+	dropped    bool                     // Set by DropTable; later operations fail with ErrTableNotFound
+	deferred   map[string]bool          // PRIMARY KEY and UNIQUE columns whose index is not built yet
+}
+
+// TableOptions changes how CreateTableWithOptions sets up a table
+type TableOptions struct {
+	// DeferIndexes skips building the indexes of PRIMARY KEY and UNIQUE columns
+	// when the table is created. Each is built by the first query with an
+	// equality condition on its column, or by CreateIndex; until then its
+	// constraint is checked and its rows are found by scanning
+	DeferIndexes bool
 }
 
 // NewTable creates a new table with the given schema
 func NewTable(name string, schema []Column) *Table {
+	return newTableWithOptions(name, schema, TableOptions{})
+}
+
+// newTableWithOptions creates a new table with the given schema and options
+func newTableWithOptions(name string, schema []Column, opts TableOptions) *Table {
 	table := &Table{
 		name:    name,
 		schema:  schema,
 		rows:    make([]Row, 0),
 		indexes: make(map[string]*Index),
+		ordered: make(map[string]*OrderedIndex),
+		nextID:  1,
 	}
 
 	// Identify primary key and create indexes
 	for _, col := range schema {
+		if col.AutoIncrement {
+			table.autoInc = col.Name
+		}
 		if col.PrimaryKey {
 			table.primaryKey = col.Name
-			table.CreateIndex(col.Name)
-		} else if col.Unique {
+		}
+		switch {
+		case !col.PrimaryKey && !col.Unique:
+		case opts.DeferIndexes:
+			if table.deferred == nil {
+				table.deferred = make(map[string]bool)
+			}
+			table.deferred[col.Name] = true
+		default:
 			table.CreateIndex(col.Name)
 		}
 	}
@@ -38,21 +85,43 @@ func (t *Table) Name() string {
 
 // Schema returns the table schema
 func (t *Table) Schema() []Column {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
 	return t.schema
 }
 
-// Rows returns all rows in the table
+// ColumnNames returns the column names in schema order
+func (t *Table) ColumnNames() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.columnNames()
+}
+
+// Rows returns a snapshot of all rows in the table
 func (t *Table) Rows() []Row {
-	return t.rows
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	rows := make([]Row, len(t.rows))
+	copy(rows, t.rows)
+	return rows
 }
 
 // PrimaryKey returns the primary key column name
 func (t *Table) PrimaryKey() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
 	return t.primaryKey
 }
 
 // CreateIndex creates an index on a column
 func (t *Table) CreateIndex(columnName string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
 	// Check if column exists
 	if !t.hasColumn(columnName) {
 		return ErrColumnNotFound{
@@ -65,6 +134,7 @@ func (t *Table) CreateIndex(columnName string) error {
 	if _, exists := t.indexes[columnName]; exists {
 		return nil // Index already exists
 	}
+	delete(t.deferred, columnName)
 
 	// Create index
 	idx := NewIndex(columnName)
@@ -80,20 +150,209 @@ func (t *Table) CreateIndex(columnName string) error {
 	return nil
 }
 
+// DropIndex removes the hash index on a column created by CreateIndex
+// The indexes of PRIMARY KEY and UNIQUE columns enforce their constraints and
+// cannot be dropped
+func (t *Table) DropIndex(columnName string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.hasColumn(columnName) {
+		return ErrColumnNotFound{
+			TableName:  t.name,
+			ColumnName: columnName,
+		}
+	}
+	if _, exists := t.indexes[columnName]; !exists {
+		return ErrIndexNotFound{TableName: t.name, ColumnName: columnName}
+	}
+	if t.implicitIndex(columnName) {
+		return ErrImplicitIndex{TableName: t.name, ColumnName: columnName}
+	}
+
+	delete(t.indexes, columnName)
+	return nil
+}
+
+// CreateOrderedIndex creates an ordered index on a column
+// Unlike the hash index created by CreateIndex, it can answer >, <, >= and <=
+func (t *Table) CreateOrderedIndex(columnName string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.hasColumn(columnName) {
+		return ErrColumnNotFound{
+			TableName:  t.name,
+			ColumnName: columnName,
+		}
+	}
+
+	if _, exists := t.ordered[columnName]; exists {
+		return nil // Index already exists
+	}
+
+	idx := NewOrderedIndex(columnName)
+	idx.reset(t.rows)
+	t.ordered[columnName] = idx
+	return nil
+}
+
+// IndexedColumns returns the sorted names of the columns with a hash or ordered index
+func (t *Table) IndexedColumns() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	columns := make([]string, 0, len(t.indexes)+len(t.ordered))
+	for column := range t.indexes {
+		columns = append(columns, column)
+	}
+	for column := range t.ordered {
+		if _, hashed := t.indexes[column]; !hashed {
+			columns = append(columns, column)
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
+
 // GetIndex returns the index for a column if it exists
 func (t *Table) GetIndex(columnName string) (*Index, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
 	idx, ok := t.indexes[columnName]
 	return idx, ok
 }
 
+// checkDropped returns ErrTableNotFound if the table was dropped after it was looked up
+// Callers must hold the table lock
+func (t *Table) checkDropped() error {
+	if t.dropped {
+		return ErrTableNotFound{TableName: t.name}
+	}
+	return nil
+}
+
 // hasColumn checks if a column exists in the table schema
 func (t *Table) hasColumn(columnName string) bool {
+	return hasColumn(t.schema, columnName)
+}
+
+// TableStats summarizes the size of a table
+type TableStats struct {
+	Rows    int
+	Columns int
+	Indexes int
+}
+
+// Stats returns the current row, column and index counts without copying rows
+func (t *Table) Stats() TableStats {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return TableStats{
+		Rows:    len(t.rows),
+		Columns: len(t.schema),
+		Indexes: len(t.indexes) + len(t.ordered),
+	}
+}
+
+// AutoIncrementColumn returns the auto-increment column name, if any
+func (t *Table) AutoIncrementColumn() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.autoInc
+}
+
+// NextAutoIncrement returns the value the next generated key will get
+func (t *Table) NextAutoIncrement() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.nextID
+}
+
+// ResetAutoIncrement sets the value the next generated key will get
+// The start must be greater than every existing key so generated keys cannot collide
+func (t *Table) ResetAutoIncrement(start int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.autoInc == "" {
+		return ErrNoAutoIncrement{TableName: t.name}
+	}
+
+	for _, row := range t.rows {
+		if id, ok := row[t.autoInc].(int); ok && id >= start {
+			return ErrAutoIncrementConflict{TableName: t.name, Start: start, Existing: id}
+		}
+	}
+
+	t.nextID = start
+	return nil
+}
+
+// assignAutoIncrement fills in the auto-increment column when it is omitted
+// The caller's row is copied rather than modified. Explicit values move the
+// counter past them so generated keys never collide or get reused
+func (t *Table) assignAutoIncrement(row Row) Row {
+	if t.autoInc == "" {
+		return row
+	}
+
+	if value, ok := row.Get(t.autoInc); ok && value != nil {
+		t.advanceAutoIncrement(value)
+		return row
+	}
+
+	row = row.Copy()
+	row.Set(t.autoInc, t.nextID)
+	t.nextID++
+	return row
+}
+
+// advanceAutoIncrement moves the counter past an explicit auto-increment value
+func (t *Table) advanceAutoIncrement(value interface{}) {
+	if id, isInt := value.(int); isInt && id >= t.nextID {
+		t.nextID = id + 1
+	}
+}
+
+// rowsByPrimaryKey returns the rows ordered by primary key without reordering the table
+// Tables without a primary key keep their physical order
+func (t *Table) rowsByPrimaryKey() []Row {
+	rows := make([]Row, len(t.rows))
+	copy(rows, t.rows)
+	t.sortByPrimaryKey(rows)
+	return rows
+}
+
+// sortByPrimaryKey sorts rows of this table in place by primary key
+func (t *Table) sortByPrimaryKey(rows []Row) {
+	if t.primaryKey == "" {
+		return
+	}
+	sortRows(rows, []OrderBy{{Column: t.primaryKey}})
+}
+
+// columnNames returns the column names in schema order
+func (t *Table) columnNames() []string {
+	names := make([]string, len(t.schema))
+	for i, col := range t.schema {
+		names[i] = col.Name
+	}
+	return names
+}
+
+// column returns the schema definition of a column
+func (t *Table) column(columnName string) (Column, bool) {
 	for _, col := range t.schema {
 		if col.Name == columnName {
-			return true
+			return col, true
 		}
 	}
-	return false
+	return Column{}, false
 }
 
 // hasPrimaryKeyValue checks if a primary key value already exists
@@ -107,13 +366,7 @@ func (t *Table) hasPrimaryKeyValue(value interface{}) bool {
 		return idx.Has(value)
 	}
 
-	// Fallback: linear scan
-	for _, row := range t.rows {
-		if rowValue, ok := row.Get(t.primaryKey); ok && rowValue == value {
-			return true
-		}
-	}
-	return false
+	return t.scanForValue(t.primaryKey, value)
 }
 
 // hasUniqueValue checks if a unique column value already exists
@@ -123,15 +376,54 @@ func (t *Table) hasUniqueValue(columnName string, value interface{}) bool {
 		return idx.Has(value)
 	}
 
-	// Fallback: linear scan
+	return t.scanForValue(columnName, value)
+}
+
+// scanForValue checks every row for a non-NULL value in a column, matching
+// values as an index lookup would
+func (t *Table) scanForValue(columnName string, value interface{}) bool {
+	if value == nil {
+		return false
+	}
 	for _, row := range t.rows {
-		if rowValue, ok := row.Get(columnName); ok && rowValue == value {
+		if rowValue, ok := row.Get(columnName); ok && rowValue != nil && valuesEqual(rowValue, value) {
 			return true
 		}
 	}
 	return false
 }
 
+// buildDeferredIndexes builds the deferred indexes of the columns a condition
+// compares with =, so the query can use them
+// It must be called without holding the table lock
+func (t *Table) buildDeferredIndexes(condition *Condition) {
+	t.mu.RLock()
+	var columns []string
+	for _, pred := range conjuncts(condition) {
+		if pred.Operator == "=" && pred.Arith == nil && t.deferred[pred.Column] {
+			columns = append(columns, pred.Column)
+		}
+	}
+	t.mu.RUnlock()
+
+	for _, column := range columns {
+		t.CreateIndex(column)
+	}
+}
+
+// buildDeferredPrimaryKeyIndex builds the primary key's index if it was deferred
+// It must be called without holding the table lock
+func (t *Table) buildDeferredPrimaryKeyIndex() {
+	t.mu.RLock()
+	column := t.primaryKey
+	deferred := t.deferred[column]
+	t.mu.RUnlock()
+
+	if deferred {
+		t.CreateIndex(column)
+	}
+}
+
 // addRow adds a row to the table and updates indexes
 func (t *Table) addRow(row Row) int {
 	rowIndex := len(t.rows)
@@ -143,14 +435,44 @@ func (t *Table) addRow(row Row) int {
 			idx.Add(value, rowIndex)
 		}
 	}
+	for colName, idx := range t.ordered {
+		if value, ok := row.Get(colName); ok {
+			idx.Add(value, rowIndex)
+		}
+	}
 
 	return rowIndex
 }
 
+// truncateRows removes every row from position n onwards and updates indexes
+func (t *Table) truncateRows(n int) {
+	for i := len(t.rows) - 1; i >= n; i-- {
+		for colName, idx := range t.indexes {
+			if value, ok := t.rows[i].Get(colName); ok {
+				idx.Remove(value, i)
+			}
+		}
+		for colName, idx := range t.ordered {
+			if value, ok := t.rows[i].Get(colName); ok {
+				idx.Remove(value, i)
+			}
+		}
+	}
+	t.rows = t.rows[:n]
+}
+
 // updateRow updates a row at a given index and updates indexes
+// An auto-increment key changed to a value at or past the counter moves the
+// counter on, as an explicit value does on insert
 func (t *Table) updateRow(rowIndex int, newRow Row) {
 	oldRow := t.rows[rowIndex]
 
+	if t.autoInc != "" {
+		if value, ok := newRow.Get(t.autoInc); ok {
+			t.advanceAutoIncrement(value)
+		}
+	}
+
 	// Update indexes
 	for colName, idx := range t.indexes {
 		oldValue, _ := oldRow.Get(colName)
@@ -159,34 +481,85 @@ func (t *Table) updateRow(rowIndex int, newRow Row) {
 			idx.Update(oldValue, newValue, rowIndex)
 		}
 	}
+	for colName, idx := range t.ordered {
+		oldValue, _ := oldRow.Get(colName)
+		newValue, _ := newRow.Get(colName)
+		if oldValue != newValue {
+			idx.Update(oldValue, newValue, rowIndex)
+		}
+	}
 
 	t.rows[rowIndex] = newRow
 }
 
-// deleteRow removes a row at a given index and updates indexes
-func (t *Table) deleteRow(rowIndex int) {
-	row := t.rows[rowIndex]
+// removeRows deletes the rows at the given positions and rebuilds indexes
+// Remaining rows keep their relative order, so every index entry is
+// recomputed from the new positions rather than patched in place
+func (t *Table) removeRows(positions []int) {
+	if len(positions) == 0 {
+		return
+	}
 
-	// Update indexes
-	for colName, idx := range t.indexes {
-		if value, ok := row.Get(colName); ok {
-			idx.Remove(value, rowIndex)
+	deleted := make(map[int]bool, len(positions))
+	for _, pos := range positions {
+		deleted[pos] = true
+	}
+
+	kept := t.rows[:0]
+	for i, row := range t.rows {
+		if !deleted[i] {
+			kept = append(kept, row)
 		}
 	}
+	// Clear the tail so removed rows can be garbage collected
+	for i := len(kept); i < len(t.rows); i++ {
+		t.rows[i] = nil
+	}
+	t.rows = kept
 
-	// Remove row by replacing with last element and truncating
-	lastIndex := len(t.rows) - 1
-	if rowIndex != lastIndex {
-		t.rows[rowIndex] = t.rows[lastIndex]
+	t.rebuildIndexes()
+}
 
-		// Update indexes for moved row
-		for colName, idx := range t.indexes {
-			if value, ok := t.rows[rowIndex].Get(colName); ok {
-				idx.Remove(value, lastIndex)
-				idx.Add(value, rowIndex)
+// Vacuum releases the memory held for deleted rows and returns how many row
+// slots it freed
+// Deletes keep the remaining rows in insertion order but reuse the rows slice
+// and index storage, which never shrink; Vacuum reallocates the rows to fit
+// and rebuilds every index from scratch. Query results do not change
+func (t *Table) Vacuum() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.vacuum()
+}
+
+// vacuum does the work of Vacuum
+// Callers must hold the table lock
+func (t *Table) vacuum() int {
+	freed := cap(t.rows) - len(t.rows)
+	t.rows = append(make([]Row, 0, len(t.rows)), t.rows...)
+
+	for _, idx := range t.indexes {
+		idx.data = nil // Rebuilt at its default size rather than the old one
+	}
+	for _, idx := range t.ordered {
+		idx.entries = nil
+	}
+	t.rebuildIndexes()
+	return freed
+}
+
+// rebuildIndexes repopulates every index in place from the current rows
+// Indexes are reset rather than replaced so existing *Index handles stay valid
+func (t *Table) rebuildIndexes() {
+	for colName, idx := range t.indexes {
+		idx.data = make(map[interface{}][]int, len(idx.data))
+		for rowIdx, row := range t.rows {
+			if value, ok := row.Get(colName); ok {
+				idx.Add(value, rowIdx)
 			}
 		}
 	}
-
-	t.rows = t.rows[:lastIndex]
+	for _, idx := range t.ordered {
+		idx.reset(t.rows)
+	}
 }
@@ -0,0 +1,48 @@
+package engine
+
+import "time"
+
+// timeoutCheckInterval is how many rows are processed between deadline checks
+const timeoutCheckInterval = 1024
+
+// SetQueryTimeout sets the maximum duration of a scan or join
+// A zero duration disables the timeout
+func (db *Database) SetQueryTimeout(d time.Duration) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.queryTimeout = d
+}
+
+// QueryTimeout returns the current query timeout
+func (db *Database) QueryTimeout() time.Duration {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.queryTimeout
+}
+
+// deadline tracks when a running query must be aborted
+type deadline struct {
+	at      time.Time
+	timeout time.Duration
+}
+
+// newDeadline starts the clock for a query
+func (db *Database) newDeadline() deadline {
+	timeout := db.QueryTimeout()
+	if timeout <= 0 {
+		return deadline{}
+	}
+	return deadline{at: time.Now().Add(timeout), timeout: timeout}
+}
+
+// check returns ErrQueryTimeout once the deadline has passed
+// The clock is only read every timeoutCheckInterval rows to keep loops cheap
+func (d deadline) check(row int) error {
+	if d.timeout == 0 || row%timeoutCheckInterval != 0 {
+		return nil
+	}
+	if time.Now().After(d.at) {
+		return ErrQueryTimeout{Timeout: d.timeout}
+	}
+	return nil
+}
@@ -0,0 +1,323 @@
+package executor
+
+import (
+	"fmt"
+	"godb/engine"
+	"godb/parser"
+	"sort"
+	"strings"
+)
+
+// Result is the outcome of a statement run by Execute
+type Result struct {
+	Kind         parser.CommandType // JOINs are CmdSelect
+	Table        string             // Table the statement acted on; empty for JOIN and SHOW TABLES
+	Columns      []string           // Result columns in order; nil when no rows are returned
+	Rows         []engine.Row
+	RowsAffected int
+	Message      string          // Summary of what the statement did, or why a query has no rows
+	Schema       []engine.Column // Column definitions shown by DESCRIBE
+	Plan         *engine.Plan    // Query plan shown by EXPLAIN
+}
+
+// IsQuery reports whether the statement reads data rather than changing it
+// Queries are SELECT, JOIN, EXPLAIN, DESCRIBE and SHOW TABLES. Other statements may
+// still return rows, such as the stored row of a single-row INSERT
+func (r *Result) IsQuery() bool {
+	switch r.Kind {
+	case parser.CmdSelect, parser.CmdExplain, parser.CmdDescribe, parser.CmdShowTables:
+		return true
+	}
+	return false
+}
+
+// Execute parses a single SQL statement and runs it against the database
+func Execute(db *engine.Database, sql string) (*Result, error) {
+	cmd, err := parser.NewParser(sql).Parse()
+	if err != nil {
+		return nil, err
+	}
+	return ExecuteCommand(db, cmd)
+}
+
+// ExecuteCommand runs a parsed statement against the database
+func ExecuteCommand(db *engine.Database, cmd parser.Command) (*Result, error) {
+	result := &Result{Kind: cmd.Type()}
+
+	switch c := cmd.(type) {
+	case *parser.CreateTableCommand:
+		if c.AsSelect != nil {
+			if err := executeCreateTableAs(db, c, result); err != nil {
+				return nil, err
+			}
+			break
+		}
+		if err := db.CreateTable(c.TableName, c.Columns); err != nil {
+			return nil, err
+		}
+		result.Table = c.TableName
+		result.Message = fmt.Sprintf("Table '%s' created", c.TableName)
+
+	case *parser.InsertCommand:
+		if err := executeInsert(db, c, result); err != nil {
+			return nil, err
+		}
+
+	case *parser.SelectCommand:
+		columns, rows, err := db.SelectOrdered(c.Query())
+		if err != nil {
+			return nil, err
+		}
+		result.Table = c.TableName
+		result.Columns = columns
+		result.Rows = rows
+
+	case *parser.UpdateCommand:
+		count, err := db.Update(c.TableName, c.Updates, c.Condition)
+		if err != nil {
+			return nil, err
+		}
+		result.Table = c.TableName
+		result.RowsAffected = count
+		result.Message = fmt.Sprintf("%d row(s) updated", count)
+
+	case *parser.DeleteCommand:
+		count, err := db.Delete(c.TableName, c.Condition)
+		if err != nil {
+			return nil, err
+		}
+		result.Table = c.TableName
+		result.RowsAffected = count
+		result.Message = fmt.Sprintf("%d row(s) deleted", count)
+
+	case *parser.JoinCommand:
+		if err := executeJoin(db, c, result); err != nil {
+			return nil, err
+		}
+
+	case *parser.ExplainCommand:
+		if err := executeExplain(db, c, result); err != nil {
+			return nil, err
+		}
+
+	case *parser.AnalyzeCommand:
+		if err := db.Analyze(c.TableName); err != nil {
+			return nil, err
+		}
+		result.Table = c.TableName
+		result.Message = fmt.Sprintf("Table '%s' analyzed", c.TableName)
+
+	case *parser.AlterTableCommand:
+		if err := executeAlterTable(db, c, result); err != nil {
+			return nil, err
+		}
+
+	case *parser.DropTableCommand:
+		if err := db.DropTable(c.TableName); err != nil {
+			return nil, err
+		}
+		result.Table = c.TableName
+		result.Message = fmt.Sprintf("Table '%s' dropped", c.TableName)
+
+	case *parser.TruncateCommand:
+		if err := db.Truncate(c.TableName); err != nil {
+			return nil, err
+		}
+		result.Table = c.TableName
+		result.Message = fmt.Sprintf("Table '%s' truncated", c.TableName)
+
+	case *parser.CreateIndexCommand:
+		table, err := db.GetTable(c.TableName)
+		if err != nil {
+			return nil, err
+		}
+		if err := table.CreateIndex(c.Column); err != nil {
+			return nil, err
+		}
+		result.Table = c.TableName
+		result.Message = fmt.Sprintf("Index on '%s.%s' created", c.TableName, c.Column)
+
+	case *parser.DropIndexCommand:
+		table, err := db.GetTable(c.TableName)
+		if err != nil {
+			return nil, err
+		}
+		if err := table.DropIndex(c.Column); err != nil {
+			return nil, err
+		}
+		result.Table = c.TableName
+		result.Message = fmt.Sprintf("Index on '%s.%s' dropped", c.TableName, c.Column)
+
+	case *parser.DescribeCommand:
+		table, err := db.GetTable(c.TableName)
+		if err != nil {
+			return nil, err
+		}
+		result.Table = c.TableName
+		result.Schema = table.Schema()
+		result.Columns = []string{"column", "type", "constraints"}
+		result.Rows = make([]engine.Row, len(result.Schema))
+		for i, col := range result.Schema {
+			result.Rows[i] = engine.Row{"column": col.Name, "type": string(col.Type), "constraints": strings.Join(col.Constraints(), " ")}
+		}
+
+	case *parser.ShowTablesCommand:
+		names := db.ListTables()
+		sort.Strings(names)
+		result.Columns = []string{"table"}
+		result.Rows = make([]engine.Row, len(names))
+		for i, name := range names {
+			result.Rows[i] = engine.Row{"table": name}
+		}
+		if len(names) == 0 {
+			result.Message = "No tables"
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown command type")
+	}
+
+	return result, nil
+}
+
+// executeInsert runs an INSERT
+// A single row inserted by column name is returned as stored, with any
+// generated key, so callers can echo it
+func executeInsert(db *engine.Database, c *parser.InsertCommand, result *Result) error {
+	result.Table = c.TableName
+
+	var count int
+	var err error
+	switch {
+	case !c.HasColumns:
+		count, err = db.InsertManyValues(c.TableName, c.Values)
+	case len(c.Rows) == 1:
+		var row engine.Row
+		if row, err = db.InsertReturning(c.TableName, c.Rows[0]); err == nil {
+			count = 1
+			result.Rows = []engine.Row{row}
+			if table, err := db.GetTable(c.TableName); err == nil {
+				result.Columns = table.ColumnNames()
+			}
+		}
+	default:
+		count, err = db.InsertMany(c.TableName, c.Rows)
+	}
+	if err != nil {
+		return err
+	}
+
+	result.RowsAffected = count
+	result.Message = fmt.Sprintf("%d row(s) inserted", count)
+	return nil
+}
+
+// executeCreateTableAs runs a CREATE TABLE ... AS SELECT
+// A bare SELECT * copies the whole table with its constraints and indexes;
+// any other query is materialized with columns inferred from its sources
+func executeCreateTableAs(db *engine.Database, c *parser.CreateTableCommand, result *Result) error {
+	var count int
+	var err error
+	if s, ok := c.AsSelect.(*parser.SelectCommand); ok && copiesTable(s.Query()) {
+		if err = db.CopyTable(s.TableName, c.TableName); err == nil {
+			count, err = db.RowCount(c.TableName)
+		}
+	} else {
+		count, err = materialize(db, c.AsSelect, c.TableName)
+	}
+	if err != nil {
+		return err
+	}
+
+	result.Table = c.TableName
+	result.RowsAffected = count
+	result.Message = fmt.Sprintf("Table '%s' created with %d row(s)", c.TableName, count)
+	return nil
+}
+
+// copiesTable reports whether a query returns every row and column of its
+// table unchanged, in table order
+func copiesTable(q engine.Query) bool {
+	return len(q.Columns) == 0 && q.Condition == nil && !q.Distinct && len(q.DistinctOn) == 0 &&
+		len(q.OrderBy) == 0 && len(q.GroupBy) == 0 && len(q.Aggregates) == 0
+}
+
+// executeJoin runs a JOIN, returning every column of the joined tables when none are selected
+func executeJoin(db *engine.Database, c *parser.JoinCommand, result *Result) error {
+	rows, err := db.Join(c.LeftTable, c.Joins, c.Condition, c.SelectColumns)
+	if err != nil {
+		return err
+	}
+
+	// Selected columns are returned under their qualified names
+	report, err := db.ResolveColumns(c.Tables(), c.SelectColumns, nil)
+	if err != nil {
+		return err
+	}
+	result.Columns = report.Output
+	result.Rows = rows
+	return nil
+}
+
+// executeExplain plans the SELECT or JOIN of an EXPLAIN without running it
+// The plan is returned as a tree, one row per node, with the access path
+// (IndexScan, RangeScan or FullScan) and its estimated candidate rows last
+func executeExplain(db *engine.Database, c *parser.ExplainCommand, result *Result) error {
+	var plan *engine.Plan
+	var err error
+	switch s := c.Statement.(type) {
+	case *parser.SelectCommand:
+		result.Table = s.TableName
+		plan, err = db.Explain(s.Query())
+	case *parser.JoinCommand:
+		plan, err = db.ExplainJoins(s.LeftTable, s.Joins, s.Condition, s.SelectColumns)
+	default:
+		return fmt.Errorf("EXPLAIN only supports SELECT statements")
+	}
+	if err != nil {
+		return err
+	}
+
+	result.Plan = plan
+	result.Columns = []string{"plan"}
+	for _, line := range strings.Split(strings.TrimSuffix(plan.TreeString(), "\n"), "\n") {
+		result.Rows = append(result.Rows, engine.Row{"plan": line})
+	}
+	return nil
+}
+
+// executeAlterTable runs an ALTER TABLE
+func executeAlterTable(db *engine.Database, c *parser.AlterTableCommand, result *Result) error {
+	table, err := db.GetTable(c.TableName)
+	if err != nil {
+		return err
+	}
+	result.Table = c.TableName
+
+	switch c.Kind {
+	case parser.AlterAutoIncrement:
+		if err := table.ResetAutoIncrement(c.AutoIncrement); err != nil {
+			return err
+		}
+		result.Message = fmt.Sprintf("Next id for table '%s' set to %d", c.TableName, c.AutoIncrement)
+	case parser.AlterAddColumn:
+		if err := db.AddColumn(c.TableName, c.Column); err != nil {
+			return err
+		}
+		result.Message = fmt.Sprintf("Column '%s' added to table '%s'", c.Column.Name, c.TableName)
+	case parser.AlterRenameTable:
+		if err := db.RenameTable(c.TableName, c.NewName); err != nil {
+			return err
+		}
+		result.Table = c.NewName
+		result.Message = fmt.Sprintf("Table '%s' renamed to '%s'", c.TableName, c.NewName)
+	case parser.AlterRenameColumn:
+		if err := db.RenameColumn(c.TableName, c.ColumnName, c.NewName); err != nil {
+			return err
+		}
+		result.Message = fmt.Sprintf("Column '%s' of table '%s' renamed to '%s'", c.ColumnName, c.TableName, c.NewName)
+	default:
+		return fmt.Errorf("unknown ALTER TABLE action")
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+// Package executor runs SQL text against a database
+// It sits above both the parser and the engine, which cannot import each other
+package executor
+
+import (
+	"fmt"
+	"godb/engine"
+	"godb/parser"
+	"strings"
+)
+
+// MaterializeQuery runs a SELECT or JOIN and stores its result set in a new table
+// The new table's columns and types are inferred from the query's source columns.
+// Join columns may be selected qualified or, when only one table has them, bare.
+// They keep their bare name unless two tables contribute the same name,
+// in which case they are named table_column. Returns the number of rows stored
+func MaterializeQuery(db *engine.Database, sql string, newTable string) (int, error) {
+	cmd, err := parser.NewParser(sql).Parse()
+	if err != nil {
+		return 0, err
+	}
+	return materialize(db, cmd, newTable)
+}
+
+// materialize runs a parsed SELECT or JOIN and stores its result set in a new table
+func materialize(db *engine.Database, cmd parser.Command, newTable string) (int, error) {
+	var (
+		schema []engine.Column
+		names  []string // result row key for each schema column
+		rows   []engine.Row
+		err    error
+	)
+
+	switch c := cmd.(type) {
+	case *parser.SelectCommand:
+		query := c.Query().Unqualified()
+		schema, err = selectSchema(db, query)
+		if err != nil {
+			return 0, err
+		}
+		for _, col := range schema {
+			names = append(names, col.Name)
+		}
+		rows, err = db.Query(query)
+
+	case *parser.JoinCommand:
+		// The join validates the selected columns before their schema is inferred
+		rows, err = db.Join(c.LeftTable, c.Joins, c.Condition, c.SelectColumns)
+		if err != nil {
+			return 0, err
+		}
+		schema, names, err = joinSchema(db, c)
+
+	default:
+		return 0, fmt.Errorf("only SELECT queries can be materialized")
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// Rename result keys to the new table's column names
+	stored := make([]engine.Row, len(rows))
+	for i, row := range rows {
+		stored[i] = make(engine.Row, len(schema))
+		for j, col := range schema {
+			value, _ := row.Get(names[j])
+			stored[i].Set(col.Name, value)
+		}
+	}
+
+	if err := db.CreateTableWithRows(newTable, schema, stored); err != nil {
+		return 0, err
+	}
+	return len(stored), nil
+}
+
+// selectSchema infers the result columns of a single-table SELECT
+func selectSchema(db *engine.Database, q engine.Query) ([]engine.Column, error) {
+	table, err := db.GetTable(q.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(q.GroupBy) > 0 || len(q.Aggregates) > 0 {
+		// Grouped results hold the selected group columns, or all of them for SELECT *
+		groupColumns := q.Columns
+		if len(q.GroupBy) > 0 && len(q.Columns) == 0 && len(q.Aggregates) == 0 {
+			groupColumns = q.GroupBy
+		}
+
+		schema := make([]engine.Column, 0, len(groupColumns)+len(q.Aggregates))
+		for _, name := range groupColumns {
+			col, ok := findColumn(table, name)
+			if !ok {
+				return nil, engine.ErrColumnNotFound{TableName: q.Table, ColumnName: name}
+			}
+			schema = append(schema, engine.Column{Name: outputName(q, col.Name), Type: col.Type})
+		}
+		for _, agg := range q.Aggregates {
+			colType, err := aggregateType(table, agg)
+			if err != nil {
+				return nil, err
+			}
+			schema = append(schema, engine.Column{Name: agg.Name(), Type: colType})
+		}
+		return schema, nil
+	}
+
+	if len(q.Columns) == 0 {
+		return plainColumns(table.Schema()), nil
+	}
+
+	schema := make([]engine.Column, 0, len(q.Columns))
+	for _, name := range q.Columns {
+		if f, ok := q.Functions[name]; ok {
+			schema = append(schema, engine.Column{Name: name, Type: f.ResultType()})
+			continue
+		}
+		col, ok := findColumn(table, name)
+		if !ok {
+			return nil, engine.ErrColumnNotFound{TableName: q.Table, ColumnName: name}
+		}
+		schema = append(schema, engine.Column{Name: outputName(q, col.Name), Type: col.Type})
+	}
+	return schema, nil
+}
+
+// aggregateType returns the type of an aggregate's result
+func aggregateType(table *engine.Table, agg engine.Aggregate) (engine.ColumnType, error) {
+	switch strings.ToUpper(agg.Func) {
+	case "COUNT", "SUM":
+		return engine.TypeInt, nil
+	case "MIN", "MAX":
+		col, ok := findColumn(table, agg.Column)
+		if !ok {
+			return "", engine.ErrColumnNotFound{TableName: table.Name(), ColumnName: agg.Column}
+		}
+		return col.Type, nil
+	default:
+		return "", fmt.Errorf("cannot materialize %s: no column type holds its result", strings.ToUpper(agg.Func))
+	}
+}
+
+// joinSchema infers the result columns of a JOIN along with the qualified
+// result key each column is read from
+func joinSchema(db *engine.Database, c *parser.JoinCommand) ([]engine.Column, []string, error) {
+	var tables []*engine.Table
+	for _, name := range c.Tables() {
+		table, err := db.GetTable(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		tables = append(tables, table)
+	}
+
+	type source struct {
+		table  string
+		column engine.Column
+	}
+	var sources []source
+
+	if len(c.SelectColumns) == 0 {
+		for _, table := range tables {
+			for _, col := range table.Schema() {
+				sources = append(sources, source{table: table.Name(), column: col})
+			}
+		}
+	} else {
+		// Bare names are resolved to the one table that has them
+		report, err := db.ResolveColumns(c.Tables(), c.SelectColumns, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		resolved := make(map[string]engine.ColumnResolution, len(report.Columns))
+		for _, res := range report.Columns {
+			resolved[res.Reference] = res
+		}
+		for _, ref := range c.SelectColumns {
+			res := resolved[ref]
+			if !res.Resolved() {
+				return nil, nil, engine.ErrColumnNotFound{TableName: c.LeftTable, ColumnName: ref}
+			}
+			for _, table := range tables {
+				if table.Name() == res.Table {
+					col, _ := findColumn(table, res.Column)
+					sources = append(sources, source{table: res.Table, column: col})
+				}
+			}
+		}
+	}
+
+	counts := make(map[string]int)
+	for _, src := range sources {
+		counts[src.column.Name]++
+	}
+
+	schema := make([]engine.Column, len(sources))
+	names := make([]string, len(sources))
+	for i, src := range sources {
+		name := src.column.Name
+		if counts[name] > 1 {
+			name = src.table + "_" + name
+		}
+		schema[i] = engine.Column{Name: name, Type: src.column.Type}
+		names[i] = src.table + "." + src.column.Name
+	}
+	return schema, names, nil
+}
+
+// outputName returns the result column name of a selected column, using its alias if it has one
+func outputName(q engine.Query, column string) string {
+	if alias, ok := q.Aliases[column]; ok {
+		return alias
+	}
+	return column
+}
+
+// plainColumns copies column names and types without constraints
+func plainColumns(columns []engine.Column) []engine.Column {
+	plain := make([]engine.Column, len(columns))
+	for i, col := range columns {
+		plain[i] = engine.Column{Name: col.Name, Type: col.Type}
+	}
+	return plain
+}
+
+// findColumn looks up a column definition by name
+func findColumn(table *engine.Table, name string) (engine.Column, bool) {
+	for _, col := range table.Schema() {
+		if col.Name == name {
+			return col, true
+		}
+	}
+	return engine.Column{}, false
+}
@@ -11,6 +11,15 @@ const (
 	CmdSelect
 	CmdUpdate
 	CmdDelete
+	CmdAnalyze
+	CmdAlterTable
+	CmdDropTable
+	CmdDescribe
+	CmdShowTables
+	CmdTruncate
+	CmdCreateIndex
+	CmdDropIndex
+	CmdExplain
 	CmdUnknown
 )
 
@@ -23,16 +32,19 @@ type Command interface {
 type CreateTableCommand struct {
 	TableName string
 	Columns   []engine.Column
+	AsSelect  Command // SELECT or JOIN of CREATE TABLE ... AS SELECT; nil when Columns are defined
 }
 
 func (c *CreateTableCommand) Type() CommandType {
 	return CmdCreateTable
 }
 
-// InsertCommand represents an INSERT INTO statement
+// InsertCommand represents an INSERT INTO statement with one or more rows
 type InsertCommand struct {
-	TableName string
-	Values    engine.Row
+	TableName  string
+	HasColumns bool            // Whether the statement names its columns
+	Rows       []engine.Row    // Rows keyed by the named columns
+	Values     [][]interface{} // Value tuples in schema order, without a column list
 }
 
 func (c *InsertCommand) Type() CommandType {
@@ -41,15 +53,38 @@ func (c *InsertCommand) Type() CommandType {
 
 // SelectCommand represents a SELECT statement
 type SelectCommand struct {
-	TableName string
-	Columns   []string
-	Condition *engine.Condition
+	TableName  string
+	Columns    []string
+	Condition  *engine.Condition
+	Distinct   bool
+	DistinctOn []string
+	OrderBy    []engine.OrderBy
+	GroupBy    []string
+	Aggregates []engine.Aggregate
+	Aliases    map[string]string            // column -> output name
+	Functions  map[string]engine.ScalarFunc // output name in Columns -> function call
 }
 
 func (c *SelectCommand) Type() CommandType {
 	return CmdSelect
 }
 
+// Query converts the command into an engine query
+func (c *SelectCommand) Query() engine.Query {
+	return engine.Query{
+		Table:      c.TableName,
+		Columns:    c.Columns,
+		Condition:  c.Condition,
+		Distinct:   c.Distinct,
+		DistinctOn: c.DistinctOn,
+		OrderBy:    c.OrderBy,
+		GroupBy:    c.GroupBy,
+		Aggregates: c.Aggregates,
+		Aliases:    c.Aliases,
+		Functions:  c.Functions,
+	}
+}
+
 // UpdateCommand represents an UPDATE statement
 type UpdateCommand struct {
 	TableName string
@@ -71,13 +106,123 @@ func (c *DeleteCommand) Type() CommandType {
 	return CmdDelete
 }
 
-// JoinCommand represents a SELECT with INNER JOIN
+// AnalyzeCommand represents an ANALYZE statement
+type AnalyzeCommand struct {
+	TableName string
+}
+
+func (c *AnalyzeCommand) Type() CommandType {
+	return CmdAnalyze
+}
+
+// AlterKind represents the kind of change made by ALTER TABLE
+type AlterKind int
+
+const (
+	AlterAutoIncrement AlterKind = iota
+	AlterAddColumn
+	AlterRenameTable
+	AlterRenameColumn
+)
+
+// AlterTableCommand represents an ALTER TABLE statement
+type AlterTableCommand struct {
+	TableName     string
+	Kind          AlterKind
+	AutoIncrement int           // Next generated key for AlterAutoIncrement
+	Column        engine.Column // New column for AlterAddColumn
+	ColumnName    string        // Column renamed by AlterRenameColumn
+	NewName       string        // New table or column name for AlterRenameTable and AlterRenameColumn
+}
+
+func (c *AlterTableCommand) Type() CommandType {
+	return CmdAlterTable
+}
+
+// DropTableCommand represents a DROP TABLE statement
+type DropTableCommand struct {
+	TableName string
+}
+
+func (c *DropTableCommand) Type() CommandType {
+	return CmdDropTable
+}
+
+// TruncateCommand represents a TRUNCATE TABLE statement
+type TruncateCommand struct {
+	TableName string
+}
+
+func (c *TruncateCommand) Type() CommandType {
+	return CmdTruncate
+}
+
+// CreateIndexCommand represents a CREATE INDEX statement
+type CreateIndexCommand struct {
+	TableName string
+	Column    string
+}
+
+func (c *CreateIndexCommand) Type() CommandType {
+	return CmdCreateIndex
+}
+
+// DropIndexCommand represents a DROP INDEX statement
+type DropIndexCommand struct {
+	TableName string
+	Column    string
+}
+
+func (c *DropIndexCommand) Type() CommandType {
+	return CmdDropIndex
+}
+
+// DescribeCommand represents a DESCRIBE statement
+type DescribeCommand struct {
+	TableName string
+}
+
+func (c *DescribeCommand) Type() CommandType {
+	return CmdDescribe
+}
+
+// ShowTablesCommand represents a SHOW TABLES statement
+type ShowTablesCommand struct{}
+
+func (c *ShowTablesCommand) Type() CommandType {
+	return CmdShowTables
+}
+
+// ExplainCommand represents an EXPLAIN statement
+// Statement is the SelectCommand or JoinCommand whose plan is shown
+type ExplainCommand struct {
+	Statement Command
+}
+
+func (c *ExplainCommand) Type() CommandType {
+	return CmdExplain
+}
+
+// JoinCommand represents a SELECT with one or more INNER, LEFT, RIGHT or FULL JOINs
+// The Left and Right fields and JoinType describe the first join
 type JoinCommand struct {
 	LeftTable     string
 	RightTable    string
 	LeftColumn    string
 	RightColumn   string
 	SelectColumns []string
+	JoinType      engine.JoinType
+	Joins         []engine.JoinStep // Every join, in the order they are applied
+	Condition     *engine.Condition // WHERE condition on the joined rows, if any
+}
+
+// Tables returns the joined tables in order
+func (c *JoinCommand) Tables() []string {
+	tables := []string{c.LeftTable}
+	for _, step := range c.Joins {
+		tables = append(tables, step.Table)
+	}
+	return tables
 }
 
 func (c *JoinCommand) Type() CommandType {
@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseError is returned when the input is not a valid statement
+type ParseError struct {
+	Message string
+	Pos     int // Byte offset in the input of the token the error is reported at
+	Token   int // Index of that token
+}
+
+func (e ParseError) Error() string {
+	return fmt.Sprintf("%s at offset %d", e.Message, e.Pos)
+}
+
+// Underline returns the line of input containing the error followed by a
+// line with a caret under the offending token, e.g.
+//
+//	SELECT FROM users
+//	       ^
+func (e ParseError) Underline(input string) string {
+	pos := min(max(e.Pos, 0), len(input))
+	start := strings.LastIndexByte(input[:pos], '\n') + 1
+	end := strings.IndexByte(input[pos:], '\n')
+	if end < 0 {
+		end = len(input)
+	} else {
+		end += pos
+	}
+	line := strings.TrimRight(input[start:end], "\r")
+
+	// Keep tabs so the caret lines up however the terminal renders them
+	var indent strings.Builder
+	for _, r := range input[start:pos] {
+		if r == '\t' {
+			indent.WriteRune('\t')
+		} else {
+			indent.WriteRune(' ')
+		}
+	}
+	return line + "\n" + indent.String() + "^"
+}
+
+// errorf returns a ParseError reported at the current token
+func (p *Parser) errorf(format string, args ...interface{}) error {
+	return ParseError{
+		Message: fmt.Sprintf(format, args...),
+		Pos:     p.current().Pos,
+		Token:   min(p.pos, len(p.tokens)-1),
+	}
+}
@@ -9,8 +9,10 @@ import (
 
 // Parser parses SQL commands from tokens
 type Parser struct {
-	tokens []Token
-	pos    int
+	tokens   []Token
+	pos      int
+	prepared bool // Accept ? placeholders for condition values
+	params   int  // Placeholders seen so far
 }
 
 // NewParser creates a new parser from input string
@@ -22,20 +24,81 @@ func NewParser(input string) *Parser {
 	}
 }
 
-// Parse parses the input and returns a Command
+// Parse parses the input as a single statement, optionally terminated by a
+// semicolon, and returns a Command
 func (p *Parser) Parse() (Command, error) {
+	cmd, err := p.parseStatement()
+	if err != nil {
+		return nil, err
+	}
+	if p.match(TokenSemicolon) {
+		p.advance()
+	}
+	if !p.match(TokenEOF) {
+		return nil, p.errorf("unexpected %v after end of statement", p.current())
+	}
+	return cmd, nil
+}
+
+// Prepare parses a single statement like Parse, also accepting ? placeholders
+// in place of the values of WHERE conditions
+// It returns the statement and its number of placeholders, which become
+// engine.Param values numbered in the order they appear. Bind them with
+// engine.Database.ExecutePrepared or BindParams before running the statement
+func (p *Parser) Prepare() (Command, int, error) {
+	p.prepared = true
+	cmd, err := p.Parse()
+	if err != nil {
+		return nil, 0, err
+	}
+	return cmd, p.params, nil
+}
+
+// ParseMany parses semicolon-separated statements and returns their commands in order
+// Empty statements are skipped; the input must contain at least one statement
+func (p *Parser) ParseMany() ([]Command, error) {
+	var cmds []Command
+	for {
+		for p.match(TokenSemicolon) {
+			p.advance()
+		}
+		if p.match(TokenEOF) {
+			break
+		}
+
+		cmd, err := p.parseStatement()
+		if err != nil {
+			return nil, fmt.Errorf("statement %d: %w", len(cmds)+1, err)
+		}
+		if !p.matchEnd() {
+			return nil, fmt.Errorf("statement %d: %w", len(cmds)+1, p.errorf("unexpected %v after end of statement", p.current()))
+		}
+		cmds = append(cmds, cmd)
+	}
+
+	if len(cmds) == 0 {
+		return nil, p.errorf("empty input")
+	}
+	return cmds, nil
+}
+
+// parseStatement parses one statement, stopping before any semicolon that ends it
+func (p *Parser) parseStatement() (Command, error) {
 	if p.pos >= len(p.tokens) {
-		return nil, fmt.Errorf("empty input")
+		return nil, p.errorf("empty input")
 	}
 
 	token := p.current()
 	if token.Type != TokenKeyword {
-		return nil, fmt.Errorf("expected keyword, got %s", token.Value)
+		return nil, p.errorf("expected keyword, got %v", token)
 	}
 
 	keyword := strings.ToUpper(token.Value)
 	switch keyword {
 	case "CREATE":
+		if strings.EqualFold(p.peek().Value, "INDEX") {
+			return p.parseCreateIndex()
+		}
 		return p.parseCreateTable()
 	case "INSERT":
 		return p.parseInsert()
@@ -45,18 +108,36 @@ func (p *Parser) Parse() (Command, error) {
 		return p.parseUpdate()
 	case "DELETE":
 		return p.parseDelete()
+	case "ANALYZE":
+		return p.parseAnalyze()
+	case "ALTER":
+		return p.parseAlterTable()
+	case "DROP":
+		if strings.EqualFold(p.peek().Value, "INDEX") {
+			return p.parseDropIndex()
+		}
+		return p.parseDropTable()
+	case "TRUNCATE":
+		return p.parseTruncate()
+	case "DESCRIBE":
+		return p.parseDescribe()
+	case "SHOW":
+		return p.parseShowTables()
+	case "EXPLAIN":
+		return p.parseExplain()
 	default:
-		return nil, fmt.Errorf("unknown command: %s", keyword)
+		return nil, p.errorf("unknown command: %s", keyword)
 	}
 }
 
 // parseCreateTable parses CREATE TABLE command
 func (p *Parser) parseCreateTable() (*CreateTableCommand, error) {
 	// CREATE TABLE table_name (col1 type [PRIMARY KEY], col2 type [UNIQUE], ...)
+	// CREATE TABLE table_name AS SELECT ...
 	p.advance() // Skip CREATE
 
 	if !p.matchKeyword("TABLE") {
-		return nil, fmt.Errorf("expected TABLE keyword")
+		return nil, p.errorf("expected TABLE keyword")
 	}
 	p.advance()
 
@@ -65,8 +146,20 @@ func (p *Parser) parseCreateTable() (*CreateTableCommand, error) {
 		return nil, err
 	}
 
+	if p.matchKeyword("AS") {
+		p.advance()
+		if !p.matchKeyword("SELECT") {
+			return nil, p.errorf("expected SELECT after AS")
+		}
+		statement, err := p.parseSelect()
+		if err != nil {
+			return nil, err
+		}
+		return &CreateTableCommand{TableName: tableName, AsSelect: statement}, nil
+	}
+
 	if !p.match(TokenLeftParen) {
-		return nil, fmt.Errorf("expected '(' after table name")
+		return nil, p.errorf("expected '(' after table name or AS SELECT")
 	}
 	p.advance()
 
@@ -76,8 +169,9 @@ func (p *Parser) parseCreateTable() (*CreateTableCommand, error) {
 	}
 
 	if !p.match(TokenRightParen) {
-		return nil, fmt.Errorf("expected ')' after column definitions")
+		return nil, p.errorf("expected ')' after column definitions")
 	}
+	p.advance()
 
 	return &CreateTableCommand{
 		TableName: tableName,
@@ -90,63 +184,160 @@ func (p *Parser) parseColumnDefinitions() ([]engine.Column, error) {
 	var columns []engine.Column
 
 	for {
-		colName, err := p.expectIdentifier()
+		col, err := p.parseColumnDefinition()
 		if err != nil {
 			return nil, err
 		}
+		columns = append(columns, col)
 
-		colTypeStr, err := p.expectKeyword()
-		if err != nil {
-			return nil, err
+		if p.match(TokenComma) {
+			p.advance()
+			continue
 		}
+		break
+	}
 
-		colType := engine.ColumnType(strings.ToUpper(colTypeStr))
+	return columns, nil
+}
 
-		col := engine.Column{
-			Name: colName,
-			Type: colType,
-		}
+// parseColumnDefinition parses a single column definition: name type [constraints]
+func (p *Parser) parseColumnDefinition() (engine.Column, error) {
+	colName, err := p.expectIdentifier()
+	if err != nil {
+		return engine.Column{}, err
+	}
 
-		// Check for PRIMARY KEY or UNIQUE
-		for p.matchKeyword("PRIMARY") || p.matchKeyword("UNIQUE") || p.matchKeyword("NOT") {
-			if p.matchKeyword("PRIMARY") {
-				p.advance()
-				if p.matchKeyword("KEY") {
-					p.advance()
-					col.PrimaryKey = true
-					col.NotNull = true
-				}
-			} else if p.matchKeyword("UNIQUE") {
+	colType, err := p.expectColumnType()
+	if err != nil {
+		return engine.Column{}, err
+	}
+
+	col := engine.Column{
+		Name: colName,
+		Type: colType,
+	}
+
+	// Check for PRIMARY KEY, UNIQUE, NOT NULL, REFERENCES, CHECK or AUTOINCREMENT
+	for p.matchColumnConstraint() {
+		if p.matchKeyword("PRIMARY") {
+			p.advance()
+			if p.matchKeyword("KEY") {
 				p.advance()
-				col.Unique = true
-			} else if p.matchKeyword("NOT") {
+				col.PrimaryKey = true
+				col.NotNull = true
+			}
+		} else if p.matchKeyword("UNIQUE") {
+			p.advance()
+			col.Unique = true
+		} else if p.matchKeyword("NOT") {
+			p.advance()
+			if p.matchKeyword("NULL") {
 				p.advance()
-				if p.matchKeyword("NULL") {
-					p.advance()
-					col.NotNull = true
-				}
+				col.NotNull = true
 			}
+		} else if p.matchKeyword("REFERENCES") {
+			fk, err := p.parseReferences()
+			if err != nil {
+				return engine.Column{}, err
+			}
+			col.References = fk
+		} else if p.matchKeyword("CHECK") {
+			check, err := p.parseCheck()
+			if err != nil {
+				return engine.Column{}, err
+			}
+			col.Check = check
+		} else {
+			p.advance()
+			col.AutoIncrement = true
 		}
+	}
 
-		columns = append(columns, col)
+	return col, nil
+}
 
-		if p.match(TokenComma) {
-			p.advance()
-			continue
+// matchColumnConstraint checks for the start of a column constraint
+func (p *Parser) matchColumnConstraint() bool {
+	return p.matchKeyword("PRIMARY") || p.matchKeyword("UNIQUE") || p.matchKeyword("NOT") ||
+		p.matchKeyword("REFERENCES") || p.matchKeyword("CHECK") ||
+		p.matchKeyword("AUTOINCREMENT") || p.matchKeyword("AUTO_INCREMENT")
+}
+
+// parseCheck parses CHECK (condition)
+func (p *Parser) parseCheck() (*engine.Condition, error) {
+	p.advance() // Skip CHECK
+
+	if !p.match(TokenLeftParen) {
+		return nil, p.errorf("expected '(' after CHECK")
+	}
+	p.advance()
+
+	check, err := p.parseCondition()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.match(TokenRightParen) {
+		return nil, p.errorf("expected ')' after CHECK condition")
+	}
+	p.advance()
+
+	return check, nil
+}
+
+// parseReferences parses REFERENCES table(column) [ON DELETE CASCADE | RESTRICT]
+func (p *Parser) parseReferences() (*engine.ForeignKey, error) {
+	p.advance() // Skip REFERENCES
+
+	table, err := p.expectIdentifier()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.match(TokenLeftParen) {
+		return nil, p.errorf("expected '(' after referenced table")
+	}
+	p.advance()
+
+	column, err := p.expectIdentifier()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.match(TokenRightParen) {
+		return nil, p.errorf("expected ')' after referenced column")
+	}
+	p.advance()
+
+	fk := &engine.ForeignKey{Table: table, Column: column}
+
+	if p.matchKeyword("ON") {
+		p.advance()
+		if !p.matchKeyword("DELETE") {
+			return nil, p.errorf("expected DELETE after ON")
 		}
-		break
+		p.advance()
+
+		switch {
+		case p.matchKeyword("CASCADE"):
+			fk.OnDeleteCascade = true
+		case p.matchKeyword("RESTRICT"):
+		default:
+			return nil, p.errorf("expected CASCADE or RESTRICT after ON DELETE")
+		}
+		p.advance()
 	}
 
-	return columns, nil
+	return fk, nil
 }
 
 // parseInsert parses INSERT INTO command
 func (p *Parser) parseInsert() (*InsertCommand, error) {
-	// INSERT INTO table_name VALUES (val1, val2, ...)
+	// INSERT INTO table_name (col1, col2, ...) VALUES (val1, val2, ...)[, (val1, val2, ...)]
 	p.advance() // Skip INSERT
 
 	if !p.matchKeyword("INTO") {
-		return nil, fmt.Errorf("expected INTO keyword")
+		return nil, p.errorf("expected INTO keyword")
 	}
 	p.advance()
 
@@ -164,63 +355,102 @@ func (p *Parser) parseInsert() (*InsertCommand, error) {
 			return nil, err
 		}
 		if !p.match(TokenRightParen) {
-			return nil, fmt.Errorf("expected ')' after column list")
+			return nil, p.errorf("expected ')' after column list")
 		}
 		p.advance()
 	}
 
 	if !p.matchKeyword("VALUES") {
-		return nil, fmt.Errorf("expected VALUES keyword")
+		return nil, p.errorf("expected VALUES keyword")
 	}
 	p.advance()
 
-	if !p.match(TokenLeftParen) {
-		return nil, fmt.Errorf("expected '(' after VALUES")
-	}
-	p.advance()
+	// Without a column list the values follow the schema order, which only
+	// the engine knows, so they are kept as tuples
+	cmd := &InsertCommand{TableName: tableName, HasColumns: columns != nil}
 
-	values, err := p.parseValueList()
-	if err != nil {
-		return nil, err
-	}
+	// One or more value tuples: VALUES (1, 'a'), (2, 'b')
+	for {
+		if !p.match(TokenLeftParen) {
+			return nil, p.errorf("expected '(' after VALUES")
+		}
+		p.advance()
 
-	if !p.match(TokenRightParen) {
-		return nil, fmt.Errorf("expected ')' after values")
-	}
+		values, err := p.parseValueList()
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.match(TokenRightParen) {
+			return nil, p.errorf("expected ')' after values")
+		}
+		p.advance()
 
-	// Map values to columns
-	row := make(engine.Row)
-	if len(columns) > 0 {
-		if len(columns) != len(values) {
-			return nil, fmt.Errorf("column count doesn't match value count")
+		if cmd.HasColumns {
+			// Map values to columns
+			if len(columns) != len(values) {
+				return nil, p.errorf("column count doesn't match value count")
+			}
+			row := make(engine.Row)
+			for i, col := range columns {
+				row[col] = values[i]
+			}
+			cmd.Rows = append(cmd.Rows, row)
+		} else {
+			cmd.Values = append(cmd.Values, values)
 		}
-		for i, col := range columns {
-			row[col] = values[i]
+
+		if p.match(TokenComma) {
+			p.advance()
+			continue
 		}
-	} else {
-		// If no columns specified, we can't proceed without schema
-		return nil, fmt.Errorf("column names must be specified in INSERT")
+		break
 	}
 
-	return &InsertCommand{
-		TableName: tableName,
-		Values:    row,
-	}, nil
+	return cmd, nil
 }
 
 // parseSelect parses SELECT command
 func (p *Parser) parseSelect() (Command, error) {
-	// SELECT col1, col2 FROM table [WHERE condition]
-	// SELECT * FROM table1 INNER JOIN table2 ON table1.col = table2.col
+	// SELECT [DISTINCT | DISTINCT ON (cols)] col1 [[AS] alias], col2 FROM table [WHERE condition] [GROUP BY cols] [ORDER BY col [ASC|DESC] [NULLS FIRST|LAST], ...]
+	// SELECT * FROM table1 {INNER | {LEFT | RIGHT | FULL} [OUTER]} JOIN table2 ON table1.col = table2.col [... JOIN table3 ON ...] [WHERE condition]
 	p.advance() // Skip SELECT
 
-	columns, err := p.parseSelectColumns()
+	var distinct bool
+	var distinctOn []string
+	if p.matchKeyword("DISTINCT") {
+		p.advance()
+		if !p.matchKeyword("ON") {
+			distinct = true
+		} else {
+			p.advance()
+
+			if !p.match(TokenLeftParen) {
+				return nil, p.errorf("expected '(' after DISTINCT ON")
+			}
+			p.advance()
+
+			var err error
+			distinctOn, err = p.parseIdentifierList()
+			if err != nil {
+				return nil, err
+			}
+
+			if !p.match(TokenRightParen) {
+				return nil, p.errorf("expected ')' after DISTINCT ON columns")
+			}
+			p.advance()
+		}
+	}
+
+	list, err := p.parseSelectColumns()
 	if err != nil {
 		return nil, err
 	}
+	columns, aggregates, aliases := list.columns, list.aggregates, list.aliases
 
 	if !p.matchKeyword("FROM") {
-		return nil, fmt.Errorf("expected FROM keyword")
+		return nil, p.errorf("expected FROM keyword")
 	}
 	p.advance()
 
@@ -230,49 +460,49 @@ func (p *Parser) parseSelect() (Command, error) {
 	}
 
 	// Check for JOIN
-	if p.matchKeyword("INNER") {
-		p.advance()
-		if !p.matchKeyword("JOIN") {
-			return nil, fmt.Errorf("expected JOIN after INNER")
+	if p.atJoin() {
+		if distinct || distinctOn != nil {
+			return nil, p.errorf("DISTINCT is not supported with JOIN")
 		}
-		p.advance()
-
-		rightTable, err := p.expectIdentifier()
-		if err != nil {
-			return nil, err
+		if len(aggregates) > 0 {
+			return nil, p.errorf("aggregates are not supported with JOIN")
 		}
-
-		if !p.matchKeyword("ON") {
-			return nil, fmt.Errorf("expected ON after JOIN")
+		if len(aliases) > 0 {
+			return nil, p.errorf("column aliases are not supported with JOIN")
 		}
-		p.advance()
-
-		// Parse join condition: table1.col = table2.col
-		leftCol, err := p.expectIdentifier()
-		if err != nil {
-			return nil, err
+		if len(list.functions) > 0 {
+			return nil, p.errorf("functions are not supported with JOIN")
 		}
 
-		if !p.matchOperator("=") {
-			return nil, fmt.Errorf("expected '=' in JOIN condition")
+		var joins []engine.JoinStep
+		previous := tableName
+		for p.atJoin() {
+			step, err := p.parseJoin(previous)
+			if err != nil {
+				return nil, err
+			}
+			joins = append(joins, step)
+			previous = step.Table
 		}
-		p.advance()
 
-		rightCol, err := p.expectIdentifier()
-		if err != nil {
-			return nil, err
+		var condition *engine.Condition
+		if p.matchKeyword("WHERE") {
+			p.advance()
+			if condition, err = p.parseCondition(); err != nil {
+				return nil, err
+			}
 		}
 
-		// Extract column names without table prefix
-		leftColName := extractColumnName(leftCol)
-		rightColName := extractColumnName(rightCol)
-
+		first := joins[0]
 		return &JoinCommand{
 			LeftTable:     tableName,
-			RightTable:    rightTable,
-			LeftColumn:    leftColName,
-			RightColumn:   rightColName,
+			RightTable:    first.Table,
+			LeftColumn:    first.LeftColumn,
+			RightColumn:   first.RightColumn,
 			SelectColumns: columns,
+			JoinType:      first.Type,
+			Joins:         joins,
+			Condition:     condition,
 		}, nil
 	}
 
@@ -286,13 +516,97 @@ func (p *Parser) parseSelect() (Command, error) {
 		}
 	}
 
+	var groupBy []string
+	if p.matchKeyword("GROUP") {
+		p.advance()
+		if !p.matchKeyword("BY") {
+			return nil, p.errorf("expected BY after GROUP")
+		}
+		p.advance()
+		groupBy, err = p.parseIdentifierList()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var orderBy []engine.OrderBy
+	if p.matchKeyword("ORDER") {
+		orderBy, err = p.parseOrderBy()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &SelectCommand{
-		TableName: tableName,
-		Columns:   columns,
-		Condition: condition,
+		TableName:  tableName,
+		Columns:    columns,
+		Condition:  condition,
+		Distinct:   distinct,
+		DistinctOn: distinctOn,
+		OrderBy:    orderBy,
+		GroupBy:    groupBy,
+		Aggregates: aggregates,
+		Aliases:    aliases,
+		Functions:  list.functions,
 	}, nil
 }
 
+// parseOrderBy parses ORDER BY followed by a comma-separated list of terms
+func (p *Parser) parseOrderBy() ([]engine.OrderBy, error) {
+	p.advance() // Skip ORDER
+
+	if !p.matchKeyword("BY") {
+		return nil, p.errorf("expected BY after ORDER")
+	}
+	p.advance()
+
+	var orderBy []engine.OrderBy
+	for {
+		order, err := p.parseOrderTerm()
+		if err != nil {
+			return nil, err
+		}
+		orderBy = append(orderBy, order)
+
+		if p.match(TokenComma) {
+			p.advance()
+			continue
+		}
+		return orderBy, nil
+	}
+}
+
+// parseOrderTerm parses col [ASC|DESC] [NULLS FIRST|LAST]
+func (p *Parser) parseOrderTerm() (engine.OrderBy, error) {
+	col, err := p.expectIdentifier()
+	if err != nil {
+		return engine.OrderBy{}, err
+	}
+
+	order := engine.OrderBy{Column: col}
+	if p.matchKeyword("DESC") {
+		p.advance()
+		order.Desc = true
+	} else if p.matchKeyword("ASC") {
+		p.advance()
+	}
+
+	if p.matchKeyword("NULLS") {
+		p.advance()
+		switch {
+		case p.matchKeyword("FIRST"):
+			order.Nulls = engine.NullsFirst
+		case p.matchKeyword("LAST"):
+			order.Nulls = engine.NullsLast
+		default:
+			return engine.OrderBy{}, p.errorf("expected FIRST or LAST after NULLS")
+		}
+		p.advance()
+	}
+
+	return order, nil
+}
+
 // parseUpdate parses UPDATE command
 func (p *Parser) parseUpdate() (*UpdateCommand, error) {
 	// UPDATE table SET col1=val1, col2=val2 WHERE condition
@@ -304,7 +618,7 @@ func (p *Parser) parseUpdate() (*UpdateCommand, error) {
 	}
 
 	if !p.matchKeyword("SET") {
-		return nil, fmt.Errorf("expected SET keyword")
+		return nil, p.errorf("expected SET keyword")
 	}
 	p.advance()
 
@@ -335,7 +649,7 @@ func (p *Parser) parseDelete() (*DeleteCommand, error) {
 	p.advance() // Skip DELETE
 
 	if !p.matchKeyword("FROM") {
-		return nil, fmt.Errorf("expected FROM keyword")
+		return nil, p.errorf("expected FROM keyword")
 	}
 	p.advance()
 
@@ -359,50 +673,478 @@ func (p *Parser) parseDelete() (*DeleteCommand, error) {
 	}, nil
 }
 
-// parseSelectColumns parses the column list in SELECT
-func (p *Parser) parseSelectColumns() ([]string, error) {
-	if p.current().Value == "*" {
-		p.advance()
-		return nil, nil // nil means all columns
+// parseAnalyze parses ANALYZE command
+func (p *Parser) parseAnalyze() (*AnalyzeCommand, error) {
+	// ANALYZE table
+	p.advance() // Skip ANALYZE
+
+	tableName, err := p.expectIdentifier()
+	if err != nil {
+		return nil, err
 	}
 
-	return p.parseIdentifierList()
+	return &AnalyzeCommand{TableName: tableName}, nil
 }
 
-// parseIdentifierList parses a comma-separated list of identifiers
-func (p *Parser) parseIdentifierList() ([]string, error) {
-	var identifiers []string
-
-	for {
-		id, err := p.expectIdentifier()
-		if err != nil {
-			return nil, err
-		}
-		identifiers = append(identifiers, id)
+// parseDescribe parses DESCRIBE command
+func (p *Parser) parseDescribe() (*DescribeCommand, error) {
+	// DESCRIBE table
+	p.advance() // Skip DESCRIBE
 
-		if p.match(TokenComma) {
-			p.advance()
-			continue
-		}
-		break
+	tableName, err := p.expectIdentifier()
+	if err != nil {
+		return nil, err
 	}
 
-	return identifiers, nil
+	return &DescribeCommand{TableName: tableName}, nil
 }
 
-// parseValueList parses a comma-separated list of values
-func (p *Parser) parseValueList() ([]interface{}, error) {
-	var values []interface{}
+// parseShowTables parses SHOW TABLES command
+func (p *Parser) parseShowTables() (*ShowTablesCommand, error) {
+	// SHOW TABLES
+	p.advance() // Skip SHOW
 
-	for {
-		val, err := p.expectValue()
-		if err != nil {
-			return nil, err
-		}
-		values = append(values, val)
+	if !p.matchKeyword("TABLES") {
+		return nil, p.errorf("expected TABLES after SHOW")
+	}
+	p.advance()
 
-		if p.match(TokenComma) {
-			p.advance()
+	return &ShowTablesCommand{}, nil
+}
+
+// parseExplain parses EXPLAIN command
+func (p *Parser) parseExplain() (*ExplainCommand, error) {
+	// EXPLAIN SELECT ...
+	p.advance() // Skip EXPLAIN
+
+	if !p.matchKeyword("SELECT") {
+		return nil, p.errorf("expected SELECT after EXPLAIN")
+	}
+
+	statement, err := p.parseSelect()
+	if err != nil {
+		return nil, err
+	}
+	return &ExplainCommand{Statement: statement}, nil
+}
+
+// parseDropTable parses DROP TABLE command
+func (p *Parser) parseDropTable() (*DropTableCommand, error) {
+	// DROP TABLE table_name
+	p.advance() // Skip DROP
+
+	if !p.matchKeyword("TABLE") {
+		return nil, p.errorf("expected TABLE keyword")
+	}
+	p.advance()
+
+	tableName, err := p.expectIdentifier()
+	if err != nil {
+		return nil, err
+	}
+
+	return &DropTableCommand{TableName: tableName}, nil
+}
+
+// parseCreateIndex parses CREATE INDEX command
+func (p *Parser) parseCreateIndex() (*CreateIndexCommand, error) {
+	// CREATE INDEX ON table_name (column)
+	// INDEX is not reserved, so columns and tables may still be named index
+	p.advance() // Skip CREATE
+	p.advance() // Skip INDEX
+
+	tableName, column, err := p.parseIndexTarget()
+	if err != nil {
+		return nil, err
+	}
+	return &CreateIndexCommand{TableName: tableName, Column: column}, nil
+}
+
+// parseDropIndex parses DROP INDEX command
+func (p *Parser) parseDropIndex() (*DropIndexCommand, error) {
+	// DROP INDEX ON table_name (column)
+	p.advance() // Skip DROP
+	p.advance() // Skip INDEX
+
+	tableName, column, err := p.parseIndexTarget()
+	if err != nil {
+		return nil, err
+	}
+	return &DropIndexCommand{TableName: tableName, Column: column}, nil
+}
+
+// parseIndexTarget parses the ON table_name (column) part of CREATE and DROP INDEX
+func (p *Parser) parseIndexTarget() (string, string, error) {
+	if !p.matchKeyword("ON") {
+		return "", "", p.errorf("expected ON after INDEX")
+	}
+	p.advance()
+
+	tableName, err := p.expectIdentifier()
+	if err != nil {
+		return "", "", err
+	}
+
+	if !p.match(TokenLeftParen) {
+		return "", "", p.errorf("expected '(' after table name")
+	}
+	p.advance()
+
+	column, err := p.expectIdentifier()
+	if err != nil {
+		return "", "", err
+	}
+
+	if !p.match(TokenRightParen) {
+		return "", "", p.errorf("expected ')' after column name")
+	}
+	p.advance()
+
+	return tableName, column, nil
+}
+
+// parseTruncate parses TRUNCATE TABLE command
+func (p *Parser) parseTruncate() (*TruncateCommand, error) {
+	// TRUNCATE TABLE table_name
+	p.advance() // Skip TRUNCATE
+
+	if !p.matchKeyword("TABLE") {
+		return nil, p.errorf("expected TABLE keyword")
+	}
+	p.advance()
+
+	tableName, err := p.expectIdentifier()
+	if err != nil {
+		return nil, err
+	}
+
+	return &TruncateCommand{TableName: tableName}, nil
+}
+
+// parseAlterTable parses ALTER TABLE command
+func (p *Parser) parseAlterTable() (*AlterTableCommand, error) {
+	// ALTER TABLE table AUTO_INCREMENT = n | ADD [COLUMN] column_definition
+	//   | RENAME TO new_name | RENAME COLUMN column TO new_name
+	p.advance() // Skip ALTER
+
+	if !p.matchKeyword("TABLE") {
+		return nil, p.errorf("expected TABLE keyword")
+	}
+	p.advance()
+
+	tableName, err := p.expectIdentifier()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := &AlterTableCommand{TableName: tableName}
+
+	switch {
+	case p.matchKeyword("AUTO_INCREMENT") || p.matchKeyword("AUTOINCREMENT"):
+		p.advance()
+		if !p.matchOperator("=") {
+			return nil, p.errorf("expected '=' after AUTO_INCREMENT")
+		}
+		p.advance()
+
+		val, err := p.expectValue()
+		if err != nil {
+			return nil, err
+		}
+		start, ok := val.(int)
+		if !ok {
+			return nil, p.errorf("expected integer AUTO_INCREMENT value")
+		}
+		cmd.Kind = AlterAutoIncrement
+		cmd.AutoIncrement = start
+	case p.matchKeyword("ADD"):
+		p.advance()
+		if p.matchKeyword("COLUMN") {
+			p.advance()
+		}
+		col, err := p.parseColumnDefinition()
+		if err != nil {
+			return nil, err
+		}
+		if !p.matchEnd() {
+			return nil, p.errorf("unexpected %v after column definition", p.current())
+		}
+		cmd.Kind = AlterAddColumn
+		cmd.Column = col
+	case p.matchKeyword("RENAME"):
+		p.advance()
+		cmd.Kind = AlterRenameTable
+		if p.matchKeyword("COLUMN") {
+			p.advance()
+			if cmd.ColumnName, err = p.expectIdentifier(); err != nil {
+				return nil, err
+			}
+			cmd.Kind = AlterRenameColumn
+		}
+		if !p.matchKeyword("TO") {
+			return nil, p.errorf("expected TO after RENAME")
+		}
+		p.advance()
+		if cmd.NewName, err = p.expectIdentifier(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, p.errorf("unsupported ALTER TABLE action: %s", p.current().Value)
+	}
+
+	return cmd, nil
+}
+
+// atJoin reports whether the next token starts a JOIN clause
+func (p *Parser) atJoin() bool {
+	return p.matchKeyword("INNER") || p.matchKeyword("LEFT") || p.matchKeyword("RIGHT") || p.matchKeyword("FULL")
+}
+
+// parseJoin parses one {INNER | {LEFT | RIGHT | FULL} [OUTER]} JOIN table ON a.col = b.col clause
+// previous is the table joined last, which an unqualified column on the left
+// of the condition refers to. Either side of the condition may name the new table
+func (p *Parser) parseJoin(previous string) (engine.JoinStep, error) {
+	step := engine.JoinStep{Type: engine.JoinInner}
+	switch {
+	case p.matchKeyword("LEFT"):
+		step.Type = engine.JoinLeft
+	case p.matchKeyword("RIGHT"):
+		step.Type = engine.JoinRight
+	case p.matchKeyword("FULL"):
+		step.Type = engine.JoinFull
+	}
+	p.advance()
+
+	// LEFT OUTER JOIN is the same as LEFT JOIN, and likewise for RIGHT and FULL
+	if step.Type != engine.JoinInner && p.matchKeyword("OUTER") {
+		p.advance()
+	}
+
+	if !p.matchKeyword("JOIN") {
+		return step, p.errorf("expected JOIN after %s", step.Type)
+	}
+	p.advance()
+
+	var err error
+	if step.Table, err = p.expectIdentifier(); err != nil {
+		return step, err
+	}
+
+	if !p.matchKeyword("ON") {
+		return step, p.errorf("expected ON after JOIN")
+	}
+	p.advance()
+
+	// Parse join condition: table1.col = table2.col
+	leftCol, err := p.expectIdentifier()
+	if err != nil {
+		return step, err
+	}
+
+	if !p.matchOperator("=") {
+		return step, p.errorf("expected '=' in JOIN condition")
+	}
+	p.advance()
+
+	rightCol, err := p.expectIdentifier()
+	if err != nil {
+		return step, err
+	}
+
+	// Put the joined table's column on the right
+	if leftTable, _, ok := strings.Cut(leftCol, "."); ok && leftTable == step.Table {
+		leftCol, rightCol = rightCol, leftCol
+	}
+	if rightTable, _, ok := strings.Cut(rightCol, "."); ok && rightTable != step.Table {
+		return step, p.errorf("JOIN condition must reference a column of '%s'", step.Table)
+	}
+
+	step.LeftTable = previous
+	if leftTable, _, ok := strings.Cut(leftCol, "."); ok {
+		step.LeftTable = leftTable
+	}
+
+	// Extract column names without table prefix
+	step.LeftColumn = extractColumnName(leftCol)
+	step.RightColumn = extractColumnName(rightCol)
+	return step, nil
+}
+
+// selectList is the parsed column list of a SELECT
+type selectList struct {
+	columns    []string
+	aggregates []engine.Aggregate
+	aliases    map[string]string            // Output names of plain columns, keyed by column
+	functions  map[string]engine.ScalarFunc // Function calls, keyed by their output name in columns
+}
+
+// parseSelectColumns parses the column list in SELECT, separating out aggregate calls
+// Aliases of plain columns are returned keyed by column; aggregates and
+// function calls carry their own. A function call appears in the columns
+// under its output name, e.g. upper_name for UPPER(name)
+func (p *Parser) parseSelectColumns() (selectList, error) {
+	var list selectList
+	if p.current().Value == "*" {
+		p.advance()
+		return list, nil // nil columns means all columns
+	}
+
+	for {
+		if p.match(TokenFunction) && isScalarFunction(strings.ToUpper(p.current().Value)) {
+			fn, col, err := p.parseCall()
+			if err != nil {
+				return list, err
+			}
+			if col == "*" {
+				return list, p.errorf("%s expects a column, not *", fn)
+			}
+			f := engine.ScalarFunc{Func: fn, Column: col}
+			if f.Alias, err = p.parseAlias(); err != nil {
+				return list, err
+			}
+			if list.functions == nil {
+				list.functions = make(map[string]engine.ScalarFunc)
+			}
+			list.functions[f.Name()] = f
+			list.columns = append(list.columns, f.Name())
+		} else if p.match(TokenFunction) {
+			agg, err := p.parseAggregate()
+			if err != nil {
+				return list, err
+			}
+			if agg.Alias, err = p.parseAlias(); err != nil {
+				return list, err
+			}
+			list.aggregates = append(list.aggregates, agg)
+		} else {
+			col, err := p.expectIdentifier()
+			if err != nil {
+				return list, err
+			}
+			alias, err := p.parseAlias()
+			if err != nil {
+				return list, err
+			}
+			if alias != "" {
+				if list.aliases == nil {
+					list.aliases = make(map[string]string)
+				}
+				list.aliases[col] = alias
+			}
+			list.columns = append(list.columns, col)
+		}
+
+		if p.match(TokenComma) {
+			p.advance()
+			continue
+		}
+		break
+	}
+
+	return list, nil
+}
+
+// parseAlias parses an optional output name after a select column: AS alias, or a bare alias
+// It returns "" when there is none
+func (p *Parser) parseAlias() (string, error) {
+	if p.matchKeyword("AS") {
+		p.advance()
+		return p.expectIdentifier()
+	}
+	if p.match(TokenIdentifier) {
+		return p.expectIdentifier()
+	}
+	return "", nil
+}
+
+// parseAggregate parses an aggregate call such as COUNT(*), AVG(age) or
+// COUNT(DISTINCT user_id)
+func (p *Parser) parseAggregate() (engine.Aggregate, error) {
+	agg := engine.Aggregate{Func: strings.ToUpper(p.current().Value)}
+	p.advance()
+
+	if !p.match(TokenLeftParen) {
+		return agg, p.errorf("expected '(' after %s", agg.Func)
+	}
+	p.advance()
+
+	if p.matchKeyword("DISTINCT") {
+		agg.Distinct = true
+		p.advance()
+	}
+
+	col, err := p.expectIdentifier()
+	if err != nil {
+		return agg, err
+	}
+	agg.Column = col
+
+	if !p.match(TokenRightParen) {
+		return agg, p.errorf("expected ')' after %s argument", agg.Func)
+	}
+	p.advance()
+
+	return agg, nil
+}
+
+// parseCall parses a single-argument function call such as COUNT(*) or
+// UPPER(name), returning the upper-cased function name and its argument
+func (p *Parser) parseCall() (string, string, error) {
+	fn := strings.ToUpper(p.current().Value)
+	p.advance()
+
+	if !p.match(TokenLeftParen) {
+		return "", "", p.errorf("expected '(' after %s", fn)
+	}
+	p.advance()
+
+	col, err := p.expectIdentifier()
+	if err != nil {
+		return "", "", err
+	}
+
+	if !p.match(TokenRightParen) {
+		return "", "", p.errorf("expected ')' after %s argument", fn)
+	}
+	p.advance()
+
+	return fn, col, nil
+}
+
+// parseIdentifierList parses a comma-separated list of identifiers
+func (p *Parser) parseIdentifierList() ([]string, error) {
+	var identifiers []string
+
+	for {
+		id, err := p.expectIdentifier()
+		if err != nil {
+			return nil, err
+		}
+		identifiers = append(identifiers, id)
+
+		if p.match(TokenComma) {
+			p.advance()
+			continue
+		}
+		break
+	}
+
+	return identifiers, nil
+}
+
+// parseValueList parses a comma-separated list of values
+func (p *Parser) parseValueList() ([]interface{}, error) {
+	var values []interface{}
+
+	for {
+		val, err := p.expectValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, val)
+
+		if p.match(TokenComma) {
+			p.advance()
 			continue
 		}
 		break
@@ -422,7 +1164,7 @@ func (p *Parser) parseSetClause() (engine.Row, error) {
 		}
 
 		if !p.matchOperator("=") {
-			return nil, fmt.Errorf("expected '=' in SET clause")
+			return nil, p.errorf("expected '=' in SET clause")
 		}
 		p.advance()
 
@@ -444,19 +1186,176 @@ func (p *Parser) parseSetClause() (engine.Row, error) {
 }
 
 // parseCondition parses a WHERE condition
+// NOT binds tighter than AND, which binds tighter than OR:
+// NOT a OR b AND c is (NOT a) OR (b AND c)
 func (p *Parser) parseCondition() (*engine.Condition, error) {
+	left, err := p.parseAndCondition()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.matchKeyword("OR") {
+		p.advance()
+		right, err := p.parseAndCondition()
+		if err != nil {
+			return nil, err
+		}
+		left = engine.Or(left, right)
+	}
+
+	return left, nil
+}
+
+// parseAndCondition parses predicates joined by AND
+func (p *Parser) parseAndCondition() (*engine.Condition, error) {
+	left, err := p.parseNotCondition()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.matchKeyword("AND") {
+		p.advance()
+		right, err := p.parseNotCondition()
+		if err != nil {
+			return nil, err
+		}
+		left = engine.And(left, right)
+	}
+
+	return left, nil
+}
+
+// parseNotCondition parses a predicate preceded by any number of NOTs
+// NOT column alone is shorthand for col = FALSE, which can use an index
+func (p *Parser) parseNotCondition() (*engine.Condition, error) {
+	if !p.matchKeyword("NOT") {
+		return p.parsePredicate()
+	}
+	p.advance()
+
+	start := p.pos
+	cond, err := p.parseNotCondition()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos == start+1 && cond.Operator == "=" && cond.Value == true {
+		return &engine.Condition{Column: cond.Column, Operator: "=", Value: false}, nil
+	}
+	return engine.Not(cond), nil
+}
+
+// parsePredicate parses a single comparison: col [arith] op value, col [arith] BETWEEN low AND high,
+// col [arith] IS [NOT] NULL, or col {LIKE | ILIKE} 'pattern' [ESCAPE 'char']
+// A bare column (WHERE active) is shorthand for col = TRUE, and a parenthesized
+// condition groups its predicates: (a OR b) AND c
+func (p *Parser) parsePredicate() (*engine.Condition, error) {
+	if p.match(TokenLeftParen) {
+		p.advance()
+		cond, err := p.parseCondition()
+		if err != nil {
+			return nil, err
+		}
+		if !p.match(TokenRightParen) {
+			return nil, p.errorf("expected ')' after condition, got %v", p.current())
+		}
+		p.advance()
+		return cond, nil
+	}
+
 	col, err := p.expectIdentifier()
 	if err != nil {
 		return nil, err
 	}
 
+	if p.matchPredicateEnd() {
+		return &engine.Condition{Column: col, Operator: "=", Value: true}, nil
+	}
+
+	if p.matchKeyword("LIKE") || p.matchKeyword("ILIKE") {
+		op := strings.ToUpper(p.current().Value)
+		p.advance()
+		var pattern interface{}
+		if p.match(TokenPlaceholder) {
+			if pattern, err = p.expectOperand(); err != nil {
+				return nil, err
+			}
+		} else if p.match(TokenString) {
+			pattern = p.current().Value
+			p.advance()
+		} else {
+			return nil, p.errorf("expected string pattern after %s", op)
+		}
+
+		cond := &engine.Condition{Column: col, Operator: op, Value: pattern}
+		if p.matchKeyword("ESCAPE") {
+			p.advance()
+			escape := []rune(p.current().Value)
+			if !p.match(TokenString) || len(escape) != 1 {
+				return nil, p.errorf("expected a single character after ESCAPE")
+			}
+			p.advance()
+			cond.Escape = escape[0]
+		}
+		return cond, nil
+	}
+
+	// Optional arithmetic on the column: col % 2 = 0
+	var arith *engine.Arithmetic
+	if p.matchArithmetic() {
+		op := p.current().Value
+		p.advance()
+
+		operand, err := p.expectValue()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := operand.(int); !ok {
+			return nil, p.errorf("expected integer operand for '%s'", op)
+		}
+		arith = &engine.Arithmetic{Operator: op, Operand: operand}
+	}
+
+	if p.matchKeyword("IS") {
+		p.advance()
+		op := "IS NULL"
+		if p.matchKeyword("NOT") {
+			p.advance()
+			op = "IS NOT NULL"
+		}
+		if !p.matchKeyword("NULL") {
+			return nil, p.errorf("expected NULL after %s", strings.TrimSuffix(op, " NULL"))
+		}
+		p.advance()
+		return &engine.Condition{Column: col, Operator: op, Arith: arith}, nil
+	}
+
+	if p.matchKeyword("BETWEEN") {
+		p.advance()
+		low, err := p.expectOperand()
+		if err != nil {
+			return nil, err
+		}
+		if !p.matchKeyword("AND") {
+			return nil, p.errorf("expected AND in BETWEEN")
+		}
+		p.advance()
+		high, err := p.expectOperand()
+		if err != nil {
+			return nil, err
+		}
+		return &engine.Condition{Column: col, Operator: "BETWEEN", Value: low, High: high, Arith: arith}, nil
+	}
+
 	if !p.match(TokenOperator) {
-		return nil, fmt.Errorf("expected operator in condition")
+		return nil, p.errorf("expected operator in condition, got %v", p.current())
 	}
 	op := p.current().Value
+	if !isComparisonOperator(op) {
+		return nil, p.errorf("expected comparison operator in condition, got '%s'", op)
+	}
 	p.advance()
 
-	val, err := p.expectValue()
+	val, err := p.expectOperand()
 	if err != nil {
 		return nil, err
 	}
@@ -465,6 +1364,7 @@ func (p *Parser) parseCondition() (*engine.Condition, error) {
 		Column:   col,
 		Operator: op,
 		Value:    val,
+		Arith:    arith,
 	}, nil
 }
 
@@ -472,7 +1372,7 @@ func (p *Parser) parseCondition() (*engine.Condition, error) {
 
 func (p *Parser) current() Token {
 	if p.pos >= len(p.tokens) {
-		return Token{Type: TokenEOF}
+		return p.tokens[len(p.tokens)-1] // Tokenize always ends with EOF
 	}
 	return p.tokens[p.pos]
 }
@@ -481,6 +1381,14 @@ func (p *Parser) advance() {
 	p.pos++
 }
 
+// peek returns the token after the current one
+func (p *Parser) peek() Token {
+	if p.pos+1 >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+1]
+}
+
 func (p *Parser) match(tokenType TokenType) bool {
 	return p.current().Type == tokenType
 }
@@ -493,9 +1401,32 @@ func (p *Parser) matchOperator(op string) bool {
 	return p.match(TokenOperator) && p.current().Value == op
 }
 
+// matchEnd checks for the end of a statement: a semicolon or the end of input
+func (p *Parser) matchEnd() bool {
+	return p.match(TokenSemicolon) || p.match(TokenEOF)
+}
+
+// matchPredicateEnd checks for the token following a complete predicate
+func (p *Parser) matchPredicateEnd() bool {
+	return p.matchEnd() || p.match(TokenRightParen) ||
+		p.matchKeyword("AND") || p.matchKeyword("OR") || p.matchKeyword("ORDER") || p.matchKeyword("GROUP")
+}
+
+// matchArithmetic checks for an arithmetic operator (* is tokenized as an identifier)
+func (p *Parser) matchArithmetic() bool {
+	token := p.current()
+	switch token.Type {
+	case TokenOperator:
+		return token.Value == "+" || token.Value == "-" || token.Value == "/" || token.Value == "%"
+	case TokenIdentifier:
+		return token.Value == "*"
+	}
+	return false
+}
+
 func (p *Parser) expectIdentifier() (string, error) {
 	if !p.match(TokenIdentifier) {
-		return "", fmt.Errorf("expected identifier, got %v", p.current())
+		return "", p.errorf("expected identifier, got %v", p.current())
 	}
 	value := p.current().Value
 	p.advance()
@@ -504,13 +1435,45 @@ func (p *Parser) expectIdentifier() (string, error) {
 
 func (p *Parser) expectKeyword() (string, error) {
 	if !p.match(TokenKeyword) {
-		return "", fmt.Errorf("expected keyword, got %v", p.current())
+		return "", p.errorf("expected keyword, got %v", p.current())
 	}
 	value := p.current().Value
 	p.advance()
 	return value, nil
 }
 
+// expectColumnType parses the type of a column definition
+// DATE and TIMESTAMP are not reserved, so columns can still be named date,
+// and both map to engine.TypeDate
+func (p *Parser) expectColumnType() (engine.ColumnType, error) {
+	if p.match(TokenIdentifier) {
+		switch strings.ToUpper(p.current().Value) {
+		case "DATE", "TIMESTAMP":
+			p.advance()
+			return engine.TypeDate, nil
+		}
+	}
+	name, err := p.expectKeyword()
+	if err != nil {
+		return "", err
+	}
+	return engine.ColumnType(strings.ToUpper(name)), nil
+}
+
+// expectOperand parses the value a condition compares with: a literal, or a
+// placeholder when preparing a statement
+func (p *Parser) expectOperand() (interface{}, error) {
+	if !p.match(TokenPlaceholder) {
+		return p.expectValue()
+	}
+	if !p.prepared {
+		return nil, p.errorf("placeholders are only allowed in prepared statements")
+	}
+	p.advance()
+	p.params++
+	return engine.Param{Index: p.params - 1}, nil
+}
+
 func (p *Parser) expectValue() (interface{}, error) {
 	token := p.current()
 
@@ -519,23 +1482,52 @@ func (p *Parser) expectValue() (interface{}, error) {
 		p.advance()
 		return token.Value, nil
 	case TokenNumber:
-		p.advance()
 		val, err := strconv.Atoi(token.Value)
 		if err != nil {
-			return nil, fmt.Errorf("invalid number: %s", token.Value)
+			return nil, p.errorf("invalid number: %s", token.Value)
 		}
+		p.advance()
 		return val, nil
+	case TokenOperator:
+		// A minus in value position is always a sign: -50, id - -1
+		if token.Value == "-" && p.peek().Type == TokenNumber {
+			number := p.peek().Value
+			val, err := strconv.Atoi("-" + number)
+			if err != nil {
+				return nil, p.errorf("invalid number: -%s", number)
+			}
+			p.advance()
+			p.advance()
+			return val, nil
+		}
+		return nil, p.errorf("expected value, got %v", token)
 	case TokenKeyword:
 		// Handle NULL, TRUE, FALSE
 		upper := strings.ToUpper(token.Value)
-		if upper == "NULL" {
+		switch upper {
+		case "NULL":
 			p.advance()
 			return nil, nil
+		case "TRUE":
+			p.advance()
+			return true, nil
+		case "FALSE":
+			p.advance()
+			return false, nil
 		}
-		return nil, fmt.Errorf("unexpected keyword in value position: %s", token.Value)
+		return nil, p.errorf("unexpected keyword in value position: %s", token.Value)
 	default:
-		return nil, fmt.Errorf("expected value, got %v", token)
+		return nil, p.errorf("expected value, got %v", token)
+	}
+}
+
+// isComparisonOperator checks if an operator compares two values
+func isComparisonOperator(op string) bool {
+	switch op {
+	case "=", "!=", "<>", ">", "<", ">=", "<=":
+		return true
 	}
+	return false
 }
 
 // extractColumnName extracts column name from qualified name (table.column)
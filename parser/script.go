@@ -0,0 +1,69 @@
+package parser
+
+import "strings"
+
+// SplitStatements splits a script into statements separated by semicolons
+// Semicolons inside quoted strings do not end a statement. Comments, -- to the
+// end of the line or /* ... */, are dropped. Empty statements are skipped
+func SplitStatements(script string) []string {
+	statements, _ := scanScript(script)
+	return statements
+}
+
+// Incomplete reports whether a script ends inside a quoted string, a block
+// comment or an open parenthesis, so more input is needed to finish its last statement
+func Incomplete(script string) bool {
+	_, open := scanScript(script)
+	return open
+}
+
+// scanScript splits a script into statements and reports whether the last
+// one was left open
+func scanScript(script string) ([]string, bool) {
+	var statements []string
+	var current strings.Builder
+	flush := func() {
+		if stmt := strings.TrimSpace(current.String()); stmt != "" {
+			statements = append(statements, stmt)
+		}
+		current.Reset()
+	}
+
+	var quote byte
+	depth := 0
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == '-' && i+1 < len(script) && script[i+1] == '-':
+			for i < len(script) && script[i] != '\n' {
+				i++
+			}
+			c = '\n' // Keep the line break so the comment still separates tokens
+		case c == '/' && i+1 < len(script) && script[i+1] == '*':
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				flush()
+				return statements, true
+			}
+			i += 2 + end + 1
+			c = ' '
+		case c == ';':
+			flush()
+			depth = 0
+			continue
+		}
+		current.WriteByte(c)
+	}
+	flush()
+	return statements, quote != 0 || depth > 0
+}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"fmt"
 	"strings"
 	"unicode"
 )
@@ -9,6 +10,15 @@ import (
 type Token struct {
 	Type  TokenType
 	Value string
+	Pos   int // Byte offset of the token in the input
+}
+
+// String describes the token for error messages, e.g. "FROM" or end of input
+func (t Token) String() string {
+	if t.Type == TokenEOF {
+		return "end of input"
+	}
+	return fmt.Sprintf("%q", t.Value)
 }
 
 // TokenType represents the type of token
@@ -23,13 +33,15 @@ const (
 	TokenComma
 	TokenLeftParen
 	TokenRightParen
+	TokenFunction
+	TokenSemicolon
+	TokenPlaceholder
 	TokenEOF
 )
 
 // Tokenize breaks an input string into tokens
 func Tokenize(input string) []Token {
 	var tokens []Token
-	input = strings.TrimSpace(input)
 	i := 0
 
 	for i < len(input) {
@@ -39,9 +51,28 @@ func Tokenize(input string) []Token {
 			continue
 		}
 
+		// Skip -- comments to the end of the line
+		if strings.HasPrefix(input[i:], "--") {
+			for i < len(input) && input[i] != '\n' {
+				i++
+			}
+			continue
+		}
+
+		// Skip /* ... */ comments; an unterminated comment runs to the end
+		if strings.HasPrefix(input[i:], "/*") {
+			end := strings.Index(input[i+2:], "*/")
+			if end < 0 {
+				break
+			}
+			i += 2 + end + 2
+			continue
+		}
+
 		// Handle strings (single or double quotes)
 		if input[i] == '\'' || input[i] == '"' {
 			quote := input[i]
+			pos := i
 			i++
 			start := i
 			for i < len(input) && input[i] != quote {
@@ -50,6 +81,7 @@ func Tokenize(input string) []Token {
 			tokens = append(tokens, Token{
 				Type:  TokenString,
 				Value: input[start:i],
+				Pos:   pos,
 			})
 			i++ // Skip closing quote
 			continue
@@ -59,37 +91,57 @@ func Tokenize(input string) []Token {
 		if input[i] == '=' || input[i] == '!' || input[i] == '>' || input[i] == '<' {
 			start := i
 			i++
-			// Handle != >= <=
-			if i < len(input) && input[i] == '=' {
+			// Handle != >= <= and <>
+			if i < len(input) && (input[i] == '=' || input[start] == '<' && input[i] == '>') {
 				i++
 			}
 			tokens = append(tokens, Token{
 				Type:  TokenOperator,
 				Value: input[start:i],
+				Pos:   start,
 			})
 			continue
 		}
 
+		// Handle arithmetic operators
+		if input[i] == '+' || input[i] == '-' || input[i] == '/' || input[i] == '%' {
+			tokens = append(tokens, Token{Type: TokenOperator, Value: string(input[i]), Pos: i})
+			i++
+			continue
+		}
+
 		if input[i] == ',' {
-			tokens = append(tokens, Token{Type: TokenComma, Value: ","})
+			tokens = append(tokens, Token{Type: TokenComma, Value: ",", Pos: i})
+			i++
+			continue
+		}
+
+		if input[i] == ';' {
+			tokens = append(tokens, Token{Type: TokenSemicolon, Value: ";", Pos: i})
+			i++
+			continue
+		}
+
+		if input[i] == '?' {
+			tokens = append(tokens, Token{Type: TokenPlaceholder, Value: "?", Pos: i})
 			i++
 			continue
 		}
 
 		if input[i] == '*' {
-			tokens = append(tokens, Token{Type: TokenIdentifier, Value: "*"})
+			tokens = append(tokens, Token{Type: TokenIdentifier, Value: "*", Pos: i})
 			i++
 			continue
 		}
 
 		if input[i] == '(' {
-			tokens = append(tokens, Token{Type: TokenLeftParen, Value: "("})
+			tokens = append(tokens, Token{Type: TokenLeftParen, Value: "(", Pos: i})
 			i++
 			continue
 		}
 
 		if input[i] == ')' {
-			tokens = append(tokens, Token{Type: TokenRightParen, Value: ")"})
+			tokens = append(tokens, Token{Type: TokenRightParen, Value: ")", Pos: i})
 			i++
 			continue
 		}
@@ -103,6 +155,7 @@ func Tokenize(input string) []Token {
 			tokens = append(tokens, Token{
 				Type:  TokenNumber,
 				Value: input[start:i],
+				Pos:   start,
 			})
 			continue
 		}
@@ -116,15 +169,18 @@ func Tokenize(input string) []Token {
 			value := input[start:i]
 			tokenType := TokenIdentifier
 
-			// Check if it's a keyword
+			// Check if it's a keyword or a function call
 			upperValue := strings.ToUpper(value)
 			if isKeyword(upperValue) {
 				tokenType = TokenKeyword
+			} else if isFunction(upperValue) && nextNonSpace(input, i) == '(' {
+				tokenType = TokenFunction
 			}
 
 			tokens = append(tokens, Token{
 				Type:  tokenType,
 				Value: value,
+				Pos:   start,
 			})
 			continue
 		}
@@ -133,7 +189,7 @@ func Tokenize(input string) []Token {
 		i++
 	}
 
-	tokens = append(tokens, Token{Type: TokenEOF, Value: ""})
+	tokens = append(tokens, Token{Type: TokenEOF, Value: "", Pos: len(strings.TrimRightFunc(input, unicode.IsSpace))})
 	return tokens
 }
 
@@ -146,6 +202,41 @@ func isKeyword(s string) bool {
 		"JOIN": true, "ON": true, "AND": true, "OR": true,
 		"PRIMARY": true, "KEY": true, "UNIQUE": true, "NOT": true,
 		"NULL": true, "INT": true, "STRING": true, "BOOL": true,
+		"DISTINCT": true, "ORDER": true, "BY": true, "ASC": true,
+		"DESC": true, "LEFT": true, "RIGHT": true, "FULL": true,
+		"OUTER": true, "ANALYZE": true,
+		"AUTOINCREMENT": true, "AUTO_INCREMENT": true, "ALTER": true,
+		"REFERENCES": true, "CASCADE": true, "RESTRICT": true,
+		"TRUE": true, "FALSE": true, "LIKE": true, "ILIKE": true, "ESCAPE": true,
+		"BETWEEN": true, "DROP": true, "NULLS": true, "FIRST": true,
+		"LAST": true, "IS": true, "ADD": true, "COLUMN": true,
+		"GROUP": true, "AS": true, "DESCRIBE": true,
+		"SHOW": true, "TABLES": true, "CHECK": true,
+		"TRUNCATE": true, "EXPLAIN": true, "RENAME": true, "TO": true,
 	}
 	return keywords[s]
 }
+
+// isFunction checks if a string is a supported function name
+func isFunction(s string) bool {
+	functions := map[string]bool{
+		"COUNT": true, "SUM": true, "AVG": true, "MIN": true, "MAX": true,
+	}
+	return functions[s] || isScalarFunction(s)
+}
+
+// isScalarFunction checks if a string names a function applied to each row
+func isScalarFunction(s string) bool {
+	return s == "UPPER" || s == "LOWER" || s == "LENGTH"
+}
+
+// nextNonSpace returns the first non-whitespace byte at or after position i
+func nextNonSpace(input string, i int) byte {
+	for i < len(input) && unicode.IsSpace(rune(input[i])) {
+		i++
+	}
+	if i >= len(input) {
+		return 0
+	}
+	return input[i]
+}
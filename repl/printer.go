@@ -1,30 +1,51 @@
 package repl
 
 import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"godb/engine"
+	"godb/parser"
+	"io"
+	"sort"
 	"strings"
+	"time"
 )
 
+// OutputMode selects how query results are printed
+type OutputMode int
+
+const (
+	OutputTable OutputMode = iota // Aligned ASCII table
+	OutputCSV                     // RFC 4180 CSV with a header line
+	OutputJSON                    // JSON array with one object per row
+)
+
+// ParseOutputMode returns the output mode named by .output
+func ParseOutputMode(name string) (OutputMode, error) {
+	switch strings.ToLower(name) {
+	case "table":
+		return OutputTable, nil
+	case "csv":
+		return OutputCSV, nil
+	case "json":
+		return OutputJSON, nil
+	}
+	return OutputTable, fmt.Errorf("unknown output mode '%s' (expected table, csv or json)", name)
+}
+
 // PrintRows formats and prints rows in a table format
-func PrintRows(rows []engine.Row) {
+// Columns are printed in the given order; with nil columns, every column
+// found in the rows is printed in alphabetical order
+func PrintRows(columns []string, rows []engine.Row) {
 	if len(rows) == 0 {
 		fmt.Println("No rows returned.")
 		return
 	}
 
-	// Collect all column names
-	columnSet := make(map[string]bool)
-	for _, row := range rows {
-		for col := range row {
-			columnSet[col] = true
-		}
-	}
-
-	// Convert to sorted list for consistent ordering
-	columns := make([]string, 0, len(columnSet))
-	for col := range columnSet {
-		columns = append(columns, col)
+	if columns == nil {
+		columns = rowColumns(rows)
 	}
 
 	// Calculate column widths
@@ -36,7 +57,7 @@ func PrintRows(rows []engine.Row) {
 	for _, row := range rows {
 		for _, col := range columns {
 			if val, ok := row[col]; ok {
-				valStr := fmt.Sprintf("%v", val)
+				valStr := formatCell(val)
 				if len(valStr) > widths[col] {
 					widths[col] = len(valStr)
 				}
@@ -64,7 +85,7 @@ func PrintRows(rows []engine.Row) {
 		for _, col := range columns {
 			val := ""
 			if v, ok := row[col]; ok && v != nil {
-				val = fmt.Sprintf("%v", v)
+				val = formatCell(v)
 			}
 			rowParts = append(rowParts, padRight(val, widths[col]))
 		}
@@ -74,6 +95,158 @@ func PrintRows(rows []engine.Row) {
 	fmt.Printf("\n%d row(s) returned.\n", len(rows))
 }
 
+// WriteCSV writes rows as CSV with a header line of column names
+// Fields containing commas, quotes or newlines are quoted and NULL is an
+// empty field. With nil columns, every column found in the rows is written in
+// alphabetical order
+func WriteCSV(w io.Writer, columns []string, rows []engine.Row) error {
+	if columns == nil {
+		columns = rowColumns(rows)
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		for i, col := range columns {
+			record[i] = ""
+			if v, ok := row[col]; ok && v != nil {
+				record[i] = formatCell(v)
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON writes rows as a JSON array of objects keyed by column name, one
+// object per line. Keys follow the order of columns and values keep their
+// native JSON types, with NULL as null. With nil columns, every column found
+// in the rows is written in alphabetical order
+func WriteJSON(w io.Writer, columns []string, rows []engine.Row) error {
+	if columns == nil {
+		columns = rowColumns(rows)
+	}
+
+	var b strings.Builder
+	b.WriteString("[")
+	for i, row := range rows {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n  {")
+		for j, col := range columns {
+			if j > 0 {
+				b.WriteString(",")
+			}
+			key, _ := json.Marshal(col)
+			value, err := json.Marshal(row[col])
+			if err != nil {
+				return err
+			}
+			b.Write(key)
+			b.WriteString(":")
+			b.Write(value)
+		}
+		b.WriteString("}")
+	}
+	if len(rows) > 0 {
+		b.WriteString("\n")
+	}
+	b.WriteString("]\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// rowColumns returns the sorted names of all columns found in rows
+func rowColumns(rows []engine.Row) []string {
+	columnSet := make(map[string]bool)
+	for _, row := range rows {
+		for col := range row {
+			columnSet[col] = true
+		}
+	}
+
+	columns := make([]string, 0, len(columnSet))
+	for col := range columnSet {
+		columns = append(columns, col)
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// PrintResolution prints how each referenced column resolves to a table
+func PrintResolution(report *engine.ResolutionReport) {
+	width := 0
+	for _, res := range report.Columns {
+		if len(res.Reference) > width {
+			width = len(res.Reference)
+		}
+	}
+
+	fmt.Printf("Tables: %s\n", strings.Join(report.Tables, ", "))
+	for _, res := range report.Columns {
+		var target string
+		switch {
+		case res.Ambiguous:
+			target = fmt.Sprintf("AMBIGUOUS (%s)", strings.Join(res.Candidates, ", "))
+		case !res.Resolved():
+			target = "NOT FOUND"
+		default:
+			target = res.Qualified()
+		}
+		fmt.Printf("  %s -> %s\n", padRight(res.Reference, width), target)
+	}
+	fmt.Printf("Output columns: %s\n", strings.Join(report.Output, ", "))
+}
+
+// PrintSchema prints each column's name, type and constraints in the PrintRows table format
+func PrintSchema(schema []engine.Column) {
+	headers := []string{"column", "type", "constraints"}
+	lines := make([][]string, len(schema))
+	widths := make([]int, len(headers))
+	for i, h := range headers {
+		widths[i] = len(h)
+	}
+
+	for i, col := range schema {
+		lines[i] = []string{col.Name, string(col.Type), strings.Join(col.Constraints(), " ")}
+		for j, cell := range lines[i] {
+			if len(cell) > widths[j] {
+				widths[j] = len(cell)
+			}
+		}
+	}
+
+	printLine := func(cells []string, sep string) {
+		parts := make([]string, len(cells))
+		for i, cell := range cells {
+			parts[i] = padRight(cell, widths[i])
+		}
+		fmt.Println(strings.Join(parts, sep))
+	}
+
+	printLine(headers, " | ")
+	separators := make([]string, len(widths))
+	for i, w := range widths {
+		separators[i] = strings.Repeat("-", w)
+	}
+	fmt.Println(strings.Join(separators, "-+-"))
+	for _, line := range lines {
+		printLine(line, " | ")
+	}
+
+	fmt.Printf("\n%d column(s)\n", len(schema))
+}
+
 // PrintSuccess prints a success message
 func PrintSuccess(message string) {
 	fmt.Printf("✓ %s\n", message)
@@ -84,6 +257,44 @@ func PrintError(err error) {
 	fmt.Printf("✗ Error: %v\n", err)
 }
 
+// PrintStatementError prints an error from running a statement; for a syntax
+// error it also prints the statement with the offending token underlined
+func PrintStatementError(stmt string, err error) {
+	PrintError(err)
+	var parseErr parser.ParseError
+	if errors.As(err, &parseErr) {
+		for _, line := range strings.Split(parseErr.Underline(stmt), "\n") {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+}
+
+// PrintTiming prints how long a command took, for .timer
+func PrintTiming(elapsed time.Duration) {
+	fmt.Printf("(completed in %s)\n", FormatDuration(elapsed))
+}
+
+// FormatDuration renders a command's run time at a precision suited to its
+// size, e.g. "412µs", "3.2ms" or "1.5s"
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d < time.Millisecond:
+		return d.Round(time.Microsecond).String()
+	case d < time.Second:
+		return d.Round(100 * time.Microsecond).String()
+	}
+	return d.Round(time.Millisecond).String()
+}
+
+// formatCell formats a value for a table cell or CSV field
+// Dates are written the way they are typed in SQL
+func formatCell(value interface{}) string {
+	if t, ok := value.(time.Time); ok {
+		return engine.FormatDate(t)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
 // padRight pads a string to a given width with spaces on the right
 func padRight(s string, width int) string {
 	if len(s) >= width {
@@ -4,15 +4,22 @@ import (
 	"bufio"
 	"fmt"
 	"godb/engine"
+	"godb/executor"
 	"godb/parser"
 	"io"
+	"os"
 	"strings"
+	"time"
 )
 
 // REPL represents the Read-Eval-Print Loop
 type REPL struct {
 	db     *engine.Database
 	reader *bufio.Reader
+	output OutputMode // How query results are printed, set with .output
+	timer  bool       // Print how long each command took, set with .timer
+
+	checkpoint *engine.Snapshot // Saved by .checkpoint, applied by .restore
 }
 
 // NewREPL creates a new REPL instance
@@ -43,6 +50,17 @@ func (r *REPL) Start() {
 			continue
 		}
 
+		// Keep reading while a quoted string or parenthesis is left open, so
+		// statements such as CREATE TABLE can span several lines
+		for parser.Incomplete(input) && !strings.HasPrefix(strings.TrimSpace(input), ".") {
+			fmt.Print("  ...> ")
+			more, err := r.reader.ReadString('\n')
+			input += more
+			if err != nil {
+				break
+			}
+		}
+
 		input = strings.TrimSpace(input)
 
 		// Handle empty input
@@ -56,101 +74,282 @@ func (r *REPL) Start() {
 			return
 		}
 
-		// Execute command
-		r.executeCommand(input)
+		// Handle meta-commands
+		if strings.HasPrefix(input, ".") {
+			r.executeMetaCommand(input)
+			continue
+		}
+
+		// Execute each statement on the line
+		for _, stmt := range parser.SplitStatements(input) {
+			if err := r.run(stmt); err != nil {
+				PrintStatementError(stmt, err)
+				break
+			}
+		}
+	}
+}
+
+// run executes a statement, printing its run time when .timer is on
+func (r *REPL) run(stmt string) error {
+	start := time.Now()
+	err := r.executeCommand(stmt)
+	if r.timer {
+		PrintTiming(time.Since(start))
+	}
+	return err
+}
+
+// readScript executes the statements of a SQL script file in order
+// Execution stops at the first statement that fails
+func (r *REPL) readScript(path string) {
+	if path == "" {
+		PrintError(fmt.Errorf("usage: .read FILE"))
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		PrintError(err)
+		return
+	}
+
+	statements := parser.SplitStatements(string(data))
+	for i, stmt := range statements {
+		if err := r.run(stmt); err != nil {
+			PrintStatementError(stmt, fmt.Errorf("%s: statement %d of %d: %w", path, i+1, len(statements), err))
+			return
+		}
 	}
 }
 
 // executeCommand parses and executes a command
-func (r *REPL) executeCommand(input string) {
+func (r *REPL) executeCommand(input string) error {
 	// Parse command
 	p := parser.NewParser(input)
 	cmd, err := p.Parse()
 	if err != nil {
-		PrintError(fmt.Errorf("parse error: %v", err))
-		return
+		return fmt.Errorf("parse error: %w", err)
 	}
 
-	// Execute based on command type
-	switch c := cmd.(type) {
-	case *parser.CreateTableCommand:
-		r.executeCreateTable(c)
-	case *parser.InsertCommand:
-		r.executeInsert(c)
-	case *parser.SelectCommand:
-		r.executeSelect(c)
-	case *parser.UpdateCommand:
-		r.executeUpdate(c)
-	case *parser.DeleteCommand:
-		r.executeDelete(c)
-	case *parser.JoinCommand:
-		r.executeJoin(c)
+	return r.execute(cmd)
+}
+
+// execute runs a parsed statement and prints its result
+func (r *REPL) execute(cmd parser.Command) error {
+	result, err := executor.ExecuteCommand(r.db, cmd)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case result.Kind == parser.CmdDescribe:
+		PrintSchema(result.Schema)
+	case result.Kind == parser.CmdExplain:
+		fmt.Print(result.Plan.TreeString())
+	case result.IsQuery() && len(result.Rows) == 0 && result.Message != "":
+		fmt.Println(result.Message + ".")
+	case result.IsQuery():
+		r.printRows(result.Columns, result.Rows)
 	default:
-		PrintError(fmt.Errorf("unknown command type"))
+		PrintSuccess(result.Message)
+		if result.Rows != nil {
+			// Single-row inserts echo the row as stored, including any generated key
+			r.printRows(result.Columns, result.Rows)
+		}
 	}
+	return nil
 }
 
-// executeCreateTable executes a CREATE TABLE command
-func (r *REPL) executeCreateTable(cmd *parser.CreateTableCommand) {
-	err := r.db.CreateTable(cmd.TableName, cmd.Columns)
-	if err != nil {
-		PrintError(err)
-		return
+// executeMetaCommand executes a dot-prefixed REPL command
+func (r *REPL) executeMetaCommand(input string) {
+	name, args, _ := strings.Cut(input, " ")
+	args = strings.TrimSpace(args)
+
+	switch strings.ToLower(name) {
+	case ".explain":
+		r.explain(args)
+	case ".explain-schema":
+		r.explainSchema(args)
+	case ".tables":
+		if err := r.execute(&parser.ShowTablesCommand{}); err != nil {
+			PrintError(err)
+		}
+	case ".output":
+		mode, err := ParseOutputMode(args)
+		if err != nil {
+			PrintError(err)
+			return
+		}
+		r.output = mode
+	case ".timer":
+		switch strings.ToLower(args) {
+		case "on":
+			r.timer = true
+		case "off":
+			r.timer = false
+		default:
+			PrintError(fmt.Errorf("usage: .timer on|off"))
+		}
+	case ".schema":
+		if args == "" {
+			PrintError(fmt.Errorf("usage: .schema TABLE"))
+			return
+		}
+		if err := r.execute(&parser.DescribeCommand{TableName: args}); err != nil {
+			PrintError(err)
+		}
+	case ".read":
+		r.readScript(args)
+	case ".vacuum":
+		if args == "" {
+			PrintError(fmt.Errorf("usage: .vacuum TABLE"))
+			return
+		}
+		freed, err := r.db.Vacuum(args)
+		if err != nil {
+			PrintError(err)
+			return
+		}
+		PrintSuccess(fmt.Sprintf("Table '%s' vacuumed, %d row slot(s) freed", args, freed))
+	case ".checkpoint":
+		r.checkpoint = r.db.Snapshot()
+		PrintSuccess(fmt.Sprintf("Checkpoint saved (%d table(s))", len(r.checkpoint.Tables())))
+	case ".restore":
+		if r.checkpoint == nil {
+			PrintError(fmt.Errorf("no checkpoint to restore; save one with .checkpoint"))
+			return
+		}
+		r.db.Restore(r.checkpoint)
+		PrintSuccess("Database restored to the last checkpoint")
+	default:
+		PrintError(fmt.Errorf("unknown meta-command: %s", name))
 	}
-	PrintSuccess(fmt.Sprintf("Table '%s' created successfully", cmd.TableName))
 }
 
-// executeInsert executes an INSERT command
-func (r *REPL) executeInsert(cmd *parser.InsertCommand) {
-	err := r.db.Insert(cmd.TableName, cmd.Values)
+// printRows prints query results in the current output mode
+func (r *REPL) printRows(columns []string, rows []engine.Row) {
+	var err error
+	switch r.output {
+	case OutputCSV:
+		err = WriteCSV(os.Stdout, columns, rows)
+	case OutputJSON:
+		err = WriteJSON(os.Stdout, columns, rows)
+	default:
+		PrintRows(columns, rows)
+	}
 	if err != nil {
 		PrintError(err)
-		return
 	}
-	PrintSuccess("1 row inserted")
 }
 
-// executeSelect executes a SELECT command
-func (r *REPL) executeSelect(cmd *parser.SelectCommand) {
-	rows, err := r.db.Select(cmd.TableName, cmd.Columns, cmd.Condition)
+// explain prints the query plan of a SELECT or JOIN as a tree without running it
+func (r *REPL) explain(input string) {
+	if input == "" {
+		PrintError(fmt.Errorf("usage: .explain SELECT ..."))
+		return
+	}
+
+	p := parser.NewParser(input)
+	cmd, err := p.Parse()
 	if err != nil {
-		PrintError(err)
+		PrintStatementError(input, fmt.Errorf("parse error: %w", err))
 		return
 	}
-	PrintRows(rows)
+
+	if err := r.execute(&parser.ExplainCommand{Statement: cmd}); err != nil {
+		PrintError(err)
+	}
 }
 
-// executeUpdate executes an UPDATE command
-func (r *REPL) executeUpdate(cmd *parser.UpdateCommand) {
-	count, err := r.db.Update(cmd.TableName, cmd.Updates, cmd.Condition)
+// explainSchema shows how the columns of a SELECT resolve to tables
+func (r *REPL) explainSchema(input string) {
+	if input == "" {
+		PrintError(fmt.Errorf("usage: .explain-schema SELECT ..."))
+		return
+	}
+
+	p := parser.NewParser(input)
+	cmd, err := p.Parse()
 	if err != nil {
-		PrintError(err)
+		PrintStatementError(input, fmt.Errorf("parse error: %w", err))
 		return
 	}
-	PrintSuccess(fmt.Sprintf("%d row(s) updated", count))
-}
 
-// executeDelete executes a DELETE command
-func (r *REPL) executeDelete(cmd *parser.DeleteCommand) {
-	count, err := r.db.Delete(cmd.TableName, cmd.Condition)
+	report, err := ExplainSchema(r.db, cmd)
 	if err != nil {
 		PrintError(err)
 		return
 	}
-	PrintSuccess(fmt.Sprintf("%d row(s) deleted", count))
+	PrintResolution(report)
 }
 
-// executeJoin executes a JOIN command
-func (r *REPL) executeJoin(cmd *parser.JoinCommand) {
-	joinCondition := engine.JoinCondition{
-		LeftColumn:  cmd.LeftColumn,
-		RightColumn: cmd.RightColumn,
+// ExplainSchema reports how the columns referenced by a SELECT or JOIN resolve to tables
+// Every column of a compound WHERE is reported, and a function such as
+// UPPER(name) is reported by the column it reads
+func ExplainSchema(db *engine.Database, cmd parser.Command) (*engine.ResolutionReport, error) {
+	var tables, columns, references []string
+	switch c := cmd.(type) {
+	case *parser.SelectCommand:
+		if len(c.Functions) > 0 {
+			return explainFunctions(db, c)
+		}
+		tables = []string{c.TableName}
+		columns = c.Columns
+		references = append(references, c.Condition.Columns()...)
+		for _, order := range c.OrderBy {
+			references = append(references, order.Column)
+		}
+		references = append(references, c.DistinctOn...)
+		references = append(references, c.GroupBy...)
+		for _, agg := range c.Aggregates {
+			if agg.Column != "*" {
+				references = append(references, agg.Column)
+			}
+		}
+	case *parser.JoinCommand:
+		tables = c.Tables()
+		columns = c.SelectColumns
+		for _, step := range c.Joins {
+			references = append(references, step.LeftTable+"."+step.LeftColumn, step.Table+"."+step.RightColumn)
+		}
+		references = append(references, c.Condition.Columns()...)
+	default:
+		return nil, fmt.Errorf(".explain-schema only supports SELECT statements")
 	}
 
-	rows, err := r.db.InnerJoin(cmd.LeftTable, cmd.RightTable, joinCondition, cmd.SelectColumns)
+	return db.ResolveColumns(tables, columns, references)
+}
+
+// explainFunctions resolves a SELECT with function columns
+// Each function resolves its source column, and is output under its own name
+func explainFunctions(db *engine.Database, c *parser.SelectCommand) (*engine.ResolutionReport, error) {
+	plain := *c
+	plain.Functions = nil
+	plain.Columns = make([]string, len(c.Columns))
+	for i, name := range c.Columns {
+		plain.Columns[i] = name
+		if f, ok := c.Functions[name]; ok {
+			plain.Columns[i] = f.Column
+		}
+	}
+
+	report, err := ExplainSchema(db, &plain)
 	if err != nil {
-		PrintError(err)
-		return
+		return nil, err
+	}
+
+	resolved := make(map[string]engine.ColumnResolution, len(report.Columns))
+	for _, res := range report.Columns {
+		resolved[res.Reference] = res
+	}
+	report.Output = report.Output[:0]
+	for i, name := range c.Columns {
+		if _, ok := c.Functions[name]; ok {
+			report.Output = append(report.Output, name)
+		} else if res := resolved[plain.Columns[i]]; res.Resolved() {
+			report.Output = append(report.Output, res.Column)
+		}
 	}
-	PrintRows(rows)
+	return report, nil
 }
@@ -0,0 +1,273 @@
+package engine_test
+
+import (
+	"godb/engine"
+	"testing"
+)
+
+func setupAggregateUsers(t *testing.T) *engine.Database {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "name", Type: engine.TypeString},
+		{Name: "age", Type: engine.TypeInt},
+	}
+	if err := db.CreateTable("users", schema); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	rows := []engine.Row{
+		{"id": 1, "name": "moses", "age": 17},
+		{"id": 2, "name": "Bob", "age": 30},
+		{"id": 3, "name": "Charlie", "age": 40},
+		{"id": 4, "name": "Dana"}, // age is NULL
+	}
+	for _, row := range rows {
+		if err := db.Insert("users", row); err != nil {
+			t.Fatalf("Insert failed: %v", err)
+		}
+	}
+
+	return db
+}
+
+func TestAggregateCount(t *testing.T) {
+	db := setupAggregateUsers(t)
+
+	results, err := db.Query(engine.Query{
+		Table: "users",
+		Aggregates: []engine.Aggregate{
+			{Func: "COUNT", Column: "*"},
+			{Func: "COUNT", Column: "age"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 row, got %d", len(results))
+	}
+
+	if results[0]["count"] != 4 {
+		t.Errorf("Expected count 4, got %v", results[0]["count"])
+	}
+
+	// COUNT(col) skips NULLs
+	if results[0]["count_age"] != 3 {
+		t.Errorf("Expected count_age 3, got %v", results[0]["count_age"])
+	}
+}
+
+func TestAggregateWithCondition(t *testing.T) {
+	db := setupAggregateUsers(t)
+
+	condition := &engine.Condition{Column: "age", Operator: ">", Value: 18}
+	results, err := db.Query(engine.Query{
+		Table:     "users",
+		Condition: condition,
+		Aggregates: []engine.Aggregate{
+			{Func: "SUM", Column: "age"},
+			{Func: "AVG", Column: "age"},
+			{Func: "MIN", Column: "age"},
+			{Func: "MAX", Column: "age"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	row := results[0]
+	if row["sum_age"] != 70 {
+		t.Errorf("Expected sum_age 70, got %v", row["sum_age"])
+	}
+	if row["avg_age"] != 35.0 {
+		t.Errorf("Expected avg_age 35, got %v", row["avg_age"])
+	}
+	if row["min_age"] != 30 {
+		t.Errorf("Expected min_age 30, got %v", row["min_age"])
+	}
+	if row["max_age"] != 40 {
+		t.Errorf("Expected max_age 40, got %v", row["max_age"])
+	}
+}
+
+func TestAggregateNonNumericColumn(t *testing.T) {
+	db := setupAggregateUsers(t)
+
+	_, err := db.Query(engine.Query{
+		Table:      "users",
+		Aggregates: []engine.Aggregate{{Func: "SUM", Column: "name"}},
+	})
+	if err == nil {
+		t.Fatal("Expected error summing a STRING column, got nil")
+	}
+
+	if _, ok := err.(engine.ErrInvalidAggregate); !ok {
+		t.Errorf("Expected ErrInvalidAggregate, got %T", err)
+	}
+}
+
+func TestAggregateCountDistinct(t *testing.T) {
+	db := setupGroupedOrders(t)
+	db.Insert("orders", engine.Row{"id": 6, "region": "west", "amount": 10})
+
+	results, err := db.Query(engine.Query{
+		Table: "orders",
+		Aggregates: []engine.Aggregate{
+			{Func: "COUNT", Column: "region"},
+			{Func: "COUNT", Column: "region", Distinct: true},
+			{Func: "COUNT", Column: "amount", Distinct: true, Alias: "amounts"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	// east, west, east, west: NULLs are skipped and repeats counted once
+	if results[0]["count_region"] != 4 || results[0]["count_distinct_region"] != 2 {
+		t.Errorf("Expected 4 regions of which 2 distinct, got %v", results[0])
+	}
+	if results[0]["amounts"] != 5 {
+		t.Errorf("Expected 5 distinct amounts, got %v", results[0]["amounts"])
+	}
+
+	// Counted per group after filtering
+	rows, err := db.Query(engine.Query{
+		Table:      "orders",
+		Condition:  &engine.Condition{Column: "amount", Operator: "<", Value: 25},
+		GroupBy:    []string{"region"},
+		Aggregates: []engine.Aggregate{{Func: "COUNT", Column: "amount", Distinct: true}},
+	})
+	if err != nil {
+		t.Fatalf("Grouped query failed: %v", err)
+	}
+	for _, row := range rows {
+		if row["region"] == "west" && row["count_distinct_amount"] != 2 {
+			t.Errorf("Expected 2 distinct west amounts, got %v", row)
+		}
+		if row["region"] == "east" && row["count_distinct_amount"] != 1 {
+			t.Errorf("Expected 1 east amount under 25, got %v", row)
+		}
+	}
+
+	for _, agg := range []engine.Aggregate{
+		{Func: "SUM", Column: "amount", Distinct: true},
+		{Func: "COUNT", Column: "*", Distinct: true},
+	} {
+		_, err := db.Query(engine.Query{Table: "orders", Aggregates: []engine.Aggregate{agg}})
+		if _, ok := err.(engine.ErrInvalidAggregate); !ok {
+			t.Errorf("%s: expected ErrInvalidAggregate, got %v", agg, err)
+		}
+	}
+}
+
+func setupGroupedOrders(t *testing.T) *engine.Database {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "region", Type: engine.TypeString},
+		{Name: "amount", Type: engine.TypeInt},
+	}
+	if err := db.CreateTable("orders", schema); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	rows := []engine.Row{
+		{"id": 1, "region": "east", "amount": 10},
+		{"id": 2, "amount": 5}, // region missing
+		{"id": 3, "region": "west", "amount": 20},
+		{"id": 4, "region": nil, "amount": 7}, // region explicitly NULL
+		{"id": 5, "region": "east", "amount": 30},
+	}
+	for _, row := range rows {
+		if err := db.Insert("orders", row); err != nil {
+			t.Fatalf("Insert failed: %v", err)
+		}
+	}
+	return db
+}
+
+func TestGroupByNullFormsOneGroup(t *testing.T) {
+	db := setupGroupedOrders(t)
+
+	results, err := db.Query(engine.Query{
+		Table:      "orders",
+		Columns:    []string{"region"},
+		GroupBy:    []string{"region"},
+		Aggregates: []engine.Aggregate{{Func: "COUNT", Column: "*"}, {Func: "SUM", Column: "amount"}},
+	})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	// Groups come back in order of their first row; missing and explicit NULL share a group
+	want := []struct {
+		region interface{}
+		count  int
+		sum    int
+	}{
+		{"east", 2, 40},
+		{nil, 2, 12},
+		{"west", 1, 20},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("Expected %d groups, got %d: %v", len(want), len(results), results)
+	}
+	for i, w := range want {
+		if results[i]["region"] != w.region || results[i]["count"] != w.count || results[i]["sum_amount"] != w.sum {
+			t.Errorf("Group %d: expected %v, got %v", i, w, results[i])
+		}
+	}
+}
+
+func TestGroupByOrderAndProjection(t *testing.T) {
+	db := setupGroupedOrders(t)
+
+	// Only aggregates are returned when no group column is selected
+	results, err := db.Query(engine.Query{
+		Table:      "orders",
+		GroupBy:    []string{"region"},
+		Aggregates: []engine.Aggregate{{Func: "MAX", Column: "amount"}},
+		OrderBy:    []engine.OrderBy{{Column: "max_amount", Desc: true}},
+	})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	want := []int{30, 20, 7}
+	if len(results) != len(want) {
+		t.Fatalf("Expected %d groups, got %d", len(want), len(results))
+	}
+	for i, max := range want {
+		if results[i]["max_amount"] != max || len(results[i]) != 1 {
+			t.Errorf("Row %d: expected only max_amount %d, got %v", i, max, results[i])
+		}
+	}
+
+	// NULL group sorts last in ascending order like any other NULL
+	results, _ = db.Query(engine.Query{
+		Table:   "orders",
+		GroupBy: []string{"region"},
+		OrderBy: []engine.OrderBy{{Column: "region"}},
+	})
+	if len(results) != 3 || results[0]["region"] != "east" || results[2]["region"] != nil {
+		t.Errorf("Unexpected group order: %v", results)
+	}
+}
+
+func TestGroupByRejectsUngroupedColumns(t *testing.T) {
+	db := setupGroupedOrders(t)
+
+	queries := []engine.Query{
+		{Table: "orders", Columns: []string{"amount"}, GroupBy: []string{"region"}},
+		{Table: "orders", GroupBy: []string{"region"}, OrderBy: []engine.OrderBy{{Column: "amount"}}},
+		{Table: "orders", GroupBy: []string{"missing"}},
+	}
+	for _, q := range queries {
+		if _, err := db.Query(q); err == nil {
+			t.Errorf("Expected error for %+v", q)
+		}
+	}
+}
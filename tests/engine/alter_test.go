@@ -0,0 +1,305 @@
+package engine_test
+
+import (
+	"errors"
+	"godb/engine"
+	"testing"
+)
+
+func setupAlterUsers(t *testing.T) *engine.Database {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "name", Type: engine.TypeString},
+	}
+	if err := db.CreateTable("users", schema); err != nil {
+		t.Fatalf("CreateTable failed: %v", err)
+	}
+	db.Insert("users", engine.Row{"id": 1, "name": "moses"})
+	db.Insert("users", engine.Row{"id": 2, "name": "Bob"})
+	return db
+}
+
+func TestAddColumnBackfillsNull(t *testing.T) {
+	db := setupAlterUsers(t)
+	table, _ := db.GetTable("users")
+	before := table.Schema()
+
+	if err := db.AddColumn("users", engine.Column{Name: "age", Type: engine.TypeInt}); err != nil {
+		t.Fatalf("AddColumn failed: %v", err)
+	}
+
+	schema := table.Schema()
+	if len(schema) != 3 || schema[2].Name != "age" {
+		t.Fatalf("Expected age appended to schema, got %+v", schema)
+	}
+	if len(before) != 2 {
+		t.Errorf("Schema returned before the change was modified: %+v", before)
+	}
+
+	results, _ := db.Select("users", nil, &engine.Condition{Column: "age", Operator: "IS NULL"})
+	if len(results) != 2 {
+		t.Fatalf("Expected both rows to have NULL age, got %d", len(results))
+	}
+	if value, ok := results[0]["age"]; !ok || value != nil {
+		t.Errorf("Expected age backfilled with NULL, got %v (present=%v)", value, ok)
+	}
+
+	// New rows can use the column right away
+	if err := db.Insert("users", engine.Row{"id": 3, "name": "Carol", "age": 30}); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	results, _ = db.Select("users", nil, &engine.Condition{Column: "age", Operator: ">", Value: 18})
+	if len(results) != 1 || results[0]["id"] != 3 {
+		t.Errorf("Expected only Carol to be older than 18, got %v", results)
+	}
+}
+
+func TestAddUniqueColumnIsIndexed(t *testing.T) {
+	db := setupAlterUsers(t)
+
+	if err := db.AddColumn("users", engine.Column{Name: "email", Type: engine.TypeString, Unique: true}); err != nil {
+		t.Fatalf("AddColumn failed: %v", err)
+	}
+
+	table, _ := db.GetTable("users")
+	if _, ok := table.GetIndex("email"); !ok {
+		t.Fatal("Expected an index on the new UNIQUE column")
+	}
+
+	db.Update("users", engine.Row{"email": "m@example.com"}, &engine.Condition{Column: "id", Operator: "=", Value: 1})
+	_, err := db.Update("users", engine.Row{"email": "m@example.com"}, &engine.Condition{Column: "id", Operator: "=", Value: 2})
+	if _, ok := err.(engine.ErrUniqueViolation); !ok {
+		t.Errorf("Expected ErrUniqueViolation, got %v", err)
+	}
+}
+
+func TestAddColumnRejected(t *testing.T) {
+	db := setupAlterUsers(t)
+	db.CreateTable("logs", []engine.Column{{Name: "message", Type: engine.TypeString}})
+
+	tests := []struct {
+		name  string
+		table string
+		col   engine.Column
+	}{
+		{"duplicate", "users", engine.Column{Name: "name", Type: engine.TypeString}},
+		{"not null on populated table", "users", engine.Column{Name: "age", Type: engine.TypeInt, NotNull: true}},
+		{"second primary key", "users", engine.Column{Name: "uid", Type: engine.TypeInt, PrimaryKey: true}},
+		{"autoincrement without primary key", "logs", engine.Column{Name: "seq", Type: engine.TypeInt, AutoIncrement: true}},
+		{"missing parent", "users", engine.Column{Name: "team_id", Type: engine.TypeInt,
+			References: &engine.ForeignKey{Table: "teams", Column: "id"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, _ := db.GetTable(tt.table)
+			before := len(table.Schema())
+			if err := db.AddColumn(tt.table, tt.col); err == nil {
+				t.Fatal("Expected AddColumn to fail")
+			}
+			if len(table.Schema()) != before {
+				t.Errorf("Schema changed after a rejected AddColumn")
+			}
+		})
+	}
+
+	// A primary key cannot be added once the table has rows
+	db.Insert("logs", engine.Row{"message": "hello"})
+	err := db.AddColumn("logs", engine.Column{Name: "id", Type: engine.TypeInt, PrimaryKey: true})
+	if _, ok := err.(engine.ErrInvalidColumnDefinition); !ok {
+		t.Errorf("Expected ErrInvalidColumnDefinition, got %v", err)
+	}
+
+	if err := db.AddColumn("missing", engine.Column{Name: "x", Type: engine.TypeInt}); err == nil {
+		t.Error("Expected error for missing table")
+	}
+}
+
+func TestAddPrimaryKeyToEmptyTable(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("events", []engine.Column{{Name: "kind", Type: engine.TypeString}})
+
+	col := engine.Column{Name: "id", Type: engine.TypeInt, PrimaryKey: true, NotNull: true, AutoIncrement: true}
+	if err := db.AddColumn("events", col); err != nil {
+		t.Fatalf("AddColumn failed: %v", err)
+	}
+
+	table, _ := db.GetTable("events")
+	if table.PrimaryKey() != "id" || table.AutoIncrementColumn() != "id" {
+		t.Fatalf("Expected id to become the auto-increment primary key")
+	}
+
+	key, err := db.InsertWithKey("events", engine.Row{"kind": "login"})
+	if err != nil || key != 1 {
+		t.Fatalf("Expected generated key 1, got %v (%v)", key, err)
+	}
+	err = db.Insert("events", engine.Row{"id": 1, "kind": "logout"})
+	if _, ok := err.(engine.ErrPrimaryKeyViolation); !ok {
+		t.Errorf("Expected ErrPrimaryKeyViolation, got %v", err)
+	}
+}
+
+func TestRenameTable(t *testing.T) {
+	db := setupAlterUsers(t)
+	db.CreateTable("posts", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "user_id", Type: engine.TypeInt, References: &engine.ForeignKey{Table: "users", Column: "id"}},
+	})
+	db.Insert("posts", engine.Row{"id": 1, "user_id": 2})
+	postsSchema := func() []engine.Column {
+		posts, _ := db.GetTable("posts")
+		return posts.Schema()
+	}
+	before := postsSchema()
+
+	if err := db.RenameTable("users", "members"); err != nil {
+		t.Fatalf("RenameTable failed: %v", err)
+	}
+	if db.TableExists("users") || !db.TableExists("members") {
+		t.Fatalf("Expected only members to exist, got %v", db.ListTables())
+	}
+
+	// Rows and indexes move with the table
+	rows, err := db.Select("members", nil, &engine.Condition{Column: "id", Operator: "=", Value: 2})
+	if err != nil || len(rows) != 1 || rows[0]["name"] != "Bob" {
+		t.Errorf("Expected Bob under members, got %v, %v", rows, err)
+	}
+	members, _ := db.GetTable("members")
+	if members.Name() != "members" {
+		t.Errorf("Expected the table to be named members, got %s", members.Name())
+	}
+	if _, ok := members.GetIndex("id"); !ok {
+		t.Error("Expected the primary key index to be kept")
+	}
+
+	// Foreign keys follow the rename and are still enforced
+	if fk := postsSchema()[1].References; fk.Table != "members" {
+		t.Errorf("Expected posts.user_id to reference members, got %s", fk.Table)
+	}
+	if before[1].References.Table != "users" {
+		t.Errorf("Schema returned before the rename was modified: %+v", before[1].References)
+	}
+	if err := db.Insert("posts", engine.Row{"id": 2, "user_id": 9}); !errors.As(err, &engine.ErrForeignKeyViolation{}) {
+		t.Errorf("Expected ErrForeignKeyViolation, got %v", err)
+	}
+	if _, err := db.Delete("members", &engine.Condition{Column: "id", Operator: "=", Value: 2}); !errors.As(err, &engine.ErrForeignKeyViolation{}) {
+		t.Errorf("Expected the referenced row to be protected, got %v", err)
+	}
+}
+
+func TestRenameTableErrors(t *testing.T) {
+	db := setupAlterUsers(t)
+	db.CreateTable("posts", []engine.Column{{Name: "id", Type: engine.TypeInt, PrimaryKey: true}})
+
+	if err := db.RenameTable("missing", "other"); !errors.As(err, &engine.ErrTableNotFound{}) {
+		t.Errorf("Expected ErrTableNotFound, got %v", err)
+	}
+	if err := db.RenameTable("users", "posts"); !errors.As(err, &engine.ErrTableAlreadyExists{}) {
+		t.Errorf("Expected ErrTableAlreadyExists, got %v", err)
+	}
+	if n, _ := db.RowCount("users"); n != 2 {
+		t.Errorf("Expected users to be untouched, got %d rows", n)
+	}
+}
+
+func TestRenameColumn(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true, AutoIncrement: true},
+		{Name: "email", Type: engine.TypeString, Unique: true, Check: &engine.Condition{Column: "email", Operator: "LIKE", Value: "%@%"}},
+		{Name: "age", Type: engine.TypeInt},
+	})
+	db.CreateTable("posts", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "author", Type: engine.TypeString, References: &engine.ForeignKey{Table: "users", Column: "email"}},
+	})
+	db.Insert("users", engine.Row{"email": "a@example.com", "age": 30})
+	db.Insert("users", engine.Row{"email": "b@example.com", "age": 40})
+	db.Insert("posts", engine.Row{"id": 1, "author": "b@example.com"})
+	users, _ := db.GetTable("users")
+	users.CreateOrderedIndex("age")
+	before, _ := db.Select("users", nil, nil)
+	emailIndex, _ := users.GetIndex("email")
+
+	if err := db.RenameColumn("users", "email", "contact"); err != nil {
+		t.Fatalf("RenameColumn failed: %v", err)
+	}
+
+	// Selects return the new name, served by the re-keyed index
+	rows, err := db.Select("users", []string{"id", "contact"}, &engine.Condition{Column: "contact", Operator: "=", Value: "b@example.com"})
+	if err != nil || len(rows) != 1 || rows[0]["id"] != 2 {
+		t.Fatalf("Expected user 2 by contact, got %v, %v", rows, err)
+	}
+	if idx, ok := users.GetIndex("contact"); !ok || idx != emailIndex {
+		t.Error("Expected the unique index to move to contact")
+	}
+	// Handles taken before the rename stay valid
+	if positions := emailIndex.Lookup("b@example.com"); len(positions) != 1 {
+		t.Errorf("Expected the existing index handle to find user 2, got %v", positions)
+	}
+	if _, ok := rows[0]["email"]; ok {
+		t.Errorf("Expected no email key in the row, got %v", rows[0])
+	}
+	if rows, _ := db.Select("users", nil, &engine.Condition{Column: "email", Operator: "=", Value: "b@example.com"}); len(rows) != 0 {
+		t.Errorf("Expected the old name to match nothing, got %v", rows)
+	}
+	if _, ok := before[0]["email"]; !ok {
+		t.Errorf("Rows returned before the rename were modified: %v", before[0])
+	}
+
+	// Constraints follow the column
+	if err := db.Insert("users", engine.Row{"contact": "a@example.com"}); !errors.As(err, &engine.ErrUniqueViolation{}) {
+		t.Errorf("Expected ErrUniqueViolation on contact, got %v", err)
+	}
+	if err := db.Insert("users", engine.Row{"contact": "nobody"}); !errors.As(err, &engine.ErrCheckViolation{}) {
+		t.Errorf("Expected the CHECK to follow the rename, got %v", err)
+	}
+	posts, _ := db.GetTable("posts")
+	if fk := posts.Schema()[1].References; fk.Column != "contact" {
+		t.Errorf("Expected posts.author to reference users.contact, got %s", fk.Column)
+	}
+	if err := db.Insert("posts", engine.Row{"id": 2, "author": "a@example.com"}); err != nil {
+		t.Errorf("Expected a post by an existing contact to be accepted, got %v", err)
+	}
+
+	// Renaming the primary key keeps key lookups and generated ids working
+	if err := db.RenameColumn("users", "id", "user_id"); err != nil {
+		t.Fatalf("RenameColumn of the primary key failed: %v", err)
+	}
+	if users.PrimaryKey() != "user_id" {
+		t.Errorf("Expected primary key user_id, got %s", users.PrimaryKey())
+	}
+	if err := db.Insert("users", engine.Row{"contact": "c@example.com"}); err != nil {
+		t.Fatalf("Insert after renaming the key failed: %v", err)
+	}
+	id := users.NextAutoIncrement() - 1
+	rows, _ = db.Select("users", nil, &engine.Condition{Column: "user_id", Operator: "=", Value: id})
+	if len(rows) != 1 || rows[0]["contact"] != "c@example.com" {
+		t.Errorf("Expected the new row under the generated user_id %d, got %v", id, rows)
+	}
+
+	// The ordered index is untouched by renaming other columns
+	rows, _ = db.Select("users", nil, &engine.Condition{Column: "age", Operator: ">", Value: 35})
+	if len(rows) != 1 || rows[0]["user_id"] != 2 {
+		t.Errorf("Expected user 2 older than 35, got %v", rows)
+	}
+}
+
+func TestRenameColumnErrors(t *testing.T) {
+	db := setupAlterUsers(t)
+
+	if err := db.RenameColumn("users", "name", "id"); !errors.As(err, &engine.ErrInvalidColumnDefinition{}) {
+		t.Errorf("Expected ErrInvalidColumnDefinition for an existing name, got %v", err)
+	}
+	if err := db.RenameColumn("users", "missing", "other"); !errors.As(err, &engine.ErrColumnNotFound{}) {
+		t.Errorf("Expected ErrColumnNotFound, got %v", err)
+	}
+	if err := db.RenameColumn("missing", "name", "other"); !errors.As(err, &engine.ErrTableNotFound{}) {
+		t.Errorf("Expected ErrTableNotFound, got %v", err)
+	}
+	if rows, _ := db.Select("users", []string{"name"}, nil); len(rows) != 2 || rows[0]["name"] != "moses" {
+		t.Errorf("Expected users to be untouched, got %v", rows)
+	}
+}
@@ -0,0 +1,132 @@
+package engine_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"godb/engine"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true, AutoIncrement: true},
+		{Name: "email", Type: engine.TypeString, Unique: true},
+		{Name: "age", Type: engine.TypeInt, Check: &engine.Condition{Column: "age", Operator: ">=", Value: 0}},
+		{Name: "active", Type: engine.TypeBool},
+	})
+	db.CreateTable("posts", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "user_id", Type: engine.TypeInt, References: &engine.ForeignKey{Table: "users", Column: "id", OnDeleteCascade: true}},
+	})
+	db.Insert("users", engine.Row{"email": "ada@example.com", "age": 36, "active": true})
+	db.Insert("users", engine.Row{"email": "bob@example.com", "age": nil, "active": false})
+	db.Insert("users", engine.Row{"email": "cy@example.com", "age": 52, "active": true})
+	db.Delete("users", &engine.Condition{Column: "id", Operator: "=", Value: 3}) // id 3 is never reused
+	db.Insert("posts", engine.Row{"id": 1, "user_id": 1})
+	db.Insert("posts", engine.Row{"id": 2, "user_id": 2})
+
+	users, _ := db.GetTable("users")
+	users.CreateIndex("active")
+	users.CreateOrderedIndex("age")
+
+	var buf bytes.Buffer
+	if err := db.SaveArchive(&buf); err != nil {
+		t.Fatalf("SaveArchive failed: %v", err)
+	}
+	restored, err := engine.LoadArchive(&buf)
+	if err != nil {
+		t.Fatalf("LoadArchive failed: %v", err)
+	}
+
+	for _, name := range []string{"users", "posts"} {
+		want, _ := db.Select(name, nil, nil)
+		got, err := restored.Select(name, nil, nil)
+		if err != nil {
+			t.Fatalf("Select from restored %s failed: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+
+	if restored.SchemaSQL() != db.SchemaSQL() {
+		t.Errorf("Expected schema\n%s\ngot\n%s", db.SchemaSQL(), restored.SchemaSQL())
+	}
+
+	query := engine.Query{Table: "users", Condition: &engine.Condition{Column: "age", Operator: ">", Value: 40}}
+	plan, _ := restored.Explain(query)
+	if op := plan.AccessPath().Op; op != "RangeScan" {
+		t.Errorf("Expected the ordered index to survive the round trip, got %s", op)
+	}
+
+	row, err := restored.InsertReturning("users", engine.Row{"email": "dee@example.com"})
+	if err != nil {
+		t.Fatalf("Insert into restored table failed: %v", err)
+	}
+	if id, _ := row.Get("id"); id != 4 {
+		t.Errorf("Expected auto-increment to continue at 4, got %v", id)
+	}
+	if err := restored.Insert("users", engine.Row{"email": "ada@example.com"}); err == nil {
+		t.Error("Expected UNIQUE to be enforced after restore")
+	}
+	if err := restored.Insert("users", engine.Row{"email": "eve@example.com", "age": -1}); err == nil {
+		t.Error("Expected CHECK to be enforced after restore")
+	}
+
+	restored.Delete("users", &engine.Condition{Column: "id", Operator: "=", Value: 1})
+	posts, _ := restored.Select("posts", nil, nil)
+	if len(posts) != 1 {
+		t.Errorf("Expected ON DELETE CASCADE to be kept, got %d posts", len(posts))
+	}
+}
+
+func TestLoadArchiveRejectsNewerVersion(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(`{"version": 99, "tables": []}`))
+	zw.Close()
+
+	_, err := engine.LoadArchive(&buf)
+	var unsupported engine.ErrUnsupportedArchive
+	if !errors.As(err, &unsupported) || unsupported.Version != 99 {
+		t.Errorf("Expected ErrUnsupportedArchive for version 99, got %v", err)
+	}
+}
+
+func TestSaveToFile(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("users", []engine.Column{{Name: "id", Type: engine.TypeInt, PrimaryKey: true}})
+	db.Insert("users", engine.Row{"id": 1})
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "godb.archive")
+	if err := db.SaveToFile(path); err != nil {
+		t.Fatalf("SaveToFile failed: %v", err)
+	}
+
+	// Saving again replaces the file and leaves no temporary files behind
+	db.Insert("users", engine.Row{"id": 2})
+	if err := db.SaveToFile(path); err != nil {
+		t.Fatalf("Second SaveToFile failed: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Expected only the archive in the directory, got %d entries", len(entries))
+	}
+
+	loaded, err := engine.LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadFromFile failed: %v", err)
+	}
+	if n, _ := loaded.RowCount("users"); n != 2 {
+		t.Errorf("Expected 2 rows, got %d", n)
+	}
+
+	if _, err := engine.LoadFromFile(filepath.Join(dir, "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected os.ErrNotExist, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package engine_test
+
+import (
+	"bytes"
+	"godb/engine"
+	"testing"
+)
+
+func TestRowEqual(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  engine.Row
+		equal bool
+	}{
+		{"same values", engine.Row{"id": 1, "name": "ada"}, engine.Row{"name": "ada", "id": 1}, true},
+		{"int and whole float", engine.Row{"id": 10}, engine.Row{"id": 10.0}, true},
+		{"both nil", engine.Row{"id": 1, "name": nil}, engine.Row{"id": 1, "name": nil}, true},
+		{"empty rows", engine.Row{}, engine.Row{}, true},
+		{"different value", engine.Row{"id": 1, "name": "ada"}, engine.Row{"id": 1, "name": "bob"}, false},
+		{"different type", engine.Row{"id": 1}, engine.Row{"id": "1"}, false},
+		{"nil and value", engine.Row{"name": nil}, engine.Row{"name": "ada"}, false},
+		{"nil and missing", engine.Row{"id": 1, "name": nil}, engine.Row{"id": 1}, false},
+		{"different keys", engine.Row{"id": 1, "name": "ada"}, engine.Row{"id": 1, "email": "ada"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Equal(tt.b); got != tt.equal {
+				t.Errorf("Expected %v.Equal(%v) to be %v", tt.a, tt.b, tt.equal)
+			}
+			if got := tt.b.Equal(tt.a); got != tt.equal {
+				t.Errorf("Expected %v.Equal(%v) to be %v", tt.b, tt.a, tt.equal)
+			}
+		})
+	}
+}
+
+func TestTablesEqual(t *testing.T) {
+	db := setupReturningUsers(t)
+
+	var buf bytes.Buffer
+	if err := db.SaveArchive(&buf); err != nil {
+		t.Fatalf("SaveArchive failed: %v", err)
+	}
+	copied, err := engine.LoadArchive(&buf)
+	if err != nil {
+		t.Fatalf("LoadArchive failed: %v", err)
+	}
+	if !db.TablesEqual(copied) {
+		t.Fatal("Expected a loaded archive to equal its database")
+	}
+
+	// Row order does not matter
+	users, _ := copied.GetTable("users")
+	copied.Delete("users", &engine.Condition{Column: "id", Operator: "=", Value: 1})
+	copied.Insert("users", engine.Row{"id": 1, "email": "ada@example.com", "age": 36})
+	original, _ := db.GetTable("users")
+	if !original.Equal(users) {
+		t.Error("Expected the same rows in a different order to be equal")
+	}
+
+	copied.Update("users", engine.Row{"age": 37}, &engine.Condition{Column: "id", Operator: "=", Value: 1})
+	if original.Equal(users) || db.TablesEqual(copied) {
+		t.Error("Expected a changed value to make the tables differ")
+	}
+
+	other := setupReturningUsers(t)
+	other.CreateTable("tags", []engine.Column{{Name: "id", Type: engine.TypeInt}})
+	if db.TablesEqual(other) || other.TablesEqual(db) {
+		t.Error("Expected an extra table to make the databases differ")
+	}
+}
@@ -0,0 +1,226 @@
+package engine_test
+
+import (
+	"fmt"
+	"godb/engine"
+	"sync"
+	"testing"
+)
+
+// Run with -race to detect unsynchronized access to rows and indexes
+func TestConcurrentInsertsAndSelects(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true, AutoIncrement: true},
+		{Name: "email", Type: engine.TypeString, Unique: true},
+		{Name: "status", Type: engine.TypeString},
+	}
+	if err := db.CreateTable("users", schema); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	const writers = 8
+	const perWriter = 100
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*perWriter)
+
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				row := engine.Row{"email": fmt.Sprintf("user%d-%d@example.com", w, i), "status": "active"}
+				if err := db.Insert("users", row); err != nil {
+					errs <- err
+				}
+			}
+		}(w)
+	}
+
+	for r := 0; r < writers; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				cond := &engine.Condition{Column: "status", Operator: "=", Value: "active"}
+				if _, err := db.Select("users", nil, cond); err != nil {
+					errs <- err
+				}
+				if _, err := db.Select("users", nil, &engine.Condition{Column: "id", Operator: "=", Value: i}); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("Concurrent operation failed: %v", err)
+	}
+
+	rows, err := db.Select("users", nil, nil)
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	if len(rows) != writers*perWriter {
+		t.Errorf("Expected %d rows, got %d", writers*perWriter, len(rows))
+	}
+
+	// Every generated key is unique and reachable through the primary key index
+	for id := 1; id <= writers*perWriter; id++ {
+		found, _ := db.Select("users", nil, &engine.Condition{Column: "id", Operator: "=", Value: id})
+		if len(found) != 1 {
+			t.Fatalf("Expected exactly one row with id %d, got %d", id, len(found))
+		}
+	}
+}
+
+func TestConcurrentForeignKeyOperations(t *testing.T) {
+	db := engine.NewDatabase()
+
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+	})
+	db.CreateTable("posts", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true, AutoIncrement: true},
+		{Name: "user_id", Type: engine.TypeInt, References: &engine.ForeignKey{
+			Table: "users", Column: "id", OnDeleteCascade: true,
+		}},
+	})
+
+	const n = 200
+	for i := 0; i < n; i++ {
+		db.Insert("users", engine.Row{"id": i})
+	}
+
+	// Child inserts lock posts then read users while parent deletes lock users
+	// then cascade into posts; neither may deadlock
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < n; i++ {
+			db.Insert("posts", engine.Row{"user_id": i})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < n; i += 2 {
+			db.Delete("users", &engine.Condition{Column: "id", Operator: "=", Value: i})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		joinCondition := engine.JoinCondition{LeftColumn: "user_id", RightColumn: "id"}
+		for i := 0; i < n/10; i++ {
+			if _, err := db.InnerJoin("posts", "users", joinCondition, nil); err != nil {
+				t.Errorf("Join failed: %v", err)
+			}
+		}
+	}()
+	wg.Wait()
+
+	// No post may outlive its user
+	posts, _ := db.Select("posts", nil, nil)
+	for _, post := range posts {
+		if post["user_id"].(int)%2 == 0 {
+			t.Errorf("Post %v references deleted user %v", post["id"], post["user_id"])
+		}
+	}
+}
+
+func TestConcurrentDropTableAndInsert(t *testing.T) {
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true, AutoIncrement: true},
+		{Name: "name", Type: engine.TypeString},
+	}
+
+	for round := 0; round < 5; round++ {
+		db := engine.NewDatabase()
+		if err := db.CreateTable("users", schema); err != nil {
+			t.Fatalf("Failed to create table: %v", err)
+		}
+		table, _ := db.GetTable("users")
+
+		const writers = 4
+		var wg, started sync.WaitGroup
+		errs := make(chan error, writers)
+
+		for w := 0; w < writers; w++ {
+			wg.Add(1)
+			started.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; ; i++ {
+					err := db.Insert("users", engine.Row{"name": "Alice"})
+					if i == 10 {
+						started.Done()
+					}
+					if err == nil {
+						continue
+					}
+					if i < 10 {
+						started.Done()
+					}
+					if _, ok := err.(engine.ErrTableNotFound); !ok {
+						errs <- err
+					}
+					return
+				}
+			}()
+		}
+
+		// Drop while every writer is busy inserting
+		started.Wait()
+		if err := db.DropTable("users"); err != nil {
+			t.Fatalf("DropTable failed: %v", err)
+		}
+		// No insert may land in the table once the drop has returned
+		rows := table.Stats().Rows
+
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Errorf("Unexpected insert error: %v", err)
+		}
+		if after := table.Stats().Rows; after != rows {
+			t.Fatalf("Round %d: %d rows were inserted into the dropped table", round, after-rows)
+		}
+	}
+}
+
+func TestConcurrentCreateTableSameName(t *testing.T) {
+	db := engine.NewDatabase()
+	schema := []engine.Column{{Name: "id", Type: engine.TypeInt, PrimaryKey: true}}
+
+	const creators = 8
+	var wg sync.WaitGroup
+	results := make(chan error, creators)
+
+	for i := 0; i < creators; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results <- db.CreateTable("users", schema)
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	created := 0
+	for err := range results {
+		switch err.(type) {
+		case nil:
+			created++
+		case engine.ErrTableAlreadyExists:
+		default:
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+	if created != 1 {
+		t.Errorf("Expected exactly one CreateTable to succeed, got %d", created)
+	}
+}
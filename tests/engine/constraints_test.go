@@ -1,6 +1,7 @@
 package engine_test
 
 import (
+	"errors"
 	"godb/engine"
 	"testing"
 )
@@ -162,3 +163,215 @@ func TestMultipleConstraints(t *testing.T) {
 		t.Errorf("Expected 1 row, got %d", len(rows))
 	}
 }
+
+func TestInsertTypeValidation(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "name", Type: engine.TypeString},
+		{Name: "active", Type: engine.TypeBool},
+	}
+	db.CreateTable("users", schema)
+
+	invalidRows := []engine.Row{
+		{"id": "1", "name": "moses"},
+		{"id": 1, "name": 42},
+		{"id": 1, "active": "true"},
+	}
+	for _, row := range invalidRows {
+		err := db.Insert("users", row)
+		if _, ok := err.(engine.ErrInvalidValue); !ok {
+			t.Errorf("Expected ErrInvalidValue for %v, got %v", row, err)
+		}
+	}
+
+	// nil is fine for a nullable column
+	if err := db.Insert("users", engine.Row{"id": 1, "name": nil, "active": true}); err != nil {
+		t.Errorf("Expected valid insert, got %v", err)
+	}
+}
+
+func TestUpdateTypeValidation(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "age", Type: engine.TypeInt},
+	}
+	db.CreateTable("users", schema)
+	db.Insert("users", engine.Row{"id": 1, "age": 30})
+
+	condition := &engine.Condition{Column: "id", Operator: "=", Value: 1}
+	_, err := db.Update("users", engine.Row{"age": "thirty"}, condition)
+	if _, ok := err.(engine.ErrInvalidValue); !ok {
+		t.Fatalf("Expected ErrInvalidValue, got %v", err)
+	}
+
+	rows, _ := db.Select("users", nil, condition)
+	if rows[0]["age"] != 30 {
+		t.Errorf("Expected age to remain 30, got %v", rows[0]["age"])
+	}
+}
+
+func TestUpdatePrimaryKey(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true, AutoIncrement: true},
+		{Name: "email", Type: engine.TypeString, Unique: true},
+	})
+	db.Insert("users", engine.Row{"email": "a@example.com"})
+	db.Insert("users", engine.Row{"email": "b@example.com"})
+	byID := func(id int) []engine.Row {
+		rows, _ := db.Select("users", nil, &engine.Condition{Column: "id", Operator: "=", Value: id})
+		return rows
+	}
+
+	// Moving a key to an unused value updates the index
+	if _, err := db.Update("users", engine.Row{"id": 5}, &engine.Condition{Column: "id", Operator: "=", Value: 1}); err != nil {
+		t.Fatalf("Update to a new key failed: %v", err)
+	}
+	if rows := byID(5); len(rows) != 1 || rows[0]["email"] != "a@example.com" {
+		t.Errorf("Expected the row under id 5, got %v", rows)
+	}
+	if rows := byID(1); len(rows) != 0 {
+		t.Errorf("Expected id 1 to be gone from the index, got %v", rows)
+	}
+
+	// Moving it onto an existing key fails and changes nothing
+	_, err := db.Update("users", engine.Row{"id": 2}, &engine.Condition{Column: "id", Operator: "=", Value: 5})
+	if !errors.As(err, &engine.ErrPrimaryKeyViolation{}) {
+		t.Fatalf("Expected ErrPrimaryKeyViolation, got %v", err)
+	}
+	if len(byID(5)) != 1 || len(byID(2)) != 1 {
+		t.Errorf("Expected ids 2 and 5 to be unchanged")
+	}
+
+	// Keeping the same key, and moving back to a freed one, are allowed
+	if _, err := db.Update("users", engine.Row{"id": 5, "email": "a2@example.com"}, &engine.Condition{Column: "id", Operator: "=", Value: 5}); err != nil {
+		t.Errorf("Expected an update keeping the key to succeed, got %v", err)
+	}
+	if _, err := db.Update("users", engine.Row{"id": 1}, &engine.Condition{Column: "id", Operator: "=", Value: 5}); err != nil {
+		t.Errorf("Expected moving back to the freed key to succeed, got %v", err)
+	}
+
+	// Generated keys continue past the highest key an update assigned
+	db.Update("users", engine.Row{"id": 7}, &engine.Condition{Column: "id", Operator: "=", Value: 2})
+	if err := db.Insert("users", engine.Row{"email": "c@example.com"}); err != nil {
+		t.Fatalf("Insert after key reassignment failed: %v", err)
+	}
+	if rows := byID(8); len(rows) != 1 || rows[0]["email"] != "c@example.com" {
+		t.Errorf("Expected the new row to get id 8, got %v", rows)
+	}
+}
+
+func TestCoerceBoolValues(t *testing.T) {
+	accepted := map[string]bool{
+		"true": true, "TRUE": true, "1": true, "yes": true, "Yes": true, "on": true, "ON": true,
+		"false": false, "False": false, "0": false, "no": false, "NO": false, "off": false, "Off": false,
+	}
+	for text, want := range accepted {
+		value, err := engine.CoerceValue("active", engine.TypeBool, text)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", text, err)
+			continue
+		}
+		if value != want {
+			t.Errorf("%q: expected %v, got %v", text, want, value)
+		}
+	}
+
+	for _, text := range []string{"maybe", "2", "y", ""} {
+		_, err := engine.CoerceValue("active", engine.TypeBool, text)
+		if _, ok := err.(engine.ErrInvalidValue); !ok {
+			t.Errorf("%q: expected ErrInvalidValue, got %v", text, err)
+		}
+	}
+}
+
+func TestCoerceIntAndStringValues(t *testing.T) {
+	if value, err := engine.CoerceValue("age", engine.TypeInt, "-42"); err != nil || value != -42 {
+		t.Errorf("Expected -42, got %v (%v)", value, err)
+	}
+	if _, err := engine.CoerceValue("age", engine.TypeInt, "forty"); err == nil {
+		t.Error("Expected error for a non-numeric INT")
+	}
+	if value, err := engine.CoerceValue("name", engine.TypeString, "yes"); err != nil || value != "yes" {
+		t.Errorf("Expected STRING values to be kept as text, got %v (%v)", value, err)
+	}
+}
+
+func TestCreateTableRejectsDuplicateColumns(t *testing.T) {
+	db := engine.NewDatabase()
+
+	err := db.CreateTable("t", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "id", Type: engine.TypeString},
+	})
+	var invalid engine.ErrInvalidColumnDefinition
+	if !errors.As(err, &invalid) || invalid.ColumnName != "id" {
+		t.Errorf("Expected ErrInvalidColumnDefinition for duplicate 'id', got %v", err)
+	}
+	if _, err := db.GetTable("t"); err == nil {
+		t.Error("Expected the table not to be created")
+	}
+}
+
+func TestCreateTableRejectsMultiplePrimaryKeys(t *testing.T) {
+	db := engine.NewDatabase()
+
+	err := db.CreateTable("t", []engine.Column{
+		{Name: "a", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "b", Type: engine.TypeInt, PrimaryKey: true},
+	})
+	if !errors.As(err, &engine.ErrMultiplePrimaryKeys{}) {
+		t.Errorf("Expected ErrMultiplePrimaryKeys, got %v", err)
+	}
+	if _, err := db.GetTable("t"); err == nil {
+		t.Error("Expected the table not to be created")
+	}
+}
+
+func TestCheckConstraint(t *testing.T) {
+	db := engine.NewDatabase()
+	err := db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "age", Type: engine.TypeInt, Check: &engine.Condition{Column: "age", Operator: ">=", Value: 0}},
+	})
+	if err != nil {
+		t.Fatalf("CreateTable failed: %v", err)
+	}
+
+	if err := db.Insert("users", engine.Row{"id": 1, "age": 30}); err != nil {
+		t.Errorf("Expected age 30 to pass the check: %v", err)
+	}
+	if err := db.Insert("users", engine.Row{"id": 2, "age": 0}); err != nil {
+		t.Errorf("Expected age 0 to pass the check: %v", err)
+	}
+	// A NULL makes the check unknown, which passes as in SQL
+	if err := db.Insert("users", engine.Row{"id": 3}); err != nil {
+		t.Errorf("Expected NULL age to pass the check: %v", err)
+	}
+
+	err = db.Insert("users", engine.Row{"id": 4, "age": -1})
+	var violation engine.ErrCheckViolation
+	if !errors.As(err, &violation) || violation.Column != "age" || violation.Check != "age >= 0" {
+		t.Errorf("Expected ErrCheckViolation on age, got %v", err)
+	}
+
+	_, err = db.Update("users", engine.Row{"age": -5}, &engine.Condition{Column: "id", Operator: "=", Value: 1})
+	if !errors.As(err, &violation) {
+		t.Errorf("Expected ErrCheckViolation on update, got %v", err)
+	}
+	rows, _ := db.Select("users", nil, &engine.Condition{Column: "id", Operator: "=", Value: 1})
+	if age, _ := rows[0].Get("age"); age != 30 {
+		t.Errorf("Expected failed update to leave age 30, got %v", age)
+	}
+
+	err = db.CreateTable("bad", []engine.Column{
+		{Name: "age", Type: engine.TypeInt, Check: &engine.Condition{Column: "missing", Operator: ">", Value: 0}},
+	})
+	if err == nil {
+		t.Error("Expected error for CHECK on an unknown column")
+	}
+}
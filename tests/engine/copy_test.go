@@ -0,0 +1,53 @@
+package engine_test
+
+import (
+	"errors"
+	"godb/engine"
+	"reflect"
+	"testing"
+)
+
+func TestCopyTable(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("nodes", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true, AutoIncrement: true},
+		{Name: "parent_id", Type: engine.TypeInt, References: &engine.ForeignKey{Table: "nodes", Column: "id"}},
+		{Name: "label", Type: engine.TypeString, Unique: true},
+	})
+	db.Insert("nodes", engine.Row{"label": "root"})
+	db.Insert("nodes", engine.Row{"parent_id": 1, "label": "child"})
+	db.Delete("nodes", &engine.Condition{Column: "id", Operator: "=", Value: 2}) // id 2 is never reused
+
+	if err := db.CopyTable("nodes", "nodes_copy"); err != nil {
+		t.Fatalf("CopyTable failed: %v", err)
+	}
+
+	want, _ := db.Select("nodes", nil, nil)
+	got, _ := db.Select("nodes_copy", nil, nil)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	// The copy keeps its own counter and constraints, and references itself
+	row, err := db.InsertReturning("nodes_copy", engine.Row{"parent_id": 1, "label": "leaf"})
+	if err != nil || row["id"] != 3 {
+		t.Errorf("Expected the copy to continue at id 3, got %v, %v", row, err)
+	}
+	copied, _ := db.GetTable("nodes_copy")
+	if ref := copied.Schema()[1].References; ref == nil || ref.Table != "nodes_copy" {
+		t.Errorf("Expected the self-reference to follow the copy, got %+v", ref)
+	}
+	if err := db.Insert("nodes_copy", engine.Row{"label": "root"}); err == nil {
+		t.Error("Expected UNIQUE to be enforced on the copy")
+	}
+	if n, _ := db.RowCount("nodes"); n != 1 {
+		t.Errorf("Expected the source to keep 1 row, got %d", n)
+	}
+
+	if err := db.CopyTable("nodes", "nodes_copy"); !errors.As(err, &engine.ErrTableAlreadyExists{}) {
+		t.Errorf("Expected ErrTableAlreadyExists, got %v", err)
+	}
+	if err := db.CopyTable("missing", "other"); !errors.As(err, &engine.ErrTableNotFound{}) {
+		t.Errorf("Expected ErrTableNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,261 @@
+package engine_test
+
+import (
+	"bytes"
+	"errors"
+	"godb/engine"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// setupEvents creates an events table with a DATE column and three rows
+// inserted from date strings
+func setupEvents(t *testing.T) *engine.Database {
+	t.Helper()
+	db := engine.NewDatabase()
+	if err := db.CreateTable("events", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "created_at", Type: engine.TypeDate},
+	}); err != nil {
+		t.Fatalf("Failed to create events: %v", err)
+	}
+	for _, row := range []engine.Row{
+		{"id": 1, "created_at": "2024-03-01"},
+		{"id": 2, "created_at": "2023-12-31 23:59:59"},
+		{"id": 3, "created_at": "2024-01-15"},
+	} {
+		if err := db.Insert("events", row); err != nil {
+			t.Fatalf("Failed to insert %v: %v", row, err)
+		}
+	}
+	return db
+}
+
+// date returns a UTC time for the given day
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseDate(t *testing.T) {
+	tests := map[string]time.Time{
+		"2024-01-15":                date(2024, 1, 15),
+		"2024-01-15 10:30:00":       time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+		"2024-01-15T10:30:00+02:00": time.Date(2024, 1, 15, 8, 30, 0, 0, time.UTC),
+	}
+	for text, want := range tests {
+		got, err := engine.ParseDate(text)
+		if err != nil || !got.Equal(want) || got.Location() != time.UTC {
+			t.Errorf("ParseDate(%q): expected %v, got %v, %v", text, want, got, err)
+		}
+	}
+
+	if _, err := engine.ParseDate("15/01/2024"); err == nil {
+		t.Error("Expected an error for an unsupported layout")
+	}
+
+	if s := engine.FormatDate(date(2024, 1, 15)); s != "2024-01-15" {
+		t.Errorf("Expected midnight to format as a bare date, got %s", s)
+	}
+	if s := engine.FormatDate(time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)); s != "2024-01-15 10:30:00" {
+		t.Errorf("Expected the time of day to be kept, got %s", s)
+	}
+}
+
+func TestInsertDate(t *testing.T) {
+	db := setupEvents(t)
+
+	rows, _ := db.Select("events", nil, &engine.Condition{Column: "id", Operator: "=", Value: 3})
+	if got := rows[0]["created_at"]; got != date(2024, 1, 15) {
+		t.Errorf("Expected the string to be stored as a time, got %#v", got)
+	}
+
+	err := db.Insert("events", engine.Row{"id": 4, "created_at": "yesterday"})
+	if _, ok := err.(engine.ErrInvalidValue); !ok {
+		t.Errorf("Expected ErrInvalidValue for an invalid date, got %v", err)
+	}
+	err = db.Insert("events", engine.Row{"id": 4, "created_at": 20240115})
+	if _, ok := err.(engine.ErrInvalidValue); !ok {
+		t.Errorf("Expected ErrInvalidValue for an int, got %v", err)
+	}
+
+	if _, err := db.Update("events", engine.Row{"created_at": "2025-06-01"}, &engine.Condition{Column: "id", Operator: "=", Value: 1}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	rows, _ = db.Select("events", nil, &engine.Condition{Column: "id", Operator: "=", Value: 1})
+	if got := rows[0]["created_at"]; got != date(2025, 6, 1) {
+		t.Errorf("Expected the updated date to be stored as a time, got %#v", got)
+	}
+}
+
+func TestPreviewDateUpdate(t *testing.T) {
+	db := setupEvents(t)
+
+	changes, err := db.PreviewUpdate("events", engine.Row{"created_at": "2024-01-15"}, &engine.Condition{Column: "id", Operator: "<=", Value: 3})
+	if err != nil {
+		t.Fatalf("PreviewUpdate failed: %v", err)
+	}
+	if len(changes) != 3 {
+		t.Fatalf("Expected 3 changes, got %d", len(changes))
+	}
+	for _, change := range changes {
+		if got := change.After["created_at"]; got != date(2024, 1, 15) {
+			t.Errorf("Expected the new value as a time, got %#v", got)
+		}
+		// Row 3 already holds the date, so only the others change
+		changed := len(change.Changed([]string{"id", "created_at"})) > 0
+		if want := change.Before["id"] != 3; changed != want {
+			t.Errorf("Row %v: expected changed=%v, got %v", change.Before["id"], want, changed)
+		}
+	}
+
+	if _, err := db.PreviewUpdate("events", engine.Row{"created_at": "soon"}, nil); !errors.As(err, &engine.ErrInvalidValue{}) {
+		t.Errorf("Expected ErrInvalidValue, got %v", err)
+	}
+}
+
+func TestCompareDates(t *testing.T) {
+	db := setupEvents(t)
+
+	ids := func(condition *engine.Condition) []interface{} {
+		t.Helper()
+		rows, err := db.Query(engine.Query{Table: "events", Condition: condition, OrderBy: []engine.OrderBy{{Column: "id"}}})
+		if err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		var got []interface{}
+		for _, row := range rows {
+			got = append(got, row["id"])
+		}
+		return got
+	}
+
+	tests := []struct {
+		condition *engine.Condition
+		want      []interface{}
+	}{
+		{&engine.Condition{Column: "created_at", Operator: ">", Value: "2024-01-01"}, []interface{}{1, 3}},
+		{&engine.Condition{Column: "created_at", Operator: "<=", Value: "2024-01-15"}, []interface{}{2, 3}},
+		{&engine.Condition{Column: "created_at", Operator: "=", Value: "2024-01-15"}, []interface{}{3}},
+		{&engine.Condition{Column: "created_at", Operator: "<", Value: date(2024, 1, 1)}, []interface{}{2}},
+		{&engine.Condition{Column: "created_at", Operator: "BETWEEN", Value: "2024-01-01", High: "2024-02-01"}, []interface{}{3}},
+	}
+	check := func(label string) {
+		t.Helper()
+		for _, tt := range tests {
+			if got := ids(tt.condition); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s: %s: expected ids %v, got %v", label, tt.condition, tt.want, got)
+			}
+		}
+	}
+	check("full scan")
+
+	// Index lookups find the same rows as a scan
+	events, _ := db.GetTable("events")
+	events.CreateIndex("created_at")
+	events.CreateOrderedIndex("created_at")
+	check("indexed")
+
+	plan, _ := db.Explain(engine.Query{Table: "events", Condition: &engine.Condition{Column: "created_at", Operator: ">", Value: "2024-01-01"}})
+	if op := plan.AccessPath().Op; op != "RangeScan" {
+		t.Errorf("Expected a RangeScan for a date string bound, got %s", op)
+	}
+}
+
+func TestInvalidDateLiteral(t *testing.T) {
+	db := setupEvents(t)
+	invalid := &engine.Condition{Column: "created_at", Operator: "=", Value: "2024-13-45"}
+	isInvalid := func(label string, err error) {
+		t.Helper()
+		if !errors.As(err, &engine.ErrInvalidValue{}) {
+			t.Errorf("%s: expected ErrInvalidValue for an invalid date literal, got %v", label, err)
+		}
+	}
+
+	_, err := db.Select("events", nil, invalid)
+	isInvalid("Select", err)
+	_, err = db.Select("events", nil, engine.Or(&engine.Condition{Column: "id", Operator: "=", Value: 1}, invalid))
+	isInvalid("Select with OR", err)
+	_, err = db.Select("events", nil, &engine.Condition{Column: "created_at", Operator: "BETWEEN", Value: "2024-01-01", High: "soon"})
+	isInvalid("Select with BETWEEN", err)
+	_, err = db.Exists("events", invalid)
+	isInvalid("Exists", err)
+	_, err = db.Update("events", engine.Row{"created_at": "2024-01-01"}, invalid)
+	isInvalid("Update", err)
+	_, err = db.PreviewUpdate("events", engine.Row{"created_at": "2024-01-01"}, invalid)
+	isInvalid("PreviewUpdate", err)
+	_, err = db.Delete("events", invalid)
+	isInvalid("Delete", err)
+
+	if rows, _ := db.Select("events", nil, nil); len(rows) != 3 {
+		t.Errorf("Expected no rows to change, got %d rows", len(rows))
+	}
+
+	// LIKE patterns are not dates, so they are not parsed
+	if _, err := db.Select("events", nil, &engine.Condition{Column: "created_at", Operator: "LIKE", Value: "2024%"}); err != nil {
+		t.Errorf("Expected LIKE on a DATE column to be allowed, got %v", err)
+	}
+}
+
+func TestOrderByDate(t *testing.T) {
+	db := setupEvents(t)
+
+	rows, err := db.Query(engine.Query{Table: "events", OrderBy: []engine.OrderBy{{Column: "created_at", Desc: true}}})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	var got []interface{}
+	for _, row := range rows {
+		got = append(got, row["id"])
+	}
+	if want := []interface{}{1, 3, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected ids %v newest first, got %v", want, got)
+	}
+}
+
+func TestDatePersistence(t *testing.T) {
+	db := setupEvents(t)
+	var log bytes.Buffer
+	db.SetOperationLog(&log)
+	db.Insert("events", engine.Row{"id": 4, "created_at": "2024-02-29 12:00:00"})
+	db.Update("events", engine.Row{"created_at": "2022-01-01"}, &engine.Condition{Column: "id", Operator: "=", Value: 2})
+	db.SetOperationLog(nil)
+
+	var buf bytes.Buffer
+	if err := db.SaveArchive(&buf); err != nil {
+		t.Fatalf("SaveArchive failed: %v", err)
+	}
+	restored, err := engine.LoadArchive(&buf)
+	if err != nil {
+		t.Fatalf("LoadArchive failed: %v", err)
+	}
+	want, _ := db.Select("events", nil, nil)
+	if got, _ := restored.Select("events", nil, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("Archive: expected %v, got %v", want, got)
+	}
+
+	replayed := setupEvents(t)
+	if _, err := replayed.Replay(bytes.NewReader(log.Bytes())); err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if got, _ := replayed.Select("events", nil, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("Replay: expected %v, got %v", want, got)
+	}
+}
+
+func TestBindDateParam(t *testing.T) {
+	db := setupEvents(t)
+	query := engine.Query{Table: "events", Condition: &engine.Condition{Column: "created_at", Operator: ">=", Value: engine.Param{Index: 0}}}
+
+	rows, err := db.ExecutePrepared(query, "2024-01-15")
+	if err != nil || len(rows) != 2 {
+		t.Errorf("Expected 2 rows for a date string argument, got %v, %v", rows, err)
+	}
+	rows, err = db.ExecutePrepared(query, date(2024, 3, 1))
+	if err != nil || len(rows) != 1 {
+		t.Errorf("Expected 1 row for a time argument, got %v, %v", rows, err)
+	}
+	if _, err := db.ExecutePrepared(query, "soon"); err == nil {
+		t.Error("Expected an error for an invalid date argument")
+	}
+}
@@ -0,0 +1,238 @@
+package engine_test
+
+import (
+	"errors"
+	"fmt"
+	"godb/engine"
+	"testing"
+)
+
+func TestDeleteByKeys(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "name", Type: engine.TypeString},
+	}
+	db.CreateTable("users", schema)
+
+	for i := 1; i <= 6; i++ {
+		db.Insert("users", engine.Row{"id": i, "name": "user"})
+	}
+
+	// 42 does not exist and is ignored, and a repeated key deletes one row
+	count, err := db.DeleteByKeys("users", []interface{}{6, 2, 4, 42, 4})
+	if err != nil {
+		t.Fatalf("DeleteByKeys failed: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 rows deleted, got %d", count)
+	}
+
+	// Deleted keys are gone, remaining keys are still found through the index
+	for i := 1; i <= 6; i++ {
+		results, _ := db.Select("users", nil, &engine.Condition{Column: "id", Operator: "=", Value: i})
+		expected := i%2 == 1
+		if (len(results) == 1) != expected {
+			t.Errorf("Key %d: expected present=%v, got %d rows", i, expected, len(results))
+		}
+	}
+
+	results, _ := db.Select("users", nil, nil)
+	if len(results) != 3 {
+		t.Errorf("Expected 3 remaining rows, got %d", len(results))
+	}
+}
+
+func TestDeleteByKeysWithoutPrimaryKey(t *testing.T) {
+	db := engine.NewDatabase()
+
+	db.CreateTable("logs", []engine.Column{{Name: "message", Type: engine.TypeString}})
+
+	_, err := db.DeleteByKeys("logs", []interface{}{1})
+	if _, ok := err.(engine.ErrNoPrimaryKey); !ok {
+		t.Errorf("Expected ErrNoPrimaryKey, got %v", err)
+	}
+}
+
+func TestDeleteMiddleRowKeepsIndexesConsistent(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "email", Type: engine.TypeString, Unique: true},
+		{Name: "team", Type: engine.TypeString},
+	}
+	db.CreateTable("users", schema)
+	table, _ := db.GetTable("users")
+	table.CreateIndex("team")
+
+	emails := []string{"a@x", "b@x", "c@x", "d@x", "e@x"}
+	for i, email := range emails {
+		db.Insert("users", engine.Row{"id": i + 1, "email": email, "team": "red"})
+	}
+
+	if _, err := db.Delete("users", &engine.Condition{Column: "email", Operator: "=", Value: "b@x"}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	// Every remaining row must be found by its unique value, and only that row
+	for i, email := range emails {
+		results, _ := db.Select("users", nil, &engine.Condition{Column: "email", Operator: "=", Value: email})
+		if email == "b@x" {
+			if len(results) != 0 {
+				t.Errorf("Deleted row still returned: %v", results)
+			}
+			continue
+		}
+		if len(results) != 1 || results[0]["id"] != i+1 {
+			t.Errorf("Lookup of %s returned %v, expected id %d", email, results, i+1)
+		}
+	}
+
+	// The non-unique index must not point at stale positions either
+	results, _ := db.Select("users", nil, &engine.Condition{Column: "team", Operator: "=", Value: "red"})
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 rows through the team index, got %d", len(results))
+	}
+
+	// Remaining rows keep their insertion order
+	all, _ := db.Select("users", nil, nil)
+	for i, want := range []int{1, 3, 4, 5} {
+		if all[i]["id"] != want {
+			t.Errorf("Row %d: expected id %d, got %v", i, want, all[i]["id"])
+		}
+	}
+}
+
+func TestVacuumAfterDeletes(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "email", Type: engine.TypeString, Unique: true},
+		{Name: "age", Type: engine.TypeInt},
+	})
+	table, _ := db.GetTable("users")
+	table.CreateOrderedIndex("age")
+
+	for i := 1; i <= 100; i++ {
+		db.Insert("users", engine.Row{"id": i, "email": fmt.Sprintf("u%d@x", i), "age": i})
+	}
+	// Delete every even id
+	for i := 2; i <= 100; i += 2 {
+		db.DeleteByKeys("users", []interface{}{i})
+	}
+
+	freed, err := db.Vacuum("users")
+	if err != nil {
+		t.Fatalf("Vacuum failed: %v", err)
+	}
+	if freed < 50 {
+		t.Errorf("Expected at least 50 row slots freed, got %d", freed)
+	}
+	if again := table.Vacuum(); again != 0 {
+		t.Errorf("Expected a second vacuum to free nothing, got %d", again)
+	}
+
+	all, _ := db.Select("users", nil, nil)
+	if len(all) != 50 {
+		t.Fatalf("Expected 50 rows, got %d", len(all))
+	}
+	for i, row := range all {
+		if row["id"] != 2*i+1 {
+			t.Fatalf("Row %d: expected id %d, got %v", i, 2*i+1, row["id"])
+		}
+	}
+
+	// Primary key and unique lookups
+	for i := 1; i <= 100; i++ {
+		byID, _ := db.Select("users", nil, &engine.Condition{Column: "id", Operator: "=", Value: i})
+		byEmail, _ := db.Select("users", nil, &engine.Condition{Column: "email", Operator: "=", Value: fmt.Sprintf("u%d@x", i)})
+		want := 0
+		if i%2 == 1 {
+			want = 1
+		}
+		if len(byID) != want || len(byEmail) != want {
+			t.Errorf("Key %d: expected %d row(s), got %d by id and %d by email", i, want, len(byID), len(byEmail))
+		}
+	}
+
+	// Ordered index range
+	rows, _ := db.Select("users", nil, &engine.Condition{Column: "age", Operator: ">", Value: 90})
+	if len(rows) != 5 {
+		t.Errorf("Expected 5 rows with age > 90, got %v", rows)
+	}
+
+	// Deleted unique values can be inserted again
+	if err := db.Insert("users", engine.Row{"id": 2, "email": "u2@x", "age": 2}); err != nil {
+		t.Errorf("Insert after vacuum failed: %v", err)
+	}
+}
+
+func TestVacuumMissingTable(t *testing.T) {
+	db := engine.NewDatabase()
+	_, err := db.Vacuum("missing")
+	if _, ok := err.(engine.ErrTableNotFound); !ok {
+		t.Errorf("Expected ErrTableNotFound, got %v", err)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true, AutoIncrement: true},
+		{Name: "email", Type: engine.TypeString, Unique: true},
+		{Name: "age", Type: engine.TypeInt},
+	})
+	table, _ := db.GetTable("users")
+	table.CreateOrderedIndex("age")
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		db.Insert("users", engine.Row{"email": email, "age": 30})
+	}
+
+	if err := db.Truncate("users"); err != nil {
+		t.Fatalf("Truncate failed: %v", err)
+	}
+	if count, _ := db.RowCount("users"); count != 0 {
+		t.Errorf("Expected no rows, got %d", count)
+	}
+	if len(table.Schema()) != 3 {
+		t.Errorf("Expected the schema to be kept, got %v", table.Schema())
+	}
+
+	// Indexes are empty but still maintained, and generated keys restart at 1
+	key, err := db.InsertWithKey("users", engine.Row{"email": "a@example.com", "age": 40})
+	if err != nil {
+		t.Fatalf("Insert after truncate failed: %v", err)
+	}
+	if key != 1 {
+		t.Errorf("Expected auto-increment to restart at 1, got %v", key)
+	}
+	if err := db.Insert("users", engine.Row{"email": "a@example.com"}); err == nil {
+		t.Error("Expected the UNIQUE index to be kept")
+	}
+	rows, _ := db.Select("users", nil, &engine.Condition{Column: "age", Operator: ">", Value: 20})
+	if len(rows) != 1 {
+		t.Errorf("Expected 1 row from the ordered index, got %d", len(rows))
+	}
+
+	if err := db.Truncate("missing"); !errors.As(err, &engine.ErrTableNotFound{}) {
+		t.Errorf("Expected ErrTableNotFound, got %v", err)
+	}
+}
+
+func TestTruncateReferencedTable(t *testing.T) {
+	db := setupForeignKeys(t, true)
+
+	if err := db.Truncate("users"); !errors.As(err, &engine.ErrForeignKeyViolation{}) {
+		t.Errorf("Expected ErrForeignKeyViolation while posts reference users, got %v", err)
+	}
+	if count, _ := db.RowCount("users"); count != 2 {
+		t.Errorf("Expected users to be kept, got %d rows", count)
+	}
+
+	db.Truncate("posts")
+	if err := db.Truncate("users"); err != nil {
+		t.Errorf("Expected truncate to succeed once posts is empty, got %v", err)
+	}
+}
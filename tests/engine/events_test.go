@@ -0,0 +1,68 @@
+package engine_test
+
+import (
+	"godb/engine"
+	"testing"
+)
+
+func TestSchemaChangeEvents(t *testing.T) {
+	db := engine.NewDatabase()
+
+	var events []engine.SchemaEvent
+	var existed []bool
+	db.OnSchemaChange(func(event engine.SchemaEvent) {
+		// Locks are released, so the listener can look at the database
+		events = append(events, event)
+		existed = append(existed, db.TableExists(event.Table))
+	})
+
+	schema := []engine.Column{{Name: "id", Type: engine.TypeInt, PrimaryKey: true}}
+	if err := db.CreateTable("users", schema); err != nil {
+		t.Fatalf("CreateTable failed: %v", err)
+	}
+	if err := db.AddColumn("users", engine.Column{Name: "name", Type: engine.TypeString}); err != nil {
+		t.Fatalf("AddColumn failed: %v", err)
+	}
+	if err := db.RenameColumn("users", "name", "nickname"); err != nil {
+		t.Fatalf("RenameColumn failed: %v", err)
+	}
+	if err := db.RenameTable("users", "members"); err != nil {
+		t.Fatalf("RenameTable failed: %v", err)
+	}
+	if err := db.DropTable("members"); err != nil {
+		t.Fatalf("DropTable failed: %v", err)
+	}
+
+	// Failed changes fire nothing
+	db.DropTable("members")
+	db.RenameTable("members", "users")
+	db.CreateTable("bad", []engine.Column{
+		{Name: "a", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "b", Type: engine.TypeInt, PrimaryKey: true},
+	})
+
+	expected := []engine.SchemaEvent{
+		{Table: "users", Kind: engine.SchemaCreate},
+		{Table: "users", Kind: engine.SchemaAlter, Column: "name"},
+		{Table: "users", Kind: engine.SchemaRename, Column: "nickname", From: "name"},
+		{Table: "members", Kind: engine.SchemaRename, From: "users"},
+		{Table: "members", Kind: engine.SchemaDrop},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, got %+v", len(expected), events)
+	}
+	for i, want := range expected {
+		if events[i] != want {
+			t.Errorf("Event %d: expected %+v, got %+v", i, want, events[i])
+		}
+	}
+
+	// Each event fires after its change is applied
+	if !existed[0] || !existed[1] || !existed[2] || !existed[3] || existed[4] {
+		t.Errorf("Expected the table to exist after create, alter and rename but not after drop, got %v", existed)
+	}
+
+	if kind := engine.SchemaAlter.String(); kind != "ALTER" {
+		t.Errorf("Expected ALTER, got %s", kind)
+	}
+}
@@ -0,0 +1,227 @@
+package engine_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"godb/engine"
+	"reflect"
+	"testing"
+)
+
+func setupForeignKeys(t *testing.T, cascade bool) *engine.Database {
+	db := engine.NewDatabase()
+
+	users := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "name", Type: engine.TypeString},
+	}
+	if err := db.CreateTable("users", users); err != nil {
+		t.Fatalf("Failed to create users: %v", err)
+	}
+
+	posts := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "user_id", Type: engine.TypeInt, References: &engine.ForeignKey{
+			Table: "users", Column: "id", OnDeleteCascade: cascade,
+		}},
+	}
+	if err := db.CreateTable("posts", posts); err != nil {
+		t.Fatalf("Failed to create posts: %v", err)
+	}
+
+	db.Insert("users", engine.Row{"id": 1, "name": "moses"})
+	db.Insert("users", engine.Row{"id": 2, "name": "alice"})
+	if err := db.Insert("posts", engine.Row{"id": 10, "user_id": 1}); err != nil {
+		t.Fatalf("Insert of valid child failed: %v", err)
+	}
+
+	return db
+}
+
+func TestForeignKeyRejectsOrphanInsert(t *testing.T) {
+	db := setupForeignKeys(t, false)
+
+	err := db.Insert("posts", engine.Row{"id": 11, "user_id": 99})
+
+	var fkErr engine.ErrForeignKeyViolation
+	if !errors.As(err, &fkErr) {
+		t.Fatalf("Expected ErrForeignKeyViolation, got %v", err)
+	}
+	if fkErr.Column != "user_id" || fkErr.Value != 99 {
+		t.Errorf("Unexpected violation details: %+v", fkErr)
+	}
+
+	// NULL references nothing and is allowed
+	if err := db.Insert("posts", engine.Row{"id": 12, "user_id": nil}); err != nil {
+		t.Errorf("Expected NULL foreign key to be allowed, got %v", err)
+	}
+}
+
+func TestForeignKeyRejectsOrphanUpdate(t *testing.T) {
+	db := setupForeignKeys(t, false)
+
+	_, err := db.Update("posts", engine.Row{"user_id": 99}, nil)
+	if !errors.As(err, &engine.ErrForeignKeyViolation{}) {
+		t.Fatalf("Expected ErrForeignKeyViolation, got %v", err)
+	}
+
+	if _, err := db.Update("posts", engine.Row{"user_id": 2}, nil); err != nil {
+		t.Errorf("Expected update to existing parent to succeed, got %v", err)
+	}
+}
+
+func TestForeignKeyBlocksParentDelete(t *testing.T) {
+	db := setupForeignKeys(t, false)
+
+	_, err := db.Delete("users", &engine.Condition{Column: "id", Operator: "=", Value: 1})
+	if !errors.As(err, &engine.ErrForeignKeyViolation{}) {
+		t.Fatalf("Expected ErrForeignKeyViolation, got %v", err)
+	}
+
+	rows, _ := db.Select("users", nil, nil)
+	if len(rows) != 2 {
+		t.Errorf("Expected parent rows to be kept, got %d rows", len(rows))
+	}
+
+	// Unreferenced parents can still be deleted
+	count, err := db.Delete("users", &engine.Condition{Column: "id", Operator: "=", Value: 2})
+	if err != nil || count != 1 {
+		t.Errorf("Expected unreferenced parent to be deleted, got count=%d err=%v", count, err)
+	}
+
+	// Referenced keys cannot be changed either
+	_, err = db.Update("users", engine.Row{"id": 5}, &engine.Condition{Column: "id", Operator: "=", Value: 1})
+	if !errors.As(err, &engine.ErrForeignKeyViolation{}) {
+		t.Errorf("Expected ErrForeignKeyViolation on key update, got %v", err)
+	}
+
+	if err := db.DropTable("users"); !errors.As(err, &engine.ErrForeignKeyViolation{}) {
+		t.Errorf("Expected ErrForeignKeyViolation on drop, got %v", err)
+	} else if want := "foreign key violation on 'posts.user_id': table 'users' is still referenced"; err.Error() != want {
+		t.Errorf("Expected %q, got %q", want, err.Error())
+	}
+}
+
+func TestForeignKeyCascadeDelete(t *testing.T) {
+	db := setupForeignKeys(t, true)
+	db.Insert("posts", engine.Row{"id": 11, "user_id": 1})
+	db.Insert("posts", engine.Row{"id": 12, "user_id": 2})
+
+	count, err := db.Delete("users", &engine.Condition{Column: "id", Operator: "=", Value: 1})
+	if err != nil {
+		t.Fatalf("Cascade delete failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 parent row deleted, got %d", count)
+	}
+
+	rows, _ := db.Select("posts", nil, nil)
+	if len(rows) != 1 || rows[0]["id"] != 12 {
+		t.Errorf("Expected only post 12 to remain, got %v", rows)
+	}
+}
+
+func TestForeignKeyCascadeDeleteManyParents(t *testing.T) {
+	db := setupForeignKeys(t, true)
+	db.Insert("users", engine.Row{"id": 3, "name": "bob"})
+	db.InsertMany("posts", []engine.Row{
+		{"id": 11, "user_id": 2}, {"id": 12, "user_id": 3}, {"id": 13, "user_id": 1}, {"id": 14, "user_id": 3},
+	})
+	var log bytes.Buffer
+	db.SetOperationLog(&log)
+
+	count, err := db.Delete("users", &engine.Condition{Column: "id", Operator: "!=", Value: 2})
+	if err != nil || count != 2 {
+		t.Fatalf("Expected 2 parent rows deleted, got %d, %v", count, err)
+	}
+	rows, _ := db.Select("posts", nil, nil)
+	if len(rows) != 1 || rows[0]["id"] != 11 {
+		t.Errorf("Expected only post 11 to remain, got %v", rows)
+	}
+
+	// The children of all deleted parents are removed together
+	var ops []engine.Operation
+	decoder := json.NewDecoder(&log)
+	for decoder.More() {
+		var op engine.Operation
+		if err := decoder.Decode(&op); err != nil {
+			t.Fatalf("Invalid log entry: %v", err)
+		}
+		ops = append(ops, op)
+	}
+	if len(ops) != 2 || ops[1].Table != "posts" || !reflect.DeepEqual(ops[1].Positions, []int{0, 2, 3, 4}) {
+		t.Errorf("Expected one delete of the users and one of their posts, got %+v", ops)
+	}
+}
+
+func TestForeignKeyDefinitionValidation(t *testing.T) {
+	db := setupForeignKeys(t, false)
+
+	tests := []struct {
+		name string
+		fk   engine.ForeignKey
+		typ  engine.ColumnType
+	}{
+		{"missing table", engine.ForeignKey{Table: "nope", Column: "id"}, engine.TypeInt},
+		{"missing column", engine.ForeignKey{Table: "users", Column: "nope"}, engine.TypeInt},
+		{"not unique", engine.ForeignKey{Table: "users", Column: "name"}, engine.TypeString},
+		{"type mismatch", engine.ForeignKey{Table: "users", Column: "id"}, engine.TypeString},
+	}
+
+	for _, tt := range tests {
+		fk := tt.fk
+		schema := []engine.Column{{Name: "ref", Type: tt.typ, References: &fk}}
+		err := db.CreateTable("child", schema)
+		if !errors.As(err, &engine.ErrInvalidColumnDefinition{}) {
+			t.Errorf("%s: expected ErrInvalidColumnDefinition, got %v", tt.name, err)
+		}
+	}
+}
+
+func TestForeignKeyCascadeBlockedByGrandchild(t *testing.T) {
+	db := setupForeignKeys(t, true)
+	db.CreateTable("comments", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "post_id", Type: engine.TypeInt, References: &engine.ForeignKey{Table: "posts", Column: "id"}},
+	})
+	db.Insert("comments", engine.Row{"id": 100, "post_id": 10})
+
+	_, err := db.Delete("users", &engine.Condition{Column: "id", Operator: "=", Value: 1})
+	var violation engine.ErrForeignKeyViolation
+	if !errors.As(err, &violation) || violation.TableName != "posts" {
+		t.Fatalf("Expected the comment to block the cascade through posts, got %v", err)
+	}
+
+	// Nothing is removed when the cascade is rejected
+	if count, _ := db.RowCount("users"); count != 2 {
+		t.Errorf("Expected both users to remain, got %d", count)
+	}
+	if count, _ := db.RowCount("posts"); count != 1 {
+		t.Errorf("Expected the post to remain, got %d", count)
+	}
+}
+
+func TestForeignKeyCascadeCycle(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("employees", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "manager_id", Type: engine.TypeInt, References: &engine.ForeignKey{
+			Table: "employees", Column: "id", OnDeleteCascade: true,
+		}},
+	})
+	db.Insert("employees", engine.Row{"id": 1})
+	db.Insert("employees", engine.Row{"id": 2, "manager_id": 1})
+	db.Insert("employees", engine.Row{"id": 3, "manager_id": 2})
+	db.Insert("employees", engine.Row{"id": 4})
+	db.Update("employees", engine.Row{"manager_id": 3}, &engine.Condition{Column: "id", Operator: "=", Value: 1})
+
+	// 1 -> 2 -> 3 -> 1 forms a cycle; the cascade must still terminate
+	if _, err := db.Delete("employees", &engine.Condition{Column: "id", Operator: "=", Value: 2}); err != nil {
+		t.Fatalf("Cascade delete failed: %v", err)
+	}
+	rows, _ := db.Select("employees", nil, nil)
+	if len(rows) != 1 || rows[0]["id"] != 4 {
+		t.Errorf("Expected only employee 4 to remain, got %v", rows)
+	}
+}
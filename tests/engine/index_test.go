@@ -0,0 +1,154 @@
+package engine_test
+
+import (
+	"errors"
+	"godb/engine"
+	"reflect"
+	"testing"
+)
+
+func TestIndexSizeAndCount(t *testing.T) {
+	idx := engine.NewIndex("city")
+	for row, city := range []interface{}{"Nairobi", "Mombasa", "Nairobi", nil, "Kisumu", "Nairobi"} {
+		idx.Add(city, row)
+	}
+
+	if size := idx.Size(); size != 3 {
+		t.Errorf("Expected 3 distinct values, got %d", size)
+	}
+	counts := map[interface{}]int{"Nairobi": 3, "Mombasa": 1, "Kisumu": 1, "Eldoret": 0, nil: 0}
+	for value, want := range counts {
+		if got := idx.Count(value); got != want {
+			t.Errorf("Count(%v): expected %d, got %d", value, want, got)
+		}
+	}
+
+	idx.Remove("Mombasa", 1)
+	idx.Update("Nairobi", "Kisumu", 0)
+	if size := idx.Size(); size != 2 {
+		t.Errorf("Expected 2 distinct values after removing Mombasa, got %d", size)
+	}
+	if idx.Count("Nairobi") != 2 || idx.Count("Kisumu") != 2 {
+		t.Errorf("Expected 2 Nairobi and 2 Kisumu, got %d and %d", idx.Count("Nairobi"), idx.Count("Kisumu"))
+	}
+	if got := idx.Lookup("Kisumu"); !reflect.DeepEqual(got, []int{4, 0}) {
+		t.Errorf("Expected Kisumu rows [4 0], got %v", got)
+	}
+}
+
+func TestTableIndexedColumns(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "email", Type: engine.TypeString, Unique: true},
+		{Name: "age", Type: engine.TypeInt},
+		{Name: "city", Type: engine.TypeString},
+	})
+	table, _ := db.GetTable("users")
+	table.CreateIndex("city")
+	table.CreateOrderedIndex("age")
+	table.CreateOrderedIndex("id") // Already hash-indexed; listed once
+
+	if got := table.IndexedColumns(); !reflect.DeepEqual(got, []string{"age", "city", "email", "id"}) {
+		t.Errorf("Expected [age city email id], got %v", got)
+	}
+}
+
+func TestDropIndex(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "email", Type: engine.TypeString, Unique: true},
+		{Name: "city", Type: engine.TypeString},
+	})
+	db.Insert("users", engine.Row{"id": 1, "email": "a@example.com", "city": "Nairobi"})
+	table, _ := db.GetTable("users")
+	table.CreateIndex("city")
+
+	if err := table.DropIndex("city"); err != nil {
+		t.Fatalf("DropIndex failed: %v", err)
+	}
+	if _, ok := table.GetIndex("city"); ok {
+		t.Error("Expected the city index to be gone")
+	}
+	rows, _ := db.Select("users", nil, &engine.Condition{Column: "city", Operator: "=", Value: "Nairobi"})
+	if len(rows) != 1 {
+		t.Errorf("Expected 1 row from a full scan, got %d", len(rows))
+	}
+
+	if err := table.DropIndex("city"); !errors.As(err, &engine.ErrIndexNotFound{}) {
+		t.Errorf("Expected ErrIndexNotFound, got %v", err)
+	}
+	if err := table.DropIndex("missing"); !errors.As(err, &engine.ErrColumnNotFound{}) {
+		t.Errorf("Expected ErrColumnNotFound, got %v", err)
+	}
+	for _, column := range []string{"id", "email"} {
+		if err := table.DropIndex(column); !errors.As(err, &engine.ErrImplicitIndex{}) {
+			t.Errorf("%s: expected ErrImplicitIndex, got %v", column, err)
+		}
+	}
+}
+
+func TestDeferredIndexes(t *testing.T) {
+	db := engine.NewDatabase()
+	err := db.CreateTableWithOptions("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "email", Type: engine.TypeString, Unique: true},
+		{Name: "name", Type: engine.TypeString},
+	}, engine.TableOptions{DeferIndexes: true})
+	if err != nil {
+		t.Fatalf("CreateTableWithOptions failed: %v", err)
+	}
+	table, _ := db.GetTable("users")
+	if cols := table.IndexedColumns(); len(cols) != 0 {
+		t.Fatalf("Expected no indexes on creation, got %v", cols)
+	}
+
+	db.Insert("users", engine.Row{"id": 1, "email": "ada@example.com", "name": "ada"})
+	db.Insert("users", engine.Row{"id": 2, "email": nil, "name": "bob"})
+	db.Insert("users", engine.Row{"id": 3, "email": nil, "name": "cy"})
+
+	// Constraints are still enforced by scanning
+	if err := db.Insert("users", engine.Row{"id": 1, "email": "x@example.com"}); !errors.As(err, &engine.ErrPrimaryKeyViolation{}) {
+		t.Errorf("Expected ErrPrimaryKeyViolation, got %v", err)
+	}
+	if err := db.Insert("users", engine.Row{"id": 4, "email": "ada@example.com"}); !errors.As(err, &engine.ErrUniqueViolation{}) {
+		t.Errorf("Expected ErrUniqueViolation, got %v", err)
+	}
+
+	// Queries that cannot use the index scan without building it
+	rows, err := db.Select("users", nil, &engine.Condition{Column: "id", Operator: ">", Value: 1})
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %v, %v", rows, err)
+	}
+	if cols := table.IndexedColumns(); len(cols) != 0 {
+		t.Errorf("Expected no indexes after a range query, got %v", cols)
+	}
+
+	// The first equality query builds the index and uses it
+	before := db.Metrics()
+	rows, err = db.Select("users", nil, &engine.Condition{Column: "id", Operator: "=", Value: 3})
+	if err != nil || len(rows) != 1 || rows[0]["name"] != "cy" {
+		t.Fatalf("Expected cy, got %v, %v", rows, err)
+	}
+	if after := db.Metrics(); after.IndexHits-before.IndexHits != 1 {
+		t.Errorf("Expected the query to use the new index, got %+v", after)
+	}
+	if cols := table.IndexedColumns(); !reflect.DeepEqual(cols, []string{"id"}) {
+		t.Errorf("Expected only id to be indexed, got %v", cols)
+	}
+
+	// An explicit CreateIndex builds the rest, which enforce their constraint as usual
+	if err := table.CreateIndex("email"); err != nil {
+		t.Fatalf("CreateIndex failed: %v", err)
+	}
+	if cols := table.IndexedColumns(); !reflect.DeepEqual(cols, []string{"email", "id"}) {
+		t.Errorf("Expected email and id to be indexed, got %v", cols)
+	}
+	if err := table.DropIndex("email"); !errors.As(err, &engine.ErrImplicitIndex{}) {
+		t.Errorf("Expected ErrImplicitIndex, got %v", err)
+	}
+	if err := db.Insert("users", engine.Row{"id": 4, "email": "ada@example.com"}); !errors.As(err, &engine.ErrUniqueViolation{}) {
+		t.Errorf("Expected ErrUniqueViolation, got %v", err)
+	}
+}
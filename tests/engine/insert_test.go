@@ -1,7 +1,10 @@
 package engine_test
 
 import (
+	"errors"
+	"fmt"
 	"godb/engine"
+	"reflect"
 	"testing"
 )
 
@@ -126,3 +129,405 @@ func TestInsertMissingRequiredColumn(t *testing.T) {
 		t.Errorf("Expected ErrMissingRequiredColumn, got %T", err)
 	}
 }
+
+func TestInsertManyAllOrNothing(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "name", Type: engine.TypeString},
+	}
+	db.CreateTable("users", schema)
+	db.Insert("users", engine.Row{"id": 10, "name": "existing"})
+
+	// Third row collides with the existing primary key
+	rows := []engine.Row{
+		{"id": 1, "name": "a"},
+		{"id": 2, "name": "b"},
+		{"id": 10, "name": "c"},
+	}
+	count, err := db.InsertMany("users", rows)
+	if err == nil {
+		t.Fatal("Expected primary key violation, got nil")
+	}
+	if count != 0 {
+		t.Errorf("Expected 0 rows inserted, got %d", count)
+	}
+
+	// Rows 1 and 2 must have been rolled back, including their index entries
+	results, _ := db.Select("users", nil, nil)
+	if len(results) != 1 {
+		t.Errorf("Expected 1 row after rollback, got %d", len(results))
+	}
+	results, _ = db.Select("users", nil, &engine.Condition{Column: "id", Operator: "=", Value: 1})
+	if len(results) != 0 {
+		t.Errorf("Expected rolled back row to be absent from index, got %d rows", len(results))
+	}
+
+	// A valid batch is inserted in full
+	count, err = db.InsertMany("users", rows[:2])
+	if err != nil {
+		t.Fatalf("InsertMany failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 rows inserted, got %d", count)
+	}
+}
+
+func TestInsertManyFailureKeepsNextID(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true, AutoIncrement: true},
+		{Name: "email", Type: engine.TypeString, Unique: true},
+	})
+	db.Insert("users", engine.Row{"email": "a@x.com"})
+
+	// The first two rows take ids before the third is rejected
+	_, err := db.InsertMany("users", []engine.Row{{"email": "b@x.com"}, {"email": "c@x.com"}, {"email": "a@x.com"}})
+	if _, ok := err.(engine.ErrUniqueViolation); !ok {
+		t.Fatalf("Expected ErrUniqueViolation, got %v", err)
+	}
+
+	id, err := db.InsertWithKey("users", engine.Row{"email": "d@x.com"})
+	if err != nil || id != 2 {
+		t.Errorf("Expected the failed batch to use up no ids, got next id %v, %v", id, err)
+	}
+}
+
+func TestInsertFailureKeepsNextID(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true, AutoIncrement: true},
+		{Name: "email", Type: engine.TypeString, Unique: true},
+	})
+	db.Insert("users", engine.Row{"email": "a@x.com"})
+
+	err := db.Insert("users", engine.Row{"email": "a@x.com"})
+	if _, ok := err.(engine.ErrUniqueViolation); !ok {
+		t.Fatalf("Expected ErrUniqueViolation, got %v", err)
+	}
+	// An explicit key that is rejected does not move the counter either
+	err = db.Insert("users", engine.Row{"id": 10, "email": "a@x.com"})
+	if _, ok := err.(engine.ErrUniqueViolation); !ok {
+		t.Fatalf("Expected ErrUniqueViolation, got %v", err)
+	}
+
+	id, err := db.InsertWithKey("users", engine.Row{"email": "b@x.com"})
+	if err != nil || id != 2 {
+		t.Errorf("Expected rejected inserts to use up no ids, got next id %v, %v", id, err)
+	}
+}
+
+func TestInsertValues(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true, AutoIncrement: true},
+		{Name: "name", Type: engine.TypeString},
+		{Name: "email", Type: engine.TypeString, Unique: true},
+	})
+
+	if err := db.InsertValues("users", []interface{}{1, "Bob", "b@x.com"}); err != nil {
+		t.Fatalf("InsertValues failed: %v", err)
+	}
+	rows, _ := db.Select("users", nil, nil)
+	want := engine.Row{"id": 1, "name": "Bob", "email": "b@x.com"}
+	if len(rows) != 1 || !reflect.DeepEqual(rows[0], want) {
+		t.Errorf("Expected %v, got %v", want, rows)
+	}
+
+	// NULL for the AUTOINCREMENT column generates the key
+	count, err := db.InsertManyValues("users", [][]interface{}{{nil, "Cy", "c@x.com"}, {nil, "Dee", nil}})
+	if err != nil || count != 2 {
+		t.Fatalf("Expected 2 rows inserted, got %d, %v", count, err)
+	}
+	rows, _ = db.Select("users", nil, &engine.Condition{Column: "name", Operator: "=", Value: "Dee"})
+	if len(rows) != 1 || rows[0]["id"] != 3 {
+		t.Errorf("Expected Dee to get id 3, got %v", rows)
+	}
+
+	err = db.InsertValues("users", []interface{}{5, "Eve"})
+	var mismatch engine.ErrValueCount
+	if !errors.As(err, &mismatch) || mismatch.Expected != 3 || mismatch.Got != 2 {
+		t.Errorf("Expected ErrValueCount for 2 of 3 values, got %v", err)
+	}
+
+	// A bad tuple rejects the whole batch
+	_, err = db.InsertManyValues("users", [][]interface{}{{6, "Fay", "f@x.com"}, {7, "Gus", "b@x.com"}})
+	if err == nil {
+		t.Error("Expected unique violation")
+	}
+	if rows, _ := db.Select("users", nil, nil); len(rows) != 3 {
+		t.Errorf("Expected 3 rows after the failed batch, got %d", len(rows))
+	}
+}
+
+func TestInsertAutoIncrement(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true, AutoIncrement: true},
+		{Name: "name", Type: engine.TypeString},
+	}
+	if err := db.CreateTable("users", schema); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	for i, name := range []string{"a", "b", "c"} {
+		key, err := db.InsertWithKey("users", engine.Row{"name": name})
+		if err != nil {
+			t.Fatalf("Insert failed: %v", err)
+		}
+		if key != i+1 {
+			t.Errorf("Expected generated id %d, got %v", i+1, key)
+		}
+	}
+
+	// Deleted ids are never reused
+	db.Delete("users", &engine.Condition{Column: "id", Operator: "=", Value: 3})
+	key, _ := db.InsertWithKey("users", engine.Row{"name": "d"})
+	if key != 4 {
+		t.Errorf("Expected generated id 4 after delete, got %v", key)
+	}
+
+	// Explicit ids move the counter forward
+	db.Insert("users", engine.Row{"id": 10, "name": "e"})
+	key, _ = db.InsertWithKey("users", engine.Row{"name": "f"})
+	if key != 11 {
+		t.Errorf("Expected generated id 11 after explicit id, got %v", key)
+	}
+}
+
+func TestAutoIncrementRequiresIntPrimaryKey(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeString, PrimaryKey: true, AutoIncrement: true},
+	}
+	err := db.CreateTable("users", schema)
+	if _, ok := err.(engine.ErrInvalidColumnDefinition); !ok {
+		t.Errorf("Expected ErrInvalidColumnDefinition, got %v", err)
+	}
+}
+
+func TestResetAutoIncrement(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true, AutoIncrement: true},
+		{Name: "name", Type: engine.TypeString},
+	}
+	db.CreateTable("users", schema)
+	db.Insert("users", engine.Row{"name": "a"})
+	db.Insert("users", engine.Row{"name": "b"})
+
+	table, _ := db.GetTable("users")
+
+	// Colliding with an existing key is rejected
+	err := table.ResetAutoIncrement(2)
+	if _, ok := err.(engine.ErrAutoIncrementConflict); !ok {
+		t.Errorf("Expected ErrAutoIncrementConflict, got %v", err)
+	}
+
+	if err := table.ResetAutoIncrement(100); err != nil {
+		t.Fatalf("ResetAutoIncrement failed: %v", err)
+	}
+
+	key, err := db.InsertWithKey("users", engine.Row{"name": "c"})
+	if err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if key != 100 {
+		t.Errorf("Expected generated id 100, got %v", key)
+	}
+}
+
+func TestCreateTableWithRows(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true, AutoIncrement: true},
+		{Name: "email", Type: engine.TypeString, Unique: true},
+	}
+	rows := []engine.Row{
+		{"email": "a@example.com"},
+		{"email": "b@example.com"},
+	}
+
+	if err := db.CreateTableWithRows("users", schema, rows); err != nil {
+		t.Fatalf("CreateTableWithRows failed: %v", err)
+	}
+
+	results, _ := db.Select("users", nil, nil)
+	if len(results) != 2 {
+		t.Errorf("Expected 2 rows, got %d", len(results))
+	}
+
+	// Rows can reference tables that already exist
+	posts := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "user_id", Type: engine.TypeInt, References: &engine.ForeignKey{Table: "users", Column: "id"}},
+	}
+	if err := db.CreateTableWithRows("posts", posts, []engine.Row{{"id": 1, "user_id": 2}}); err != nil {
+		t.Errorf("Expected valid foreign key to be accepted, got %v", err)
+	}
+}
+
+func TestCreateTableWithRowsRollsBack(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "email", Type: engine.TypeString, Unique: true},
+	}
+	rows := []engine.Row{
+		{"id": 1, "email": "a@example.com"},
+		{"id": 2, "email": "b@example.com"},
+		{"id": 3, "email": "a@example.com"}, // duplicate email
+	}
+
+	err := db.CreateTableWithRows("users", schema, rows)
+	if err == nil {
+		t.Fatal("Expected unique violation, got nil")
+	}
+	if _, ok := err.(engine.ErrUniqueViolation); !ok {
+		t.Errorf("Expected ErrUniqueViolation, got %T", err)
+	}
+
+	if db.TableExists("users") {
+		t.Error("Expected table creation to be rolled back")
+	}
+
+	// The name is free to use again
+	if err := db.CreateTableWithRows("users", schema, rows[:2]); err != nil {
+		t.Errorf("Expected retry to succeed, got %v", err)
+	}
+}
+
+func TestInsertReturningIncludesGeneratedKey(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true, AutoIncrement: true},
+		{Name: "name", Type: engine.TypeString},
+	}
+	db.CreateTable("users", schema)
+	db.Insert("users", engine.Row{"name": "a"})
+
+	input := engine.Row{"name": "b"}
+	row, err := db.InsertReturning("users", input)
+	if err != nil {
+		t.Fatalf("InsertReturning failed: %v", err)
+	}
+	if id, _ := row.Get("id"); id != 2 {
+		t.Errorf("Expected generated id 2, got %v", id)
+	}
+	if name, _ := row.Get("name"); name != "b" {
+		t.Errorf("Expected name b, got %v", name)
+	}
+	if _, ok := input.Get("id"); ok {
+		t.Error("Expected the caller's row to be left untouched")
+	}
+
+	// The returned row is a copy, so changing it doesn't change the table
+	row.Set("name", "changed")
+	results, _ := db.Select("users", nil, &engine.Condition{Column: "id", Operator: "=", Value: 2})
+	if name, _ := results[0].Get("name"); name != "b" {
+		t.Errorf("Expected stored name b, got %v", name)
+	}
+
+	if _, err := db.InsertReturning("users", engine.Row{"id": 2, "name": "dup"}); err == nil {
+		t.Error("Expected primary key violation")
+	}
+}
+
+func TestBulkInsert(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true, AutoIncrement: true},
+		{Name: "email", Type: engine.TypeString, Unique: true},
+	})
+	db.Insert("users", engine.Row{"email": "existing@example.com"})
+
+	err := db.BulkInsert("users", []engine.Row{
+		{"email": "a@example.com"},
+		{"email": "b@example.com"},
+		{"email": nil},
+		{"email": nil}, // NULLs never collide
+	})
+	if err != nil {
+		t.Fatalf("BulkInsert failed: %v", err)
+	}
+
+	results, _ := db.Select("users", nil, &engine.Condition{Column: "email", Operator: "=", Value: "b@example.com"})
+	if len(results) != 1 || results[0]["id"] != 3 {
+		t.Errorf("Expected b@example.com to be indexed with id 3, got %v", results)
+	}
+
+	tests := []struct {
+		name string
+		rows []engine.Row
+		want error
+	}{
+		{"duplicate primary key in batch", []engine.Row{{"id": 10, "email": "c@example.com"}, {"id": 10, "email": "d@example.com"}}, engine.ErrPrimaryKeyViolation{}},
+		{"duplicate unique value in batch", []engine.Row{{"email": "c@example.com"}, {"email": "c@example.com"}}, engine.ErrUniqueViolation{}},
+		{"duplicate of an existing row", []engine.Row{{"email": "c@example.com"}, {"email": "a@example.com"}}, engine.ErrUniqueViolation{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := db.BulkInsert("users", tt.rows)
+			if reflect.TypeOf(err) != reflect.TypeOf(tt.want) {
+				t.Fatalf("Expected %T, got %v", tt.want, err)
+			}
+			if rows, _ := db.Select("users", nil, nil); len(rows) != 5 {
+				t.Errorf("Expected no rows to be inserted, got %d rows", len(rows))
+			}
+		})
+	}
+
+	// Failed batches give back the ids they assigned
+	id, _ := db.InsertWithKey("users", engine.Row{"email": "e@example.com"})
+	if id != 6 {
+		t.Errorf("Expected next id 6, got %v", id)
+	}
+}
+
+const bulkRows = 1000
+
+func bulkBatch() []engine.Row {
+	rows := make([]engine.Row, bulkRows)
+	for i := range rows {
+		rows[i] = engine.Row{"id": i, "email": fmt.Sprintf("user%d@example.com", i)}
+	}
+	return rows
+}
+
+func benchmarkInsert(b *testing.B, insert func(db *engine.Database, rows []engine.Row) error) {
+	rows := bulkBatch()
+	for i := 0; i < b.N; i++ {
+		db := engine.NewDatabase()
+		db.CreateTable("users", []engine.Column{
+			{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+			{Name: "email", Type: engine.TypeString, Unique: true},
+		})
+		if err := insert(db, rows); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkInsertLoop(b *testing.B) {
+	benchmarkInsert(b, func(db *engine.Database, rows []engine.Row) error {
+		for _, row := range rows {
+			if err := db.Insert("users", row); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func BenchmarkBulkInsert(b *testing.B) {
+	benchmarkInsert(b, func(db *engine.Database, rows []engine.Row) error {
+		return db.BulkInsert("users", rows)
+	})
+}
@@ -2,6 +2,8 @@ package engine_test
 
 import (
 	"godb/engine"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -136,3 +138,486 @@ func TestInnerJoinNoMatches(t *testing.T) {
 		t.Errorf("Expected 0 joined rows, got %d", len(results))
 	}
 }
+
+func TestLeftJoinNoMatches(t *testing.T) {
+	db := engine.NewDatabase()
+
+	// Create tables
+	usersSchema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "name", Type: engine.TypeString},
+	}
+	db.CreateTable("users", usersSchema)
+
+	postsSchema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "user_id", Type: engine.TypeInt},
+		{Name: "title", Type: engine.TypeString},
+	}
+	db.CreateTable("posts", postsSchema)
+
+	// Insert users
+	db.Insert("users", engine.Row{"id": 1, "name": "moses"})
+
+	// Insert posts with non-matching user_id
+	db.Insert("posts", engine.Row{"id": 1, "user_id": 999, "title": "Post 1"})
+
+	// Perform join
+	joinCondition := engine.JoinCondition{
+		LeftColumn:  "user_id",
+		RightColumn: "id",
+	}
+
+	results, err := db.LeftJoin("posts", "users", joinCondition, nil)
+	if err != nil {
+		t.Fatalf("Join failed: %v", err)
+	}
+
+	// The unmatched post is kept with nil user columns
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 joined row, got %d", len(results))
+	}
+
+	if results[0]["posts.title"] != "Post 1" {
+		t.Errorf("Expected posts.title 'Post 1', got %v", results[0]["posts.title"])
+	}
+
+	for _, col := range []string{"users.id", "users.name"} {
+		value, ok := results[0][col]
+		if !ok {
+			t.Errorf("Expected %s to be present", col)
+		}
+		if value != nil {
+			t.Errorf("Expected %s to be nil, got %v", col, value)
+		}
+	}
+}
+
+func TestLeftJoinMixedMatches(t *testing.T) {
+	db := engine.NewDatabase()
+
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "name", Type: engine.TypeString},
+	})
+	db.CreateTable("posts", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "user_id", Type: engine.TypeInt},
+	})
+
+	db.Insert("users", engine.Row{"id": 1, "name": "moses"})
+	db.Insert("posts", engine.Row{"id": 1, "user_id": 1})
+	db.Insert("posts", engine.Row{"id": 2, "user_id": 2})
+	db.Insert("posts", engine.Row{"id": 3})
+
+	joinCondition := engine.JoinCondition{LeftColumn: "user_id", RightColumn: "id"}
+	results, err := db.LeftJoin("posts", "users", joinCondition, nil)
+	if err != nil {
+		t.Fatalf("Join failed: %v", err)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 joined rows, got %d", len(results))
+	}
+
+	matched := 0
+	for _, row := range results {
+		if row["users.name"] != nil {
+			matched++
+		}
+	}
+	if matched != 1 {
+		t.Errorf("Expected 1 matched row, got %d", matched)
+	}
+}
+
+func TestInnerJoinOrderStableAcrossDelete(t *testing.T) {
+	db := engine.NewDatabase()
+
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "name", Type: engine.TypeString},
+	})
+	db.CreateTable("posts", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "user_id", Type: engine.TypeInt},
+	})
+
+	db.Insert("users", engine.Row{"id": 1, "name": "moses"})
+	db.Insert("users", engine.Row{"id": 2, "name": "Bob"})
+	// Posts are stored in reverse key order and users are re-inserted out of order
+	for i := 6; i >= 1; i-- {
+		db.Insert("posts", engine.Row{"id": i, "user_id": 1 + i%2})
+	}
+
+	db.Delete("posts", &engine.Condition{Column: "id", Operator: "=", Value: 2})
+	db.Delete("users", &engine.Condition{Column: "id", Operator: "=", Value: 1})
+	db.Insert("users", engine.Row{"id": 1, "name": "moses"})
+
+	joinCondition := engine.JoinCondition{LeftColumn: "user_id", RightColumn: "id"}
+
+	// Left side is ordered by its primary key
+	results, err := db.InnerJoin("posts", "users", joinCondition, []string{"posts.id"})
+	if err != nil {
+		t.Fatalf("Join failed: %v", err)
+	}
+	want := []int{1, 3, 4, 5, 6}
+	if len(results) != len(want) {
+		t.Fatalf("Expected %d rows, got %d", len(want), len(results))
+	}
+	for i, id := range want {
+		if results[i]["posts.id"] != id {
+			t.Errorf("Row %d: expected posts.id %d, got %v", i, id, results[i]["posts.id"])
+		}
+	}
+
+	// Matches on the right side are ordered by the right primary key
+	joinCondition = engine.JoinCondition{LeftColumn: "id", RightColumn: "user_id"}
+	results, err = db.InnerJoin("users", "posts", joinCondition, []string{"users.id", "posts.id"})
+	if err != nil {
+		t.Fatalf("Join failed: %v", err)
+	}
+	wantPairs := [][2]int{{1, 4}, {1, 6}, {2, 1}, {2, 3}, {2, 5}}
+	if len(results) != len(wantPairs) {
+		t.Fatalf("Expected %d rows, got %d", len(wantPairs), len(results))
+	}
+	for i, pair := range wantPairs {
+		if results[i]["users.id"] != pair[0] || results[i]["posts.id"] != pair[1] {
+			t.Errorf("Row %d: expected (%d, %d), got (%v, %v)",
+				i, pair[0], pair[1], results[i]["users.id"], results[i]["posts.id"])
+		}
+	}
+}
+
+func setupBlog(t *testing.T) *engine.Database {
+	t.Helper()
+	db := engine.NewDatabase()
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "name", Type: engine.TypeString},
+	})
+	db.CreateTable("posts", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "user_id", Type: engine.TypeInt},
+	})
+	db.CreateTable("comments", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "post_id", Type: engine.TypeInt},
+		{Name: "body", Type: engine.TypeString},
+	})
+
+	db.Insert("users", engine.Row{"id": 1, "name": "moses"})
+	db.Insert("users", engine.Row{"id": 2, "name": "Bob"})
+	db.Insert("posts", engine.Row{"id": 10, "user_id": 1})
+	db.Insert("posts", engine.Row{"id": 11, "user_id": 2})
+	db.Insert("posts", engine.Row{"id": 12, "user_id": 1})
+	db.Insert("comments", engine.Row{"id": 102, "post_id": 10, "body": "second"})
+	db.Insert("comments", engine.Row{"id": 101, "post_id": 10, "body": "first"})
+	db.Insert("comments", engine.Row{"id": 103, "post_id": 12, "body": "third"})
+	return db
+}
+
+func TestJoinThreeTables(t *testing.T) {
+	db := setupBlog(t)
+
+	steps := []engine.JoinStep{
+		{Type: engine.JoinInner, Table: "users", LeftTable: "posts", LeftColumn: "user_id", RightColumn: "id"},
+		{Type: engine.JoinInner, Table: "comments", LeftTable: "posts", LeftColumn: "id", RightColumn: "post_id"},
+	}
+	results, err := db.Join("posts", steps, nil, []string{"users.name", "posts.id", "comments.body"})
+	if err != nil {
+		t.Fatalf("Join failed: %v", err)
+	}
+
+	want := []engine.Row{
+		{"users.name": "moses", "posts.id": 10, "comments.body": "first"},
+		{"users.name": "moses", "posts.id": 10, "comments.body": "second"},
+		{"users.name": "moses", "posts.id": 12, "comments.body": "third"},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("Expected %v, got %v", want, results)
+	}
+
+	// A LEFT join keeps posts without comments
+	steps[1].Type = engine.JoinLeft
+	results, err = db.Join("posts", steps, nil, nil)
+	if err != nil {
+		t.Fatalf("Join failed: %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 rows, got %d", len(results))
+	}
+	row := results[2]
+	if row["posts.id"] != 11 || row["users.name"] != "Bob" || row["comments.id"] != nil || len(row) != 7 {
+		t.Errorf("Expected post 11 with NULL comment columns, got %v", row)
+	}
+}
+
+func TestJoinChainValidation(t *testing.T) {
+	db := setupBlog(t)
+
+	tests := []struct {
+		name  string
+		steps []engine.JoinStep
+	}{
+		{"table joined twice", []engine.JoinStep{
+			{Type: engine.JoinInner, Table: "users", LeftTable: "posts", LeftColumn: "user_id", RightColumn: "id"},
+			{Type: engine.JoinInner, Table: "posts", LeftTable: "users", LeftColumn: "id", RightColumn: "user_id"},
+		}},
+		{"condition on a later table", []engine.JoinStep{
+			{Type: engine.JoinInner, Table: "users", LeftTable: "comments", LeftColumn: "id", RightColumn: "id"},
+			{Type: engine.JoinInner, Table: "comments", LeftTable: "posts", LeftColumn: "id", RightColumn: "post_id"},
+		}},
+		{"unknown column", []engine.JoinStep{
+			{Type: engine.JoinInner, Table: "users", LeftTable: "posts", LeftColumn: "user_id", RightColumn: "id"},
+			{Type: engine.JoinInner, Table: "comments", LeftTable: "users", LeftColumn: "post_id", RightColumn: "post_id"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := db.Join("posts", tt.steps, nil, nil); err == nil {
+				t.Error("Expected an error, got nil")
+			}
+		})
+	}
+}
+
+func TestJoinWhere(t *testing.T) {
+	db := setupBlog(t)
+	steps := []engine.JoinStep{
+		{Type: engine.JoinInner, Table: "users", LeftTable: "posts", LeftColumn: "user_id", RightColumn: "id"},
+	}
+
+	tests := []struct {
+		name      string
+		condition *engine.Condition
+		want      []int // posts.id of the expected rows
+	}{
+		{"qualified right-table column", &engine.Condition{Column: "users.name", Operator: "=", Value: "Bob"}, []int{11}},
+		{"unqualified column of one table", &engine.Condition{Column: "name", Operator: "=", Value: "moses"}, []int{10, 12}},
+		{"compound condition across tables", engine.And(
+			&engine.Condition{Column: "users.name", Operator: "=", Value: "moses"},
+			&engine.Condition{Column: "posts.id", Operator: ">", Value: 10},
+		), []int{12}},
+		{"no match", &engine.Condition{Column: "users.name", Operator: "=", Value: "Eve"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := db.Join("posts", steps, tt.condition, []string{"posts.id"})
+			if err != nil {
+				t.Fatalf("Join failed: %v", err)
+			}
+			var got []int
+			for _, row := range results {
+				got = append(got, row["posts.id"].(int))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected posts %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	// WHERE applies after a LEFT JOIN, so unmatched rows can be selected by their NULLs
+	steps = []engine.JoinStep{
+		{Type: engine.JoinLeft, Table: "comments", LeftTable: "posts", LeftColumn: "id", RightColumn: "post_id"},
+	}
+	results, err := db.Join("posts", steps, &engine.Condition{Column: "comments.id", Operator: "IS NULL"}, nil)
+	if err != nil {
+		t.Fatalf("Join failed: %v", err)
+	}
+	if len(results) != 1 || results[0]["posts.id"] != 11 {
+		t.Errorf("Expected only post 11 without comments, got %v", results)
+	}
+
+	// id exists in both tables
+	if _, err := db.Join("posts", steps, &engine.Condition{Column: "id", Operator: "=", Value: 10}, nil); err == nil {
+		t.Error("Expected error for an ambiguous column")
+	}
+	if _, err := db.Join("posts", steps, &engine.Condition{Column: "users.name", Operator: "=", Value: "Bob"}, nil); err == nil {
+		t.Error("Expected error for a column of a table that is not joined")
+	}
+}
+
+func TestJoinSelectColumns(t *testing.T) {
+	db := setupBlog(t)
+	steps := []engine.JoinStep{
+		{Type: engine.JoinInner, Table: "users", LeftTable: "posts", LeftColumn: "user_id", RightColumn: "id"},
+	}
+
+	// A bare column of one table is returned under its qualified name
+	results, err := db.Join("posts", steps, &engine.Condition{Column: "posts.id", Operator: "=", Value: 11}, []string{"name", "posts.id"})
+	if err != nil {
+		t.Fatalf("Join failed: %v", err)
+	}
+	want := []engine.Row{{"users.name": "Bob", "posts.id": 11}}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("Expected %v, got %v", want, results)
+	}
+
+	tests := []struct {
+		name   string
+		column string
+		err    error
+	}{
+		{"ambiguous bare column", "id", nil},
+		{"unknown bare column", "title", engine.ErrColumnNotFound{TableName: "posts", ColumnName: "title"}},
+		{"unknown qualified column", "users.title", engine.ErrColumnNotFound{TableName: "users", ColumnName: "title"}},
+		{"column of a table not joined", "comments.body", engine.ErrColumnNotFound{TableName: "comments", ColumnName: "body"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := db.Join("posts", steps, nil, []string{"posts.id", tt.column})
+			switch {
+			case err == nil:
+				t.Error("Expected an error, got nil")
+			case tt.err == nil && !strings.Contains(err.Error(), "ambiguous"):
+				t.Errorf("Expected an ambiguity error, got %v", err)
+			case tt.err != nil && err != tt.err:
+				t.Errorf("Expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestHashJoin(t *testing.T) {
+	db := setupBlog(t)
+	db.Insert("comments", engine.Row{"id": 104, "post_id": nil, "body": "orphan"})
+	steps := []engine.JoinStep{
+		{Type: engine.JoinLeft, Table: "comments", LeftTable: "posts", LeftColumn: "id", RightColumn: "post_id"},
+	}
+
+	// comments.post_id has no index, so comments is scanned once however many posts there are
+	before := db.Metrics()
+	results, err := db.Join("posts", steps, nil, []string{"posts.id", "comments.id"})
+	if err != nil {
+		t.Fatalf("Join failed: %v", err)
+	}
+	after := db.Metrics()
+
+	want := []engine.Row{
+		{"posts.id": 10, "comments.id": 101},
+		{"posts.id": 10, "comments.id": 102},
+		{"posts.id": 11, "comments.id": nil},
+		{"posts.id": 12, "comments.id": 103},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("Expected %v, got %v", want, results)
+	}
+	if scans := after.FullScans - before.FullScans; scans != 2 {
+		t.Errorf("Expected one scan of each table, got %d", scans)
+	}
+	if scanned := after.RowsScanned - before.RowsScanned; scanned != 3+4 {
+		t.Errorf("Expected 7 rows scanned, got %d", scanned)
+	}
+
+	plan, err := db.ExplainJoins("posts", steps, nil, nil)
+	if err != nil {
+		t.Fatalf("ExplainJoins failed: %v", err)
+	}
+	if !strings.Contains(plan.String(), "HashJoin(LEFT posts.id = comments.post_id)") {
+		t.Errorf("Expected a hash join, got %s", plan.String())
+	}
+}
+
+const benchmarkJoinRows = 5000
+
+func benchmarkJoin(b *testing.B, indexed bool) {
+	db := engine.NewDatabase()
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "group_id", Type: engine.TypeInt},
+	})
+	db.CreateTable("groups", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "code", Type: engine.TypeInt},
+	})
+	for i := 0; i < benchmarkJoinRows; i++ {
+		db.Insert("users", engine.Row{"id": i, "group_id": (i * 7919) % benchmarkJoinRows})
+		db.Insert("groups", engine.Row{"id": i, "code": i})
+	}
+	if indexed {
+		table, _ := db.GetTable("groups")
+		table.CreateIndex("code")
+	}
+	condition := engine.JoinCondition{LeftColumn: "group_id", RightColumn: "code"}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		results, _ := db.InnerJoin("users", "groups", condition, nil)
+		if len(results) != benchmarkJoinRows {
+			b.Fatalf("Expected %d rows, got %d", benchmarkJoinRows, len(results))
+		}
+	}
+}
+
+func BenchmarkJoinIndexedNestedLoop(b *testing.B) {
+	benchmarkJoin(b, true)
+}
+
+func BenchmarkJoinHash(b *testing.B) {
+	benchmarkJoin(b, false)
+}
+
+func TestRightAndFullJoin(t *testing.T) {
+	db := setupBlog(t)
+	db.Insert("users", engine.Row{"id": 3, "name": "Cy"})  // No posts
+	db.Insert("posts", engine.Row{"id": 13, "user_id": 9}) // No user
+	condition := engine.JoinCondition{LeftColumn: "user_id", RightColumn: "id"}
+	columns := []string{"posts.id", "users.name"}
+
+	// users.id is the primary key, so the right side is probed through its index
+	right, err := db.RightJoin("posts", "users", condition, columns)
+	if err != nil {
+		t.Fatalf("RightJoin failed: %v", err)
+	}
+	want := []engine.Row{
+		{"posts.id": 10, "users.name": "moses"},
+		{"posts.id": 11, "users.name": "Bob"},
+		{"posts.id": 12, "users.name": "moses"},
+		{"posts.id": nil, "users.name": "Cy"},
+	}
+	if !reflect.DeepEqual(right, want) {
+		t.Errorf("RIGHT: expected %v, got %v", want, right)
+	}
+
+	full, err := db.FullJoin("posts", "users", condition, columns)
+	if err != nil {
+		t.Fatalf("FullJoin failed: %v", err)
+	}
+	want = []engine.Row{
+		{"posts.id": 10, "users.name": "moses"},
+		{"posts.id": 11, "users.name": "Bob"},
+		{"posts.id": 12, "users.name": "moses"},
+		{"posts.id": 13, "users.name": nil},
+		{"posts.id": nil, "users.name": "Cy"},
+	}
+	if !reflect.DeepEqual(full, want) {
+		t.Errorf("FULL: expected %v, got %v", want, full)
+	}
+
+	// Unmatched rows of a hash-joined table are kept too, with every earlier
+	// table's columns set to nil
+	db.Insert("comments", engine.Row{"id": 104, "post_id": 99, "body": "orphan"})
+	steps := []engine.JoinStep{
+		{Type: engine.JoinInner, Table: "users", LeftTable: "posts", LeftColumn: "user_id", RightColumn: "id"},
+		{Type: engine.JoinFull, Table: "comments", LeftTable: "posts", LeftColumn: "id", RightColumn: "post_id"},
+	}
+	results, err := db.Join("posts", steps, nil, []string{"posts.id", "comments.id"})
+	if err != nil {
+		t.Fatalf("Join failed: %v", err)
+	}
+	want = []engine.Row{
+		{"posts.id": 10, "comments.id": 101},
+		{"posts.id": 10, "comments.id": 102},
+		{"posts.id": 11, "comments.id": nil},
+		{"posts.id": 12, "comments.id": 103},
+		{"posts.id": nil, "comments.id": 104},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("Chain: expected %v, got %v", want, results)
+	}
+	all, _ := db.Join("posts", steps, nil, nil)
+	if last := all[len(all)-1]; len(last) != 7 || last["users.name"] != nil || last["comments.body"] != "orphan" {
+		t.Errorf("Expected the orphan comment with nil post and user columns, got %v", last)
+	}
+}
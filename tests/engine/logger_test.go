@@ -0,0 +1,76 @@
+package engine_test
+
+import (
+	"bytes"
+	"godb/engine"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupLoggedUsers(t *testing.T, level engine.LogLevel) (*engine.Database, *bytes.Buffer) {
+	var buf bytes.Buffer
+	db := engine.NewDatabase()
+	db.SetLogger(engine.NewStdLogger(&buf, level))
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "name", Type: engine.TypeString},
+	}
+	if err := db.CreateTable("users", schema); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	db.Insert("users", engine.Row{"id": 1, "name": "moses"})
+
+	return db, &buf
+}
+
+func TestSlowQueryLoggedAtDebug(t *testing.T) {
+	db, buf := setupLoggedUsers(t, engine.LevelDebug)
+
+	// Every query counts as slow
+	db.SetSlowQueryThreshold(time.Nanosecond)
+
+	if _, err := db.Select("users", nil, nil); err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "[DEBUG] slow query") || !strings.Contains(out, "SELECT from users") {
+		t.Errorf("Expected slow query log, got %q", out)
+	}
+}
+
+func TestSlowQueryNotLoggedAboveDebug(t *testing.T) {
+	db, buf := setupLoggedUsers(t, engine.LevelInfo)
+	db.SetSlowQueryThreshold(time.Nanosecond)
+
+	db.Select("users", nil, nil)
+
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output at INFO level, got %q", buf.String())
+	}
+}
+
+func TestSlowQueryThresholdDisabled(t *testing.T) {
+	db, buf := setupLoggedUsers(t, engine.LevelDebug)
+	db.SetSlowQueryThreshold(0)
+
+	db.Select("users", nil, nil)
+
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output with threshold disabled, got %q", buf.String())
+	}
+}
+
+func TestConstraintViolationLogged(t *testing.T) {
+	db, buf := setupLoggedUsers(t, engine.LevelDebug)
+
+	if err := db.Insert("users", engine.Row{"id": 1, "name": "dup"}); err == nil {
+		t.Fatal("Expected primary key violation")
+	}
+
+	if !strings.Contains(buf.String(), "constraint violation on table 'users'") {
+		t.Errorf("Expected constraint violation log, got %q", buf.String())
+	}
+}
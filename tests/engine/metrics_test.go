@@ -0,0 +1,79 @@
+package engine_test
+
+import (
+	"bytes"
+	"godb/engine"
+	"strings"
+	"testing"
+)
+
+func TestMetricsCounters(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "name", Type: engine.TypeString},
+	}
+	db.CreateTable("users", schema)
+
+	if m := db.Metrics(); m != (engine.Metrics{}) {
+		t.Fatalf("Expected zero metrics for a new database, got %+v", m)
+	}
+
+	db.Insert("users", engine.Row{"id": 1, "name": "moses"})
+	db.Insert("users", engine.Row{"id": 2, "name": "Bob"})
+	db.InsertMany("users", []engine.Row{{"id": 3, "name": "Carol"}, {"id": 4, "name": "Dan"}})
+	db.Insert("users", engine.Row{"id": 1, "name": "dup"}) // violation
+
+	db.Select("users", nil, &engine.Condition{Column: "id", Operator: "=", Value: 2})       // index hit, 1 row
+	db.Select("users", nil, &engine.Condition{Column: "name", Operator: "=", Value: "Bob"}) // full scan, 4 rows
+	db.Update("users", engine.Row{"name": "Robert"}, &engine.Condition{Column: "id", Operator: "=", Value: 2})
+	db.Delete("users", &engine.Condition{Column: "id", Operator: "=", Value: 4})
+
+	m := db.Metrics()
+	want := engine.Metrics{
+		Selects:              2,
+		Inserts:              4,
+		Updates:              1,
+		Deletes:              1,
+		ConstraintViolations: 1,
+		RowsScanned:          1 + 4 + 4 + 4, // index lookup, scan, update scan, delete scan
+		IndexHits:            1,
+		FullScans:            3,
+	}
+	if m != want {
+		t.Errorf("Unexpected metrics:\n got  %+v\n want %+v", m, want)
+	}
+
+	var buf bytes.Buffer
+	m.WriteTo(&buf)
+	if !strings.Contains(buf.String(), "godb_selects_total 2\n") ||
+		!strings.Contains(buf.String(), "godb_constraint_violations_total 1\n") {
+		t.Errorf("Unexpected text format:\n%s", buf.String())
+	}
+}
+
+func TestMetricsJoinIndexHits(t *testing.T) {
+	db := engine.NewDatabase()
+
+	db.CreateTable("users", []engine.Column{{Name: "id", Type: engine.TypeInt, PrimaryKey: true}})
+	db.CreateTable("posts", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "user_id", Type: engine.TypeInt},
+	})
+	db.Insert("users", engine.Row{"id": 1})
+	db.Insert("posts", engine.Row{"id": 1, "user_id": 1})
+	db.Insert("posts", engine.Row{"id": 2, "user_id": 1})
+
+	before := db.Metrics()
+	db.InnerJoin("posts", "users", engine.JoinCondition{LeftColumn: "user_id", RightColumn: "id"}, nil)
+	after := db.Metrics()
+
+	if after.Selects-before.Selects != 1 {
+		t.Errorf("Expected join to count as one select")
+	}
+	// One scan of posts, then one primary key lookup into users per post
+	if after.FullScans-before.FullScans != 1 || after.IndexHits-before.IndexHits != 2 {
+		t.Errorf("Expected 1 full scan and 2 index hits, got %+v", after)
+	}
+}
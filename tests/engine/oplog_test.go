@@ -0,0 +1,140 @@
+package engine_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"godb/engine"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// setupLogSchema creates the tables used by the operation log tests
+func setupLogSchema(t *testing.T) *engine.Database {
+	t.Helper()
+	db := engine.NewDatabase()
+	if err := db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true, AutoIncrement: true},
+		{Name: "email", Type: engine.TypeString, Unique: true},
+		{Name: "age", Type: engine.TypeInt},
+	}); err != nil {
+		t.Fatalf("Failed to create users: %v", err)
+	}
+	if err := db.CreateTable("posts", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "user_id", Type: engine.TypeInt, References: &engine.ForeignKey{Table: "users", Column: "id", OnDeleteCascade: true}},
+	}); err != nil {
+		t.Fatalf("Failed to create posts: %v", err)
+	}
+	return db
+}
+
+func TestOperationLogReplay(t *testing.T) {
+	db := setupLogSchema(t)
+	var log bytes.Buffer
+	db.SetOperationLog(&log)
+
+	db.Insert("users", engine.Row{"email": "ada@example.com", "age": 36})
+	db.InsertMany("users", []engine.Row{{"email": "bob@example.com", "age": 41}, {"email": "cy@example.com"}})
+	db.BulkInsert("users", []engine.Row{{"email": "dee@example.com", "age": 20}})
+	db.InsertValues("posts", []interface{}{1, 1})
+	db.InsertManyValues("posts", [][]interface{}{{2, 2}, {3, 2}, {4, 3}})
+
+	// Rejected statements change nothing and log nothing
+	if err := db.Insert("users", engine.Row{"id": 10, "email": "ada@example.com"}); err == nil {
+		t.Fatal("Expected unique violation")
+	}
+
+	db.Update("users", engine.Row{"age": 50}, &engine.Condition{Column: "age", Operator: ">", Value: 30})
+	// Updates applied before a failure are kept, and logged
+	if _, err := db.Update("users", engine.Row{"email": "same@example.com"}, nil); err == nil {
+		t.Fatal("Expected unique violation")
+	}
+	db.Delete("users", &engine.Condition{Column: "id", Operator: "=", Value: 2}) // Cascades to posts 2 and 3
+	db.DeleteByKeys("posts", []interface{}{4})
+	db.Truncate("posts")
+	db.Insert("posts", engine.Row{"id": 5, "user_id": 4})
+
+	db.SetOperationLog(nil)
+	db.Insert("users", engine.Row{"email": "unlogged@example.com"})
+	db.Delete("users", &engine.Condition{Column: "email", Operator: "=", Value: "unlogged@example.com"})
+
+	replayed := setupLogSchema(t)
+	applied, err := replayed.Replay(bytes.NewReader(log.Bytes()))
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if lines := strings.Count(log.String(), "\n"); applied != lines {
+		t.Errorf("Expected %d operations applied, got %d", lines, applied)
+	}
+
+	for _, name := range []string{"users", "posts"} {
+		want, _ := db.Select(name, nil, nil)
+		got, _ := replayed.Select(name, nil, nil)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+
+	// Indexes and the auto-increment counter are rebuilt as well
+	rows, _ := replayed.Select("users", nil, &engine.Condition{Column: "email", Operator: "=", Value: "same@example.com"})
+	if len(rows) != 1 {
+		t.Errorf("Expected the unique index to find the updated row, got %v", rows)
+	}
+	id, err := replayed.InsertWithKey("users", engine.Row{"email": "eve@example.com"})
+	if err != nil || id != 5 {
+		t.Errorf("Expected the next id to be 5, got %v, %v", id, err)
+	}
+}
+
+func TestOperationLogEntries(t *testing.T) {
+	db := setupLogSchema(t)
+	var log bytes.Buffer
+	db.SetOperationLog(&log)
+
+	db.Insert("users", engine.Row{"email": "ada@example.com"})
+	db.Update("users", engine.Row{"age": 36}, nil)
+	db.Delete("users", nil)
+
+	var ops []engine.Operation
+	decoder := json.NewDecoder(&log)
+	for decoder.More() {
+		var op engine.Operation
+		if err := decoder.Decode(&op); err != nil {
+			t.Fatalf("Invalid log entry: %v", err)
+		}
+		ops = append(ops, op)
+	}
+
+	if len(ops) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(ops))
+	}
+	if ops[0].Op != "insert" || ops[0].Table != "users" || ops[0].Rows[0]["email"] != "ada@example.com" {
+		t.Errorf("Expected the stored row to be logged, got %+v", ops[0])
+	}
+	if ops[1].Op != "update" || !reflect.DeepEqual(ops[1].Positions, []int{0}) {
+		t.Errorf("Expected an update of position 0, got %+v", ops[1])
+	}
+	if ops[2].Op != "delete" || !reflect.DeepEqual(ops[2].Positions, []int{0}) {
+		t.Errorf("Expected a delete of position 0, got %+v", ops[2])
+	}
+}
+
+func TestReplayRejectsInvalidEntries(t *testing.T) {
+	tests := []struct {
+		log  string
+		want string
+	}{
+		{`{"op": "insert", "table": "missing", "rows": [{"id": 1}]}`, "entry 1: table 'missing' does not exist"},
+		{`{"op": "insert", "table": "posts", "rows": [{"id": 1}]}` + "\n" + `{"op": "delete", "table": "posts", "positions": [3]}`, "entry 2: table 'posts' has no row at position 3"},
+		{`{"op": "insert", "table": "posts", "rows": [{"title": "x"}]}`, "entry 1: column 'title' does not exist"},
+		{`{"op": "merge", "table": "posts"}`, `entry 1: unknown operation "merge"`},
+		{`not json`, "entry 1: invalid character"},
+	}
+	for _, tt := range tests {
+		_, err := setupLogSchema(t).Replay(strings.NewReader(tt.log))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.log, tt.want, err)
+		}
+	}
+}
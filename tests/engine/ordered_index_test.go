@@ -0,0 +1,145 @@
+package engine_test
+
+import (
+	"fmt"
+	"godb/engine"
+	"testing"
+)
+
+func setupAges(t testing.TB, db *engine.Database, name string, ages []interface{}) *engine.Table {
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "age", Type: engine.TypeInt},
+	}
+	if err := db.CreateTable(name, schema); err != nil {
+		t.Fatalf("Failed to create %s: %v", name, err)
+	}
+	for i, age := range ages {
+		if err := db.Insert(name, engine.Row{"id": i + 1, "age": age}); err != nil {
+			t.Fatalf("Failed to insert: %v", err)
+		}
+	}
+	table, _ := db.GetTable(name)
+	return table
+}
+
+func ids(rows []engine.Row) []interface{} {
+	result := make([]interface{}, len(rows))
+	for i, row := range rows {
+		result[i], _ = row.Get("id")
+	}
+	return result
+}
+
+func TestOrderedIndexMatchesFullScan(t *testing.T) {
+	db := engine.NewDatabase()
+	ages := []interface{}{30, 17, nil, 42, 30, 25, 64, 17}
+
+	setupAges(t, db, "scanned", ages)
+	indexed := setupAges(t, db, "indexed", ages)
+	if err := indexed.CreateOrderedIndex("age"); err != nil {
+		t.Fatalf("CreateOrderedIndex failed: %v", err)
+	}
+
+	for _, op := range []string{">", ">=", "<", "<="} {
+		for _, bound := range []int{0, 17, 29, 30, 64, 100} {
+			condition := &engine.Condition{Column: "age", Operator: op, Value: bound}
+			want, _ := db.Select("scanned", nil, condition)
+			got, err := db.Select("indexed", nil, condition)
+			if err != nil {
+				t.Fatalf("Select failed: %v", err)
+			}
+			if fmt.Sprint(ids(got)) != fmt.Sprint(ids(want)) {
+				t.Errorf("age %s %d: expected ids %v, got %v", op, bound, ids(want), ids(got))
+			}
+		}
+	}
+}
+
+func TestOrderedIndexUsedByPlanner(t *testing.T) {
+	db := engine.NewDatabase()
+	table := setupAges(t, db, "users", []interface{}{30, 17, 42})
+
+	query := engine.Query{Table: "users", Condition: &engine.Condition{Column: "age", Operator: ">", Value: 20}}
+	plan, _ := db.Explain(query)
+	if op := plan.AccessPath().Op; op != "FullScan" {
+		t.Errorf("Expected FullScan without an ordered index, got %s", op)
+	}
+
+	table.CreateOrderedIndex("age")
+
+	plan, _ = db.Explain(query)
+	scan := plan.AccessPath()
+	if scan.Op != "RangeScan" || scan.Detail != "users.age > 20" || scan.EstimatedRows != 2 {
+		t.Errorf("Expected RangeScan(users.age > 20) rows=2, got %s rows=%d", scan, scan.EstimatedRows)
+	}
+
+	// An equality predicate on a hash index is still preferred
+	query.Condition = engine.And(query.Condition, &engine.Condition{Column: "id", Operator: "=", Value: 3})
+	plan, _ = db.Explain(query)
+	if op := plan.AccessPath().Op; op != "IndexScan" {
+		t.Errorf("Expected IndexScan for the primary key, got %s", op)
+	}
+}
+
+func TestOrderedIndexTracksWrites(t *testing.T) {
+	db := engine.NewDatabase()
+	table := setupAges(t, db, "users", nil)
+	table.CreateOrderedIndex("age")
+
+	for i, age := range []int{10, 20, 30, 40, 50} {
+		db.Insert("users", engine.Row{"id": i + 1, "age": age})
+	}
+	db.Update("users", engine.Row{"age": 5}, &engine.Condition{Column: "id", Operator: "=", Value: 5})
+	db.Delete("users", &engine.Condition{Column: "id", Operator: "=", Value: 2})
+
+	results, err := db.Select("users", nil, &engine.Condition{Column: "age", Operator: ">=", Value: 10})
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	if got := fmt.Sprint(ids(results)); got != "[1 3 4]" {
+		t.Errorf("Expected ids [1 3 4], got %s", got)
+	}
+
+	results, _ = db.Select("users", nil, &engine.Condition{Column: "age", Operator: "<", Value: 10})
+	if got := fmt.Sprint(ids(results)); got != "[5]" {
+		t.Errorf("Expected ids [5], got %s", got)
+	}
+
+	if err := table.CreateOrderedIndex("missing"); err == nil {
+		t.Error("Expected error for unknown column")
+	}
+}
+
+const benchmarkRows = 100000
+
+func benchmarkRange(b *testing.B, ordered bool) {
+	db := engine.NewDatabase()
+	ages := make([]interface{}, benchmarkRows)
+	for i := range ages {
+		ages[i] = (i * 7919) % benchmarkRows // Scattered, distinct values
+	}
+	table := setupAges(b, db, "users", ages)
+	if ordered {
+		table.CreateOrderedIndex("age")
+	}
+
+	// Matches 1% of the rows
+	condition := &engine.Condition{Column: "age", Operator: ">=", Value: benchmarkRows - benchmarkRows/100}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		results, _ := db.Select("users", nil, condition)
+		if len(results) != benchmarkRows/100 {
+			b.Fatalf("Expected %d rows, got %d", benchmarkRows/100, len(results))
+		}
+	}
+}
+
+func BenchmarkRangeFullScan(b *testing.B) {
+	benchmarkRange(b, false)
+}
+
+func BenchmarkRangeOrderedIndex(b *testing.B) {
+	benchmarkRange(b, true)
+}
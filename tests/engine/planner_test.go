@@ -0,0 +1,161 @@
+package engine_test
+
+import (
+	"fmt"
+	"godb/engine"
+	"strings"
+	"testing"
+)
+
+func setupPlannerUsers(t *testing.T) *engine.Database {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "status", Type: engine.TypeString},
+		{Name: "email", Type: engine.TypeString, Unique: true},
+	}
+	db.CreateTable("users", schema)
+
+	table, _ := db.GetTable("users")
+	table.CreateIndex("status")
+
+	for i := 0; i < 100; i++ {
+		status := "active"
+		if i%2 == 0 {
+			status = "inactive"
+		}
+		db.Insert("users", engine.Row{
+			"id":     i,
+			"status": status,
+			"email":  fmt.Sprintf("user%d@example.com", i),
+		})
+	}
+
+	return db
+}
+
+func TestAnalyzePicksHigherCardinalityIndex(t *testing.T) {
+	db := setupPlannerUsers(t)
+
+	condition := engine.And(
+		&engine.Condition{Column: "status", Operator: "=", Value: "active"},
+		&engine.Condition{Column: "email", Operator: "=", Value: "user7@example.com"},
+	)
+	query := engine.Query{Table: "users", Condition: condition}
+
+	// Without statistics the first indexed predicate is used
+	plan, err := db.Explain(query)
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	if !strings.Contains(plan.AccessPath().Detail, "users.status") {
+		t.Errorf("Expected status index before ANALYZE, got %s", plan.AccessPath())
+	}
+
+	if err := db.Analyze("users"); err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+
+	table, _ := db.GetTable("users")
+	analysis, ok := table.Analysis()
+	if !ok {
+		t.Fatal("Expected statistics after ANALYZE")
+	}
+	if analysis.Cardinality["status"] != 2 || analysis.Cardinality["email"] != 100 {
+		t.Errorf("Unexpected cardinality: %v", analysis.Cardinality)
+	}
+
+	// With statistics the more selective email index wins
+	plan, _ = db.Explain(query)
+	access := plan.AccessPath()
+	if access.Op != "IndexScan" || !strings.Contains(access.Detail, "users.email") {
+		t.Errorf("Expected email index after ANALYZE, got %s", access)
+	}
+	if access.EstimatedRows != 1 {
+		t.Errorf("Expected 1 estimated row, got %d", access.EstimatedRows)
+	}
+
+	// Results are the same either way
+	results, err := db.Query(query)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(results) != 1 || results[0]["id"] != 7 {
+		t.Errorf("Expected user 7, got %v", results)
+	}
+}
+
+func TestExplainFullScanForOr(t *testing.T) {
+	db := setupPlannerUsers(t)
+
+	// Neither side of an OR must hold on its own, so no index applies
+	condition := engine.Or(
+		&engine.Condition{Column: "email", Operator: "=", Value: "user1@example.com"},
+		&engine.Condition{Column: "email", Operator: "=", Value: "user2@example.com"},
+	)
+	query := engine.Query{Table: "users", Condition: condition}
+
+	plan, err := db.Explain(query)
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	if plan.AccessPath().Op != "FullScan" {
+		t.Errorf("Expected FullScan, got %s", plan.AccessPath())
+	}
+
+	results, _ := db.Query(query)
+	if len(results) != 2 {
+		t.Errorf("Expected 2 rows, got %d", len(results))
+	}
+}
+
+func TestPlanTreeStringFilteredAggregate(t *testing.T) {
+	db := setupPlannerUsers(t)
+
+	plan, err := db.Explain(engine.Query{
+		Table:      "users",
+		Condition:  &engine.Condition{Column: "email", Operator: "=", Value: "user7@example.com"},
+		Aggregates: []engine.Aggregate{{Func: "count", Column: "*"}},
+	})
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+
+	expected := "Aggregate(COUNT(*)) rows=1\n" +
+		"└─ Filter(email = 'user7@example.com') rows=1\n" +
+		"   └─ IndexScan(users.email = 'user7@example.com') rows=1\n"
+	if got := plan.TreeString(); got != expected {
+		t.Errorf("Unexpected tree:\n%s\nexpected:\n%s", got, expected)
+	}
+}
+
+func TestPlanTreeStringJoin(t *testing.T) {
+	db := setupPlannerUsers(t)
+
+	db.CreateTable("posts", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "user_id", Type: engine.TypeInt},
+	})
+	db.Insert("posts", engine.Row{"id": 1, "user_id": 3})
+	db.Insert("posts", engine.Row{"id": 2, "user_id": 4})
+
+	plan, err := db.ExplainJoin("posts", "users",
+		engine.JoinCondition{LeftColumn: "user_id", RightColumn: "id"}, engine.JoinInner, nil)
+	if err != nil {
+		t.Fatalf("ExplainJoin failed: %v", err)
+	}
+
+	// Both join inputs hang off the join node, the last one with a closing branch
+	expected := "Project(*) rows=2\n" +
+		"└─ NestedLoopJoin(INNER posts.user_id = users.id) rows=2\n" +
+		"   ├─ FullScan(posts) rows=2\n" +
+		"   └─ IndexLookup(users.id) rows=1\n"
+	if got := plan.TreeString(); got != expected {
+		t.Errorf("Unexpected tree:\n%s\nexpected:\n%s", got, expected)
+	}
+
+	if !strings.Contains(plan.String(), "Project(*) -> NestedLoopJoin") {
+		t.Errorf("Flat rendering changed: %s", plan.String())
+	}
+}
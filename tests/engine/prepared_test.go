@@ -0,0 +1,131 @@
+package engine_test
+
+import (
+	"errors"
+	"godb/engine"
+	"testing"
+)
+
+// setupPreparedUsers creates a users table for the prepared statement tests
+func setupPreparedUsers(t *testing.T) *engine.Database {
+	t.Helper()
+	db := engine.NewDatabase()
+	if err := db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "name", Type: engine.TypeString},
+		{Name: "age", Type: engine.TypeInt},
+	}); err != nil {
+		t.Fatalf("Failed to create users: %v", err)
+	}
+	db.Insert("users", engine.Row{"id": 1, "name": "ada", "age": 36})
+	db.Insert("users", engine.Row{"id": 2, "name": "bob", "age": 41})
+	db.Insert("users", engine.Row{"id": 3, "name": "cy", "age": 52})
+	return db
+}
+
+func TestExecutePreparedBindsIntParam(t *testing.T) {
+	db := setupPreparedUsers(t)
+	query := engine.Query{
+		Table:     "users",
+		Columns:   []string{"name"},
+		Condition: &engine.Condition{Column: "id", Operator: "=", Value: engine.Param{Index: 0}},
+	}
+
+	// The same query runs with different arguments
+	for id, name := range map[int]string{1: "ada", 3: "cy"} {
+		rows, err := db.ExecutePrepared(query, id)
+		if err != nil {
+			t.Fatalf("ExecutePrepared(%d) failed: %v", id, err)
+		}
+		if len(rows) != 1 || rows[0]["name"] != name {
+			t.Errorf("id %d: expected %s, got %v", id, name, rows)
+		}
+	}
+	if query.Condition.Value != (engine.Param{Index: 0}) {
+		t.Errorf("Expected the prepared query to keep its placeholder, got %v", query.Condition.Value)
+	}
+}
+
+func TestExecutePreparedBindsStringParams(t *testing.T) {
+	db := setupPreparedUsers(t)
+
+	// name = ? OR (name LIKE ? AND age BETWEEN ? AND ?)
+	query := engine.Query{
+		Table:   "users",
+		Columns: []string{"id"},
+		Condition: engine.Or(
+			&engine.Condition{Column: "name", Operator: "=", Value: engine.Param{Index: 0}},
+			engine.And(
+				&engine.Condition{Column: "name", Operator: "LIKE", Value: engine.Param{Index: 1}},
+				&engine.Condition{Column: "age", Operator: "BETWEEN", Value: engine.Param{Index: 2}, High: engine.Param{Index: 3}},
+			),
+		),
+	}
+	rows, err := db.ExecutePrepared(query, "ada", "c%", 50, 60)
+	if err != nil {
+		t.Fatalf("ExecutePrepared failed: %v", err)
+	}
+	if len(rows) != 2 || rows[0]["id"] != 1 || rows[1]["id"] != 3 {
+		t.Errorf("Expected ids 1 and 3, got %v", rows)
+	}
+
+	// Quotes in a string argument are data, not SQL
+	byName := engine.Query{Table: "users", Condition: &engine.Condition{Column: "name", Operator: "=", Value: engine.Param{Index: 0}}}
+	rows, err = db.ExecutePrepared(byName, "x' OR '1' = '1")
+	if err != nil || len(rows) != 0 {
+		t.Errorf("Expected no rows, got %v, %v", rows, err)
+	}
+}
+
+func TestExecutePreparedChecksArguments(t *testing.T) {
+	db := setupPreparedUsers(t)
+	query := engine.Query{Table: "users", Condition: engine.And(
+		&engine.Condition{Column: "id", Operator: "=", Value: engine.Param{Index: 0}},
+		&engine.Condition{Column: "name", Operator: "=", Value: engine.Param{Index: 1}},
+	)}
+
+	var count engine.ErrParamCount
+	if _, err := db.ExecutePrepared(query, 1); !errors.As(err, &count) || count.Expected != 2 || count.Got != 1 {
+		t.Errorf("Expected ErrParamCount 2/1, got %v", err)
+	}
+
+	var invalid engine.ErrInvalidValue
+	if _, err := db.ExecutePrepared(query, "1", "ada"); !errors.As(err, &invalid) || invalid.Column != "id" {
+		t.Errorf("Expected ErrInvalidValue for id, got %v", err)
+	}
+	if _, err := db.ExecutePrepared(query, 1, 2); !errors.As(err, &invalid) || invalid.Column != "name" {
+		t.Errorf("Expected ErrInvalidValue for name, got %v", err)
+	}
+
+	// NULL is accepted for any column and, under SQL comparison rules, matches nothing
+	rows, err := db.ExecutePrepared(query, nil, "ada")
+	if err != nil || len(rows) != 0 {
+		t.Errorf("Expected no rows for a NULL argument, got %v, %v", rows, err)
+	}
+}
+
+func TestBindParamsForJoin(t *testing.T) {
+	db := setupPreparedUsers(t)
+	db.CreateTable("posts", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "user_id", Type: engine.TypeInt},
+		{Name: "title", Type: engine.TypeString},
+	})
+	db.Insert("posts", engine.Row{"id": 1, "user_id": 2, "title": "hello"})
+
+	condition := &engine.Condition{Column: "posts.title", Operator: "=", Value: engine.Param{Index: 0}}
+	steps := []engine.JoinStep{{Type: engine.JoinInner, Table: "posts", LeftTable: "users", LeftColumn: "id", RightColumn: "user_id"}}
+
+	bound, err := db.BindParams([]string{"users", "posts"}, condition, []interface{}{"hello"})
+	if err != nil {
+		t.Fatalf("BindParams failed: %v", err)
+	}
+	rows, _ := db.Join("users", steps, bound, nil)
+	if len(rows) != 1 || rows[0]["users.name"] != "bob" {
+		t.Errorf("Expected bob's post, got %v", rows)
+	}
+
+	if _, err := db.BindParams([]string{"users", "posts"}, condition, []interface{}{7}); err == nil {
+		t.Error("Expected an int argument for a TEXT column to be rejected")
+	}
+}
@@ -0,0 +1,567 @@
+package engine_test
+
+import (
+	"godb/engine"
+	"reflect"
+	"testing"
+)
+
+func TestDistinctOnLatestPerGroup(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "user_id", Type: engine.TypeInt},
+		{Name: "created", Type: engine.TypeInt},
+	}
+	db.CreateTable("posts", schema)
+
+	// Insert posts for two users in scrambled order
+	rows := []engine.Row{
+		{"id": 1, "user_id": 1, "created": 10},
+		{"id": 2, "user_id": 2, "created": 15},
+		{"id": 3, "user_id": 1, "created": 30},
+		{"id": 4, "user_id": 2, "created": 5},
+		{"id": 5, "user_id": 1, "created": 20},
+	}
+	for _, row := range rows {
+		db.Insert("posts", row)
+	}
+
+	// Latest post per user
+	results, err := db.Query(engine.Query{
+		Table:      "posts",
+		DistinctOn: []string{"user_id"},
+		OrderBy:    []engine.OrderBy{{Column: "created", Desc: true}},
+	})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(results))
+	}
+
+	if results[0]["id"] != 3 {
+		t.Errorf("Expected latest post id 3 first, got %v", results[0]["id"])
+	}
+
+	if results[1]["id"] != 2 {
+		t.Errorf("Expected latest post id 2 second, got %v", results[1]["id"])
+	}
+}
+
+func TestOrderByAscending(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "name", Type: engine.TypeString},
+	}
+	db.CreateTable("users", schema)
+
+	db.Insert("users", engine.Row{"id": 1, "name": "moses"})
+	db.Insert("users", engine.Row{"id": 2, "name": "Bob"})
+	db.Insert("users", engine.Row{"id": 3})
+
+	results, err := db.Query(engine.Query{
+		Table:   "users",
+		OrderBy: []engine.OrderBy{{Column: "name"}},
+	})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	expected := []int{2, 1, 3} // NULL name sorts last
+	for i, id := range expected {
+		if results[i]["id"] != id {
+			t.Errorf("Row %d: expected id %d, got %v", i, id, results[i]["id"])
+		}
+	}
+}
+
+func TestDistinctProjectedValues(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "city", Type: engine.TypeString},
+		{Name: "country", Type: engine.TypeString},
+	}
+	db.CreateTable("users", schema)
+
+	rows := []engine.Row{
+		{"id": 1, "city": "Nairobi", "country": "KE"},
+		{"id": 2, "city": "Mombasa", "country": "KE"},
+		{"id": 3, "city": "Nairobi", "country": "KE"},
+		{"id": 4, "city": "Kampala", "country": "UG"},
+		{"id": 5, "city": nil, "country": "UG"},
+		{"id": 6, "city": nil, "country": "UG"},
+	}
+	for _, row := range rows {
+		db.Insert("users", row)
+	}
+
+	// Duplicates are judged on the projected columns only
+	results, err := db.Query(engine.Query{Table: "users", Columns: []string{"country"}, Distinct: true})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("Expected 2 distinct countries, got %d", len(results))
+	}
+
+	// NULLs are equal to each other for DISTINCT
+	results, _ = db.Query(engine.Query{Table: "users", Columns: []string{"city", "country"}, Distinct: true})
+	if len(results) != 4 {
+		t.Errorf("Expected 4 distinct (city, country) pairs, got %d", len(results))
+	}
+
+	// Composes with ORDER BY, keeping the sorted order
+	results, _ = db.Query(engine.Query{
+		Table:    "users",
+		Columns:  []string{"city"},
+		Distinct: true,
+		OrderBy:  []engine.OrderBy{{Column: "city"}},
+	})
+	want := []interface{}{"Kampala", "Mombasa", "Nairobi", nil}
+	if len(results) != len(want) {
+		t.Fatalf("Expected %d rows, got %d", len(want), len(results))
+	}
+	for i, city := range want {
+		if results[i]["city"] != city {
+			t.Errorf("Row %d: expected %v, got %v", i, city, results[i]["city"])
+		}
+	}
+
+	// SELECT DISTINCT * only removes fully identical rows
+	results, _ = db.Query(engine.Query{Table: "users", Distinct: true})
+	if len(results) != 6 {
+		t.Errorf("Expected all 6 rows to be distinct, got %d", len(results))
+	}
+}
+
+func TestOrderByNullsPlacement(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "score", Type: engine.TypeInt},
+	}
+	db.CreateTable("scores", schema)
+
+	db.Insert("scores", engine.Row{"id": 1, "score": 20})
+	db.Insert("scores", engine.Row{"id": 2})
+	db.Insert("scores", engine.Row{"id": 3, "score": 10})
+	db.Insert("scores", engine.Row{"id": 4})
+	db.Insert("scores", engine.Row{"id": 5, "score": 30})
+
+	tests := []struct {
+		name     string
+		order    engine.OrderBy
+		expected []int
+	}{
+		{"asc default", engine.OrderBy{Column: "score"}, []int{3, 1, 5, 2, 4}},
+		{"asc nulls first", engine.OrderBy{Column: "score", Nulls: engine.NullsFirst}, []int{2, 4, 3, 1, 5}},
+		{"asc nulls last", engine.OrderBy{Column: "score", Nulls: engine.NullsLast}, []int{3, 1, 5, 2, 4}},
+		{"desc default", engine.OrderBy{Column: "score", Desc: true}, []int{2, 4, 5, 1, 3}},
+		{"desc nulls first", engine.OrderBy{Column: "score", Desc: true, Nulls: engine.NullsFirst}, []int{2, 4, 5, 1, 3}},
+		{"desc nulls last", engine.OrderBy{Column: "score", Desc: true, Nulls: engine.NullsLast}, []int{5, 1, 3, 2, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := tt.order
+			results, err := db.Query(engine.Query{Table: "scores", OrderBy: []engine.OrderBy{order}})
+			if err != nil {
+				t.Fatalf("Query failed: %v", err)
+			}
+			for i, id := range tt.expected {
+				if results[i]["id"] != id {
+					t.Errorf("Row %d: expected id %d, got %v", i, id, results[i]["id"])
+				}
+			}
+		})
+	}
+}
+
+func TestOrderByTiesKeepRowidOrder(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "team", Type: engine.TypeString},
+		{Name: "score", Type: engine.TypeInt},
+	}
+	db.CreateTable("players", schema)
+	table, _ := db.GetTable("players")
+	table.CreateIndex("team")
+
+	// Insert in scrambled key order; every row has the same score
+	ids := []int{17, 3, 42, 8, 25, 1, 33, 12, 50, 6, 29, 14, 38, 21, 9, 46}
+	for _, id := range ids {
+		db.Insert("players", engine.Row{"id": id, "team": "red", "score": 7})
+	}
+
+	// Moving rows out of the team and back reorders their index entries
+	for _, id := range []int{17, 42, 25} {
+		cond := &engine.Condition{Column: "id", Operator: "=", Value: id}
+		db.Update("players", engine.Row{"team": "blue"}, cond)
+		db.Update("players", engine.Row{"team": "red"}, cond)
+	}
+
+	queries := []engine.Query{
+		{Table: "players", OrderBy: []engine.OrderBy{{Column: "score"}}},
+		{Table: "players", OrderBy: []engine.OrderBy{{Column: "score", Desc: true}}},
+		{
+			Table:     "players",
+			Condition: &engine.Condition{Column: "team", Operator: "=", Value: "red"},
+			OrderBy:   []engine.OrderBy{{Column: "score"}},
+		},
+	}
+
+	for run := 0; run < 20; run++ {
+		for _, q := range queries {
+			results, err := db.Query(q)
+			if err != nil {
+				t.Fatalf("Query failed: %v", err)
+			}
+			if len(results) != len(ids) {
+				t.Fatalf("Expected %d rows, got %d", len(ids), len(results))
+			}
+			for i, id := range ids {
+				if results[i]["id"] != id {
+					t.Fatalf("Run %d: row %d expected id %d, got %v", run, i, id, results[i]["id"])
+				}
+			}
+		}
+	}
+}
+
+func TestOrderByMultipleKeys(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "name", Type: engine.TypeString},
+		{Name: "age", Type: engine.TypeInt},
+	})
+	db.Insert("users", engine.Row{"id": 1, "name": "dee", "age": 30})
+	db.Insert("users", engine.Row{"id": 2, "name": "ada", "age": 41})
+	db.Insert("users", engine.Row{"id": 3, "name": "cy", "age": 30})
+	db.Insert("users", engine.Row{"id": 4, "name": "bob", "age": 30})
+	db.Insert("users", engine.Row{"id": 5, "name": "eve"})
+
+	tests := []struct {
+		name     string
+		orderBy  []engine.OrderBy
+		expected []int
+	}{
+		{"age desc, name asc", []engine.OrderBy{{Column: "age", Desc: true}, {Column: "name"}}, []int{5, 2, 4, 3, 1}},
+		{"age asc, name desc", []engine.OrderBy{{Column: "age"}, {Column: "name", Desc: true}}, []int{1, 3, 4, 2, 5}},
+		{"age nulls first, name", []engine.OrderBy{{Column: "age", Nulls: engine.NullsFirst}, {Column: "name"}}, []int{5, 4, 3, 1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := db.Query(engine.Query{Table: "users", OrderBy: tt.orderBy})
+			if err != nil {
+				t.Fatalf("Query failed: %v", err)
+			}
+			for i, id := range tt.expected {
+				if results[i]["id"] != id {
+					t.Errorf("Row %d: expected id %d, got %v", i, id, results[i]["id"])
+				}
+			}
+		})
+	}
+
+	_, err := db.Query(engine.Query{Table: "users", OrderBy: []engine.OrderBy{{Column: "age"}, {Column: "missing"}}})
+	if _, ok := err.(engine.ErrColumnNotFound); !ok {
+		t.Errorf("Expected ErrColumnNotFound for the second key, got %v", err)
+	}
+}
+
+func TestOrderByBoolColumn(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "active", Type: engine.TypeBool},
+	})
+	for i, active := range []bool{true, false, true, false, false} {
+		db.Insert("users", engine.Row{"id": i + 1, "active": active})
+	}
+
+	ids := func(rows []engine.Row) []int {
+		result := make([]int, len(rows))
+		for i, row := range rows {
+			result[i] = row["id"].(int)
+		}
+		return result
+	}
+
+	rows, err := db.Query(engine.Query{Table: "users", OrderBy: []engine.OrderBy{{Column: "active"}}})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if got := ids(rows); !reflect.DeepEqual(got, []int{2, 4, 5, 1, 3}) {
+		t.Errorf("Expected false before true, got %v", got)
+	}
+	rows, _ = db.Query(engine.Query{Table: "users", OrderBy: []engine.OrderBy{{Column: "active", Desc: true}}})
+	if got := ids(rows); !reflect.DeepEqual(got, []int{1, 3, 2, 4, 5}) {
+		t.Errorf("Expected true before false, got %v", got)
+	}
+
+	// Range operators order bools the same way, with or without an ordered index
+	table, _ := db.GetTable("users")
+	for _, indexed := range []bool{false, true} {
+		if indexed {
+			table.CreateOrderedIndex("active")
+		}
+		rows, _ = db.Select("users", nil, &engine.Condition{Column: "active", Operator: ">", Value: false})
+		if got := ids(rows); !reflect.DeepEqual(got, []int{1, 3}) {
+			t.Errorf("indexed=%v: expected active > false to return [1 3], got %v", indexed, got)
+		}
+		rows, _ = db.Select("users", nil, &engine.Condition{Column: "active", Operator: "<", Value: true})
+		if got := ids(rows); !reflect.DeepEqual(got, []int{2, 4, 5}) {
+			t.Errorf("indexed=%v: expected active < true to return [2 4 5], got %v", indexed, got)
+		}
+	}
+}
+
+func TestDistinctTreatsMissingAndNullAlike(t *testing.T) {
+	db := engine.NewDatabase()
+
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "city", Type: engine.TypeString},
+	})
+	db.Insert("users", engine.Row{"id": 1, "city": "Nairobi"})
+	db.Insert("users", engine.Row{"id": 2})
+	db.Insert("users", engine.Row{"id": 3, "city": nil})
+	db.Insert("users", engine.Row{"id": 4, "city": "Nairobi"})
+
+	results, err := db.Query(engine.Query{Table: "users", Columns: []string{"city"}, Distinct: true})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected Nairobi and a single NULL, got %v", results)
+	}
+	if value, _ := results[1].Get("city"); value != nil {
+		t.Errorf("Expected the second distinct value to be NULL, got %v", value)
+	}
+}
+
+func TestPreviewUpdateLeavesTableUnchanged(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "name", Type: engine.TypeString},
+		{Name: "age", Type: engine.TypeInt},
+	}
+	db.CreateTable("users", schema)
+	db.Insert("users", engine.Row{"id": 1, "name": "Alice", "age": 30})
+	db.Insert("users", engine.Row{"id": 2, "name": "Bob", "age": 17})
+	db.Insert("users", engine.Row{"id": 3, "name": "Carol", "age": 40})
+
+	condition := &engine.Condition{Column: "age", Operator: ">", Value: 18}
+	changes, err := db.PreviewUpdate("users", engine.Row{"age": 40}, condition)
+	if err != nil {
+		t.Fatalf("PreviewUpdate failed: %v", err)
+	}
+
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 matched rows, got %d", len(changes))
+	}
+	if before, _ := changes[0].Before.Get("age"); before != 30 {
+		t.Errorf("Expected age 30 before, got %v", before)
+	}
+	if after, _ := changes[0].After.Get("age"); after != 40 {
+		t.Errorf("Expected age 40 after, got %v", after)
+	}
+
+	columns := []string{"id", "name", "age"}
+	if changed := changes[0].Changed(columns); len(changed) != 1 || changed[0] != "age" {
+		t.Errorf("Expected only age to change for Alice, got %v", changed)
+	}
+	if changed := changes[1].Changed(columns); len(changed) != 0 {
+		t.Errorf("Expected no changes for Carol, got %v", changed)
+	}
+
+	results, _ := db.Select("users", nil, &engine.Condition{Column: "id", Operator: "=", Value: 1})
+	if age, _ := results[0].Get("age"); age != 30 {
+		t.Errorf("Expected preview to leave age at 30, got %v", age)
+	}
+
+	if _, err := db.PreviewUpdate("users", engine.Row{"missing": 1}, nil); err == nil {
+		t.Error("Expected error for unknown column")
+	}
+}
+
+func TestQueryAliases(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "name", Type: engine.TypeString},
+		{Name: "region", Type: engine.TypeString},
+	}
+	db.CreateTable("users", schema)
+	db.Insert("users", engine.Row{"id": 1, "name": "Alice", "region": "eu"})
+	db.Insert("users", engine.Row{"id": 2, "name": "Bob", "region": "us"})
+	db.Insert("users", engine.Row{"id": 3, "name": "Carol", "region": "eu"})
+
+	results, err := db.Query(engine.Query{
+		Table:   "users",
+		Columns: []string{"id", "name"},
+		Aliases: map[string]string{"name": "full_name"},
+		OrderBy: []engine.OrderBy{{Column: "name", Desc: true}},
+	})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if name, _ := results[0].Get("full_name"); name != "Carol" {
+		t.Errorf("Expected full_name Carol first, got %v", results[0])
+	}
+	if _, ok := results[0].Get("name"); ok {
+		t.Errorf("Expected name to be renamed, got %v", results[0])
+	}
+
+	// Aliases that swap names don't clobber each other
+	results, _ = db.Query(engine.Query{
+		Table:   "users",
+		Columns: []string{"name", "region"},
+		Aliases: map[string]string{"name": "region", "region": "name"},
+	})
+	if region, _ := results[0].Get("region"); region != "Alice" {
+		t.Errorf("Expected region to hold the name, got %v", results[0])
+	}
+
+	results, err = db.Query(engine.Query{
+		Table:      "users",
+		Columns:    []string{"region"},
+		GroupBy:    []string{"region"},
+		Aggregates: []engine.Aggregate{{Func: "COUNT", Column: "*", Alias: "total"}},
+		Aliases:    map[string]string{"region": "area"},
+		OrderBy:    []engine.OrderBy{{Column: "total", Desc: true}},
+	})
+	if err != nil {
+		t.Fatalf("Grouped query failed: %v", err)
+	}
+	if area, _ := results[0].Get("area"); area != "eu" {
+		t.Errorf("Expected area eu first, got %v", results[0])
+	}
+	if total, _ := results[0].Get("total"); total != 2 {
+		t.Errorf("Expected total 2, got %v", results[0])
+	}
+
+	if _, err := db.Query(engine.Query{Table: "users", Columns: []string{"id", "name"}, Aliases: map[string]string{"name": "id"}}); err == nil {
+		t.Error("Expected error for an alias that duplicates another column")
+	}
+	if _, err := db.Query(engine.Query{Table: "users", Columns: []string{"id"}, Aliases: map[string]string{"name": "n"}}); err == nil {
+		t.Error("Expected error for an alias of an unselected column")
+	}
+}
+
+func TestQualifiedColumnsInSingleTableQuery(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "name", Type: engine.TypeString},
+	}
+	db.CreateTable("users", schema)
+	db.Insert("users", engine.Row{"id": 1, "name": "Alice"})
+	db.Insert("users", engine.Row{"id": 2, "name": "Bob"})
+
+	condition := &engine.Condition{Column: "users.id", Operator: "=", Value: 2}
+	results, err := db.Query(engine.Query{
+		Table:     "users",
+		Columns:   []string{"users.name"},
+		Condition: condition,
+		OrderBy:   []engine.OrderBy{{Column: "users.name"}},
+	})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 row, got %d", len(results))
+	}
+	if name, _ := results[0].Get("name"); name != "Bob" || len(results[0]) != 1 {
+		t.Errorf("Expected only name Bob, got %v", results[0])
+	}
+	if condition.Column != "users.id" {
+		t.Errorf("Expected the caller's condition to be left alone, got %s", condition.Column)
+	}
+
+	plan, _ := db.Explain(engine.Query{Table: "users", Condition: condition})
+	if scan := plan.AccessPath(); scan.Op != "IndexScan" {
+		t.Errorf("Expected the qualified primary key to use the index, got %s", scan)
+	}
+
+	if _, err := db.Query(engine.Query{Table: "users", Columns: []string{"posts.name"}}); err == nil {
+		t.Error("Expected error for a column qualified with another table")
+	}
+}
+
+func TestQueryScalarFunctions(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "name", Type: engine.TypeString},
+	})
+	db.Insert("users", engine.Row{"id": 1, "name": "Zoë"})
+	db.Insert("users", engine.Row{"id": 2, "name": nil})
+
+	q := engine.Query{
+		Table:   "users",
+		Columns: []string{"id", "upper_name", "lower", "length_name"},
+		Functions: map[string]engine.ScalarFunc{
+			"upper_name":  {Func: "UPPER", Column: "name"},
+			"lower":       {Func: "LOWER", Column: "name", Alias: "lower"},
+			"length_name": {Func: "LENGTH", Column: "name"},
+		},
+		OrderBy: []engine.OrderBy{{Column: "id"}},
+	}
+	columns, results, err := db.SelectOrdered(q)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(columns) != 4 || columns[1] != "upper_name" || columns[2] != "lower" {
+		t.Errorf("Expected columns in select order, got %v", columns)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(results))
+	}
+	if results[0]["upper_name"] != "ZOË" || results[0]["lower"] != "zoë" {
+		t.Errorf("Expected ZOË and zoë, got %v", results[0])
+	}
+	if results[0]["length_name"] != 3 {
+		t.Errorf("Expected LENGTH to count characters, got %v", results[0]["length_name"])
+	}
+	if _, ok := results[0]["name"]; ok {
+		t.Error("Expected the function argument not to be projected")
+	}
+	if results[1]["upper_name"] != nil || results[1]["length_name"] != nil {
+		t.Errorf("Expected NULL to stay NULL, got %v", results[1])
+	}
+
+	_, err = db.Query(engine.Query{
+		Table:     "users",
+		Columns:   []string{"upper_id"},
+		Functions: map[string]engine.ScalarFunc{"upper_id": {Func: "UPPER", Column: "id"}},
+	})
+	if _, ok := err.(engine.ErrInvalidFunction); !ok {
+		t.Errorf("Expected ErrInvalidFunction for UPPER on an INT column, got %v", err)
+	}
+
+	_, err = db.Query(engine.Query{
+		Table:     "users",
+		Columns:   []string{"upper_missing"},
+		Functions: map[string]engine.ScalarFunc{"upper_missing": {Func: "UPPER", Column: "missing"}},
+	})
+	if _, ok := err.(engine.ErrColumnNotFound); !ok {
+		t.Errorf("Expected ErrColumnNotFound, got %v", err)
+	}
+}
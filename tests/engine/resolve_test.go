@@ -0,0 +1,64 @@
+package engine_test
+
+import (
+	"godb/engine"
+	"testing"
+)
+
+func TestResolveColumnsForJoin(t *testing.T) {
+	db := engine.NewDatabase()
+
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "name", Type: engine.TypeString},
+	})
+	db.CreateTable("posts", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "user_id", Type: engine.TypeInt},
+		{Name: "title", Type: engine.TypeString},
+	})
+
+	report, err := db.ResolveColumns(
+		[]string{"posts", "users"},
+		[]string{"title", "name", "id", "missing"},
+		[]string{"posts.user_id", "users.id"},
+	)
+	if err != nil {
+		t.Fatalf("ResolveColumns failed: %v", err)
+	}
+
+	if len(report.Columns) != 6 {
+		t.Fatalf("Expected 6 resolved references, got %d", len(report.Columns))
+	}
+
+	byRef := make(map[string]engine.ColumnResolution)
+	for _, res := range report.Columns {
+		byRef[res.Reference] = res
+	}
+
+	if byRef["title"].Table != "posts" {
+		t.Errorf("Expected 'title' to resolve to posts, got '%s'", byRef["title"].Table)
+	}
+	if byRef["name"].Table != "users" {
+		t.Errorf("Expected 'name' to resolve to users, got '%s'", byRef["name"].Table)
+	}
+	if !byRef["id"].Ambiguous || len(byRef["id"].Candidates) != 2 {
+		t.Errorf("Expected 'id' to be ambiguous between both tables, got %+v", byRef["id"])
+	}
+	if byRef["missing"].Resolved() || byRef["missing"].Ambiguous {
+		t.Errorf("Expected 'missing' to be unresolved, got %+v", byRef["missing"])
+	}
+	if byRef["posts.user_id"].Qualified() != "posts.user_id" {
+		t.Errorf("Expected 'posts.user_id' to resolve to itself, got %+v", byRef["posts.user_id"])
+	}
+
+	expectedOutput := []string{"posts.title", "users.name"}
+	if len(report.Output) != len(expectedOutput) {
+		t.Fatalf("Expected output %v, got %v", expectedOutput, report.Output)
+	}
+	for i, col := range expectedOutput {
+		if report.Output[i] != col {
+			t.Errorf("Output column %d: expected '%s', got '%s'", i, col, report.Output[i])
+		}
+	}
+}
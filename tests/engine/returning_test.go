@@ -0,0 +1,100 @@
+package engine_test
+
+import (
+	"godb/engine"
+	"reflect"
+	"testing"
+)
+
+// setupReturningUsers creates a users table with three rows
+func setupReturningUsers(t *testing.T) *engine.Database {
+	t.Helper()
+	db := engine.NewDatabase()
+	if err := db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "email", Type: engine.TypeString, Unique: true},
+		{Name: "age", Type: engine.TypeInt},
+	}); err != nil {
+		t.Fatalf("Failed to create users: %v", err)
+	}
+	db.Insert("users", engine.Row{"id": 1, "email": "ada@example.com", "age": 36})
+	db.Insert("users", engine.Row{"id": 2, "email": "bob@example.com", "age": 41})
+	db.Insert("users", engine.Row{"id": 3, "email": "cy@example.com", "age": 52})
+	return db
+}
+
+func TestUpdateReturning(t *testing.T) {
+	db := setupReturningUsers(t)
+
+	rows, err := db.UpdateReturning("users", engine.Row{"age": 60}, &engine.Condition{Column: "age", Operator: ">", Value: 40})
+	if err != nil {
+		t.Fatalf("UpdateReturning failed: %v", err)
+	}
+	want := []engine.Row{
+		{"id": 2, "email": "bob@example.com", "age": 60},
+		{"id": 3, "email": "cy@example.com", "age": 60},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("Expected %v, got %v", want, rows)
+	}
+
+	// The returned rows are copies
+	rows[0]["age"] = 0
+	stored, _ := db.Select("users", nil, &engine.Condition{Column: "id", Operator: "=", Value: 2})
+	if stored[0]["age"] != 60 {
+		t.Errorf("Expected the stored row to keep age 60, got %v", stored[0]["age"])
+	}
+
+	rows, err = db.UpdateReturning("users", engine.Row{"age": 1}, &engine.Condition{Column: "id", Operator: "=", Value: 99})
+	if err != nil || len(rows) != 0 {
+		t.Errorf("Expected no rows for an unmatched condition, got %v, %v", rows, err)
+	}
+}
+
+func TestUpdateReturningPartialFailure(t *testing.T) {
+	db := setupReturningUsers(t)
+
+	// The second row collides with the first's new email
+	rows, err := db.UpdateReturning("users", engine.Row{"email": "same@example.com"}, nil)
+	if _, ok := err.(engine.ErrUniqueViolation); !ok {
+		t.Fatalf("Expected ErrUniqueViolation, got %v", err)
+	}
+	want := []engine.Row{{"id": 1, "email": "same@example.com", "age": 36}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("Expected the rows updated before the failure, got %v", rows)
+	}
+}
+
+func TestDeleteReturning(t *testing.T) {
+	db := setupReturningUsers(t)
+	db.CreateTable("posts", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "user_id", Type: engine.TypeInt, References: &engine.ForeignKey{Table: "users", Column: "id", OnDeleteCascade: true}},
+	})
+	db.Insert("posts", engine.Row{"id": 1, "user_id": 1})
+
+	rows, err := db.DeleteReturning("users", &engine.Condition{Column: "age", Operator: "<", Value: 50})
+	if err != nil {
+		t.Fatalf("DeleteReturning failed: %v", err)
+	}
+	want := []engine.Row{
+		{"id": 1, "email": "ada@example.com", "age": 36},
+		{"id": 2, "email": "bob@example.com", "age": 41},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("Expected %v, got %v", want, rows)
+	}
+
+	// Cascaded deletes happen but are not returned
+	if n, _ := db.RowCount("posts"); n != 0 {
+		t.Errorf("Expected the cascade to delete the post, got %d rows", n)
+	}
+	if n, _ := db.RowCount("users"); n != 1 {
+		t.Errorf("Expected 1 remaining user, got %d", n)
+	}
+
+	rows, err = db.DeleteReturning("users", &engine.Condition{Column: "id", Operator: "=", Value: 1})
+	if err != nil || len(rows) != 0 {
+		t.Errorf("Expected no rows for an unmatched condition, got %v, %v", rows, err)
+	}
+}
@@ -1,6 +1,7 @@
 package engine_test
 
 import (
+	"fmt"
 	"godb/engine"
 	"testing"
 )
@@ -158,3 +159,571 @@ func TestSelectSpecificColumns(t *testing.T) {
 		t.Error("Did not expect 'email' column to be present")
 	}
 }
+
+func TestSelectModuloEvenOdd(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+	}
+	db.CreateTable("numbers", schema)
+
+	for i := 1; i <= 10; i++ {
+		db.Insert("numbers", engine.Row{"id": i})
+	}
+
+	// Even ids: id % 2 = 0
+	even := &engine.Condition{
+		Column:   "id",
+		Operator: "=",
+		Value:    0,
+		Arith:    &engine.Arithmetic{Operator: "%", Operand: 2},
+	}
+	results, err := db.Select("numbers", nil, even)
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	if len(results) != 5 {
+		t.Errorf("Expected 5 even rows, got %d", len(results))
+	}
+	for _, row := range results {
+		if row["id"].(int)%2 != 0 {
+			t.Errorf("Expected even id, got %v", row["id"])
+		}
+	}
+
+	// Odd ids: id % 2 != 0
+	odd := &engine.Condition{
+		Column:   "id",
+		Operator: "!=",
+		Value:    0,
+		Arith:    &engine.Arithmetic{Operator: "%", Operand: 2},
+	}
+	results, _ = db.Select("numbers", nil, odd)
+	if len(results) != 5 {
+		t.Errorf("Expected 5 odd rows, got %d", len(results))
+	}
+}
+
+func TestSelectNotEqualSpellings(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "age", Type: engine.TypeInt},
+	})
+	db.Insert("users", engine.Row{"id": 1, "age": 30})
+	db.Insert("users", engine.Row{"id": 2, "age": 40})
+	db.Insert("users", engine.Row{"id": 3, "age": nil})
+
+	// <> behaves as !=, so NULLs match neither
+	for _, op := range []string{"!=", "<>"} {
+		results, err := db.Select("users", nil, &engine.Condition{Column: "id", Operator: op, Value: 1})
+		if err != nil || len(results) != 2 {
+			t.Errorf("id %s 1: expected 2 rows, got %v, %v", op, results, err)
+		}
+		results, _ = db.Select("users", nil, &engine.Condition{Column: "age", Operator: op, Value: 30})
+		if len(results) != 1 || results[0]["id"] != 2 {
+			t.Errorf("age %s 30: expected only id 2, got %v", op, results)
+		}
+	}
+}
+
+func TestSelectIntegerDivision(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+	}
+	db.CreateTable("numbers", schema)
+
+	for i := 1; i <= 10; i++ {
+		db.Insert("numbers", engine.Row{"id": i})
+	}
+
+	// id / 4 = 2 matches 8, 9 and 10 with integer division
+	condition := &engine.Condition{
+		Column:   "id",
+		Operator: "=",
+		Value:    2,
+		Arith:    &engine.Arithmetic{Operator: "/", Operand: 4},
+	}
+	results, err := db.Select("numbers", nil, condition)
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Errorf("Expected 3 rows, got %d", len(results))
+	}
+}
+
+func TestSelectLike(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "name", Type: engine.TypeString},
+	}
+	db.CreateTable("users", schema)
+
+	names := []string{"Alice", "Alan", "Bob", "Carla", "Al", "alex"}
+	for i, name := range names {
+		db.Insert("users", engine.Row{"id": i + 1, "name": name})
+	}
+	db.Insert("users", engine.Row{"id": 100, "name": nil})
+
+	tests := []struct {
+		pattern string
+		want    int
+	}{
+		{"A%", 3},    // prefix: Alice, Alan, Al
+		{"%a", 1},    // suffix: Carla
+		{"%l%", 5},   // contains: Alice, Alan, Carla, Al, alex
+		{"A_", 1},    // single char: Al
+		{"_l_n", 1},  // Alan
+		{"B_b", 1},   // Bob
+		{"%", 6},     // everything except NULL
+		{"Alice", 1}, // exact
+		{"A", 0},
+	}
+
+	for _, tt := range tests {
+		condition := &engine.Condition{Column: "name", Operator: "LIKE", Value: tt.pattern}
+		results, err := db.Select("users", nil, condition)
+		if err != nil {
+			t.Fatalf("Select failed: %v", err)
+		}
+		if len(results) != tt.want {
+			t.Errorf("LIKE '%s': expected %d rows, got %d", tt.pattern, tt.want, len(results))
+		}
+	}
+
+	// ILIKE folds case; LIKE does not
+	for _, tt := range []struct {
+		pattern string
+		want    int
+	}{
+		{"a%", 4},    // Alice, Alan, Al, alex
+		{"ALICE", 1}, // case-insensitive equality
+		{"%LA", 1},   // Carla
+		{"b_B", 1},   // Bob
+		{"x%", 0},
+	} {
+		condition := &engine.Condition{Column: "name", Operator: "ILIKE", Value: tt.pattern}
+		results, err := db.Select("users", nil, condition)
+		if err != nil {
+			t.Fatalf("Select failed: %v", err)
+		}
+		if len(results) != tt.want {
+			t.Errorf("ILIKE '%s': expected %d rows, got %d", tt.pattern, tt.want, len(results))
+		}
+	}
+
+	// Non-string columns never match
+	condition := &engine.Condition{Column: "id", Operator: "LIKE", Value: "1%"}
+	results, err := db.Select("users", nil, condition)
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Expected LIKE on INT column to match nothing, got %d rows", len(results))
+	}
+}
+
+func TestSelectBetween(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "age", Type: engine.TypeInt},
+		{Name: "name", Type: engine.TypeString},
+	}
+	db.CreateTable("users", schema)
+
+	ages := []int{10, 17, 18, 30, 65, 66}
+	names := []string{"Ann", "Bea", "Cal", "Dee", "Eve", "Fay"}
+	for i := range ages {
+		db.Insert("users", engine.Row{"id": i + 1, "age": ages[i], "name": names[i]})
+	}
+	db.Insert("users", engine.Row{"id": 100, "age": nil, "name": nil})
+
+	// Both bounds are inclusive
+	condition := &engine.Condition{Column: "age", Operator: "BETWEEN", Value: 18, High: 65}
+	results, err := db.Select("users", nil, condition)
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Errorf("Expected 3 rows (18, 30, 65), got %d", len(results))
+	}
+	for _, row := range results {
+		age := row["age"].(int)
+		if age < 18 || age > 65 {
+			t.Errorf("Unexpected age %d in range", age)
+		}
+	}
+
+	// Strings compare lexicographically
+	condition = &engine.Condition{Column: "name", Operator: "BETWEEN", Value: "Bea", High: "Dee"}
+	results, _ = db.Select("users", nil, condition)
+	if len(results) != 3 {
+		t.Errorf("Expected 3 rows (Bea, Cal, Dee), got %d", len(results))
+	}
+
+	// Mismatched types never match
+	condition = &engine.Condition{Column: "age", Operator: "BETWEEN", Value: "a", High: "z"}
+	results, _ = db.Select("users", nil, condition)
+	if len(results) != 0 {
+		t.Errorf("Expected no rows for mismatched types, got %d", len(results))
+	}
+
+	// Empty range
+	condition = &engine.Condition{Column: "age", Operator: "BETWEEN", Value: 65, High: 18}
+	results, _ = db.Select("users", nil, condition)
+	if len(results) != 0 {
+		t.Errorf("Expected no rows for reversed bounds, got %d", len(results))
+	}
+}
+
+func TestSelectMixedIntFloat(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("items", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "price", Type: engine.TypeInt},
+	})
+	for i, price := range []int{5, 10, 10, 20} {
+		db.Insert("items", engine.Row{"id": i + 1, "price": price})
+	}
+
+	tests := []struct {
+		cond *engine.Condition
+		want int
+	}{
+		{&engine.Condition{Column: "price", Operator: "=", Value: 10.0}, 2},
+		{&engine.Condition{Column: "price", Operator: "!=", Value: 10.0}, 2},
+		{&engine.Condition{Column: "price", Operator: "=", Value: 10.5}, 0},
+		{&engine.Condition{Column: "price", Operator: ">", Value: 9.5}, 3},
+		{&engine.Condition{Column: "price", Operator: "<=", Value: 10.0}, 3},
+		{&engine.Condition{Column: "price", Operator: "BETWEEN", Value: 7.5, High: 10.0}, 2},
+	}
+	check := func(label string) {
+		for _, tt := range tests {
+			results, err := db.Select("items", nil, tt.cond)
+			if err != nil {
+				t.Fatalf("Select failed: %v", err)
+			}
+			if len(results) != tt.want {
+				t.Errorf("%s: %s: expected %d rows, got %d", label, tt.cond, tt.want, len(results))
+			}
+		}
+	}
+
+	check("scan")
+
+	// Index lookups must agree with the scan
+	items, _ := db.GetTable("items")
+	items.CreateIndex("price")
+	items.CreateOrderedIndex("price")
+	check("indexed")
+
+	results, _ := db.Select("items", nil, &engine.Condition{Column: "id", Operator: "=", Value: 4.0})
+	if len(results) != 1 || results[0]["price"] != 20 {
+		t.Errorf("Expected primary key lookup with 4.0 to find id 4, got %v", results)
+	}
+}
+
+func TestSelectNot(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "active", Type: engine.TypeBool},
+	})
+	db.Insert("users", engine.Row{"id": 1, "active": true})
+	db.Insert("users", engine.Row{"id": 2, "active": false})
+	db.Insert("users", engine.Row{"id": 3, "active": true})
+	db.Insert("users", engine.Row{"id": 4, "active": nil})
+
+	isActive := &engine.Condition{Column: "active", Operator: "=", Value: true}
+	tests := []struct {
+		cond *engine.Condition
+		want int
+	}{
+		// NOT of a comparison with NULL is still unknown, so id 4 never matches
+		{engine.Not(isActive), 1},
+		{engine.Not(engine.Not(isActive)), 2},
+		{engine.Not(&engine.Condition{Column: "active", Operator: "IS NULL"}), 3},
+		{engine.Not(engine.And(isActive, &engine.Condition{Column: "id", Operator: ">", Value: 1})), 2},
+		{engine.Or(engine.Not(isActive), &engine.Condition{Column: "id", Operator: "=", Value: 4}), 2},
+	}
+	for _, tt := range tests {
+		results, err := db.Select("users", nil, tt.cond)
+		if err != nil {
+			t.Fatalf("Select failed: %v", err)
+		}
+		if len(results) != tt.want {
+			t.Errorf("%s: expected %d rows, got %d", tt.cond, tt.want, len(results))
+		}
+	}
+}
+
+func TestSelectLikeEscape(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "name", Type: engine.TypeString},
+	}
+	db.CreateTable("files", schema)
+
+	names := []string{"a_b", "axb", "100%", "100x", `a\b`}
+	for i, name := range names {
+		db.Insert("files", engine.Row{"id": i + 1, "name": name})
+	}
+
+	tests := []struct {
+		pattern string
+		escape  rune
+		want    []string
+	}{
+		{`a_b`, 0, []string{"a_b", "axb", `a\b`}}, // no escape: _ is a wildcard
+		{`a\_b`, '\\', []string{"a_b"}},
+		{`a!_b`, '!', []string{"a_b"}},
+		{`100\%`, '\\', []string{"100%"}},
+		{`a\\b`, '\\', []string{`a\b`}},
+		{`%\_%`, '\\', []string{"a_b"}},
+	}
+
+	for _, tt := range tests {
+		condition := &engine.Condition{Column: "name", Operator: "LIKE", Value: tt.pattern, Escape: tt.escape}
+		results, err := db.Select("files", nil, condition)
+		if err != nil {
+			t.Fatalf("Select failed: %v", err)
+		}
+
+		got := make(map[interface{}]bool)
+		for _, row := range results {
+			got[row["name"]] = true
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("LIKE '%s': expected %v, got %v", tt.pattern, tt.want, results)
+			continue
+		}
+		for _, name := range tt.want {
+			if !got[name] {
+				t.Errorf("LIKE '%s': expected %q to match", tt.pattern, name)
+			}
+		}
+	}
+}
+
+func TestSelectIsNull(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "email", Type: engine.TypeString},
+	}
+	db.CreateTable("users", schema)
+
+	db.Insert("users", engine.Row{"id": 1, "email": "a@example.com"})
+	db.Insert("users", engine.Row{"id": 2})               // email missing
+	db.Insert("users", engine.Row{"id": 3, "email": nil}) // explicit NULL
+	db.Insert("users", engine.Row{"id": 4, "email": "d@example.com"})
+
+	ids := func(cond *engine.Condition) []int {
+		results, err := db.Select("users", nil, cond)
+		if err != nil {
+			t.Fatalf("Select failed: %v", err)
+		}
+		var ids []int
+		for _, row := range results {
+			ids = append(ids, row["id"].(int))
+		}
+		return ids
+	}
+
+	got := ids(&engine.Condition{Column: "email", Operator: "IS NULL"})
+	if len(got) != 2 || got[0] != 2 || got[1] != 3 {
+		t.Errorf("IS NULL: expected [2 3], got %v", got)
+	}
+
+	got = ids(&engine.Condition{Column: "email", Operator: "IS NOT NULL"})
+	if len(got) != 2 || got[0] != 1 || got[1] != 4 {
+		t.Errorf("IS NOT NULL: expected [1 4], got %v", got)
+	}
+
+	// Comparisons never match NULLs, not even = NULL
+	got = ids(&engine.Condition{Column: "email", Operator: "!=", Value: "a@example.com"})
+	if len(got) != 1 || got[0] != 4 {
+		t.Errorf("!=: expected [4], got %v", got)
+	}
+	if got = ids(&engine.Condition{Column: "email", Operator: "=", Value: nil}); len(got) != 0 {
+		t.Errorf("= NULL: expected no rows, got %v", got)
+	}
+
+	// Combines with other predicates
+	got = ids(engine.Or(
+		&engine.Condition{Column: "email", Operator: "IS NULL"},
+		&engine.Condition{Column: "id", Operator: "=", Value: 4},
+	))
+	if len(got) != 3 {
+		t.Errorf("IS NULL OR id = 4: expected 3 rows, got %v", got)
+	}
+}
+
+func TestSelectOrderedColumns(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("users", []engine.Column{
+		{Name: "zip", Type: engine.TypeString},
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "age", Type: engine.TypeInt},
+	})
+	db.Insert("users", engine.Row{"zip": "00100", "id": 1, "age": 30})
+
+	tests := []struct {
+		query engine.Query
+		want  string
+	}{
+		{engine.Query{Table: "users"}, "[zip id age]"},
+		{engine.Query{Table: "users", Columns: []string{"age", "users.zip"}}, "[age zip]"},
+		{engine.Query{Table: "users", Columns: []string{"id", "age"}, Aliases: map[string]string{"age": "years"}}, "[id years]"},
+		{engine.Query{Table: "users", GroupBy: []string{"zip"}, Columns: []string{"zip"},
+			Aggregates: []engine.Aggregate{{Func: "COUNT", Column: "*"}}}, "[zip count]"},
+	}
+	for _, tt := range tests {
+		columns, rows, err := db.SelectOrdered(tt.query)
+		if err != nil {
+			t.Fatalf("SelectOrdered failed: %v", err)
+		}
+		if got := fmt.Sprint(columns); got != tt.want {
+			t.Errorf("Expected columns %s, got %s", tt.want, got)
+		}
+		for _, col := range columns {
+			if _, ok := rows[0].Get(col); !ok {
+				t.Errorf("Column %s missing from row %v", col, rows[0])
+			}
+		}
+	}
+
+	if _, _, err := db.SelectOrdered(engine.Query{Table: "missing"}); err == nil {
+		t.Error("Expected error for unknown table")
+	}
+}
+
+func TestExists(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "city", Type: engine.TypeString},
+	})
+
+	if found, err := db.Exists("users", nil); err != nil || found {
+		t.Errorf("Expected no rows in an empty table, got %v, %v", found, err)
+	}
+
+	for i := 1; i <= 100; i++ {
+		city := "Nairobi"
+		if i == 100 {
+			city = "Kisumu"
+		}
+		db.Insert("users", engine.Row{"id": i, "city": city})
+	}
+
+	tests := []struct {
+		condition *engine.Condition
+		want      bool
+	}{
+		{nil, true},
+		{&engine.Condition{Column: "city", Operator: "=", Value: "Nairobi"}, true},
+		{&engine.Condition{Column: "city", Operator: "=", Value: "Kisumu"}, true},
+		{&engine.Condition{Column: "city", Operator: "=", Value: "Eldoret"}, false},
+		{&engine.Condition{Column: "id", Operator: "=", Value: 42}, true},
+		{&engine.Condition{Column: "id", Operator: "=", Value: 420}, false},
+	}
+	for _, tt := range tests {
+		found, err := db.Exists("users", tt.condition)
+		if err != nil {
+			t.Fatalf("Exists failed: %v", err)
+		}
+		if found != tt.want {
+			t.Errorf("Exists(%v): expected %v, got %v", tt.condition, tt.want, found)
+		}
+	}
+
+	// The scan stops at the first match
+	before := db.Metrics().RowsScanned
+	db.Exists("users", &engine.Condition{Column: "city", Operator: "=", Value: "Nairobi"})
+	if scanned := db.Metrics().RowsScanned - before; scanned != 1 {
+		t.Errorf("Expected 1 row scanned without an index, got %d", scanned)
+	}
+
+	// With an index only the matching rows are examined
+	table, _ := db.GetTable("users")
+	table.CreateIndex("city")
+	before, hits := db.Metrics().RowsScanned, db.Metrics().IndexHits
+	found, _ := db.Exists("users", &engine.Condition{Column: "city", Operator: "=", Value: "Kisumu"})
+	if !found || db.Metrics().RowsScanned-before != 1 || db.Metrics().IndexHits != hits+1 {
+		t.Errorf("Expected an index lookup of 1 row, got found=%v scanned=%d", found, db.Metrics().RowsScanned-before)
+	}
+
+	if _, err := db.Exists("missing", nil); err == nil {
+		t.Error("Expected error for unknown table")
+	}
+}
+
+func TestScan(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "city", Type: engine.TypeString},
+	})
+	for i := 1; i <= 100; i++ {
+		city := "Nairobi"
+		if i%10 == 0 {
+			city = "Kisumu"
+		}
+		db.Insert("users", engine.Row{"id": i, "city": city})
+	}
+
+	// Count every matching row
+	count := 0
+	err := db.Scan("users", &engine.Condition{Column: "city", Operator: "=", Value: "Kisumu"}, func(row engine.Row) bool {
+		count++
+		return true
+	})
+	if err != nil || count != 10 {
+		t.Errorf("Expected 10 rows, got %d, %v", count, err)
+	}
+
+	// Stop after N rows; only the rows visited so far are scanned
+	before := db.Metrics().RowsScanned
+	var ids []interface{}
+	db.Scan("users", nil, func(row engine.Row) bool {
+		ids = append(ids, row["id"])
+		row["id"] = -1 // Rows are copies
+		return len(ids) < 5
+	})
+	if fmt.Sprint(ids) != "[1 2 3 4 5]" {
+		t.Errorf("Expected the first 5 ids in order, got %v", ids)
+	}
+	if scanned := db.Metrics().RowsScanned - before; scanned != 5 {
+		t.Errorf("Expected 5 rows scanned, got %d", scanned)
+	}
+	if rows, _ := db.Select("users", nil, &engine.Condition{Column: "id", Operator: "=", Value: 1}); len(rows) != 1 {
+		t.Error("Expected the stored row to be unchanged")
+	}
+
+	// Equality on an indexed column visits only the matching rows
+	before, hits := db.Metrics().RowsScanned, db.Metrics().IndexHits
+	count = 0
+	db.Scan("users", &engine.Condition{Column: "id", Operator: "=", Value: 42}, func(row engine.Row) bool {
+		count++
+		return true
+	})
+	if count != 1 || db.Metrics().RowsScanned-before != 1 || db.Metrics().IndexHits != hits+1 {
+		t.Errorf("Expected an index lookup of 1 row, got count=%d scanned=%d", count, db.Metrics().RowsScanned-before)
+	}
+
+	if err := db.Scan("missing", nil, func(engine.Row) bool { return true }); err == nil {
+		t.Error("Expected error for unknown table")
+	}
+}
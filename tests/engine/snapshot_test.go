@@ -0,0 +1,93 @@
+package engine_test
+
+import (
+	"godb/engine"
+	"reflect"
+	"testing"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true, AutoIncrement: true},
+		{Name: "email", Type: engine.TypeString, Unique: true},
+		{Name: "age", Type: engine.TypeInt},
+	})
+	db.Insert("users", engine.Row{"email": "ada@example.com", "age": 36})
+	db.Insert("users", engine.Row{"email": "bob@example.com", "age": 41})
+	users, _ := db.GetTable("users")
+	users.CreateOrderedIndex("age")
+
+	before, _ := db.Select("users", nil, nil)
+	snapshot := db.Snapshot()
+
+	// Mutate the original in every way a snapshot must not see
+	db.Insert("users", engine.Row{"email": "cy@example.com", "age": 52})
+	db.Update("users", engine.Row{"age": 99}, &engine.Condition{Column: "id", Operator: "=", Value: 1})
+	db.Delete("users", &engine.Condition{Column: "id", Operator: "=", Value: 2})
+	db.CreateTable("posts", []engine.Column{{Name: "id", Type: engine.TypeInt, PrimaryKey: true}})
+	before[0]["age"] = -1 // Rows returned by Select are copies
+
+	if got := snapshot.Tables(); !reflect.DeepEqual(got, []string{"users"}) {
+		t.Errorf("Expected the snapshot to hold only users, got %v", got)
+	}
+
+	db.Restore(snapshot)
+
+	after, err := db.Select("users", nil, nil)
+	if err != nil {
+		t.Fatalf("Select after restore failed: %v", err)
+	}
+	want := []engine.Row{
+		{"id": 1, "email": "ada@example.com", "age": 36},
+		{"id": 2, "email": "bob@example.com", "age": 41},
+	}
+	if !reflect.DeepEqual(after, want) {
+		t.Errorf("Expected %v after restore, got %v", want, after)
+	}
+	if db.TableExists("posts") {
+		t.Error("Expected a table created after the snapshot to be dropped")
+	}
+
+	// Indexes, constraints and the auto-increment counter come back too
+	plan, _ := db.Explain(engine.Query{Table: "users", Condition: &engine.Condition{Column: "age", Operator: ">", Value: 40}})
+	if op := plan.AccessPath().Op; op != "RangeScan" {
+		t.Errorf("Expected the ordered index to be restored, got %s", op)
+	}
+	id, err := db.InsertWithKey("users", engine.Row{"email": "dee@example.com"})
+	if err != nil || id != 3 {
+		t.Errorf("Expected the next id to be 3, got %v, %v", id, err)
+	}
+	if err := db.Insert("users", engine.Row{"email": "ada@example.com"}); err == nil {
+		t.Error("Expected UNIQUE to be enforced after restore")
+	}
+
+	// The restored table replaces the old one rather than sharing its storage
+	if restored, _ := db.GetTable("users"); restored == users {
+		t.Error("Expected restore to register a new table")
+	}
+
+	// Changes after a restore leave the snapshot intact for another restore
+	db.Restore(snapshot)
+	if n, _ := db.RowCount("users"); n != 2 {
+		t.Errorf("Expected 2 rows after restoring again, got %d", n)
+	}
+}
+
+func TestRestoreNotifiesSchemaListeners(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("a", []engine.Column{{Name: "id", Type: engine.TypeInt}})
+	snapshot := db.Snapshot()
+	db.CreateTable("b", []engine.Column{{Name: "id", Type: engine.TypeInt}})
+
+	var events []string
+	db.OnSchemaChange(func(e engine.SchemaEvent) {
+		events = append(events, e.Kind.String()+" "+e.Table)
+	})
+	db.Restore(snapshot)
+
+	want := []string{"DROP a", "DROP b", "CREATE a"}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("Expected events %v, got %v", want, events)
+	}
+}
@@ -0,0 +1,43 @@
+package engine_test
+
+import (
+	"godb/engine"
+	"testing"
+)
+
+func TestRowCountAndStats(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "email", Type: engine.TypeString, Unique: true},
+		{Name: "name", Type: engine.TypeString},
+	}
+	db.CreateTable("users", schema)
+
+	for i := 1; i <= 3; i++ {
+		db.Insert("users", engine.Row{"id": i, "email": string(rune('a'+i)) + "@x", "name": "user"})
+	}
+	db.Delete("users", &engine.Condition{Column: "id", Operator: "=", Value: 2})
+
+	count, err := db.RowCount("users")
+	if err != nil {
+		t.Fatalf("RowCount failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 rows, got %d", count)
+	}
+
+	table, _ := db.GetTable("users")
+	stats := table.Stats()
+	expected := engine.TableStats{Rows: 2, Columns: 3, Indexes: 2}
+	if stats != expected {
+		t.Errorf("Expected %+v, got %+v", expected, stats)
+	}
+
+	if _, err := db.RowCount("missing"); err == nil {
+		t.Error("Expected error for missing table")
+	} else if _, ok := err.(engine.ErrTableNotFound); !ok {
+		t.Errorf("Expected ErrTableNotFound, got %T", err)
+	}
+}
@@ -0,0 +1,65 @@
+package engine_test
+
+import (
+	"godb/engine"
+	"testing"
+	"time"
+)
+
+func TestQueryTimeoutOnLargeScan(t *testing.T) {
+	db := engine.NewDatabase()
+
+	schema := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "age", Type: engine.TypeInt},
+	}
+	db.CreateTable("users", schema)
+
+	for i := 0; i < 100000; i++ {
+		db.Insert("users", engine.Row{"id": i, "age": i % 100})
+	}
+
+	db.SetQueryTimeout(time.Nanosecond)
+
+	condition := &engine.Condition{Column: "age", Operator: ">", Value: 50}
+	_, err := db.Select("users", nil, condition)
+	if _, ok := err.(engine.ErrQueryTimeout); !ok {
+		t.Fatalf("Expected ErrQueryTimeout, got %v", err)
+	}
+
+	// Disabling the timeout lets the same scan complete
+	db.SetQueryTimeout(0)
+	results, err := db.Select("users", nil, condition)
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	if len(results) != 49000 {
+		t.Errorf("Expected 49000 rows, got %d", len(results))
+	}
+}
+
+func TestQueryTimeoutOnJoin(t *testing.T) {
+	db := engine.NewDatabase()
+
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "group_id", Type: engine.TypeInt},
+	})
+	db.CreateTable("groups", []engine.Column{
+		{Name: "id", Type: engine.TypeInt},
+	})
+
+	for i := 0; i < 5000; i++ {
+		db.Insert("users", engine.Row{"id": i, "group_id": i})
+		db.Insert("groups", engine.Row{"id": i})
+	}
+
+	db.SetQueryTimeout(time.Nanosecond)
+
+	// No index on groups.id, so the join scans groups to build a hash table
+	joinCondition := engine.JoinCondition{LeftColumn: "group_id", RightColumn: "id"}
+	_, err := db.InnerJoin("users", "groups", joinCondition, nil)
+	if _, ok := err.(engine.ErrQueryTimeout); !ok {
+		t.Fatalf("Expected ErrQueryTimeout, got %v", err)
+	}
+}
@@ -0,0 +1,348 @@
+package executor_test
+
+import (
+	"errors"
+	"godb/engine"
+	"godb/executor"
+	"godb/parser"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExecuteStatements(t *testing.T) {
+	db := engine.NewDatabase()
+
+	tests := []struct {
+		sql      string
+		kind     parser.CommandType
+		table    string
+		message  string
+		affected int
+		columns  []string
+		rows     []engine.Row
+	}{
+		{
+			sql: "SHOW TABLES", kind: parser.CmdShowTables,
+			message: "No tables", columns: []string{"table"}, rows: []engine.Row{},
+		},
+		{
+			sql: "CREATE TABLE users (id INT PRIMARY KEY AUTO_INCREMENT, name STRING)", kind: parser.CmdCreateTable,
+			table: "users", message: "Table 'users' created",
+		},
+		{
+			sql: "INSERT INTO users (name) VALUES ('moses')", kind: parser.CmdInsert,
+			table: "users", message: "1 row(s) inserted", affected: 1,
+			columns: []string{"id", "name"}, rows: []engine.Row{{"id": 1, "name": "moses"}},
+		},
+		{
+			sql: "INSERT INTO users (name) VALUES ('bob'), ('cy')", kind: parser.CmdInsert,
+			table: "users", message: "2 row(s) inserted", affected: 2,
+		},
+		{
+			sql: "INSERT INTO users VALUES (10, 'dee')", kind: parser.CmdInsert,
+			table: "users", message: "1 row(s) inserted", affected: 1,
+		},
+		{
+			sql: "SELECT name FROM users WHERE id > 2", kind: parser.CmdSelect,
+			table: "users", columns: []string{"name"}, rows: []engine.Row{{"name": "cy"}, {"name": "dee"}},
+		},
+		{
+			sql: "UPDATE users SET name = 'Bob' WHERE id = 2", kind: parser.CmdUpdate,
+			table: "users", message: "1 row(s) updated", affected: 1,
+		},
+		{
+			sql: "DELETE FROM users WHERE id = 3", kind: parser.CmdDelete,
+			table: "users", message: "1 row(s) deleted", affected: 1,
+		},
+		{
+			sql: "CREATE TABLE posts (id INT PRIMARY KEY, user_id INT)", kind: parser.CmdCreateTable,
+			table: "posts", message: "Table 'posts' created",
+		},
+		{
+			sql: "INSERT INTO posts VALUES (1, 2)", kind: parser.CmdInsert,
+			table: "posts", message: "1 row(s) inserted", affected: 1,
+		},
+		{
+			sql: "SELECT * FROM posts INNER JOIN users ON posts.user_id = users.id", kind: parser.CmdSelect,
+			columns: []string{"posts.id", "posts.user_id", "users.id", "users.name"},
+			rows:    []engine.Row{{"posts.id": 1, "posts.user_id": 2, "users.id": 2, "users.name": "Bob"}},
+		},
+		{
+			sql: "SELECT name, posts.id FROM posts INNER JOIN users ON posts.user_id = users.id", kind: parser.CmdSelect,
+			columns: []string{"users.name", "posts.id"},
+			rows:    []engine.Row{{"users.name": "Bob", "posts.id": 1}},
+		},
+		{
+			sql: "ALTER TABLE posts ADD COLUMN title STRING", kind: parser.CmdAlterTable,
+			table: "posts", message: "Column 'title' added to table 'posts'",
+		},
+		{
+			sql: "ALTER TABLE users AUTO_INCREMENT = 50", kind: parser.CmdAlterTable,
+			table: "users", message: "Next id for table 'users' set to 50",
+		},
+		{
+			sql: "ANALYZE users", kind: parser.CmdAnalyze,
+			table: "users", message: "Table 'users' analyzed",
+		},
+		{
+			sql: "DESCRIBE posts", kind: parser.CmdDescribe,
+			table: "posts", columns: []string{"column", "type", "constraints"},
+			rows: []engine.Row{
+				{"column": "id", "type": "INT", "constraints": "PRIMARY KEY"},
+				{"column": "user_id", "type": "INT", "constraints": ""},
+				{"column": "title", "type": "STRING", "constraints": ""},
+			},
+		},
+		{
+			sql: "ALTER TABLE posts RENAME COLUMN title TO heading", kind: parser.CmdAlterTable,
+			table: "posts", message: "Column 'title' of table 'posts' renamed to 'heading'",
+		},
+		{
+			sql: "ALTER TABLE posts RENAME TO articles", kind: parser.CmdAlterTable,
+			table: "articles", message: "Table 'posts' renamed to 'articles'",
+		},
+		{
+			sql: "TRUNCATE TABLE articles", kind: parser.CmdTruncate,
+			table: "articles", message: "Table 'articles' truncated",
+		},
+		{
+			sql: "DROP TABLE articles", kind: parser.CmdDropTable,
+			table: "articles", message: "Table 'articles' dropped",
+		},
+		{
+			sql: "SHOW TABLES", kind: parser.CmdShowTables,
+			columns: []string{"table"}, rows: []engine.Row{{"table": "users"}},
+		},
+	}
+
+	for _, tt := range tests {
+		result, err := executor.Execute(db, tt.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.sql, err)
+		}
+		if result.Kind != tt.kind || result.Table != tt.table || result.Message != tt.message || result.RowsAffected != tt.affected {
+			t.Errorf("%s: unexpected result %+v", tt.sql, result)
+		}
+		if !reflect.DeepEqual(result.Columns, tt.columns) {
+			t.Errorf("%s: expected columns %v, got %v", tt.sql, tt.columns, result.Columns)
+		}
+		if tt.rows != nil && !reflect.DeepEqual(result.Rows, tt.rows) {
+			t.Errorf("%s: expected rows %v, got %v", tt.sql, tt.rows, result.Rows)
+		}
+	}
+}
+
+func TestExecuteIsQuery(t *testing.T) {
+	db := setupBlog(t)
+
+	tests := []struct {
+		sql   string
+		query bool
+	}{
+		{"SELECT * FROM users", true},
+		{"SELECT * FROM posts INNER JOIN users ON posts.user_id = users.id", true},
+		{"EXPLAIN SELECT * FROM users", true},
+		{"DESCRIBE users", true},
+		{"SHOW TABLES", true},
+		{"INSERT INTO users (id, name) VALUES (3, 'cy')", false},
+		{"UPDATE users SET name = 'x' WHERE id = 3", false},
+	}
+	for _, tt := range tests {
+		result, err := executor.Execute(db, tt.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.sql, err)
+		}
+		if result.IsQuery() != tt.query {
+			t.Errorf("%s: expected IsQuery %v", tt.sql, tt.query)
+		}
+	}
+}
+
+func TestExecuteCreateIndex(t *testing.T) {
+	db := engine.NewDatabase()
+	for _, sql := range []string{
+		"CREATE TABLE users (id INT PRIMARY KEY, email STRING)",
+		"INSERT INTO users VALUES (1, 'ada@example.com'), (2, 'bob@example.com'), (3, 'cy@example.com')",
+	} {
+		if _, err := executor.Execute(db, sql); err != nil {
+			t.Fatalf("%s: %v", sql, err)
+		}
+	}
+
+	// lookup runs the email query and returns the rows it scanned and whether it used an index
+	lookup := func() (int64, bool) {
+		t.Helper()
+		before := db.Metrics()
+		result, err := executor.Execute(db, "SELECT id FROM users WHERE email = 'bob@example.com'")
+		if err != nil || len(result.Rows) != 1 {
+			t.Fatalf("Expected bob's row, got %v, %v", result, err)
+		}
+		after := db.Metrics()
+		return after.RowsScanned - before.RowsScanned, after.IndexHits > before.IndexHits
+	}
+
+	if scanned, indexed := lookup(); indexed || scanned != 3 {
+		t.Errorf("Expected a full scan of 3 rows, got scanned=%d indexed=%v", scanned, indexed)
+	}
+
+	result, err := executor.Execute(db, "CREATE INDEX ON users (email)")
+	if err != nil {
+		t.Fatalf("CREATE INDEX failed: %v", err)
+	}
+	if result.Kind != parser.CmdCreateIndex || result.Message != "Index on 'users.email' created" {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if scanned, indexed := lookup(); !indexed || scanned != 1 {
+		t.Errorf("Expected an index lookup of 1 row, got scanned=%d indexed=%v", scanned, indexed)
+	}
+
+	result, err = executor.Execute(db, "DROP INDEX ON users (email)")
+	if err != nil {
+		t.Fatalf("DROP INDEX failed: %v", err)
+	}
+	if result.Kind != parser.CmdDropIndex || result.Message != "Index on 'users.email' dropped" {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if scanned, indexed := lookup(); indexed || scanned != 3 {
+		t.Errorf("Expected a full scan after DROP INDEX, got scanned=%d indexed=%v", scanned, indexed)
+	}
+
+	if _, err := executor.Execute(db, "DROP INDEX ON users (id)"); !errors.As(err, &engine.ErrImplicitIndex{}) {
+		t.Errorf("Expected ErrImplicitIndex, got %v", err)
+	}
+	if _, err := executor.Execute(db, "CREATE INDEX ON missing (id)"); !errors.As(err, &engine.ErrTableNotFound{}) {
+		t.Errorf("Expected ErrTableNotFound, got %v", err)
+	}
+}
+
+func TestExecuteExplain(t *testing.T) {
+	db := setupBlog(t)
+	before := db.Metrics()
+
+	tests := []struct {
+		sql  string
+		op   string
+		rows int
+	}{
+		{"EXPLAIN SELECT * FROM posts WHERE id = 2", "IndexScan", 1},
+		{"EXPLAIN SELECT title FROM posts WHERE user_id = 1", "FullScan", 3},
+		{"EXPLAIN SELECT * FROM posts WHERE id = 9", "IndexScan", 0},
+	}
+	for _, tt := range tests {
+		result, err := executor.Execute(db, tt.sql)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.sql, err)
+		}
+		access := result.Plan.AccessPath()
+		if access.Op != tt.op || access.EstimatedRows != tt.rows {
+			t.Errorf("%s: expected %s of %d rows, got %s", tt.sql, tt.op, tt.rows, result.Plan.TreeString())
+		}
+		if result.Kind != parser.CmdExplain || result.Table != "posts" || !reflect.DeepEqual(result.Columns, []string{"plan"}) {
+			t.Errorf("%s: unexpected result %+v", tt.sql, result)
+		}
+		// One row per plan node, ending with the access path
+		last := result.Rows[len(result.Rows)-1]["plan"].(string)
+		if !strings.Contains(last, tt.op) {
+			t.Errorf("%s: expected the last row to show %s, got %q", tt.sql, tt.op, last)
+		}
+	}
+
+	// Nothing was executed
+	if after := db.Metrics(); after.Selects != before.Selects || after.RowsScanned != before.RowsScanned {
+		t.Errorf("Expected EXPLAIN not to run the query, got %+v", after)
+	}
+
+	result, err := executor.Execute(db, "EXPLAIN SELECT * FROM posts INNER JOIN users ON posts.user_id = users.id")
+	if err != nil {
+		t.Fatalf("EXPLAIN JOIN failed: %v", err)
+	}
+	if result.Plan == nil || len(result.Rows) == 0 {
+		t.Errorf("Expected a join plan, got %+v", result)
+	}
+}
+
+func TestExecuteJoinColumns(t *testing.T) {
+	db := setupBlog(t)
+	join := " FROM posts INNER JOIN users ON posts.user_id = users.id WHERE posts.id = 2"
+
+	tests := []struct {
+		columns string
+		want    []string
+	}{
+		{"posts.title, users.name", []string{"posts.title", "users.name"}},
+		{"users.name, posts.title", []string{"users.name", "posts.title"}},
+		{"name, title", []string{"users.name", "posts.title"}},
+		{"title, users.id", []string{"posts.title", "users.id"}},
+	}
+	for _, tt := range tests {
+		sql := "SELECT " + tt.columns + join
+		result, err := executor.Execute(db, sql)
+		if err != nil {
+			t.Fatalf("%s: %v", sql, err)
+		}
+		// Columns come back in the order requested, each under its qualified key
+		if !reflect.DeepEqual(result.Columns, tt.want) {
+			t.Errorf("%s: expected columns %v, got %v", sql, tt.want, result.Columns)
+		}
+		if len(result.Rows) != 1 || len(result.Rows[0]) != len(tt.want) {
+			t.Fatalf("%s: expected one row with %d columns, got %v", sql, len(tt.want), result.Rows)
+		}
+		for _, key := range tt.want {
+			if _, ok := result.Rows[0][key]; !ok {
+				t.Errorf("%s: expected key %s in %v", sql, key, result.Rows[0])
+			}
+		}
+	}
+
+	result, err := executor.Execute(db, "EXPLAIN SELECT name, title"+join)
+	if err != nil {
+		t.Fatalf("EXPLAIN failed: %v", err)
+	}
+	if root := result.Plan.Root; root.Op != "Project" || root.Detail != "users.name, posts.title" {
+		t.Errorf("Expected a projection of the qualified columns, got %s", result.Plan.TreeString())
+	}
+	if _, err := executor.Execute(db, "EXPLAIN SELECT users.title"+join); !errors.As(err, &engine.ErrColumnNotFound{}) {
+		t.Errorf("Expected ErrColumnNotFound, got %v", err)
+	}
+}
+
+func TestExecuteDateRange(t *testing.T) {
+	db := engine.NewDatabase()
+	for _, sql := range []string{
+		"CREATE TABLE events (id INT PRIMARY KEY, created_at DATE)",
+		"INSERT INTO events (id, created_at) VALUES (1, '2023-11-30'), (2, '2024-02-10'), (3, '2024-01-05 09:00:00')",
+	} {
+		if _, err := executor.Execute(db, sql); err != nil {
+			t.Fatalf("%s: %v", sql, err)
+		}
+	}
+
+	result, err := executor.Execute(db, "SELECT id FROM events WHERE created_at > '2024-01-01' ORDER BY created_at")
+	if err != nil {
+		t.Fatalf("SELECT failed: %v", err)
+	}
+	want := []engine.Row{{"id": 3}, {"id": 2}}
+	if !reflect.DeepEqual(result.Rows, want) {
+		t.Errorf("Expected %v, got %v", want, result.Rows)
+	}
+
+	if _, err := executor.Execute(db, "INSERT INTO events (id, created_at) VALUES (4, 'soon')"); !errors.As(err, &engine.ErrInvalidValue{}) {
+		t.Errorf("Expected ErrInvalidValue, got %v", err)
+	}
+}
+
+func TestExecuteErrors(t *testing.T) {
+	db := setupBlog(t)
+
+	var parseErr parser.ParseError
+	if _, err := executor.Execute(db, "SELEKT * FROM users"); !errors.As(err, &parseErr) {
+		t.Errorf("Expected a ParseError, got %v", err)
+	}
+	if _, err := executor.Execute(db, "SELECT * FROM missing"); !errors.As(err, &engine.ErrTableNotFound{}) {
+		t.Errorf("Expected ErrTableNotFound, got %v", err)
+	}
+	if _, err := executor.Execute(db, "INSERT INTO users (id, name) VALUES (1, 'dup')"); !errors.As(err, &engine.ErrPrimaryKeyViolation{}) {
+		t.Errorf("Expected ErrPrimaryKeyViolation, got %v", err)
+	}
+}
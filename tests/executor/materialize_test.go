@@ -0,0 +1,215 @@
+package executor_test
+
+import (
+	"godb/engine"
+	"godb/executor"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func setupBlog(t *testing.T) *engine.Database {
+	db := engine.NewDatabase()
+
+	if err := db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "name", Type: engine.TypeString},
+	}); err != nil {
+		t.Fatalf("Failed to create users: %v", err)
+	}
+	if err := db.CreateTable("posts", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "user_id", Type: engine.TypeInt},
+		{Name: "title", Type: engine.TypeString},
+	}); err != nil {
+		t.Fatalf("Failed to create posts: %v", err)
+	}
+
+	db.Insert("users", engine.Row{"id": 1, "name": "moses"})
+	db.Insert("users", engine.Row{"id": 2, "name": "Bob"})
+	db.Insert("posts", engine.Row{"id": 1, "user_id": 1, "title": "Hello"})
+	db.Insert("posts", engine.Row{"id": 2, "user_id": 2, "title": "Hi"})
+	db.Insert("posts", engine.Row{"id": 3, "user_id": 1, "title": "Again"})
+
+	return db
+}
+
+func TestMaterializeFilteredSelect(t *testing.T) {
+	db := setupBlog(t)
+
+	count, err := executor.MaterializeQuery(db, "SELECT id, title FROM posts WHERE user_id = 1", "moses_posts")
+	if err != nil {
+		t.Fatalf("MaterializeQuery failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 rows materialized, got %d", count)
+	}
+
+	table, err := db.GetTable("moses_posts")
+	if err != nil {
+		t.Fatalf("Expected new table: %v", err)
+	}
+	schema := table.Schema()
+	if len(schema) != 2 || schema[0].Name != "id" || schema[0].Type != engine.TypeInt ||
+		schema[1].Name != "title" || schema[1].Type != engine.TypeString {
+		t.Errorf("Unexpected inferred schema: %+v", schema)
+	}
+
+	rows, err := db.Select("moses_posts", nil, &engine.Condition{Column: "title", Operator: "=", Value: "Again"})
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["id"] != 3 {
+		t.Errorf("Expected post 3 in materialized table, got %v", rows)
+	}
+}
+
+func TestMaterializeJoin(t *testing.T) {
+	db := setupBlog(t)
+
+	sql := "SELECT posts.title, users.name, users.id FROM posts INNER JOIN users ON posts.user_id = users.id"
+	count, err := executor.MaterializeQuery(db, sql, "post_authors")
+	if err != nil {
+		t.Fatalf("MaterializeQuery failed: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 rows materialized, got %d", count)
+	}
+
+	rows, err := db.Select("post_authors", nil, &engine.Condition{Column: "name", Operator: "=", Value: "moses"})
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Errorf("Expected 2 posts by moses, got %d", len(rows))
+	}
+	for _, row := range rows {
+		if row["id"] != 1 {
+			t.Errorf("Expected id column from users, got %v", row["id"])
+		}
+	}
+
+	// Columns present on both sides are prefixed with their table
+	if _, err := executor.MaterializeQuery(db, "SELECT * FROM posts INNER JOIN users ON posts.user_id = users.id", "wide"); err != nil {
+		t.Fatalf("MaterializeQuery failed: %v", err)
+	}
+	table, _ := db.GetTable("wide")
+	names := make(map[string]bool)
+	for _, col := range table.Schema() {
+		names[col.Name] = true
+	}
+	for _, want := range []string{"posts_id", "users_id", "user_id", "title", "name"} {
+		if !names[want] {
+			t.Errorf("Expected column %s in %v", want, table.Schema())
+		}
+	}
+}
+
+func TestMaterializeJoinBareColumns(t *testing.T) {
+	db := setupBlog(t)
+
+	// Bare names resolve to the one table that has them
+	sql := "SELECT title, name, users.id FROM posts INNER JOIN users ON posts.user_id = users.id"
+	if _, err := executor.MaterializeQuery(db, sql, "post_authors"); err != nil {
+		t.Fatalf("MaterializeQuery failed: %v", err)
+	}
+	table, _ := db.GetTable("post_authors")
+	if names := table.ColumnNames(); !reflect.DeepEqual(names, []string{"title", "name", "id"}) {
+		t.Errorf("Expected columns [title name id], got %v", names)
+	}
+
+	sql = "SELECT id FROM posts INNER JOIN users ON posts.user_id = users.id"
+	if _, err := executor.MaterializeQuery(db, sql, "ids"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("Expected an ambiguity error, got %v", err)
+	}
+	if db.TableExists("ids") {
+		t.Error("Did not expect table to be created")
+	}
+}
+
+func TestMaterializeRejectsExistingTableAndNonSelect(t *testing.T) {
+	db := setupBlog(t)
+
+	if _, err := executor.MaterializeQuery(db, "SELECT * FROM posts", "users"); err == nil {
+		t.Error("Expected error materializing into an existing table")
+	}
+	if _, err := executor.MaterializeQuery(db, "DELETE FROM posts", "copy"); err == nil {
+		t.Error("Expected error materializing a DELETE")
+	}
+	if db.TableExists("copy") {
+		t.Error("Did not expect table to be created")
+	}
+}
+
+func TestCreateTableAsSelectClone(t *testing.T) {
+	db := setupBlog(t)
+	posts, _ := db.GetTable("posts")
+	posts.CreateIndex("user_id")
+
+	result, err := executor.Execute(db, "CREATE TABLE posts_copy AS SELECT * FROM posts")
+	if err != nil {
+		t.Fatalf("CREATE TABLE AS SELECT failed: %v", err)
+	}
+	if result.RowsAffected != 3 || result.Message != "Table 'posts_copy' created with 3 row(s)" {
+		t.Errorf("Unexpected result: %d, %q", result.RowsAffected, result.Message)
+	}
+
+	want, _ := db.Select("posts", nil, nil)
+	got, _ := db.Select("posts_copy", nil, nil)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	// The whole schema is cloned, constraints and indexes included
+	copied, _ := db.GetTable("posts_copy")
+	if !reflect.DeepEqual(copied.Schema(), posts.Schema()) {
+		t.Errorf("Expected schema %+v, got %+v", posts.Schema(), copied.Schema())
+	}
+	if _, ok := copied.GetIndex("user_id"); !ok {
+		t.Error("Expected the user_id index to be copied")
+	}
+	if err := db.Insert("posts_copy", engine.Row{"id": 1, "user_id": 2}); err == nil {
+		t.Error("Expected the copy to enforce its PRIMARY KEY")
+	}
+}
+
+func TestCreateTableAsSelectFiltered(t *testing.T) {
+	db := setupBlog(t)
+
+	result, err := executor.Execute(db, "CREATE TABLE archive AS SELECT * FROM posts WHERE user_id = 1")
+	if err != nil {
+		t.Fatalf("CREATE TABLE AS SELECT failed: %v", err)
+	}
+	if result.RowsAffected != 2 {
+		t.Errorf("Expected 2 rows copied, got %d", result.RowsAffected)
+	}
+
+	rows, _ := db.Select("archive", nil, nil)
+	want := []engine.Row{
+		{"id": 1, "user_id": 1, "title": "Hello"},
+		{"id": 3, "user_id": 1, "title": "Again"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("Expected %v, got %v", want, rows)
+	}
+
+	// A filtered copy infers plain columns from its source
+	table, _ := db.GetTable("archive")
+	for _, col := range table.Schema() {
+		if col.PrimaryKey {
+			t.Errorf("Did not expect constraints on %+v", col)
+		}
+	}
+
+	sql := "CREATE TABLE authors AS SELECT title, name FROM posts INNER JOIN users ON posts.user_id = users.id"
+	if result, err := executor.Execute(db, sql); err != nil || result.RowsAffected != 3 {
+		t.Errorf("Expected 3 joined rows copied, got %v, %v", result, err)
+	}
+
+	if _, err := executor.Execute(db, "CREATE TABLE users AS SELECT * FROM posts"); err == nil {
+		t.Error("Expected an error creating an existing table")
+	}
+	if _, err := executor.Execute(db, "CREATE TABLE copy AS SELECT * FROM missing"); err == nil {
+		t.Error("Expected an error copying a missing table")
+	}
+}
@@ -0,0 +1,55 @@
+package parser_test
+
+import (
+	"errors"
+	"godb/parser"
+	"strings"
+	"testing"
+)
+
+func TestParseErrorPosition(t *testing.T) {
+	tests := []struct {
+		input   string
+		pos     int
+		message string
+	}{
+		{"SELECT id, FROM users", 11, `expected identifier, got "FROM"`},
+		{"SELECT * FROM users WHERE age 30", 30, `expected operator in condition, got "30"`},
+		{"  SELECT * FROM users WHERE", 27, "expected identifier, got end of input"},
+		{"SELECT * FROM users\nWHERE id = ;", 31, `expected value, got ";"`},
+		{"SELECT * FROM users users", 20, `unexpected "users" after end of statement`},
+	}
+	for _, tt := range tests {
+		_, err := parser.NewParser(tt.input).Parse()
+		var parseErr parser.ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%q: expected a ParseError, got %v", tt.input, err)
+			continue
+		}
+		if parseErr.Pos != tt.pos || parseErr.Message != tt.message {
+			t.Errorf("%q: expected %q at %d, got %q at %d", tt.input, tt.message, tt.pos, parseErr.Message, parseErr.Pos)
+		}
+	}
+}
+
+func TestParseErrorUnderline(t *testing.T) {
+	input := "SELECT *\nFROM users\nWHERE name = 'Zoë' AND id 5"
+	_, err := parser.NewParser(input).Parse()
+	var parseErr parser.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected a ParseError, got %v", err)
+	}
+
+	want := "WHERE name = 'Zoë' AND id 5\n" + strings.Repeat(" ", 26) + "^"
+	if got := parseErr.Underline(input); got != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestParseManyErrorPosition(t *testing.T) {
+	_, err := parser.NewParser("SELECT * FROM a; SELECT FROM b").ParseMany()
+	var parseErr parser.ParseError
+	if !errors.As(err, &parseErr) || parseErr.Pos != 24 {
+		t.Errorf("Expected a ParseError at offset 24 in statement 2, got %v", err)
+	}
+}
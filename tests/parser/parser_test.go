@@ -1,7 +1,10 @@
 package parser_test
 
 import (
+	"godb/engine"
 	"godb/parser"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -65,16 +68,21 @@ func TestParseInsert(t *testing.T) {
 		t.Errorf("Expected table name 'users', got '%s'", insertCmd.TableName)
 	}
 
-	if len(insertCmd.Values) != 2 {
-		t.Errorf("Expected 2 values, got %d", len(insertCmd.Values))
+	if len(insertCmd.Rows) != 1 {
+		t.Fatalf("Expected 1 row, got %d", len(insertCmd.Rows))
 	}
 
-	if insertCmd.Values["id"] != 1 {
-		t.Errorf("Expected id=1, got %v", insertCmd.Values["id"])
+	values := insertCmd.Rows[0]
+	if len(values) != 2 {
+		t.Errorf("Expected 2 values, got %d", len(values))
 	}
 
-	if insertCmd.Values["name"] != "moses" {
-		t.Errorf("Expected name='moses', got %v", insertCmd.Values["name"])
+	if values["id"] != 1 {
+		t.Errorf("Expected id=1, got %v", values["id"])
+	}
+
+	if values["name"] != "moses" {
+		t.Errorf("Expected name='moses', got %v", values["name"])
 	}
 }
 
@@ -221,3 +229,1258 @@ func TestParseJoin(t *testing.T) {
 		t.Errorf("Expected right column 'id', got '%s'", joinCmd.RightColumn)
 	}
 }
+
+func TestParseMultiJoin(t *testing.T) {
+	input := "SELECT * FROM posts INNER JOIN users ON posts.user_id = users.id LEFT JOIN comments ON comments.post_id = posts.id"
+	cmd, err := parser.NewParser(input).Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	joinCmd, ok := cmd.(*parser.JoinCommand)
+	if !ok {
+		t.Fatalf("Expected JoinCommand, got %T", cmd)
+	}
+
+	want := []engine.JoinStep{
+		{Type: engine.JoinInner, Table: "users", LeftTable: "posts", LeftColumn: "user_id", RightColumn: "id"},
+		// The new table's column is moved to the right
+		{Type: engine.JoinLeft, Table: "comments", LeftTable: "posts", LeftColumn: "id", RightColumn: "post_id"},
+	}
+	if !reflect.DeepEqual(joinCmd.Joins, want) {
+		t.Errorf("Expected joins %+v, got %+v", want, joinCmd.Joins)
+	}
+	if tables := joinCmd.Tables(); !reflect.DeepEqual(tables, []string{"posts", "users", "comments"}) {
+		t.Errorf("Expected tables posts, users, comments, got %v", tables)
+	}
+
+	if joinCmd.Condition != nil {
+		t.Errorf("Expected no condition, got %v", joinCmd.Condition)
+	}
+
+	input = "SELECT * FROM posts INNER JOIN users ON posts.user_id = users.id WHERE users.name = 'Bob'"
+	cmd, err = parser.NewParser(input).Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	joinCmd = cmd.(*parser.JoinCommand)
+	if c := joinCmd.Condition; c == nil || c.Column != "users.name" || c.Value != "Bob" {
+		t.Errorf("Expected WHERE users.name = 'Bob', got %v", c)
+	}
+
+	// The joined table must appear in its condition
+	input = "SELECT * FROM posts INNER JOIN users ON posts.user_id = comments.id"
+	if _, err := parser.NewParser(input).Parse(); err == nil {
+		t.Error("Expected error for a JOIN condition that does not reference the joined table")
+	}
+
+	// Aggregates over a join are rejected rather than ignored
+	input = "SELECT COUNT(*) FROM posts INNER JOIN users ON posts.user_id = users.id"
+	if _, err := parser.NewParser(input).Parse(); err == nil || !strings.Contains(err.Error(), "aggregates are not supported with JOIN") {
+		t.Errorf("Expected an error for aggregates with JOIN, got %v", err)
+	}
+}
+
+func TestParseDistinctOn(t *testing.T) {
+	input := "SELECT DISTINCT ON (user_id) * FROM posts ORDER BY created DESC"
+	p := parser.NewParser(input)
+	cmd, err := p.Parse()
+
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	selectCmd, ok := cmd.(*parser.SelectCommand)
+	if !ok {
+		t.Fatalf("Expected SelectCommand, got %T", cmd)
+	}
+
+	if len(selectCmd.DistinctOn) != 1 || selectCmd.DistinctOn[0] != "user_id" {
+		t.Errorf("Expected DISTINCT ON (user_id), got %v", selectCmd.DistinctOn)
+	}
+
+	if len(selectCmd.OrderBy) != 1 {
+		t.Fatal("Expected ORDER BY to be present")
+	}
+
+	if selectCmd.OrderBy[0].Column != "created" || !selectCmd.OrderBy[0].Desc {
+		t.Errorf("Expected ORDER BY created DESC, got %+v", selectCmd.OrderBy)
+	}
+}
+
+func TestParseAggregates(t *testing.T) {
+	input := "SELECT COUNT(*), AVG(age) FROM users WHERE age > 18"
+	p := parser.NewParser(input)
+	cmd, err := p.Parse()
+
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	selectCmd, ok := cmd.(*parser.SelectCommand)
+	if !ok {
+		t.Fatalf("Expected SelectCommand, got %T", cmd)
+	}
+
+	if len(selectCmd.Aggregates) != 2 {
+		t.Fatalf("Expected 2 aggregates, got %d", len(selectCmd.Aggregates))
+	}
+
+	if selectCmd.Aggregates[0].Func != "COUNT" || selectCmd.Aggregates[0].Column != "*" {
+		t.Errorf("Expected COUNT(*), got %+v", selectCmd.Aggregates[0])
+	}
+
+	if selectCmd.Aggregates[1].Name() != "avg_age" {
+		t.Errorf("Expected column name 'avg_age', got '%s'", selectCmd.Aggregates[1].Name())
+	}
+
+	if selectCmd.Condition == nil {
+		t.Error("Expected condition to be present")
+	}
+}
+
+func TestParseCountDistinct(t *testing.T) {
+	cmd, err := parser.NewParser("SELECT COUNT(DISTINCT user_id) AS authors, COUNT(user_id) FROM posts").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	aggregates := cmd.(*parser.SelectCommand).Aggregates
+	want := []engine.Aggregate{
+		{Func: "COUNT", Column: "user_id", Distinct: true, Alias: "authors"},
+		{Func: "COUNT", Column: "user_id"},
+	}
+	if !reflect.DeepEqual(aggregates, want) {
+		t.Errorf("Expected %+v, got %+v", want, aggregates)
+	}
+
+	if _, err := parser.NewParser("SELECT COUNT(DISTINCT) FROM posts").Parse(); err == nil {
+		t.Error("Expected error for COUNT(DISTINCT) without a column")
+	}
+}
+
+func TestParseModuloCondition(t *testing.T) {
+	input := "SELECT * FROM users WHERE id % 2 = 0"
+	p := parser.NewParser(input)
+	cmd, err := p.Parse()
+
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	selectCmd := cmd.(*parser.SelectCommand)
+	cond := selectCmd.Condition
+	if cond == nil || cond.Arith == nil {
+		t.Fatal("Expected condition with arithmetic to be present")
+	}
+
+	if cond.Arith.Operator != "%" || cond.Arith.Operand != 2 {
+		t.Errorf("Expected '%% 2', got %+v", cond.Arith)
+	}
+
+	if cond.Operator != "=" || cond.Value != 0 {
+		t.Errorf("Expected '= 0', got '%s %v'", cond.Operator, cond.Value)
+	}
+}
+
+func TestTokenizeModuloOperator(t *testing.T) {
+	tokens := parser.Tokenize("id % 2 = 0 AND name = '50%'")
+
+	if tokens[1].Type != parser.TokenOperator || tokens[1].Value != "%" {
+		t.Errorf("Expected '%%' operator token, got %+v", tokens[1])
+	}
+
+	// A % inside a string literal stays part of the string
+	last := tokens[len(tokens)-2]
+	if last.Type != parser.TokenString || last.Value != "50%" {
+		t.Errorf("Expected string token '50%%', got %+v", last)
+	}
+}
+
+func TestTokenizeNotEqual(t *testing.T) {
+	for _, input := range []string{"id <> 1", "id<>1", "id != 1"} {
+		tokens := parser.Tokenize(input)
+		if len(tokens) != 4 || tokens[1].Type != parser.TokenOperator || (tokens[1].Value != "<>" && tokens[1].Value != "!=") {
+			t.Errorf("%q: expected a single not-equal operator, got %+v", input, tokens)
+		}
+	}
+
+	// < followed by a space and > stays two operators
+	if tokens := parser.Tokenize("a < > b"); tokens[1].Value != "<" || tokens[2].Value != ">" {
+		t.Errorf("Expected separate < and > operators, got %+v", tokens)
+	}
+
+	cmd, err := parser.NewParser("SELECT * FROM users WHERE id <> 1").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if cond := cmd.(*parser.SelectCommand).Condition; cond.Operator != "<>" || cond.Value != 1 {
+		t.Errorf("Expected '<> 1', got '%s %v'", cond.Operator, cond.Value)
+	}
+}
+
+func TestTokenizeComments(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"SELECT id -- the key\nFROM users", []string{"SELECT", "id", "FROM", "users"}},
+		{"SELECT /* all */ * FROM/**/users", []string{"SELECT", "*", "FROM", "users"}},
+		{"SELECT * FROM users /* spans\nlines */ WHERE id = 1 -- trailing", []string{"SELECT", "*", "FROM", "users", "WHERE", "id", "=", "1"}},
+		{"SELECT * FROM users /* unterminated", []string{"SELECT", "*", "FROM", "users"}},
+		{"UPDATE t SET n = n - 1", []string{"UPDATE", "t", "SET", "n", "=", "n", "-", "1"}},
+		{"SELECT id / 2 FROM t", []string{"SELECT", "id", "/", "2", "FROM", "t"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, token := range parser.Tokenize(tt.input) {
+			if token.Type != parser.TokenEOF {
+				got = append(got, token.Value)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tokenize(%q): expected %q, got %q", tt.input, tt.want, got)
+		}
+	}
+
+	// Comment markers inside string literals are kept
+	tokens := parser.Tokenize("INSERT INTO notes (body) VALUES ('a -- b /* c */')")
+	last := tokens[len(tokens)-3]
+	if last.Type != parser.TokenString || last.Value != "a -- b /* c */" {
+		t.Errorf("Expected string token 'a -- b /* c */', got %+v", last)
+	}
+
+	cmd, err := parser.NewParser("SELECT name -- who\nFROM users /* filter */ WHERE id = 1").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if sel := cmd.(*parser.SelectCommand); sel.TableName != "users" || sel.Condition == nil {
+		t.Errorf("Expected SELECT from users with a condition, got %+v", sel)
+	}
+}
+
+func TestParseMultiRowInsert(t *testing.T) {
+	input := "INSERT INTO users (id,name) VALUES (1,'a'),(2,'b'),(3,'c')"
+	p := parser.NewParser(input)
+	cmd, err := p.Parse()
+
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	insertCmd, ok := cmd.(*parser.InsertCommand)
+	if !ok {
+		t.Fatalf("Expected InsertCommand, got %T", cmd)
+	}
+
+	if len(insertCmd.Rows) != 3 {
+		t.Fatalf("Expected 3 rows, got %d", len(insertCmd.Rows))
+	}
+
+	if insertCmd.Rows[2]["id"] != 3 || insertCmd.Rows[2]["name"] != "c" {
+		t.Errorf("Expected third row (3, 'c'), got %v", insertCmd.Rows[2])
+	}
+}
+
+func TestParsePositionalInsert(t *testing.T) {
+	cmd, err := parser.NewParser("INSERT INTO users VALUES (1, 'Bob', 'b@x.com'), (2, 'Cy')").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	insertCmd := cmd.(*parser.InsertCommand)
+	if insertCmd.HasColumns || len(insertCmd.Rows) != 0 {
+		t.Errorf("Expected no column list, got %+v", insertCmd)
+	}
+	want := [][]interface{}{{1, "Bob", "b@x.com"}, {2, "Cy"}}
+	if !reflect.DeepEqual(insertCmd.Values, want) {
+		t.Errorf("Expected values %v, got %v", want, insertCmd.Values)
+	}
+
+	cmd, _ = parser.NewParser("INSERT INTO users (id) VALUES (1)").Parse()
+	if !cmd.(*parser.InsertCommand).HasColumns {
+		t.Error("Expected HasColumns for an INSERT with a column list")
+	}
+}
+
+func TestParseLeftJoin(t *testing.T) {
+	inputs := []string{
+		"SELECT * FROM posts LEFT JOIN users ON posts.user_id = users.id",
+		"SELECT * FROM posts LEFT OUTER JOIN users ON posts.user_id = users.id",
+	}
+
+	for _, input := range inputs {
+		p := parser.NewParser(input)
+		cmd, err := p.Parse()
+		if err != nil {
+			t.Fatalf("Parse failed for %q: %v", input, err)
+		}
+
+		joinCmd, ok := cmd.(*parser.JoinCommand)
+		if !ok {
+			t.Fatalf("Expected JoinCommand, got %T", cmd)
+		}
+
+		if joinCmd.JoinType != engine.JoinLeft {
+			t.Errorf("Expected LEFT join type for %q, got '%s'", input, joinCmd.JoinType)
+		}
+
+		if joinCmd.RightTable != "users" {
+			t.Errorf("Expected right table 'users', got '%s'", joinCmd.RightTable)
+		}
+	}
+}
+
+func TestParseRightAndFullJoin(t *testing.T) {
+	tests := []struct {
+		input    string
+		joinType engine.JoinType
+	}{
+		{"SELECT * FROM posts RIGHT JOIN users ON posts.user_id = users.id", engine.JoinRight},
+		{"SELECT * FROM posts RIGHT OUTER JOIN users ON posts.user_id = users.id", engine.JoinRight},
+		{"SELECT * FROM posts FULL JOIN users ON posts.user_id = users.id", engine.JoinFull},
+		{"SELECT * FROM posts full outer join users ON posts.user_id = users.id", engine.JoinFull},
+	}
+
+	for _, tt := range tests {
+		cmd, err := parser.NewParser(tt.input).Parse()
+		if err != nil {
+			t.Fatalf("Parse failed for %q: %v", tt.input, err)
+		}
+		joinCmd, ok := cmd.(*parser.JoinCommand)
+		if !ok {
+			t.Fatalf("Expected JoinCommand, got %T", cmd)
+		}
+		if joinCmd.JoinType != tt.joinType || joinCmd.RightTable != "users" {
+			t.Errorf("Expected %s join of users for %q, got %s join of %s", tt.joinType, tt.input, joinCmd.JoinType, joinCmd.RightTable)
+		}
+	}
+
+	if _, err := parser.NewParser("SELECT * FROM posts FULL OUTER users ON posts.user_id = users.id").Parse(); err == nil {
+		t.Error("Expected an error for FULL OUTER without JOIN")
+	}
+}
+
+func TestParseAnalyze(t *testing.T) {
+	p := parser.NewParser("ANALYZE users")
+	cmd, err := p.Parse()
+
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	analyzeCmd, ok := cmd.(*parser.AnalyzeCommand)
+	if !ok {
+		t.Fatalf("Expected AnalyzeCommand, got %T", cmd)
+	}
+
+	if analyzeCmd.TableName != "users" {
+		t.Errorf("Expected table name 'users', got '%s'", analyzeCmd.TableName)
+	}
+}
+
+func TestParseAndOrPrecedence(t *testing.T) {
+	p := parser.NewParser("SELECT * FROM users WHERE a = 1 OR b = 2 AND c = 3")
+	cmd, err := p.Parse()
+
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	cond := cmd.(*parser.SelectCommand).Condition
+	if cond.Operator != "OR" {
+		t.Fatalf("Expected top-level OR, got '%s'", cond.Operator)
+	}
+
+	if cond.Left.Column != "a" {
+		t.Errorf("Expected left operand on 'a', got %s", cond.Left)
+	}
+
+	if cond.Right.Operator != "AND" {
+		t.Errorf("Expected right operand to be AND, got %s", cond.Right)
+	}
+}
+
+func TestParseCreateTableAutoIncrement(t *testing.T) {
+	p := parser.NewParser("CREATE TABLE users (id INT PRIMARY KEY AUTOINCREMENT, name STRING)")
+	cmd, err := p.Parse()
+
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	createCmd := cmd.(*parser.CreateTableCommand)
+	if !createCmd.Columns[0].AutoIncrement {
+		t.Error("Expected 'id' to be AUTOINCREMENT")
+	}
+
+	if createCmd.Columns[1].AutoIncrement {
+		t.Error("Did not expect 'name' to be AUTOINCREMENT")
+	}
+}
+
+func TestParseCreateTableDate(t *testing.T) {
+	p := parser.NewParser("CREATE TABLE events (id INT PRIMARY KEY, created_at TIMESTAMP NOT NULL, date DATE)")
+	cmd, err := p.Parse()
+
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	createCmd := cmd.(*parser.CreateTableCommand)
+	if col := createCmd.Columns[1]; col.Type != engine.TypeDate || !col.NotNull {
+		t.Errorf("Expected 'created_at' to be a NOT NULL DATE, got %+v", col)
+	}
+	if col := createCmd.Columns[2]; col.Name != "date" || col.Type != engine.TypeDate {
+		t.Errorf("Expected a DATE column named 'date', got %+v", col)
+	}
+}
+
+func TestParseCreateTableAsSelect(t *testing.T) {
+	p := parser.NewParser("CREATE TABLE archive AS SELECT * FROM posts WHERE user_id = 1")
+	cmd, err := p.Parse()
+
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	createCmd := cmd.(*parser.CreateTableCommand)
+	if createCmd.TableName != "archive" || createCmd.Columns != nil {
+		t.Errorf("Unexpected command: %+v", createCmd)
+	}
+	selectCmd, ok := createCmd.AsSelect.(*parser.SelectCommand)
+	if !ok {
+		t.Fatalf("Expected a SelectCommand, got %T", createCmd.AsSelect)
+	}
+	if selectCmd.TableName != "posts" || selectCmd.Condition == nil || selectCmd.Condition.Column != "user_id" {
+		t.Errorf("Unexpected inner SELECT: %+v", selectCmd)
+	}
+
+	if _, err := parser.NewParser("CREATE TABLE archive AS DELETE FROM posts").Parse(); err == nil {
+		t.Error("Expected an error for AS without SELECT")
+	}
+}
+
+func TestParseAlterTableAutoIncrement(t *testing.T) {
+	p := parser.NewParser("ALTER TABLE users AUTO_INCREMENT = 100")
+	cmd, err := p.Parse()
+
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	alterCmd, ok := cmd.(*parser.AlterTableCommand)
+	if !ok {
+		t.Fatalf("Expected AlterTableCommand, got %T", cmd)
+	}
+
+	if alterCmd.TableName != "users" || alterCmd.Kind != parser.AlterAutoIncrement {
+		t.Errorf("Unexpected command: %+v", alterCmd)
+	}
+
+	if alterCmd.AutoIncrement != 100 {
+		t.Errorf("Expected AUTO_INCREMENT 100, got %d", alterCmd.AutoIncrement)
+	}
+}
+
+func TestParseCreateTableReferences(t *testing.T) {
+	p := parser.NewParser("CREATE TABLE posts (id INT PRIMARY KEY, user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE, editor_id INT REFERENCES users(id))")
+	cmd, err := p.Parse()
+
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	createCmd := cmd.(*parser.CreateTableCommand)
+
+	fk := createCmd.Columns[1].References
+	if fk == nil {
+		t.Fatal("Expected 'user_id' to reference users(id)")
+	}
+	if fk.Table != "users" || fk.Column != "id" || !fk.OnDeleteCascade {
+		t.Errorf("Unexpected foreign key: %+v", fk)
+	}
+	if !createCmd.Columns[1].NotNull {
+		t.Error("Expected 'user_id' to be NOT NULL")
+	}
+
+	fk = createCmd.Columns[2].References
+	if fk == nil || fk.OnDeleteCascade {
+		t.Errorf("Expected 'editor_id' to reference users(id) without cascade, got %+v", fk)
+	}
+
+	if createCmd.Columns[0].References != nil {
+		t.Error("Did not expect 'id' to reference anything")
+	}
+}
+
+func TestParseBooleanShorthand(t *testing.T) {
+	tests := []struct {
+		input string
+		value bool
+	}{
+		{"SELECT * FROM users WHERE active", true},
+		{"SELECT * FROM users WHERE NOT active", false},
+		{"SELECT * FROM users WHERE active = TRUE", true},
+		{"SELECT * FROM users WHERE active = false", false},
+	}
+
+	for _, tt := range tests {
+		cmd, err := parser.NewParser(tt.input).Parse()
+		if err != nil {
+			t.Fatalf("%s: parse failed: %v", tt.input, err)
+		}
+
+		cond := cmd.(*parser.SelectCommand).Condition
+		if cond == nil || cond.Column != "active" || cond.Operator != "=" || cond.Value != tt.value {
+			t.Errorf("%s: expected active = %v, got %+v", tt.input, tt.value, cond)
+		}
+	}
+}
+
+func TestBooleanShorthandFiltersRows(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "active", Type: engine.TypeBool},
+	})
+	db.Insert("users", engine.Row{"id": 1, "active": true})
+	db.Insert("users", engine.Row{"id": 2, "active": false})
+	db.Insert("users", engine.Row{"id": 3, "active": true})
+	db.Insert("users", engine.Row{"id": 4, "active": nil})
+
+	tests := []struct {
+		input string
+		want  int
+	}{
+		{"SELECT * FROM users WHERE active", 2},
+		{"SELECT * FROM users WHERE NOT active", 1},
+		{"SELECT * FROM users WHERE active AND id > 1", 1},
+		{"SELECT * FROM users WHERE NOT active OR id = 1", 2},
+	}
+
+	for _, tt := range tests {
+		cmd, err := parser.NewParser(tt.input).Parse()
+		if err != nil {
+			t.Fatalf("%s: parse failed: %v", tt.input, err)
+		}
+
+		rows, err := db.Query(cmd.(*parser.SelectCommand).Query())
+		if err != nil {
+			t.Fatalf("%s: query failed: %v", tt.input, err)
+		}
+		if len(rows) != tt.want {
+			t.Errorf("%s: expected %d rows, got %d", tt.input, tt.want, len(rows))
+		}
+	}
+}
+
+func TestParseNot(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"SELECT * FROM users WHERE NOT active = true", "NOT active = true"},
+		{"SELECT * FROM users WHERE NOT NOT id > 1", "NOT NOT id > 1"},
+		{"SELECT * FROM users WHERE NOT id = 1 AND name = 'a'", "(NOT id = 1 AND name = 'a')"},
+		{"SELECT * FROM users WHERE id = 1 OR NOT name LIKE 'a%'", "(id = 1 OR NOT name LIKE 'a%')"},
+		{"SELECT * FROM users WHERE NOT email IS NULL", "NOT email IS NULL"},
+	}
+	for _, tt := range tests {
+		cmd, err := parser.NewParser(tt.input).Parse()
+		if err != nil {
+			t.Fatalf("%s: parse failed: %v", tt.input, err)
+		}
+		if got := cmd.(*parser.SelectCommand).Condition.String(); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.input, tt.want, got)
+		}
+	}
+
+	if _, err := parser.NewParser("SELECT * FROM users WHERE NOT").Parse(); err == nil {
+		t.Error("Expected error for NOT without a condition")
+	}
+}
+
+func TestParseGroupedConditions(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"SELECT * FROM t WHERE (a = 1 OR b = 2) AND c = 3", "((a = 1 OR b = 2) AND c = 3)"},
+		{"SELECT * FROM t WHERE a = 1 OR b = 2 AND c = 3", "(a = 1 OR (b = 2 AND c = 3))"},
+		{"SELECT * FROM t WHERE ((a = 1))", "a = 1"},
+		{"SELECT * FROM t WHERE NOT (a = 1 AND b = 2)", "NOT (a = 1 AND b = 2)"},
+		{"SELECT * FROM t WHERE NOT (flag) OR a = 1", "(NOT flag = true OR a = 1)"},
+	}
+	for _, tt := range tests {
+		cmd, err := parser.NewParser(tt.input).Parse()
+		if err != nil {
+			t.Fatalf("%s: parse failed: %v", tt.input, err)
+		}
+		if got := cmd.(*parser.SelectCommand).Condition.String(); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.input, tt.want, got)
+		}
+	}
+
+	for _, input := range []string{
+		"SELECT * FROM t WHERE (a = 1 OR b = 2 AND c = 3",
+		"SELECT * FROM t WHERE ()",
+		"SELECT * FROM t WHERE a = 1)",
+	} {
+		if _, err := parser.NewParser(input).Parse(); err == nil {
+			t.Errorf("%s: expected error", input)
+		}
+	}
+}
+
+func TestGroupedConditionsFilterRows(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("t", []engine.Column{
+		{Name: "a", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "b", Type: engine.TypeInt},
+		{Name: "c", Type: engine.TypeInt},
+	})
+	db.Insert("t", engine.Row{"a": 1, "b": 0, "c": 0})
+	db.Insert("t", engine.Row{"a": 2, "b": 2, "c": 3})
+	db.Insert("t", engine.Row{"a": 3, "b": 2, "c": 0})
+
+	tests := []struct {
+		input string
+		want  int
+	}{
+		{"SELECT * FROM t WHERE a = 1 OR b = 2 AND c = 3", 2},
+		{"SELECT * FROM t WHERE (a = 1 OR b = 2) AND c = 3", 1},
+		{"SELECT * FROM t WHERE NOT (a = 1 OR b = 2)", 0},
+		{"SELECT * FROM t WHERE NOT (b = 2 AND c = 3)", 2},
+	}
+	for _, tt := range tests {
+		cmd, err := parser.NewParser(tt.input).Parse()
+		if err != nil {
+			t.Fatalf("%s: parse failed: %v", tt.input, err)
+		}
+		rows, err := db.Query(cmd.(*parser.SelectCommand).Query())
+		if err != nil {
+			t.Fatalf("%s: query failed: %v", tt.input, err)
+		}
+		if len(rows) != tt.want {
+			t.Errorf("%s: expected %d rows, got %d", tt.input, tt.want, len(rows))
+		}
+	}
+}
+
+func TestParseLike(t *testing.T) {
+	cmd, err := parser.NewParser("SELECT * FROM users WHERE name LIKE 'A%' AND id > 1").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	cond := cmd.(*parser.SelectCommand).Condition
+	if cond.Operator != "AND" {
+		t.Fatalf("Expected AND condition, got %s", cond.Operator)
+	}
+	if cond.Left.Column != "name" || cond.Left.Operator != "LIKE" || cond.Left.Value != "A%" {
+		t.Errorf("Unexpected LIKE condition: %+v", cond.Left)
+	}
+
+	cmd, err = parser.NewParser("SELECT * FROM users WHERE name ilike 'bob'").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if cond := cmd.(*parser.SelectCommand).Condition; cond.Operator != "ILIKE" || cond.Value != "bob" {
+		t.Errorf("Unexpected ILIKE condition: %+v", cond)
+	}
+
+	if _, err := parser.NewParser("SELECT * FROM users WHERE name LIKE 5").Parse(); err == nil {
+		t.Error("Expected error for non-string LIKE pattern")
+	}
+}
+
+func TestParseBetween(t *testing.T) {
+	cmd, err := parser.NewParser("SELECT * FROM users WHERE age BETWEEN 18 AND 65 AND name = 'x'").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	cond := cmd.(*parser.SelectCommand).Condition
+	if cond.Operator != "AND" {
+		t.Fatalf("Expected AND condition, got %s", cond.Operator)
+	}
+
+	between := cond.Left
+	if between.Column != "age" || between.Operator != "BETWEEN" || between.Value != 18 || between.High != 65 {
+		t.Errorf("Unexpected BETWEEN condition: %+v", between)
+	}
+	if between.String() != "age BETWEEN 18 AND 65" {
+		t.Errorf("Unexpected rendering: %s", between.String())
+	}
+
+	if _, err := parser.NewParser("SELECT * FROM users WHERE age BETWEEN 18 OR 65").Parse(); err == nil {
+		t.Error("Expected error for BETWEEN without AND")
+	}
+}
+
+func TestParseLikeEscape(t *testing.T) {
+	cmd, err := parser.NewParser(`SELECT * FROM users WHERE name LIKE 'a\_b' ESCAPE '\'`).Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	cond := cmd.(*parser.SelectCommand).Condition
+	if cond.Value != `a\_b` || cond.Escape != '\\' {
+		t.Errorf("Unexpected LIKE condition: %+v", cond)
+	}
+	if cond.String() != `name LIKE 'a\_b' ESCAPE '\'` {
+		t.Errorf("Unexpected rendering: %s", cond.String())
+	}
+
+	if _, err := parser.NewParser(`SELECT * FROM users WHERE name LIKE 'a' ESCAPE 'ab'`).Parse(); err == nil {
+		t.Error("Expected error for multi-character ESCAPE")
+	}
+}
+
+func TestParseDropTable(t *testing.T) {
+	cmd, err := parser.NewParser("DROP TABLE users").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	dropCmd, ok := cmd.(*parser.DropTableCommand)
+	if !ok {
+		t.Fatalf("Expected DropTableCommand, got %T", cmd)
+	}
+	if dropCmd.TableName != "users" {
+		t.Errorf("Expected table name 'users', got '%s'", dropCmd.TableName)
+	}
+
+	if _, err := parser.NewParser("DROP users").Parse(); err == nil {
+		t.Error("Expected error for DROP without TABLE")
+	}
+}
+
+func TestParseCreateAndDropIndex(t *testing.T) {
+	cmd, err := parser.NewParser("CREATE INDEX ON users (email)").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	create, ok := cmd.(*parser.CreateIndexCommand)
+	if !ok {
+		t.Fatalf("Expected CreateIndexCommand, got %T", cmd)
+	}
+	if create.TableName != "users" || create.Column != "email" {
+		t.Errorf("Expected users(email), got %s(%s)", create.TableName, create.Column)
+	}
+
+	cmd, err = parser.NewParser("drop index on users (email);").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	drop, ok := cmd.(*parser.DropIndexCommand)
+	if !ok {
+		t.Fatalf("Expected DropIndexCommand, got %T", cmd)
+	}
+	if drop.TableName != "users" || drop.Column != "email" {
+		t.Errorf("Expected users(email), got %s(%s)", drop.TableName, drop.Column)
+	}
+
+	// INDEX is not reserved
+	if _, err := parser.NewParser("CREATE TABLE t (index INT)").Parse(); err != nil {
+		t.Errorf("Expected a column named index to parse, got %v", err)
+	}
+
+	for _, sql := range []string{
+		"CREATE INDEX users (email)",
+		"CREATE INDEX ON users email",
+		"CREATE INDEX ON users (email",
+		"DROP INDEX ON users ()",
+	} {
+		if _, err := parser.NewParser(sql).Parse(); err == nil {
+			t.Errorf("Expected error for %q", sql)
+		}
+	}
+}
+
+func TestParseExplain(t *testing.T) {
+	cmd, err := parser.NewParser("EXPLAIN SELECT * FROM users WHERE email = 'x'").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	explain, ok := cmd.(*parser.ExplainCommand)
+	if !ok {
+		t.Fatalf("Expected ExplainCommand, got %T", cmd)
+	}
+	selectCmd, ok := explain.Statement.(*parser.SelectCommand)
+	if !ok || selectCmd.TableName != "users" || selectCmd.Condition.Column != "email" {
+		t.Errorf("Expected the SELECT on users.email, got %+v", explain.Statement)
+	}
+
+	cmd, err = parser.NewParser("EXPLAIN SELECT * FROM posts INNER JOIN users ON posts.user_id = users.id").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if _, ok := cmd.(*parser.ExplainCommand).Statement.(*parser.JoinCommand); !ok {
+		t.Errorf("Expected an explained JoinCommand, got %T", cmd.(*parser.ExplainCommand).Statement)
+	}
+
+	if _, err := parser.NewParser("EXPLAIN DELETE FROM users").Parse(); err == nil {
+		t.Error("Expected error for EXPLAIN of a DELETE")
+	}
+}
+
+func TestParseSelectDistinct(t *testing.T) {
+	cmd, err := parser.NewParser("SELECT DISTINCT country FROM users ORDER BY country").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	selectCmd := cmd.(*parser.SelectCommand)
+	if !selectCmd.Distinct {
+		t.Error("Expected Distinct to be set")
+	}
+	if selectCmd.DistinctOn != nil {
+		t.Errorf("Did not expect DISTINCT ON columns, got %v", selectCmd.DistinctOn)
+	}
+	if len(selectCmd.Columns) != 1 || selectCmd.Columns[0] != "country" {
+		t.Errorf("Expected columns [country], got %v", selectCmd.Columns)
+	}
+
+	cmd, _ = parser.NewParser("SELECT DISTINCT ON (country) * FROM users").Parse()
+	if cmd.(*parser.SelectCommand).Distinct {
+		t.Error("Did not expect Distinct to be set for DISTINCT ON")
+	}
+}
+
+func TestParseOrderByNulls(t *testing.T) {
+	tests := []struct {
+		input string
+		desc  bool
+		nulls engine.NullsOrder
+	}{
+		{"SELECT * FROM users ORDER BY age", false, engine.NullsDefault},
+		{"SELECT * FROM users ORDER BY age NULLS FIRST", false, engine.NullsFirst},
+		{"SELECT * FROM users ORDER BY age ASC NULLS LAST", false, engine.NullsLast},
+		{"SELECT * FROM users ORDER BY age DESC NULLS LAST", true, engine.NullsLast},
+		{"select * from users order by age desc nulls first", true, engine.NullsFirst},
+	}
+
+	for _, tt := range tests {
+		cmd, err := parser.NewParser(tt.input).Parse()
+		if err != nil {
+			t.Fatalf("Parse %q failed: %v", tt.input, err)
+		}
+		order := cmd.(*parser.SelectCommand).OrderBy[0]
+		if order.Desc != tt.desc || order.Nulls != tt.nulls {
+			t.Errorf("%q: expected desc=%v nulls=%v, got %+v", tt.input, tt.desc, tt.nulls, order)
+		}
+	}
+
+	if _, err := parser.NewParser("SELECT * FROM users ORDER BY age NULLS").Parse(); err == nil {
+		t.Error("Expected error for NULLS without FIRST or LAST")
+	}
+}
+
+func TestParseMultipleOrderByKeys(t *testing.T) {
+	cmd, err := parser.NewParser("SELECT * FROM users ORDER BY age DESC, name ASC, id NULLS FIRST").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	expected := []engine.OrderBy{
+		{Column: "age", Desc: true},
+		{Column: "name"},
+		{Column: "id", Nulls: engine.NullsFirst},
+	}
+	if got := cmd.(*parser.SelectCommand).OrderBy; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+
+	if _, err := parser.NewParser("SELECT * FROM users ORDER BY age,").Parse(); err == nil {
+		t.Error("Expected error for a trailing comma in ORDER BY")
+	}
+}
+
+func TestPreparePlaceholders(t *testing.T) {
+	cmd, params, err := parser.NewParser("SELECT * FROM users WHERE id = ? OR (name LIKE ? AND age BETWEEN ? AND ?)").Prepare()
+	if err != nil {
+		t.Fatalf("Prepare failed: %v", err)
+	}
+	if params != 4 {
+		t.Errorf("Expected 4 placeholders, got %d", params)
+	}
+
+	cond := cmd.(*parser.SelectCommand).Condition
+	like := cond.Right.Left
+	between := cond.Right.Right
+	got := []interface{}{cond.Left.Value, like.Value, between.Value, between.High}
+	for i, value := range got {
+		if value != (engine.Param{Index: i}) {
+			t.Errorf("Placeholder %d: expected engine.Param{Index: %d}, got %#v", i, i, value)
+		}
+	}
+
+	// Statements without placeholders prepare like they parse
+	if _, params, err := parser.NewParser("DELETE FROM users WHERE id = 1").Prepare(); err != nil || params != 0 {
+		t.Errorf("Expected no placeholders, got %d, %v", params, err)
+	}
+}
+
+func TestParseRejectsPlaceholders(t *testing.T) {
+	_, err := parser.NewParser("SELECT * FROM users WHERE id = ?").Parse()
+	if err == nil || !strings.Contains(err.Error(), "only allowed in prepared statements") {
+		t.Errorf("Expected placeholders to need Prepare, got %v", err)
+	}
+
+	// Values outside conditions are never placeholders
+	if _, _, err := parser.NewParser("INSERT INTO users (id) VALUES (?)").Prepare(); err == nil {
+		t.Error("Expected a placeholder in VALUES to be rejected")
+	}
+}
+
+func TestParseNegativeNumbers(t *testing.T) {
+	cmd, err := parser.NewParser("INSERT INTO accounts (id, balance) VALUES (1, -50)").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if balance := cmd.(*parser.InsertCommand).Rows[0]["balance"]; balance != -50 {
+		t.Errorf("Expected balance -50, got %v", balance)
+	}
+
+	cmd, err = parser.NewParser("SELECT * FROM accounts WHERE balance < -10").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	cond := cmd.(*parser.SelectCommand).Condition
+	if cond.Operator != "<" || cond.Value != -10 {
+		t.Errorf("Expected '< -10', got '%s %v'", cond.Operator, cond.Value)
+	}
+
+	// A minus after a column is still subtraction, and its operand may be negative
+	cmd, err = parser.NewParser("SELECT * FROM accounts WHERE balance - -5 = 0").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	cond = cmd.(*parser.SelectCommand).Condition
+	if cond.Arith == nil || cond.Arith.Operator != "-" || cond.Arith.Operand != -5 {
+		t.Errorf("Expected '- -5' arithmetic, got %+v", cond.Arith)
+	}
+
+	if _, err := parser.NewParser("INSERT INTO accounts (id, balance) VALUES (1, -)").Parse(); err == nil {
+		t.Error("Expected error for a lone minus")
+	}
+}
+
+func TestNegativeNumbersRoundTrip(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("accounts", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "balance", Type: engine.TypeInt},
+	})
+
+	for _, sql := range []string{
+		"INSERT INTO accounts (id, balance) VALUES (1, -50)",
+		"INSERT INTO accounts (id, balance) VALUES (2, 20), (3, -5)",
+	} {
+		cmd, err := parser.NewParser(sql).Parse()
+		if err != nil {
+			t.Fatalf("Parse %q failed: %v", sql, err)
+		}
+		insert := cmd.(*parser.InsertCommand)
+		if _, err := db.InsertMany(insert.TableName, insert.Rows); err != nil {
+			t.Fatalf("Insert failed: %v", err)
+		}
+	}
+
+	cmd, err := parser.NewParser("SELECT id FROM accounts WHERE balance < -1 ORDER BY balance").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	rows, err := db.Query(cmd.(*parser.SelectCommand).Query())
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(rows) != 2 || rows[0]["id"] != 1 || rows[1]["id"] != 3 {
+		t.Errorf("Expected ids [1 3], got %v", rows)
+	}
+}
+
+func TestParseIsNull(t *testing.T) {
+	tests := []struct {
+		input    string
+		operator string
+	}{
+		{"SELECT * FROM users WHERE email IS NULL", "IS NULL"},
+		{"SELECT * FROM users WHERE email is not null", "IS NOT NULL"},
+	}
+
+	for _, tt := range tests {
+		cmd, err := parser.NewParser(tt.input).Parse()
+		if err != nil {
+			t.Fatalf("Parse %q failed: %v", tt.input, err)
+		}
+		cond := cmd.(*parser.SelectCommand).Condition
+		if cond.Column != "email" || cond.Operator != tt.operator {
+			t.Errorf("%q: expected email %s, got %s", tt.input, tt.operator, cond)
+		}
+	}
+
+	cmd, err := parser.NewParser("DELETE FROM users WHERE email IS NULL AND id > 3").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if got := cmd.(*parser.DeleteCommand).Condition.String(); got != "(email IS NULL AND id > 3)" {
+		t.Errorf("Unexpected condition: %s", got)
+	}
+
+	for _, input := range []string{
+		"SELECT * FROM users WHERE email IS",
+		"SELECT * FROM users WHERE email IS NOT 5",
+	} {
+		if _, err := parser.NewParser(input).Parse(); err == nil {
+			t.Errorf("Expected error for %q", input)
+		}
+	}
+}
+
+func TestParseAlterTableAddColumn(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected engine.Column
+	}{
+		{"ALTER TABLE users ADD COLUMN age INT", engine.Column{Name: "age", Type: engine.TypeInt}},
+		{"ALTER TABLE users ADD email STRING UNIQUE", engine.Column{Name: "email", Type: engine.TypeString, Unique: true}},
+		{"alter table users add column active BOOL NOT NULL", engine.Column{Name: "active", Type: engine.TypeBool, NotNull: true}},
+	}
+
+	for _, tt := range tests {
+		cmd, err := parser.NewParser(tt.input).Parse()
+		if err != nil {
+			t.Fatalf("Parse %q failed: %v", tt.input, err)
+		}
+		alter := cmd.(*parser.AlterTableCommand)
+		if alter.TableName != "users" || alter.Kind != parser.AlterAddColumn {
+			t.Errorf("%q: expected ADD COLUMN on users, got %+v", tt.input, alter)
+		}
+		if alter.Column != tt.expected {
+			t.Errorf("%q: expected %+v, got %+v", tt.input, tt.expected, alter.Column)
+		}
+	}
+
+	for _, input := range []string{
+		"ALTER TABLE users ADD COLUMN",
+		"ALTER TABLE users ADD COLUMN age INT, name STRING",
+	} {
+		if _, err := parser.NewParser(input).Parse(); err == nil {
+			t.Errorf("Expected error for %q", input)
+		}
+	}
+}
+
+func TestParseAlterTableRename(t *testing.T) {
+	cmd, err := parser.NewParser("ALTER TABLE users RENAME TO members").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	alter := cmd.(*parser.AlterTableCommand)
+	if alter.TableName != "users" || alter.Kind != parser.AlterRenameTable || alter.NewName != "members" {
+		t.Errorf("Expected users renamed to members, got %+v", alter)
+	}
+
+	cmd, err = parser.NewParser("alter table users rename column email to contact").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	alter = cmd.(*parser.AlterTableCommand)
+	if alter.Kind != parser.AlterRenameColumn || alter.ColumnName != "email" || alter.NewName != "contact" {
+		t.Errorf("Expected email renamed to contact, got %+v", alter)
+	}
+
+	for _, input := range []string{
+		"ALTER TABLE users RENAME members",
+		"ALTER TABLE users RENAME TO",
+		"ALTER TABLE users RENAME COLUMN email contact",
+		"ALTER TABLE users RENAME COLUMN TO contact",
+	} {
+		if _, err := parser.NewParser(input).Parse(); err == nil {
+			t.Errorf("Expected error for %q", input)
+		}
+	}
+}
+
+func TestParseGroupBy(t *testing.T) {
+	cmd, err := parser.NewParser("SELECT region, COUNT(*) FROM orders WHERE paid GROUP BY region, year ORDER BY region").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	selectCmd := cmd.(*parser.SelectCommand)
+	if len(selectCmd.GroupBy) != 2 || selectCmd.GroupBy[0] != "region" || selectCmd.GroupBy[1] != "year" {
+		t.Errorf("Expected GROUP BY region, year, got %v", selectCmd.GroupBy)
+	}
+	if selectCmd.Condition == nil || selectCmd.Condition.Column != "paid" {
+		t.Errorf("Expected boolean shorthand before GROUP BY, got %v", selectCmd.Condition)
+	}
+	if len(selectCmd.OrderBy) != 1 || selectCmd.OrderBy[0].Column != "region" {
+		t.Errorf("Expected ORDER BY after GROUP BY, got %+v", selectCmd.OrderBy)
+	}
+	if q := selectCmd.Query(); len(q.GroupBy) != 2 {
+		t.Errorf("Expected GROUP BY carried into the query, got %+v", q)
+	}
+
+	if _, err := parser.NewParser("SELECT COUNT(*) FROM orders GROUP region").Parse(); err == nil {
+		t.Error("Expected error for GROUP without BY")
+	}
+}
+
+func TestParseSelectAliases(t *testing.T) {
+	cmd, err := parser.NewParser("SELECT name AS full_name, email contact, COUNT(*) AS total FROM users").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	selectCmd := cmd.(*parser.SelectCommand)
+	if len(selectCmd.Columns) != 2 || selectCmd.Columns[0] != "name" || selectCmd.Columns[1] != "email" {
+		t.Errorf("Expected columns name, email, got %v", selectCmd.Columns)
+	}
+	if selectCmd.Aliases["name"] != "full_name" || selectCmd.Aliases["email"] != "contact" {
+		t.Errorf("Expected aliases full_name and contact, got %v", selectCmd.Aliases)
+	}
+	if len(selectCmd.Aggregates) != 1 || selectCmd.Aggregates[0].Name() != "total" {
+		t.Errorf("Expected COUNT(*) aliased as total, got %+v", selectCmd.Aggregates)
+	}
+
+	if _, err := parser.NewParser("SELECT name AS FROM users").Parse(); err == nil {
+		t.Error("Expected error for AS without an alias")
+	}
+	if _, err := parser.NewParser("SELECT name AS n FROM users INNER JOIN posts ON users.id = posts.user_id").Parse(); err == nil {
+		t.Error("Expected error for aliases with JOIN")
+	}
+}
+
+func TestParseScalarFunctions(t *testing.T) {
+	cmd, err := parser.NewParser("SELECT id, upper(name), LENGTH(name) AS len FROM users").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	selectCmd := cmd.(*parser.SelectCommand)
+	want := []string{"id", "upper_name", "len"}
+	if !reflect.DeepEqual(selectCmd.Columns, want) {
+		t.Errorf("Expected columns %v, got %v", want, selectCmd.Columns)
+	}
+	if f := selectCmd.Functions["upper_name"]; f.Func != "UPPER" || f.Column != "name" {
+		t.Errorf("Expected UPPER(name), got %+v", f)
+	}
+	if f := selectCmd.Functions["len"]; f.Func != "LENGTH" || f.Alias != "len" {
+		t.Errorf("Expected LENGTH(name) AS len, got %+v", f)
+	}
+
+	if _, err := parser.NewParser("SELECT UPPER(*) FROM users").Parse(); err == nil {
+		t.Error("Expected error for UPPER(*)")
+	}
+}
+
+func TestQualifiedColumnsInSingleTableSelect(t *testing.T) {
+	cmd, err := parser.NewParser("SELECT users.name FROM users WHERE users.id = 1").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	selectCmd := cmd.(*parser.SelectCommand)
+	if selectCmd.Columns[0] != "users.name" || selectCmd.Condition.Column != "users.id" {
+		t.Errorf("Expected qualified names to be kept, got %v and %v", selectCmd.Columns, selectCmd.Condition)
+	}
+
+	db := engine.NewDatabase()
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "name", Type: engine.TypeString},
+	})
+	db.Insert("users", engine.Row{"id": 1, "name": "moses"})
+
+	results, err := db.Query(selectCmd.Query())
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 row, got %d", len(results))
+	}
+	if name, _ := results[0].Get("name"); name != "moses" {
+		t.Errorf("Expected name moses, got %v", results[0])
+	}
+}
+
+func TestParseDescribe(t *testing.T) {
+	cmd, err := parser.NewParser("DESCRIBE users").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	describeCmd, ok := cmd.(*parser.DescribeCommand)
+	if !ok {
+		t.Fatalf("Expected DescribeCommand, got %T", cmd)
+	}
+	if describeCmd.TableName != "users" || describeCmd.Type() != parser.CmdDescribe {
+		t.Errorf("Expected DESCRIBE users, got %+v", describeCmd)
+	}
+
+	if _, err := parser.NewParser("DESCRIBE").Parse(); err == nil {
+		t.Error("Expected error for DESCRIBE without a table")
+	}
+}
+
+func TestParseShowTables(t *testing.T) {
+	cmd, err := parser.NewParser("SHOW TABLES").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if cmd.Type() != parser.CmdShowTables {
+		t.Errorf("Expected SHOW TABLES, got %T", cmd)
+	}
+
+	if _, err := parser.NewParser("SHOW users").Parse(); err == nil {
+		t.Error("Expected error for SHOW without TABLES")
+	}
+}
+
+func TestParseCreateTableCheck(t *testing.T) {
+	p := parser.NewParser("CREATE TABLE users (id INT PRIMARY KEY, age INT CHECK (age >= 0 AND age < 150) NOT NULL)")
+	cmd, err := p.Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	age := cmd.(*parser.CreateTableCommand).Columns[1]
+	if age.Check == nil || age.Check.String() != "(age >= 0 AND age < 150)" {
+		t.Errorf("Expected CHECK (age >= 0 AND age < 150), got %v", age.Check)
+	}
+	if !age.NotNull {
+		t.Error("Expected constraints after CHECK to be parsed")
+	}
+
+	for _, sql := range []string{
+		"CREATE TABLE users (age INT CHECK age >= 0)",
+		"CREATE TABLE users (age INT CHECK (age >= 0)",
+	} {
+		if _, err := parser.NewParser(sql).Parse(); err == nil {
+			t.Errorf("Expected parse error for %q", sql)
+		}
+	}
+}
+
+func TestParseTruncate(t *testing.T) {
+	cmd, err := parser.NewParser("TRUNCATE TABLE users").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	truncateCmd, ok := cmd.(*parser.TruncateCommand)
+	if !ok {
+		t.Fatalf("Expected TruncateCommand, got %T", cmd)
+	}
+	if truncateCmd.TableName != "users" {
+		t.Errorf("Expected table name 'users', got '%s'", truncateCmd.TableName)
+	}
+
+	if _, err := parser.NewParser("TRUNCATE users").Parse(); err == nil {
+		t.Error("Expected error for TRUNCATE without TABLE")
+	}
+}
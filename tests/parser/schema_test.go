@@ -0,0 +1,94 @@
+package parser_test
+
+import (
+	"godb/engine"
+	"godb/parser"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSchemaSQLReparses(t *testing.T) {
+	db := engine.NewDatabase()
+
+	statements := []string{
+		"CREATE TABLE users (id INT PRIMARY KEY AUTOINCREMENT, email STRING UNIQUE NOT NULL, active BOOL)",
+		"CREATE TABLE posts (id INT PRIMARY KEY, user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE, title STRING)",
+		"CREATE TABLE comments (id INT PRIMARY KEY CHECK (id > 0), post_id INT REFERENCES posts(id), body STRING)",
+	}
+	for _, sql := range statements {
+		cmd, err := parser.NewParser(sql).Parse()
+		if err != nil {
+			t.Fatalf("Parse %q failed: %v", sql, err)
+		}
+		create := cmd.(*parser.CreateTableCommand)
+		if err := db.CreateTable(create.TableName, create.Columns); err != nil {
+			t.Fatalf("CreateTable failed: %v", err)
+		}
+	}
+	db.Insert("users", engine.Row{"email": "a@example.com"})
+	posts, _ := db.GetTable("posts")
+	posts.CreateIndex("title")
+
+	schema := db.SchemaSQL()
+	lines := strings.Split(strings.TrimSuffix(schema, "\n"), "\n")
+
+	// Parents come before children even though names sort the other way
+	expected := []string{
+		"CREATE TABLE users (id INT PRIMARY KEY AUTOINCREMENT, email STRING NOT NULL UNIQUE, active BOOL);",
+		"CREATE TABLE posts (id INT PRIMARY KEY, user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE, title STRING);",
+		"CREATE INDEX ON posts (title);",
+		"CREATE TABLE comments (id INT PRIMARY KEY CHECK (id > 0), post_id INT REFERENCES posts(id), body STRING);",
+	}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Fatalf("Unexpected schema:\n%s", schema)
+	}
+	if strings.Contains(schema, "INSERT") || strings.Contains(schema, "a@example.com") {
+		t.Errorf("Schema must not contain data:\n%s", schema)
+	}
+
+	// Replaying the CREATE TABLE statements yields the same definitions
+	replay := engine.NewDatabase()
+	for _, line := range lines {
+		if strings.HasPrefix(line, "CREATE INDEX") {
+			continue
+		}
+		cmd, err := parser.NewParser(line).Parse()
+		if err != nil {
+			t.Fatalf("Re-parse %q failed: %v", line, err)
+		}
+		create := cmd.(*parser.CreateTableCommand)
+		if err := replay.CreateTable(create.TableName, create.Columns); err != nil {
+			t.Fatalf("Replay of %q failed: %v", line, err)
+		}
+	}
+	for _, name := range []string{"users", "posts", "comments"} {
+		original, _ := db.GetTable(name)
+		copied, err := replay.GetTable(name)
+		if err != nil {
+			t.Fatalf("Table %s missing after replay", name)
+		}
+		if !reflect.DeepEqual(original.Schema(), copied.Schema()) {
+			t.Errorf("Schema of %s differs:\n got  %+v\n want %+v", name, copied.Schema(), original.Schema())
+		}
+	}
+}
+
+func TestColumnConstraints(t *testing.T) {
+	cmd, err := parser.NewParser("CREATE TABLE posts (id INT PRIMARY KEY AUTOINCREMENT, slug STRING UNIQUE NOT NULL, user_id INT REFERENCES users(id) ON DELETE CASCADE, body STRING)").Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	expected := []string{
+		"PRIMARY KEY AUTOINCREMENT",
+		"NOT NULL UNIQUE",
+		"REFERENCES users(id) ON DELETE CASCADE",
+		"",
+	}
+	for i, col := range cmd.(*parser.CreateTableCommand).Columns {
+		if got := strings.Join(col.Constraints(), " "); got != expected[i] {
+			t.Errorf("Column %s: expected constraints %q, got %q", col.Name, expected[i], got)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package parser_test
+
+import (
+	"fmt"
+	"godb/parser"
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{"single statement without semicolon", "SELECT * FROM users", []string{"SELECT * FROM users"}},
+		{"several statements on one line", "DELETE FROM users; SELECT * FROM users;", []string{"DELETE FROM users", "SELECT * FROM users"}},
+		{"semicolon inside a string", "INSERT INTO notes (body) VALUES ('a; b');", []string{"INSERT INTO notes (body) VALUES ('a; b')"}},
+		{"double-quoted string", `INSERT INTO notes (body) VALUES ("x;--y")`, []string{`INSERT INTO notes (body) VALUES ("x;--y")`}},
+		{"comments and blank statements", "-- schema\nCREATE TABLE t (id INT); ;\n\n-- data; not a statement\nINSERT INTO t (id) VALUES (1); -- trailing\n",
+			[]string{"CREATE TABLE t (id INT)", "INSERT INTO t (id) VALUES (1)"}},
+		{"statement spanning lines", "CREATE TABLE t (\n  id INT, -- key\n  name STRING\n);", []string{"CREATE TABLE t (\n  id INT, \n  name STRING\n)"}},
+		{"block comments", "/* setup; */ DELETE FROM users; /* unterminated; SELECT", []string{"DELETE FROM users"}},
+		{"only comments", "-- nothing here", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parser.SplitStatements(tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestIncomplete(t *testing.T) {
+	tests := []struct {
+		script string
+		want   bool
+	}{
+		{"SELECT * FROM users", false},
+		{"CREATE TABLE t (\n  id INT,\n", true},
+		{"CREATE TABLE t (\n  id INT\n);", false},
+		{"INSERT INTO notes (body) VALUES ('first line\n", true},
+		{"INSERT INTO notes (body) VALUES ('(')", false},
+		{"CREATE TABLE t ( -- columns follow )\n", true},
+		{"SELECT * FROM users /* still", true},
+		{"SELECT * FROM users /* done */", false},
+	}
+	for _, tt := range tests {
+		if got := parser.Incomplete(tt.script); got != tt.want {
+			t.Errorf("Incomplete(%q): expected %v, got %v", tt.script, tt.want, got)
+		}
+	}
+}
+
+func TestParseMany(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string // Type of each command
+	}{
+		{"SELECT * FROM users", []string{"*parser.SelectCommand"}},
+		{"DELETE FROM users; SELECT * FROM users", []string{"*parser.DeleteCommand", "*parser.SelectCommand"}},
+		{"CREATE TABLE t (id INT PRIMARY KEY); INSERT INTO t (id) VALUES (1);; SELECT * FROM t;",
+			[]string{"*parser.CreateTableCommand", "*parser.InsertCommand", "*parser.SelectCommand"}},
+	}
+	for _, tt := range tests {
+		cmds, err := parser.NewParser(tt.input).ParseMany()
+		if err != nil {
+			t.Fatalf("ParseMany(%q) failed: %v", tt.input, err)
+		}
+		var got []string
+		for _, cmd := range cmds {
+			got = append(got, fmt.Sprintf("%T", cmd))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseMany(%q): expected %v, got %v", tt.input, tt.want, got)
+		}
+	}
+
+	for _, input := range []string{"", ";;", "SELECT * FROM users; DELETE users", "SELECT * FROM users SELECT * FROM posts"} {
+		if _, err := parser.NewParser(input).ParseMany(); err == nil {
+			t.Errorf("ParseMany(%q): expected error, got nil", input)
+		}
+	}
+}
+
+func TestParseSemicolon(t *testing.T) {
+	if _, err := parser.NewParser("SELECT * FROM users;").Parse(); err != nil {
+		t.Errorf("Expected a trailing semicolon to be accepted, got %v", err)
+	}
+	if _, err := parser.NewParser("SELECT * FROM users; SELECT * FROM posts").Parse(); err == nil {
+		t.Error("Expected Parse to reject a second statement")
+	}
+}
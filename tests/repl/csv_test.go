@@ -0,0 +1,69 @@
+package repl_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"godb/engine"
+	"godb/repl"
+	"reflect"
+	"testing"
+)
+
+func TestWriteCSVQuotesTrickyValues(t *testing.T) {
+	rows := []engine.Row{
+		{"id": 1, "name": "Smith, John", "note": `says "hi"`, "active": true},
+		{"id": 2, "name": "two\nlines", "note": nil, "active": false},
+		{"id": 3, "name": " padded "},
+	}
+
+	var buf bytes.Buffer
+	if err := repl.WriteCSV(&buf, []string{"id", "name", "note", "active"}, rows); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+
+	want := "id,name,note,active\n" +
+		"1,\"Smith, John\",\"says \"\"hi\"\"\",true\n" +
+		"2,\"two\nlines\",,false\n" +
+		"3,\" padded \",,\n"
+	if buf.String() != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, buf.String())
+	}
+
+	// The output reads back to the original values
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read CSV back: %v", err)
+	}
+	if got := records[2]; !reflect.DeepEqual(got, []string{"2", "two\nlines", "", "false"}) {
+		t.Errorf("Unexpected record %q", got)
+	}
+}
+
+func TestWriteCSVWithoutColumns(t *testing.T) {
+	var buf bytes.Buffer
+	repl.WriteCSV(&buf, nil, []engine.Row{{"b": 2, "a": 1}})
+	if buf.String() != "a,b\n1,2\n" {
+		t.Errorf("Expected alphabetical columns, got %q", buf.String())
+	}
+
+	buf.Reset()
+	repl.WriteCSV(&buf, []string{"id"}, nil)
+	if buf.String() != "id\n" {
+		t.Errorf("Expected only the header for no rows, got %q", buf.String())
+	}
+}
+
+func TestParseOutputMode(t *testing.T) {
+	if mode, err := repl.ParseOutputMode("CSV"); err != nil || mode != repl.OutputCSV {
+		t.Errorf("Expected csv mode, got %v, %v", mode, err)
+	}
+	if mode, err := repl.ParseOutputMode("table"); err != nil || mode != repl.OutputTable {
+		t.Errorf("Expected table mode, got %v, %v", mode, err)
+	}
+	if mode, err := repl.ParseOutputMode("json"); err != nil || mode != repl.OutputJSON {
+		t.Errorf("Expected json mode, got %v, %v", mode, err)
+	}
+	if _, err := repl.ParseOutputMode("xml"); err == nil {
+		t.Error("Expected error for unknown mode")
+	}
+}
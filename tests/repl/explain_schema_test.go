@@ -0,0 +1,96 @@
+package repl_test
+
+import (
+	"godb/engine"
+	"godb/parser"
+	"godb/repl"
+	"reflect"
+	"testing"
+)
+
+// setupSchemaDB creates users and posts tables for the .explain-schema tests
+func setupSchemaDB(t *testing.T) *engine.Database {
+	t.Helper()
+	db := engine.NewDatabase()
+	if err := db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "name", Type: engine.TypeString},
+		{Name: "age", Type: engine.TypeInt},
+	}); err != nil {
+		t.Fatalf("Failed to create users: %v", err)
+	}
+	if err := db.CreateTable("posts", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "user_id", Type: engine.TypeInt},
+		{Name: "title", Type: engine.TypeString},
+	}); err != nil {
+		t.Fatalf("Failed to create posts: %v", err)
+	}
+	return db
+}
+
+// explainSchema returns the resolved target of each reference reported for sql
+func explainSchema(t *testing.T, db *engine.Database, sql string) map[string]string {
+	t.Helper()
+	cmd, err := parser.NewParser(sql).Parse()
+	if err != nil {
+		t.Fatalf("%s: parse failed: %v", sql, err)
+	}
+	report, err := repl.ExplainSchema(db, cmd)
+	if err != nil {
+		t.Fatalf("%s: %v", sql, err)
+	}
+	targets := make(map[string]string, len(report.Columns))
+	for _, res := range report.Columns {
+		target := "NOT FOUND"
+		if res.Resolved() {
+			target = res.Qualified()
+		}
+		targets[res.Reference] = target
+	}
+	return targets
+}
+
+func TestExplainSchemaCompoundWhere(t *testing.T) {
+	db := setupSchemaDB(t)
+
+	got := explainSchema(t, db, "SELECT name AS n FROM users WHERE id = 1 AND (age > 3 OR NOT name = 'x')")
+	want := map[string]string{"name": "users.name", "id": "users.id", "age": "users.age"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestExplainSchemaCompoundJoinWhere(t *testing.T) {
+	db := setupSchemaDB(t)
+
+	sql := "SELECT title FROM posts INNER JOIN users ON posts.user_id = users.id WHERE users.age > 3 OR posts.id = 1"
+	got := explainSchema(t, db, sql)
+	want := map[string]string{
+		"title":         "posts.title",
+		"posts.user_id": "posts.user_id",
+		"users.id":      "users.id",
+		"users.age":     "users.age",
+		"posts.id":      "posts.id",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestExplainSchemaFunctions(t *testing.T) {
+	db := setupSchemaDB(t)
+
+	sql := "SELECT id, UPPER(name) FROM users"
+	got := explainSchema(t, db, sql)
+	want := map[string]string{"id": "users.id", "name": "users.name"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	cmd, _ := parser.NewParser(sql).Parse()
+	report, _ := repl.ExplainSchema(db, cmd)
+	if output := []string{"id", "upper_name"}; !reflect.DeepEqual(report.Output, output) {
+		t.Errorf("Expected output columns %v, got %v", output, report.Output)
+	}
+}
@@ -0,0 +1,45 @@
+package repl_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"godb/engine"
+	"godb/repl"
+	"reflect"
+	"testing"
+)
+
+func TestWriteJSONKeepsNativeTypes(t *testing.T) {
+	rows := []engine.Row{
+		{"id": 1, "name": "Ada \"A\"", "score": 9.5, "active": true, "note": nil},
+		{"id": 2, "name": "Bob"}, // Missing columns are NULL
+	}
+
+	var buf bytes.Buffer
+	if err := repl.WriteJSON(&buf, []string{"id", "name", "score", "active", "note"}, rows); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+
+	want := "[\n" +
+		`  {"id":1,"name":"Ada \"A\"","score":9.5,"active":true,"note":null},` + "\n" +
+		`  {"id":2,"name":"Bob","score":null,"active":null,"note":null}` + "\n" +
+		"]\n"
+	if buf.String() != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, buf.String())
+	}
+
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	first := map[string]interface{}{"id": 1.0, "name": `Ada "A"`, "score": 9.5, "active": true, "note": nil}
+	if !reflect.DeepEqual(decoded[0], first) {
+		t.Errorf("Expected %v, got %v", first, decoded[0])
+	}
+
+	buf.Reset()
+	repl.WriteJSON(&buf, []string{"id"}, nil)
+	if buf.String() != "[]\n" {
+		t.Errorf("Expected an empty array, got %q", buf.String())
+	}
+}
@@ -0,0 +1,24 @@
+package repl_test
+
+import (
+	"godb/repl"
+	"testing"
+	"time"
+)
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{412*time.Microsecond + 300*time.Nanosecond, "412µs"},
+		{3*time.Millisecond + 217*time.Microsecond, "3.2ms"},
+		{1500*time.Millisecond + 400*time.Microsecond, "1.5s"},
+		{0, "0s"},
+	}
+	for _, tt := range tests {
+		if got := repl.FormatDuration(tt.d); got != tt.want {
+			t.Errorf("FormatDuration(%d): expected %q, got %q", tt.d, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package web_test
+
+import (
+	"encoding/json"
+	"godb/engine"
+	"godb/web"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func getJSON(t *testing.T, handler http.HandlerFunc, target string, v interface{}) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("Invalid JSON response %q: %v", rec.Body.String(), err)
+		}
+	}
+	return rec
+}
+
+func TestGetUsersPagination(t *testing.T) {
+	handler, db := setupHandler(t)
+	db.Insert("users", engine.Row{"id": 3, "email": "c@example.com"})
+
+	var users []web.UserResponse
+	rec := getJSON(t, handler.GetUsers, "/users?limit=1&offset=1", &users)
+	if rec.Code != http.StatusOK || len(users) != 1 || users[0].ID != 2 {
+		t.Errorf("Expected only user 2, got %d: %+v", rec.Code, users)
+	}
+	if total := rec.Header().Get("X-Total-Count"); total != "3" {
+		t.Errorf("Expected X-Total-Count 3, got %q", total)
+	}
+
+	rec = getJSON(t, handler.GetUsers, "/users?offset=2", &users)
+	if len(users) != 1 || users[0].ID != 3 {
+		t.Errorf("Expected the last user without a limit, got %+v", users)
+	}
+
+	rec = getJSON(t, handler.GetUsers, "/users?offset=10", &users)
+	if rec.Code != http.StatusOK || len(users) != 0 {
+		t.Errorf("Expected an empty page past the end, got %d: %+v", rec.Code, users)
+	}
+
+	for _, query := range []string{"limit=abc", "limit=0", "offset=-1"} {
+		rec = getJSON(t, handler.GetUsers, "/users?"+query, &users)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, rec.Code)
+		}
+	}
+}
+
+func TestGetPostsPagination(t *testing.T) {
+	handler, db := setupHandler(t)
+	for i := 1; i <= 5; i++ {
+		db.Insert("posts", engine.Row{"id": i, "user_id": 1})
+	}
+
+	var posts []web.PostWithUserResponse
+	rec := getJSON(t, handler.GetPosts, "/posts?limit=2&offset=2", &posts)
+	if rec.Code != http.StatusOK || len(posts) != 2 || posts[0].PostID != 3 || posts[1].PostID != 4 {
+		t.Errorf("Expected posts 3 and 4, got %d: %+v", rec.Code, posts)
+	}
+	if total := rec.Header().Get("X-Total-Count"); total != "5" {
+		t.Errorf("Expected X-Total-Count 5, got %q", total)
+	}
+}
+
+func TestCountUsers(t *testing.T) {
+	handler, _ := setupHandler(t)
+
+	var resp web.CountResponse
+	rec := getJSON(t, handler.CountUsers, "/users/count", &resp)
+	if rec.Code != http.StatusOK || resp.Count != 2 {
+		t.Errorf("Expected count 2, got %d: %+v", rec.Code, resp)
+	}
+}
+
+func TestDeleteUserByID(t *testing.T) {
+	handler, db := setupHandler(t)
+
+	deleteUser := func(target string) (int, web.ExecResponse) {
+		rec := httptest.NewRecorder()
+		handler.DeleteUser(rec, httptest.NewRequest(http.MethodDelete, target, nil))
+		var resp web.ExecResponse
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec.Code, resp
+	}
+
+	if status, resp := deleteUser("/users?id=1"); status != http.StatusOK || resp.RowsAffected != 1 {
+		t.Errorf("Expected 1 row deleted, got %d: %+v", status, resp)
+	}
+	if count, _ := db.RowCount("users"); count != 1 {
+		t.Errorf("Expected 1 user left, got %d", count)
+	}
+
+	if status, resp := deleteUser("/users?id=99"); status != http.StatusOK || resp.RowsAffected != 0 {
+		t.Errorf("Expected 0 rows affected for a missing id, got %d: %+v", status, resp)
+	}
+
+	for _, target := range []string{"/users", "/users?id=abc"} {
+		if status, _ := deleteUser(target); status != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", target, status)
+		}
+	}
+}
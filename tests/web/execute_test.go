@@ -0,0 +1,273 @@
+package web_test
+
+import (
+	"encoding/json"
+	"godb/engine"
+	"godb/web"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecuteDropTable(t *testing.T) {
+	handler, db := setupHandler(t)
+
+	body := postForm(handler.ExecuteSQL, "/execute", url.Values{"sql": {"DROP TABLE posts"}})
+	if !strings.Contains(body, "Table &#39;posts&#39; dropped") {
+		t.Errorf("Expected success message, got:\n%s", body)
+	}
+	if db.TableExists("posts") {
+		t.Error("Expected posts to be dropped")
+	}
+
+	body = postForm(handler.ExecuteSQL, "/execute", url.Values{"sql": {"DROP TABLE posts"}})
+	if !strings.Contains(body, "does not exist") {
+		t.Errorf("Expected table not found error, got:\n%s", body)
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	handler, _ := setupHandler(t)
+
+	postForm(handler.ExecuteSQL, "/execute", url.Values{"sql": {"SELECT * FROM users"}})
+
+	body := postForm(handler.Metrics, "/metrics", nil)
+	if !strings.Contains(body, "godb_selects_total 1\n") {
+		t.Errorf("Expected select counter in metrics, got:\n%s", body)
+	}
+	if !strings.Contains(body, "godb_full_scans_total 1\n") {
+		t.Errorf("Expected full scan counter in metrics, got:\n%s", body)
+	}
+}
+
+func TestConsoleShowsTableStats(t *testing.T) {
+	handler, _ := setupHandler(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/tabs/console", nil)
+	rec := httptest.NewRecorder()
+	handler.ConsoleTab(rec, req)
+	body := rec.Body.String()
+
+	if !strings.Contains(body, "<code>users</code>") || !strings.Contains(body, "2 rows") {
+		t.Errorf("Expected users stats in console, got:\n%s", body)
+	}
+	if !strings.Contains(body, "<code>posts</code>") || !strings.Contains(body, "0 rows") {
+		t.Errorf("Expected posts stats in console, got:\n%s", body)
+	}
+}
+
+func TestExecuteAddColumn(t *testing.T) {
+	handler, db := setupHandler(t)
+
+	body := postForm(handler.ExecuteSQL, "/execute", url.Values{"sql": {"ALTER TABLE users ADD COLUMN age INT"}})
+	if !strings.Contains(body, "Column &#39;age&#39; added to table &#39;users&#39;") {
+		t.Errorf("Expected success message, got:\n%s", body)
+	}
+
+	table, _ := db.GetTable("users")
+	if schema := table.Schema(); schema[len(schema)-1].Name != "age" {
+		t.Errorf("Expected age column in schema, got %+v", schema)
+	}
+
+	body = postForm(handler.ExecuteSQL, "/execute", url.Values{"sql": {"ALTER TABLE users ADD COLUMN score INT NOT NULL"}})
+	if !strings.Contains(body, "table with rows") {
+		t.Errorf("Expected NOT NULL rejection, got:\n%s", body)
+	}
+}
+
+func TestPreviewUpdateShowsOldAndNewValues(t *testing.T) {
+	handler, db := setupHandler(t)
+
+	body := postForm(handler.PreviewUpdate, "/preview-update", url.Values{
+		"table_name":   {"users"},
+		"where_column": {"id"},
+		"where_value":  {"1"},
+		"email":        {"new@example.com"},
+	})
+	if !strings.Contains(body, "a@example.com") || !strings.Contains(body, "new@example.com") {
+		t.Errorf("Expected old and new email in preview, got:\n%s", body)
+	}
+	if !strings.Contains(body, "1 row(s) will change") {
+		t.Errorf("Expected changed row count, got:\n%s", body)
+	}
+
+	results, _ := db.Select("users", nil, nil)
+	if email, _ := results[0].Get("email"); email != "a@example.com" {
+		t.Errorf("Expected preview to leave email unchanged, got %v", email)
+	}
+
+	body = postForm(handler.PreviewUpdate, "/preview-update", url.Values{
+		"table_name":   {"users"},
+		"where_column": {"id"},
+		"where_value":  {"1"},
+		"email":        {"a@example.com"},
+	})
+	if !strings.Contains(body, "Nothing will change") {
+		t.Errorf("Expected no-op message, got:\n%s", body)
+	}
+}
+
+func TestCreateUserEchoesRow(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("users", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "name", Type: engine.TypeString},
+		{Name: "email", Type: engine.TypeString},
+	})
+	handler := web.NewHandler(db, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"id": 7, "name": "moses", "email": "moses@example.com"}`))
+	rec := httptest.NewRecorder()
+	handler.CreateUser(rec, req)
+
+	var resp web.SuccessResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Invalid JSON response: %v", err)
+	}
+	if resp.Row["id"] != float64(7) || resp.Row["email"] != "moses@example.com" {
+		t.Errorf("Expected created row in response, got %v", resp.Row)
+	}
+}
+
+func TestExecuteSelectShowsAliasHeader(t *testing.T) {
+	handler, _ := setupHandler(t)
+
+	body := postForm(handler.ExecuteSQL, "/execute", url.Values{"sql": {"SELECT email AS contact FROM users"}})
+	if !strings.Contains(body, "contact") || strings.Contains(body, "email") || !strings.Contains(body, "<td>a@example.com</td>") {
+		t.Errorf("Expected alias as the column header, got:\n%s", body)
+	}
+}
+
+func TestExecuteSelectFormatsDates(t *testing.T) {
+	handler, db := setupHandler(t)
+	db.CreateTable("events", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "created_at", Type: engine.TypeDate},
+	})
+	db.Insert("events", engine.Row{"id": 1, "created_at": "2024-01-02"})
+	db.Insert("events", engine.Row{"id": 2, "created_at": "2024-01-02 10:30:00"})
+
+	body := postForm(handler.ExecuteSQL, "/execute", url.Values{"sql": {"SELECT * FROM events"}})
+	if !strings.Contains(body, "<td>2024-01-02</td>") || !strings.Contains(body, "<td>2024-01-02 10:30:00</td>") || strings.Contains(body, "UTC") {
+		t.Errorf("Expected dates formatted as in SQL, got:\n%s", body)
+	}
+
+	// The stored rows keep their times
+	rows, _ := db.Select("events", nil, nil)
+	if _, ok := rows[0]["created_at"].(time.Time); !ok {
+		t.Errorf("Expected the stored value to stay a time, got %#v", rows[0]["created_at"])
+	}
+}
+
+func TestBuildInsertCoercesBoolForms(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("flags", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "active", Type: engine.TypeBool},
+	})
+	handler := web.NewHandler(db, template.Must(template.ParseGlob("../../web/templates/*.html")))
+
+	postForm(handler.BuildInsert, "/build-insert", url.Values{"table_name": {"flags"}, "id": {"1"}, "active": {"Yes"}})
+	postForm(handler.BuildInsert, "/build-insert", url.Values{"table_name": {"flags"}, "id": {"2"}, "active": {"off"}})
+
+	rows, _ := db.Select("flags", nil, &engine.Condition{Column: "active", Operator: "=", Value: true})
+	if len(rows) != 1 {
+		t.Fatalf("Expected 1 active row, got %d", len(rows))
+	}
+	if id, _ := rows[0].Get("id"); id != 1 {
+		t.Errorf("Expected row 1 to be active, got %v", rows[0])
+	}
+
+	body := postForm(handler.BuildInsert, "/build-insert", url.Values{"table_name": {"flags"}, "id": {"3"}, "active": {"maybe"}})
+	if !strings.Contains(body, "expected BOOL") {
+		t.Errorf("Expected invalid BOOL error, got:\n%s", body)
+	}
+	if count, _ := db.RowCount("flags"); count != 2 {
+		t.Errorf("Expected rejected row not to be inserted, got %d rows", count)
+	}
+
+	// Conditions built from forms accept the same forms
+	body = postForm(handler.PreviewDelete, "/preview-delete", url.Values{
+		"table_name": {"flags"}, "where_column": {"active"}, "where_operator": {"="}, "where_value": {"0"},
+	})
+	if !strings.Contains(body, `<span class="delete-count">1</span>`) || !strings.Contains(body, "<td>2</td>") {
+		t.Errorf("Expected one row matching active = 0, got:\n%s", body)
+	}
+}
+
+func TestExecuteShowTables(t *testing.T) {
+	handler, db := setupHandler(t)
+
+	body := postForm(handler.ExecuteSQL, "/execute", url.Values{"sql": {"SHOW TABLES"}})
+	posts, users := strings.Index(body, "<td>posts</td>"), strings.Index(body, "<td>users</td>")
+	if posts < 0 || users < 0 || posts > users {
+		t.Errorf("Expected posts then users, got:\n%s", body)
+	}
+
+	db.DropTable("posts")
+	db.DropTable("users")
+	body = postForm(handler.ExecuteSQL, "/execute", url.Values{"sql": {"SHOW TABLES"}})
+	if !strings.Contains(body, "No tables") {
+		t.Errorf("Expected empty message, got:\n%s", body)
+	}
+}
+
+func TestExecuteSelectKeepsSchemaOrder(t *testing.T) {
+	handler, _ := setupHandler(t)
+
+	body := postForm(handler.ExecuteSQL, "/execute", url.Values{"sql": {"SELECT * FROM users"}})
+	id, email := strings.Index(body, "<td>1</td>"), strings.Index(body, "<td>a@example.com</td>")
+	if id < 0 || email < 0 || id > email {
+		t.Errorf("Expected id before email, got:\n%s", body)
+	}
+
+	body = postForm(handler.ExecuteSQL, "/execute", url.Values{"sql": {"SELECT email, id FROM users"}})
+	id, email = strings.Index(body, "<td>1</td>"), strings.Index(body, "<td>a@example.com</td>")
+	if id < 0 || email < 0 || email > id {
+		t.Errorf("Expected email before id, got:\n%s", body)
+	}
+}
+
+func TestExecuteMultipleStatements(t *testing.T) {
+	handler, db := setupHandler(t)
+
+	sql := "INSERT INTO users (id, email) VALUES (3, 'c@example.com'); DELETE FROM posts; SELECT * FROM users WHERE id = 3;"
+	body := postForm(handler.ExecuteSQL, "/execute", url.Values{"sql": {sql}})
+	if !strings.Contains(body, "<td>c@example.com</td>") {
+		t.Errorf("Expected the last statement's rows, got:\n%s", body)
+	}
+	if posts, _ := db.Select("posts", nil, nil); len(posts) != 0 {
+		t.Errorf("Expected every statement to run, got %d posts", len(posts))
+	}
+
+	// Statements before the failing one stay applied; later ones do not run
+	sql = "INSERT INTO users (id, email) VALUES (4, 'd@example.com'); INSERT INTO users (id, email) VALUES (1, 'x@example.com'); DELETE FROM users"
+	body = postForm(handler.ExecuteSQL, "/execute", url.Values{"sql": {sql}})
+	if !strings.Contains(body, "statement 2") {
+		t.Errorf("Expected the failing statement to be named, got:\n%s", body)
+	}
+	if users, _ := db.Select("users", nil, nil); len(users) != 4 {
+		t.Errorf("Expected 4 users, got %d", len(users))
+	}
+}
+
+func TestExecutePositionalInsert(t *testing.T) {
+	handler, db := setupHandler(t)
+
+	body := postForm(handler.ExecuteSQL, "/execute", url.Values{"sql": {"INSERT INTO users VALUES (3, 'c@example.com'), (4, NULL)"}})
+	if !strings.Contains(body, "2 row(s) inserted") {
+		t.Errorf("Expected 2 rows inserted, got:\n%s", body)
+	}
+	if rows, _ := db.Select("users", nil, &engine.Condition{Column: "id", Operator: "=", Value: 3}); len(rows) != 1 || rows[0]["email"] != "c@example.com" {
+		t.Errorf("Expected values mapped in schema order, got %v", rows)
+	}
+
+	body = postForm(handler.ExecuteSQL, "/execute", url.Values{"sql": {"INSERT INTO users VALUES (5)"}})
+	if !strings.Contains(body, "2 column(s) but 1 value(s)") {
+		t.Errorf("Expected a value count error, got:\n%s", body)
+	}
+}
@@ -0,0 +1,115 @@
+package web_test
+
+import (
+	"godb/engine"
+	"godb/web"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func setupHandler(t *testing.T) (*web.Handler, *engine.Database) {
+	db := engine.NewDatabase()
+
+	users := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "email", Type: engine.TypeString, Unique: true},
+	}
+	if err := db.CreateTable("users", users); err != nil {
+		t.Fatalf("Failed to create users: %v", err)
+	}
+
+	posts := []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "user_id", Type: engine.TypeInt},
+	}
+	if err := db.CreateTable("posts", posts); err != nil {
+		t.Fatalf("Failed to create posts: %v", err)
+	}
+
+	db.Insert("users", engine.Row{"id": 1, "email": "a@example.com"})
+	db.Insert("users", engine.Row{"id": 2, "email": "b@example.com"})
+
+	templates := template.Must(template.ParseGlob("../../web/templates/*.html"))
+	return web.NewHandler(db, templates), db
+}
+
+func postForm(handler http.HandlerFunc, path string, values url.Values) string {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(values.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec.Body.String()
+}
+
+func TestExplainIndexedQuery(t *testing.T) {
+	handler, _ := setupHandler(t)
+
+	body := postForm(handler.ExplainSQL, "/explain", url.Values{
+		"sql": {"SELECT * FROM users WHERE email = 'a@example.com'"},
+	})
+
+	if !strings.Contains(body, "IndexScan") {
+		t.Errorf("Expected IndexScan in plan, got:\n%s", body)
+	}
+	if !strings.Contains(body, "users.email = &#39;a@example.com&#39;") {
+		t.Errorf("Expected access path detail in plan, got:\n%s", body)
+	}
+}
+
+func TestExplainFullScan(t *testing.T) {
+	handler, _ := setupHandler(t)
+
+	body := postForm(handler.ExplainSQL, "/explain", url.Values{
+		"sql": {"SELECT * FROM posts WHERE user_id = 1"},
+	})
+
+	if !strings.Contains(body, "FullScan") {
+		t.Errorf("Expected FullScan in plan, got:\n%s", body)
+	}
+}
+
+func TestExplainAcceptsExplainStatement(t *testing.T) {
+	handler, _ := setupHandler(t)
+
+	body := postForm(handler.ExplainSQL, "/explain", url.Values{
+		"sql": {"EXPLAIN SELECT * FROM users WHERE id = 1"},
+	})
+
+	if !strings.Contains(body, "IndexScan") {
+		t.Errorf("Expected IndexScan in plan, got:\n%s", body)
+	}
+}
+
+func TestExplainJoinOrder(t *testing.T) {
+	handler, _ := setupHandler(t)
+
+	body := postForm(handler.ExplainSQL, "/explain", url.Values{
+		"sql": {"SELECT * FROM posts INNER JOIN users ON posts.user_id = users.id"},
+	})
+
+	if !strings.Contains(body, "NestedLoopJoin") {
+		t.Errorf("Expected NestedLoopJoin in plan, got:\n%s", body)
+	}
+	if !strings.Contains(body, "IndexLookup") {
+		t.Errorf("Expected primary key lookup on users, got:\n%s", body)
+	}
+	if strings.Index(body, "FullScan") > strings.Index(body, "IndexLookup") {
+		t.Errorf("Expected posts to drive the join, got:\n%s", body)
+	}
+}
+
+func TestExplainRejectsNonSelect(t *testing.T) {
+	handler, _ := setupHandler(t)
+
+	body := postForm(handler.ExplainSQL, "/explain", url.Values{
+		"sql": {"DELETE FROM users WHERE id = 1"},
+	})
+
+	if !strings.Contains(body, "Only SELECT queries can be explained") {
+		t.Errorf("Expected error for non-SELECT, got:\n%s", body)
+	}
+}
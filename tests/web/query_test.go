@@ -0,0 +1,134 @@
+package web_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func postQuery(t *testing.T, handler http.HandlerFunc, sql string) (int, map[string]interface{}) {
+	body, _ := json.Marshal(map[string]string{"sql": sql})
+	req := httptest.NewRequest(http.MethodPost, "/api/query", strings.NewReader(string(body)))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	var resp map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Invalid JSON response %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, resp
+}
+
+func TestQueryAPISelect(t *testing.T) {
+	handler, _ := setupHandler(t)
+
+	status, resp := postQuery(t, handler.Query, "SELECT * FROM users WHERE id = 2")
+	if status != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %v", status, resp)
+	}
+	if cols := resp["columns"]; !reflect.DeepEqual(cols, []interface{}{"id", "email"}) {
+		t.Errorf("Expected columns [id email], got %v", cols)
+	}
+	want := []interface{}{map[string]interface{}{"id": 2.0, "email": "b@example.com"}}
+	if !reflect.DeepEqual(resp["rows"], want) {
+		t.Errorf("Expected rows %v, got %v", want, resp["rows"])
+	}
+
+	_, resp = postQuery(t, handler.Query, "SELECT * FROM users WHERE id = 99")
+	if rows, ok := resp["rows"].([]interface{}); !ok || len(rows) != 0 {
+		t.Errorf("Expected an empty rows array, got %v", resp["rows"])
+	}
+}
+
+func TestQueryAPIMutations(t *testing.T) {
+	handler, db := setupHandler(t)
+
+	status, resp := postQuery(t, handler.Query, "UPDATE users SET email = 'c@example.com' WHERE id = 1")
+	if status != http.StatusOK || resp["rowsAffected"] != 1.0 || resp["message"] != "1 row(s) updated" {
+		t.Errorf("Unexpected update response %d: %v", status, resp)
+	}
+
+	status, resp = postQuery(t, handler.Query, "DELETE FROM users WHERE id > 0")
+	if status != http.StatusOK || resp["rowsAffected"] != 2.0 {
+		t.Errorf("Unexpected delete response %d: %v", status, resp)
+	}
+	if count, _ := db.RowCount("users"); count != 0 {
+		t.Errorf("Expected users to be empty, got %d rows", count)
+	}
+}
+
+func TestQueryAPIParams(t *testing.T) {
+	handler, db := setupHandler(t)
+
+	post := func(body string) (int, map[string]interface{}) {
+		req := httptest.NewRequest(http.MethodPost, "/api/query", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		handler.Query(rec, req)
+
+		var resp map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Invalid JSON response %q: %v", rec.Body.String(), err)
+		}
+		return rec.Code, resp
+	}
+
+	status, resp := post(`{"sql": "SELECT * FROM users WHERE id = ?", "params": [2]}`)
+	want := []interface{}{map[string]interface{}{"id": 2.0, "email": "b@example.com"}}
+	if status != http.StatusOK || !reflect.DeepEqual(resp["rows"], want) {
+		t.Errorf("Expected user 2, got %d: %v", status, resp)
+	}
+
+	// A string parameter is never interpreted as SQL
+	status, resp = post(`{"sql": "UPDATE users SET email = 'x@example.com' WHERE email = ?", "params": ["a@example.com' OR '1' = '1"]}`)
+	if status != http.StatusOK || resp["rowsAffected"] != 0.0 {
+		t.Errorf("Expected no rows updated, got %d: %v", status, resp)
+	}
+	status, resp = post(`{"sql": "DELETE FROM users WHERE email = ?", "params": ["a@example.com"]}`)
+	if status != http.StatusOK || resp["rowsAffected"] != 1.0 {
+		t.Errorf("Expected one row deleted, got %d: %v", status, resp)
+	}
+	if count, _ := db.RowCount("users"); count != 1 {
+		t.Errorf("Expected 1 remaining user, got %d", count)
+	}
+
+	for _, body := range []string{
+		`{"sql": "SELECT * FROM users WHERE id = ?"}`,                  // Missing argument
+		`{"sql": "SELECT * FROM users WHERE id = ?", "params": ["2"]}`, // Wrong type
+		`{"sql": "SELECT * FROM users WHERE id = ?", "params": [2.5]}`, // Not an integer
+		`{"sql": "SELECT * FROM users", "params": [1]}`,                // Extra argument
+		`{"sql": "INSERT INTO users (id) VALUES (?)", "params": [1]}`,  // Not a condition value
+	} {
+		if status, resp := post(body); status != http.StatusBadRequest || resp["error"] == nil {
+			t.Errorf("%s: expected 400 with an error, got %d: %v", body, status, resp)
+		}
+	}
+}
+
+func TestQueryAPIErrors(t *testing.T) {
+	handler, _ := setupHandler(t)
+
+	tests := []struct {
+		sql    string
+		status int
+	}{
+		{"SELEKT * FROM users", http.StatusBadRequest},
+		{"SELECT * FROM missing", http.StatusNotFound},
+		{"INSERT INTO users (id, email) VALUES (1, 'x@example.com')", http.StatusConflict},
+	}
+	for _, tt := range tests {
+		status, resp := postQuery(t, handler.Query, tt.sql)
+		if status != tt.status || resp["error"] == nil {
+			t.Errorf("%s: expected %d with an error, got %d: %v", tt.sql, tt.status, status, resp)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/query", nil)
+	rec := httptest.NewRecorder()
+	handler.Query(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for GET, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,198 @@
+package web_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"godb/engine"
+	"godb/web"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestServerServesAndShutsDown(t *testing.T) {
+	dataFile := filepath.Join(t.TempDir(), "godb.archive")
+
+	server := web.NewServerWithConfig(web.Config{
+		Logger:   engine.NewStdLogger(io.Discard, engine.LevelInfo),
+		DataFile: dataFile,
+	})
+	if err := server.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(listener) }()
+
+	resp, err := http.Post("http://"+listener.Addr().String()+"/api/query", "application/json",
+		strings.NewReader(`{"sql": "INSERT INTO users (id, name) VALUES (1, 'ada')"}`))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", resp.StatusCode)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected Serve to return nil after Shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve did not return after Shutdown")
+	}
+
+	// The database was saved on the way out
+	db, err := engine.LoadFromFile(dataFile)
+	if err != nil {
+		t.Fatalf("LoadFromFile failed: %v", err)
+	}
+	if n, _ := db.RowCount("users"); n != 1 {
+		t.Errorf("Expected the inserted user to be saved, got %d rows", n)
+	}
+}
+
+func TestServersHaveSeparateRoutes(t *testing.T) {
+	logger := engine.NewStdLogger(io.Discard, engine.LevelInfo)
+
+	// Each server has its own mux, so a second one neither panics on
+	// duplicate routes nor serves the first one's database
+	first := web.NewServerWithConfig(web.Config{Logger: logger})
+	second := web.NewServerWithConfig(web.Config{Logger: logger})
+	first.Initialize()
+
+	for _, tt := range []struct {
+		server *web.Server
+		status int
+	}{{first, http.StatusOK}, {second, http.StatusInternalServerError}} {
+		rec := httptest.NewRecorder()
+		tt.server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/count", nil))
+		if rec.Code != tt.status {
+			t.Errorf("Expected %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestServerWithDBAndTemplates(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("users", []engine.Column{{Name: "id", Type: engine.TypeInt, PrimaryKey: true}})
+	db.Insert("users", engine.Row{"id": 1})
+
+	assets := fstest.MapFS{
+		"templates/layout.html": {Data: []byte(`{{range .TableStats}}{{.Name}}={{.Stats.Rows}};{{end}}`)},
+		"static/style.css":      {Data: []byte("body {}")},
+	}
+	server := web.NewServerWithDB(db, web.Config{
+		Logger: engine.NewStdLogger(io.Discard, engine.LevelInfo),
+		Assets: assets,
+	})
+
+	// The page is rendered from the given templates over the injected database
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "users=1;" {
+		t.Errorf("Expected the users summary, got %d: %q", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/static/style.css", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "body {}" {
+		t.Errorf("Expected the static file, got %d: %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestServerQueriesInjectedDB(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("books", []engine.Column{
+		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
+		{Name: "title", Type: engine.TypeString},
+	})
+	db.Insert("books", engine.Row{"id": 1, "title": "Dune"})
+
+	// Two servers share the database, and neither is initialized
+	logger := engine.NewStdLogger(io.Discard, engine.LevelInfo)
+	first := httptest.NewServer(web.NewServerWithDB(db, web.Config{Logger: logger}).Handler())
+	defer first.Close()
+	second := httptest.NewServer(web.NewServerWithDB(db, web.Config{Logger: logger}).Handler())
+	defer second.Close()
+
+	post := func(url, sql string) map[string]interface{} {
+		t.Helper()
+		body, _ := json.Marshal(map[string]string{"sql": sql})
+		resp, err := http.Post(url+"/api/query", "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		var result map[string]interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %v", sql, resp.StatusCode, result)
+		}
+		return result
+	}
+
+	post(first.URL, "INSERT INTO books VALUES (2, 'Emma')")
+	result := post(second.URL, "SELECT title FROM books")
+	want := []interface{}{map[string]interface{}{"title": "Dune"}, map[string]interface{}{"title": "Emma"}}
+	if !reflect.DeepEqual(result["rows"], want) {
+		t.Errorf("Expected both books, got %v", result["rows"])
+	}
+	if names := db.ListTables(); len(names) != 1 {
+		t.Errorf("Expected no demo tables, got %v", names)
+	}
+}
+
+func TestInitializeKeepsExistingTables(t *testing.T) {
+	db := engine.NewDatabase()
+	db.CreateTable("users", []engine.Column{{Name: "id", Type: engine.TypeInt, PrimaryKey: true}})
+	db.Insert("users", engine.Row{"id": 1})
+
+	server := web.NewServerWithDB(db, web.Config{Logger: engine.NewStdLogger(io.Discard, engine.LevelInfo)})
+	if err := server.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if n, _ := db.RowCount("users"); n != 1 {
+		t.Errorf("Expected the existing users to be kept, got %d rows", n)
+	}
+	if _, err := db.GetTable("posts"); err != nil {
+		t.Errorf("Expected the missing posts table to be created, got %v", err)
+	}
+	if err := server.Initialize(); err != nil {
+		t.Errorf("Expected a second Initialize to succeed, got %v", err)
+	}
+}
+
+func TestServerKeepsInjectedDBLogger(t *testing.T) {
+	db := engine.NewDatabase()
+	own := engine.NewStdLogger(io.Discard, engine.LevelDebug)
+	db.SetLogger(own)
+
+	web.NewServerWithDB(db, web.Config{})
+	if db.Logger() != engine.Logger(own) {
+		t.Errorf("Expected the database to keep its logger, got %v", db.Logger())
+	}
+
+	configured := engine.NewStdLogger(io.Discard, engine.LevelInfo)
+	web.NewServerWithDB(db, web.Config{Logger: configured})
+	if db.Logger() != engine.Logger(configured) {
+		t.Errorf("Expected the Config's logger to be set, got %v", db.Logger())
+	}
+}
@@ -1,5 +1,7 @@
 package web
 
+import "godb/engine"
+
 // CreateUserRequest represents a request to create a user
 type CreateUserRequest struct {
 	ID    int    `json:"id"`
@@ -15,6 +17,29 @@ type CreatePostRequest struct {
 	Body   string `json:"body"`
 }
 
+// QueryRequest represents a request to run a SQL statement
+type QueryRequest struct {
+	SQL    string        `json:"sql"`
+	Params []interface{} `json:"params,omitempty"` // Values for the ? placeholders, in order
+}
+
+// QueryResponse represents the result set of a SELECT or JOIN
+type QueryResponse struct {
+	Columns []string     `json:"columns"`
+	Rows    []engine.Row `json:"rows"`
+}
+
+// ExecResponse represents the result of a statement that returns no rows
+type ExecResponse struct {
+	Message      string `json:"message"`
+	RowsAffected int    `json:"rowsAffected"`
+}
+
+// CountResponse represents the number of rows in a table
+type CountResponse struct {
+	Count int `json:"count"`
+}
+
 // ErrorResponse represents an error response
 type ErrorResponse struct {
 	Error string `json:"error"`
@@ -22,8 +47,9 @@ type ErrorResponse struct {
 
 // SuccessResponse represents a success response
 type SuccessResponse struct {
-	Message string `json:"message"`
-	Count   int    `json:"count,omitempty"`
+	Message string     `json:"message"`
+	Count   int        `json:"count,omitempty"`
+	Row     engine.Row `json:"row,omitempty"` // The created row, as stored
 }
 
 // UserResponse represents a user in the response
@@ -4,11 +4,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"godb/engine"
+	"godb/executor"
 	"godb/parser"
 	"html/template"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Handler contains the database instance and HTTP handlers
@@ -44,26 +47,35 @@ func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		"email": req.Email,
 	}
 
-	if err := h.db.Insert("users", row); err != nil {
+	created, err := h.db.InsertReturning("users", row)
+	if err != nil {
 		respondError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	respondSuccess(w, "User created successfully", 1)
+	respondCreated(w, "User created successfully", created)
 }
 
 // GetUsers handles GET /users
+// Optional limit and offset query parameters select a page of users
 func (h *Handler) GetUsers(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	page, err := parsePage(r)
+	if err != nil {
+		respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	rows, err := h.db.Select("users", nil, nil)
 	if err != nil {
 		respondError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	rows = page.apply(w, rows)
 
 	users := make([]UserResponse, 0, len(rows))
 	for _, row := range rows {
@@ -78,6 +90,50 @@ func (h *Handler) GetUsers(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, users)
 }
 
+// CountUsers handles GET /users/count
+func (h *Handler) CountUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	count, err := h.db.RowCount("users")
+	if err != nil {
+		respondError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, CountResponse{Count: count})
+}
+
+// DeleteUser handles DELETE /users?id=N
+// Deleting an id that does not exist succeeds with no rows affected
+func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	value := r.URL.Query().Get("id")
+	if value == "" {
+		respondError(w, "id is required", http.StatusBadRequest)
+		return
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		respondError(w, fmt.Sprintf("invalid id '%s'", value), http.StatusBadRequest)
+		return
+	}
+
+	count, err := h.db.Delete("users", &engine.Condition{Column: "id", Operator: "=", Value: id})
+	if err != nil {
+		respondError(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	respondJSON(w, ExecResponse{Message: fmt.Sprintf("%d row(s) deleted", count), RowsAffected: count})
+}
+
 // CreatePost handles POST /posts
 func (h *Handler) CreatePost(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -98,21 +154,29 @@ func (h *Handler) CreatePost(w http.ResponseWriter, r *http.Request) {
 		"body":    req.Body,
 	}
 
-	if err := h.db.Insert("posts", row); err != nil {
+	created, err := h.db.InsertReturning("posts", row)
+	if err != nil {
 		respondError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	respondSuccess(w, "Post created successfully", 1)
+	respondCreated(w, "Post created successfully", created)
 }
 
 // GetPosts handles GET /posts (with JOIN to users)
+// Optional limit and offset query parameters select a page of posts
 func (h *Handler) GetPosts(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	page, err := parsePage(r)
+	if err != nil {
+		respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Perform INNER JOIN between posts and users
 	joinCondition := engine.JoinCondition{
 		LeftColumn:  "user_id",
@@ -124,6 +188,7 @@ func (h *Handler) GetPosts(w http.ResponseWriter, r *http.Request) {
 		respondError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	rows = page.apply(w, rows)
 
 	posts := make([]PostWithUserResponse, 0, len(rows))
 	for _, row := range rows {
@@ -158,6 +223,16 @@ func respondSuccess(w http.ResponseWriter, message string, count int) {
 	})
 }
 
+func respondCreated(w http.ResponseWriter, message string, row engine.Row) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(SuccessResponse{
+		Message: message,
+		Count:   1,
+		Row:     row,
+	})
+}
+
 func respondError(w http.ResponseWriter, message string, status int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -166,6 +241,49 @@ func respondError(w http.ResponseWriter, message string, status int) {
 	})
 }
 
+// page is the window of rows requested with the limit and offset query parameters
+type page struct {
+	limit  int // 0 means no limit
+	offset int
+}
+
+// parsePage reads the limit and offset query parameters
+func parsePage(r *http.Request) (page, error) {
+	var p page
+	query := r.URL.Query()
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return p, fmt.Errorf("invalid limit '%s': must be a positive integer", value)
+		}
+		p.limit = limit
+	}
+	if value := query.Get("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return p, fmt.Errorf("invalid offset '%s': must be a non-negative integer", value)
+		}
+		p.offset = offset
+	}
+	return p, nil
+}
+
+// apply returns the rows in the page and reports the total row count in the
+// X-Total-Count header so clients can paginate
+func (p page) apply(w http.ResponseWriter, rows []engine.Row) []engine.Row {
+	w.Header().Set("X-Total-Count", strconv.Itoa(len(rows)))
+
+	if p.offset >= len(rows) {
+		return nil
+	}
+	rows = rows[p.offset:]
+	if p.limit > 0 && p.limit < len(rows) {
+		rows = rows[:p.limit]
+	}
+	return rows
+}
+
 func getInt(row engine.Row, key string) int {
 	if val, ok := row[key]; ok {
 		if intVal, ok := val.(int); ok {
@@ -186,16 +304,44 @@ func getString(row engine.Row, key string) string {
 
 // === NEW UI HANDLERS ===
 
+// tableSummary pairs a table name with its size statistics for display
+type tableSummary struct {
+	Name  string
+	Stats engine.TableStats
+}
+
+// tableSummaries returns statistics for every table, sorted by name
+func (h *Handler) tableSummaries() []tableSummary {
+	names := h.db.ListTables()
+	sort.Strings(names)
+
+	summaries := make([]tableSummary, 0, len(names))
+	for _, name := range names {
+		table, err := h.db.GetTable(name)
+		if err != nil {
+			continue // Dropped since ListTables
+		}
+		summaries = append(summaries, tableSummary{Name: name, Stats: table.Stats()})
+	}
+	return summaries
+}
+
 // Index renders the main page
 func (h *Handler) Index(w http.ResponseWriter, r *http.Request) {
-	if err := h.templates.ExecuteTemplate(w, "layout.html", nil); err != nil {
+	data := map[string]interface{}{
+		"TableStats": h.tableSummaries(),
+	}
+	if err := h.templates.ExecuteTemplate(w, "layout.html", data); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
 
 // ConsoleTab renders the SQL console tab
 func (h *Handler) ConsoleTab(w http.ResponseWriter, r *http.Request) {
-	if err := h.templates.ExecuteTemplate(w, "console", nil); err != nil {
+	data := map[string]interface{}{
+		"TableStats": h.tableSummaries(),
+	}
+	if err := h.templates.ExecuteTemplate(w, "console", data); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
@@ -313,73 +459,84 @@ func (h *Handler) ExecuteSQL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse the SQL
+	// Parse the SQL, which may hold several semicolon-separated statements
 	p := parser.NewParser(sql)
-	cmd, err := p.Parse()
+	cmds, err := p.ParseMany()
 	if err != nil {
 		h.renderResults(w, nil, fmt.Sprintf("Parse error: %v", err))
 		return
 	}
 
-	// Execute based on command type
-	switch c := cmd.(type) {
-	case *parser.CreateTableCommand:
-		err = h.db.CreateTable(c.TableName, c.Columns)
+	// Statements run in order until one fails; the last one's result is shown
+	var result *executor.Result
+	for i, cmd := range cmds {
+		result, err = executor.ExecuteCommand(h.db, cmd)
 		if err != nil {
+			if len(cmds) > 1 {
+				err = fmt.Errorf("statement %d: %v", i+1, err)
+			}
 			h.renderResults(w, nil, err.Error())
 			return
 		}
-		h.renderSuccess(w, "Table created successfully")
+	}
+	if !result.IsQuery() || (len(result.Rows) == 0 && result.Message != "") {
+		h.renderSuccess(w, result.Message)
+		return
+	}
+	h.renderRowsWithTable(w, result.Columns, result.Rows, result.Table)
+}
 
-	case *parser.InsertCommand:
-		err = h.db.Insert(c.TableName, c.Values)
-		if err != nil {
-			h.renderResults(w, nil, err.Error())
-			return
-		}
-		h.renderSuccess(w, "Row inserted successfully")
+// ExplainSQL renders the query plan of a SELECT or JOIN without executing it
+func (h *Handler) ExplainSQL(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		h.renderResults(w, nil, err.Error())
+		return
+	}
 
-	case *parser.SelectCommand:
-		rows, err := h.db.Select(c.TableName, c.Columns, c.Condition)
-		if err != nil {
-			h.renderResults(w, nil, err.Error())
-			return
-		}
-		h.renderRowsWithTable(w, rows, c.TableName)
+	sql := r.FormValue("sql")
+	if sql == "" {
+		h.renderResults(w, nil, "SQL command is required")
+		return
+	}
 
-	case *parser.UpdateCommand:
-		rowsAffected, err := h.db.Update(c.TableName, c.Updates, c.Condition)
-		if err != nil {
-			h.renderResults(w, nil, err.Error())
-			return
-		}
-		h.renderSuccess(w, fmt.Sprintf("%d row(s) updated", rowsAffected))
+	p := parser.NewParser(sql)
+	cmd, err := p.Parse()
+	if err != nil {
+		h.renderResults(w, nil, fmt.Sprintf("Parse error: %v", err))
+		return
+	}
 
-	case *parser.DeleteCommand:
-		rowsAffected, err := h.db.Delete(c.TableName, c.Condition)
-		if err != nil {
-			h.renderResults(w, nil, err.Error())
-			return
-		}
-		h.renderSuccess(w, fmt.Sprintf("%d row(s) deleted", rowsAffected))
+	// The statement may also be written EXPLAIN SELECT ...
+	if explain, ok := cmd.(*parser.ExplainCommand); ok {
+		cmd = explain.Statement
+	}
 
+	var plan *engine.Plan
+	switch c := cmd.(type) {
+	case *parser.SelectCommand:
+		plan, err = h.db.Explain(c.Query())
 	case *parser.JoinCommand:
-		joinCondition := engine.JoinCondition{
-			LeftColumn:  c.LeftColumn,
-			RightColumn: c.RightColumn,
-		}
-		rows, err := h.db.InnerJoin(c.LeftTable, c.RightTable, joinCondition, c.SelectColumns)
-		if err != nil {
-			h.renderResults(w, nil, err.Error())
-			return
-		}
-		h.renderRows(w, rows)
-
+		plan, err = h.db.ExplainJoins(c.LeftTable, c.Joins, c.Condition, c.SelectColumns)
 	default:
-		h.renderResults(w, nil, "Unknown command type")
+		h.renderResults(w, nil, "Only SELECT queries can be explained")
+		return
+	}
+	if err != nil {
+		h.renderResults(w, nil, err.Error())
+		return
+	}
+
+	if err := h.templates.ExecuteTemplate(w, "plan", plan); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
 
+// Metrics writes the database's operation counters as plain text
+func (h *Handler) Metrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	h.db.Metrics().WriteTo(w)
+}
+
 // TableSchema returns the schema for a table (for dynamic form generation)
 func (h *Handler) TableSchema(w http.ResponseWriter, r *http.Request) {
 	tableName := r.URL.Query().Get("table")
@@ -426,19 +583,12 @@ func (h *Handler) BuildInsert(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
-		switch col.Type {
-		case engine.TypeInt:
-			intVal, err := strconv.Atoi(value)
-			if err != nil {
-				h.renderResults(w, nil, fmt.Sprintf("Invalid integer for %s: %s", col.Name, value))
-				return
-			}
-			row[col.Name] = intVal
-		case engine.TypeBool:
-			row[col.Name] = value == "true"
-		default:
-			row[col.Name] = value
+		typed, err := engine.CoerceValue(col.Name, col.Type, value)
+		if err != nil {
+			h.renderResults(w, nil, err.Error())
+			return
 		}
+		row[col.Name] = typed
 	}
 
 	// Execute INSERT
@@ -506,13 +656,14 @@ func (h *Handler) BuildSelect(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Execute SELECT
-	rows, err := h.db.Select(tableName, columns, condition)
+	query := engine.Query{Table: tableName, Columns: columns, Condition: condition}
+	outputColumns, rows, err := h.db.SelectOrdered(query)
 	if err != nil {
 		h.renderResults(w, nil, err.Error())
 		return
 	}
 
-	h.renderRowsWithTable(w, rows, tableName)
+	h.renderRowsWithTable(w, outputColumns, rows, tableName)
 }
 
 // Helper functions for rendering results
@@ -543,11 +694,13 @@ type ColumnInfo struct {
 	IsPrimaryKey bool
 }
 
-func (h *Handler) renderRows(w http.ResponseWriter, rows []engine.Row) {
-	h.renderRowsWithTable(w, rows, "")
+func (h *Handler) renderRows(w http.ResponseWriter, columnNames []string, rows []engine.Row) {
+	h.renderRowsWithTable(w, columnNames, rows, "")
 }
 
-func (h *Handler) renderRowsWithTable(w http.ResponseWriter, rows []engine.Row, tableName string) {
+// renderRowsWithTable renders rows with their columns in the given order
+// With nil columnNames, the first row's columns are shown in alphabetical order
+func (h *Handler) renderRowsWithTable(w http.ResponseWriter, columnNames []string, rows []engine.Row, tableName string) {
 	if len(rows) == 0 {
 		data := map[string]interface{}{
 			"Success": true,
@@ -558,10 +711,11 @@ func (h *Handler) renderRowsWithTable(w http.ResponseWriter, rows []engine.Row,
 		return
 	}
 
-	// Extract column names from first row
-	var columnNames []string
-	for col := range rows[0] {
-		columnNames = append(columnNames, col)
+	if columnNames == nil {
+		for col := range rows[0] {
+			columnNames = append(columnNames, col)
+		}
+		sort.Strings(columnNames)
 	}
 
 	// Build column info with primary key flags
@@ -583,12 +737,42 @@ func (h *Handler) renderRowsWithTable(w http.ResponseWriter, rows []engine.Row,
 	}
 
 	data := map[string]interface{}{
-		"Rows":    rows,
+		"Rows":    displayRows(rows),
 		"Columns": columns,
 	}
 	h.renderResults(w, data, "")
 }
 
+// displayValue formats a value for the templates
+// Dates are shown the way they are typed in SQL, as the REPL prints them
+func displayValue(value interface{}) interface{} {
+	if t, ok := value.(time.Time); ok {
+		return engine.FormatDate(t)
+	}
+	return value
+}
+
+// displayRows returns rows with their values formatted by displayValue
+// Rows holding a date are copied, so stored rows are never changed
+func displayRows(rows []engine.Row) []engine.Row {
+	shown := make([]engine.Row, len(rows))
+	for i, row := range rows {
+		shown[i] = row
+		copied := false
+		for col, value := range row {
+			if _, ok := value.(time.Time); !ok {
+				continue
+			}
+			if !copied {
+				shown[i] = row.Copy()
+				copied = true
+			}
+			shown[i][col] = displayValue(value)
+		}
+	}
+	return shown
+}
+
 // === UPDATE & DELETE HANDLERS ===
 
 // UpdateTab renders the update data tab
@@ -679,7 +863,11 @@ func (h *Handler) FetchRow(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Build condition with type conversion
-	condition := h.buildCondition(table, whereColumn, "=", whereValue)
+	condition, err := h.buildCondition(table, whereColumn, "=", whereValue)
+	if err != nil {
+		h.renderUpdateEditor(w, nil, err.Error())
+		return
+	}
 
 	// Fetch matching rows
 	rows, err := h.db.Select(tableName, nil, condition)
@@ -701,7 +889,8 @@ func (h *Handler) FetchRow(w http.ResponseWriter, r *http.Request) {
 		data["MultipleRows"] = true
 		data["RowCount"] = len(rows)
 	} else {
-		data["RowData"] = rows[0]
+		// Dates are shown the way the form reads them back
+		data["RowData"] = displayRows(rows)[0]
 	}
 
 	h.renderUpdateEditor(w, data, "")
@@ -726,7 +915,11 @@ func (h *Handler) PreviewDelete(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Build condition with type conversion
-	condition := h.buildCondition(table, whereColumn, whereOperator, whereValue)
+	condition, err := h.buildCondition(table, whereColumn, whereOperator, whereValue)
+	if err != nil {
+		h.renderDeletePreview(w, nil, err.Error())
+		return
+	}
 
 	// Fetch matching rows
 	rows, err := h.db.Select(tableName, nil, condition)
@@ -751,7 +944,7 @@ func (h *Handler) PreviewDelete(w http.ResponseWriter, r *http.Request) {
 		"WhereOperator": whereOperator,
 		"WhereValue":    formatWhereValue(whereValue),
 		"Columns":       columns,
-		"Rows":          rows,
+		"Rows":          displayRows(rows),
 		"RowCount":      len(rows),
 	}
 
@@ -779,7 +972,125 @@ func (h *Handler) BuildUpdate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Build updates from form values (excluding primary key)
+	updates, err := formUpdates(r, table)
+	if err != nil {
+		h.renderResults(w, nil, err.Error())
+		return
+	}
+
+	// Build condition
+	condition, err := h.buildCondition(table, whereColumn, "=", whereValue)
+	if err != nil {
+		h.renderResults(w, nil, err.Error())
+		return
+	}
+
+	// Execute UPDATE
+	rowsAffected, err := h.db.Update(tableName, updates, condition)
+	if err != nil {
+		h.renderResults(w, nil, err.Error())
+		return
+	}
+
+	h.renderSuccess(w, fmt.Sprintf("%d row(s) updated successfully", rowsAffected))
+}
+
+// cellChange is a column's old and new value in an update preview
+type cellChange struct {
+	Column  string
+	Before  interface{}
+	After   interface{}
+	Changed bool
+}
+
+// rowPreview is a row in an update preview, identified by its primary key
+type rowPreview struct {
+	Key   interface{}
+	Cells []cellChange
+}
+
+// PreviewUpdate shows the before and after values of the rows an update would change
+func (h *Handler) PreviewUpdate(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		h.renderUpdatePreview(w, nil, "Failed to parse form")
+		return
+	}
+
+	tableName := r.FormValue("table_name")
+	whereColumn := r.FormValue("where_column")
+	whereValue := r.FormValue("where_value")
+
+	table, err := h.db.GetTable(tableName)
+	if err != nil {
+		h.renderUpdatePreview(w, nil, err.Error())
+		return
+	}
+
+	updates, err := formUpdates(r, table)
+	if err != nil {
+		h.renderUpdatePreview(w, nil, err.Error())
+		return
+	}
+
+	condition, err := h.buildCondition(table, whereColumn, "=", whereValue)
+	if err != nil {
+		h.renderUpdatePreview(w, nil, err.Error())
+		return
+	}
+
+	changes, err := h.db.PreviewUpdate(tableName, updates, condition)
+	if err != nil {
+		h.renderUpdatePreview(w, nil, err.Error())
+		return
+	}
+
+	// Only columns being set can change
+	schema := table.Schema()
+	columns := make([]string, 0, len(updates))
+	for _, col := range schema {
+		if _, ok := updates[col.Name]; ok {
+			columns = append(columns, col.Name)
+		}
+	}
+
+	var previews []rowPreview
+	for _, change := range changes {
+		changed := change.Changed(columns)
+		if len(changed) == 0 {
+			continue
+		}
+		key, _ := change.Before.Get(table.PrimaryKey())
+		preview := rowPreview{Key: key}
+		for _, col := range columns {
+			before, _ := change.Before.Get(col)
+			after, _ := change.After.Get(col)
+			preview.Cells = append(preview.Cells, cellChange{
+				Column:  col,
+				Before:  displayValue(before),
+				After:   displayValue(after),
+				Changed: before != after,
+			})
+		}
+		previews = append(previews, preview)
+	}
+
+	data := map[string]interface{}{
+		"TableName":  tableName,
+		"PrimaryKey": table.PrimaryKey(),
+		"Rows":       previews,
+		"RowCount":   len(previews),
+		"Matched":    len(changes),
+	}
+	if len(previews) == 0 {
+		data["NoChanges"] = true
+	}
+
+	h.renderUpdatePreview(w, data, "")
+}
+
+// formUpdates reads the new column values of an update form, skipping the
+// primary key and columns left empty
+func formUpdates(r *http.Request, table *engine.Table) (engine.Row, error) {
 	updates := make(engine.Row)
 	pkColumn := table.PrimaryKey()
 
@@ -793,32 +1104,14 @@ func (h *Handler) BuildUpdate(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
-		switch col.Type {
-		case engine.TypeInt:
-			intVal, err := strconv.Atoi(value)
-			if err != nil {
-				h.renderResults(w, nil, fmt.Sprintf("Invalid integer for %s: %s", col.Name, value))
-				return
-			}
-			updates[col.Name] = intVal
-		case engine.TypeBool:
-			updates[col.Name] = value == "true"
-		default:
-			updates[col.Name] = value
+		typed, err := engine.CoerceValue(col.Name, col.Type, value)
+		if err != nil {
+			return nil, err
 		}
+		updates[col.Name] = typed
 	}
 
-	// Build condition
-	condition := h.buildCondition(table, whereColumn, "=", whereValue)
-
-	// Execute UPDATE
-	rowsAffected, err := h.db.Update(tableName, updates, condition)
-	if err != nil {
-		h.renderResults(w, nil, err.Error())
-		return
-	}
-
-	h.renderSuccess(w, fmt.Sprintf("%d row(s) updated successfully", rowsAffected))
+	return updates, nil
 }
 
 // BuildDelete builds and executes a DELETE statement from form data
@@ -840,7 +1133,11 @@ func (h *Handler) BuildDelete(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Build condition
-	condition := h.buildCondition(table, whereColumn, whereOperator, whereValue)
+	condition, err := h.buildCondition(table, whereColumn, whereOperator, whereValue)
+	if err != nil {
+		h.renderResults(w, nil, err.Error())
+		return
+	}
 
 	// Execute DELETE
 	rowsAffected, err := h.db.Delete(tableName, condition)
@@ -853,7 +1150,7 @@ func (h *Handler) BuildDelete(w http.ResponseWriter, r *http.Request) {
 }
 
 // Helper: build condition with proper type conversion
-func (h *Handler) buildCondition(table *engine.Table, column, operator, value string) *engine.Condition {
+func (h *Handler) buildCondition(table *engine.Table, column, operator, value string) (*engine.Condition, error) {
 	// Find column type
 	var colType engine.ColumnType
 	for _, col := range table.Schema() {
@@ -863,25 +1160,16 @@ func (h *Handler) buildCondition(table *engine.Table, column, operator, value st
 		}
 	}
 
-	var typedValue interface{}
-	switch colType {
-	case engine.TypeInt:
-		if intVal, err := strconv.Atoi(value); err == nil {
-			typedValue = intVal
-		} else {
-			typedValue = value
-		}
-	case engine.TypeBool:
-		typedValue = value == "true"
-	default:
-		typedValue = value
+	typedValue, err := engine.CoerceValue(column, colType, value)
+	if err != nil {
+		return nil, err
 	}
 
 	return &engine.Condition{
 		Column:   column,
 		Operator: operator,
 		Value:    typedValue,
-	}
+	}, nil
 }
 
 // Helper: format where value for SQL display
@@ -924,3 +1212,16 @@ func (h *Handler) renderDeletePreview(w http.ResponseWriter, data map[string]int
 	}
 }
 
+// Helper: render update preview template
+func (h *Handler) renderUpdatePreview(w http.ResponseWriter, data map[string]interface{}, errorMsg string) {
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	if errorMsg != "" {
+		data["Error"] = errorMsg
+	}
+	if err := h.templates.ExecuteTemplate(w, "update-preview", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
@@ -1,33 +1,92 @@
 package web
 
 import (
+	"context"
+	"embed"
+	"errors"
 	"fmt"
 	"godb/engine"
 	"html/template"
-	"log"
+	"io/fs"
+	"net"
 	"net/http"
+	"os"
 )
 
+// assets holds the templates and static files built into the binary
+//
+//go:embed templates static
+var assets embed.FS
+
 // Server represents the HTTP server
 type Server struct {
 	db        *engine.Database
 	addr      string
 	templates *template.Template
+	static    fs.FS
+	logger    engine.Logger
+	dataFile  string
+	http      *http.Server
+}
+
+// Config holds the settings of a Server
+type Config struct {
+	Addr     string
+	Logger   engine.Logger // Shared with the database; defaults to INFO level on stderr, leaving an injected database's logger alone
+	DataFile string        // Archive the database is saved to on Shutdown; empty disables saving
+	Assets   fs.FS         // Holds templates/*.html and static/; defaults to the files embedded in the binary
 }
 
 // NewServer creates a new HTTP server
 func NewServer(addr string) *Server {
-	// Parse all templates
-	templates := template.Must(template.ParseGlob("web/templates/*.html"))
+	return NewServerWithConfig(Config{Addr: addr})
+}
 
-	return &Server{
-		db:        engine.NewDatabase(),
-		addr:      addr,
+// NewServerWithConfig creates a new HTTP server with an empty database from a Config
+func NewServerWithConfig(cfg Config) *Server {
+	if cfg.Logger == nil {
+		cfg.Logger = engine.NewStdLogger(os.Stderr, engine.LevelInfo)
+	}
+	return NewServerWithDB(engine.NewDatabase(), cfg)
+}
+
+// NewServerWithDB creates a new HTTP server for an existing database
+// The database's logger is replaced by the Config's if it sets one, and is
+// otherwise left as the caller configured it. It panics if the templates in
+// the Config's Assets cannot be parsed
+func NewServerWithDB(db *engine.Database, cfg Config) *Server {
+	files := cfg.Assets
+	if files == nil {
+		files = assets
+	}
+	templates := template.Must(template.ParseFS(files, "templates/*.html"))
+	static, err := fs.Sub(files, "static")
+	if err != nil {
+		panic(err)
+	}
+
+	logger := cfg.Logger
+	if logger != nil {
+		db.SetLogger(logger)
+	} else {
+		logger = engine.NewStdLogger(os.Stderr, engine.LevelInfo)
+	}
+
+	s := &Server{
+		db:        db,
+		addr:      cfg.Addr,
 		templates: templates,
+		static:    static,
+		logger:    logger,
+		dataFile:  cfg.DataFile,
 	}
+	s.http = &http.Server{Addr: cfg.Addr, Handler: s.routes()}
+	return s
 }
 
 // Initialize sets up the database schema for the demo
+// It is optional: a server works over any database, and tables that already
+// exist, such as those of an injected or loaded database, are left as they are
 func (s *Server) Initialize() error {
 	// Create users table
 	usersSchema := []engine.Column{
@@ -36,18 +95,23 @@ func (s *Server) Initialize() error {
 		{Name: "email", Type: engine.TypeString, Unique: true},
 	}
 
-	if err := s.db.CreateTable("users", usersSchema); err != nil {
-		return fmt.Errorf("failed to create users table: %v", err)
+	if !s.db.TableExists("users") {
+		if err := s.db.CreateTable("users", usersSchema); err != nil {
+			return fmt.Errorf("failed to create users table: %v", err)
+		}
 	}
 
 	// Create posts table
 	postsSchema := []engine.Column{
 		{Name: "id", Type: engine.TypeInt, PrimaryKey: true},
-		{Name: "user_id", Type: engine.TypeInt, NotNull: true},
+		{Name: "user_id", Type: engine.TypeInt, NotNull: true, References: &engine.ForeignKey{Table: "users", Column: "id"}},
 		{Name: "title", Type: engine.TypeString, NotNull: true},
 		{Name: "body", Type: engine.TypeString},
 	}
 
+	if s.db.TableExists("posts") {
+		return nil
+	}
 	if err := s.db.CreateTable("posts", postsSchema); err != nil {
 		return fmt.Errorf("failed to create posts table: %v", err)
 	}
@@ -61,54 +125,69 @@ func (s *Server) Initialize() error {
 	return nil
 }
 
-// Start starts the HTTP server
-func (s *Server) Start() error {
+// Handler returns the server's routes, for serving them without Start
+func (s *Server) Handler() http.Handler {
+	return s.http.Handler
+}
+
+// routes registers every route on a new ServeMux
+func (s *Server) routes() *http.ServeMux {
+	mux := http.NewServeMux()
 	handler := NewHandler(s.db, s.templates)
 
 	// Serve static files
-	fs := http.FileServer(http.Dir("web/static"))
-	http.Handle("/static/", http.StripPrefix("/static/", fs))
+	mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.FS(s.static))))
 
 	// UI routes
-	http.HandleFunc("/", handler.Index)
-	http.HandleFunc("/tabs/console", handler.ConsoleTab)
-	http.HandleFunc("/tabs/create", handler.CreateTab)
-	http.HandleFunc("/tabs/insert", handler.InsertTab)
-	http.HandleFunc("/tabs/query", handler.QueryTab)
-	http.HandleFunc("/tabs/update", handler.UpdateTab)
-	http.HandleFunc("/tabs/delete", handler.DeleteTab)
+	mux.HandleFunc("/", handler.Index)
+	mux.HandleFunc("/tabs/console", handler.ConsoleTab)
+	mux.HandleFunc("/tabs/create", handler.CreateTab)
+	mux.HandleFunc("/tabs/insert", handler.InsertTab)
+	mux.HandleFunc("/tabs/query", handler.QueryTab)
+	mux.HandleFunc("/tabs/update", handler.UpdateTab)
+	mux.HandleFunc("/tabs/delete", handler.DeleteTab)
 
 	// Wizard routes
-	http.HandleFunc("/wizard/create/step2", handler.CreateStep2)
-	http.HandleFunc("/wizard/create/review", handler.CreateReview)
+	mux.HandleFunc("/wizard/create/step2", handler.CreateStep2)
+	mux.HandleFunc("/wizard/create/review", handler.CreateReview)
 
 	// Action routes
-	http.HandleFunc("/execute", handler.ExecuteSQL)
-	http.HandleFunc("/table-schema", handler.TableSchema)
-	http.HandleFunc("/build-insert", handler.BuildInsert)
-	http.HandleFunc("/build-select", handler.BuildSelect)
-	http.HandleFunc("/build-update", handler.BuildUpdate)
-	http.HandleFunc("/build-delete", handler.BuildDelete)
+	mux.HandleFunc("/execute", handler.ExecuteSQL)
+	mux.HandleFunc("/explain", handler.ExplainSQL)
+	mux.HandleFunc("/metrics", handler.Metrics)
+	mux.HandleFunc("/table-schema", handler.TableSchema)
+	mux.HandleFunc("/build-insert", handler.BuildInsert)
+	mux.HandleFunc("/build-select", handler.BuildSelect)
+	mux.HandleFunc("/build-update", handler.BuildUpdate)
+	mux.HandleFunc("/build-delete", handler.BuildDelete)
 
 	// Update/Delete helper routes
-	http.HandleFunc("/table-schema-update", handler.TableSchemaUpdate)
-	http.HandleFunc("/table-schema-delete", handler.TableSchemaDelete)
-	http.HandleFunc("/fetch-row", handler.FetchRow)
-	http.HandleFunc("/preview-delete", handler.PreviewDelete)
+	mux.HandleFunc("/table-schema-update", handler.TableSchemaUpdate)
+	mux.HandleFunc("/table-schema-delete", handler.TableSchemaDelete)
+	mux.HandleFunc("/fetch-row", handler.FetchRow)
+	mux.HandleFunc("/preview-delete", handler.PreviewDelete)
+	mux.HandleFunc("/preview-update", handler.PreviewUpdate)
+
+	// JSON API
+	mux.HandleFunc("/api/query", handler.Query)
 
 	// Legacy API routes (kept for backward compatibility)
-	http.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodPost:
 			handler.CreateUser(w, r)
 		case http.MethodGet:
 			handler.GetUsers(w, r)
+		case http.MethodDelete:
+			handler.DeleteUser(w, r)
 		default:
 			respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
 	})
 
-	http.HandleFunc("/posts", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/users/count", handler.CountUsers)
+
+	mux.HandleFunc("/posts", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodPost:
 			handler.CreatePost(w, r)
@@ -119,10 +198,47 @@ func (s *Server) Start() error {
 		}
 	})
 
-	log.Printf("Starting godb web server on %s", s.addr)
-	log.Println("Available interfaces:")
-	log.Println("  Web UI:  http://localhost:8080/")
-	log.Println("  API:     POST /users, GET /users, POST /posts, GET /posts")
+	return mux
+}
+
+// Start listens on the configured address and serves until Shutdown
+// It returns nil once Shutdown has been called
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve serves on an existing listener until Shutdown
+// It returns nil once Shutdown has been called
+func (s *Server) Serve(listener net.Listener) error {
+	s.logger.Info("Starting godb web server on %s", listener.Addr())
+	s.logger.Info("Available interfaces:")
+	if addr, ok := listener.Addr().(*net.TCPAddr); ok {
+		s.logger.Info("  Web UI:  http://localhost:%d/", addr.Port)
+	}
+	s.logger.Info("  API:     POST /api/query, POST /users, GET /users, GET /users/count, DELETE /users, POST /posts, GET /posts")
+
+	if err := s.http.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops accepting connections, waits for active requests to finish
+// until ctx is done, then saves the database to the configured DataFile
+// The database is saved even if requests were cut off by ctx
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.logger.Info("Shutting down godb web server")
+	shutdownErr := s.http.Shutdown(ctx)
 
-	return http.ListenAndServe(s.addr, nil)
+	if s.dataFile != "" {
+		if err := s.db.SaveToFile(s.dataFile); err != nil {
+			return fmt.Errorf("failed to save database to %s: %v", s.dataFile, err)
+		}
+		s.logger.Info("Database saved to %s", s.dataFile)
+	}
+	return shutdownErr
 }
@@ -0,0 +1,145 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"godb/engine"
+	"godb/executor"
+	"godb/parser"
+	"net/http"
+	"strconv"
+)
+
+// bind substitutes the arguments of a prepared statement into its condition
+// Only the WHERE condition of SELECT, UPDATE, DELETE and JOIN takes placeholders
+func (h *Handler) bind(cmd parser.Command, params int, args []interface{}) (parser.Command, error) {
+	switch c := cmd.(type) {
+	case *parser.SelectCommand:
+		bound := *c
+		condition, err := h.db.BindParams([]string{c.TableName}, c.Condition, args)
+		bound.Condition = condition
+		return &bound, err
+	case *parser.UpdateCommand:
+		bound := *c
+		condition, err := h.db.BindParams([]string{c.TableName}, c.Condition, args)
+		bound.Condition = condition
+		return &bound, err
+	case *parser.DeleteCommand:
+		bound := *c
+		condition, err := h.db.BindParams([]string{c.TableName}, c.Condition, args)
+		bound.Condition = condition
+		return &bound, err
+	case *parser.JoinCommand:
+		bound := *c
+		condition, err := h.db.BindParams(c.Tables(), c.Condition, args)
+		bound.Condition = condition
+		return &bound, err
+	}
+
+	if params > 0 {
+		return nil, fmt.Errorf("placeholders are only supported in the WHERE clause of SELECT, UPDATE and DELETE")
+	}
+	if len(args) > 0 {
+		return nil, engine.ErrParamCount{Expected: 0, Got: len(args)}
+	}
+	return cmd, nil
+}
+
+// jsonParam converts a parameter decoded from JSON to the Go value the engine stores
+// Numbers must be integers, since no column type holds fractions
+func jsonParam(value interface{}) (interface{}, error) {
+	number, ok := value.(json.Number)
+	if !ok {
+		return value, nil
+	}
+	n, err := strconv.Atoi(number.String())
+	if err != nil {
+		return nil, fmt.Errorf("parameter %s is not an integer", number)
+	}
+	return n, nil
+}
+
+// Query handles POST /api/query
+// The body is {"sql": "...", "params": [...]}. Values in params are bound to
+// the statement's ? placeholders in order, so they never need quoting into the
+// SQL. Queries return their columns and rows, other statements a message and
+// the number of affected rows
+func (h *Handler) Query(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req QueryRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber() // Keep integer parameters exact
+	if err := decoder.Decode(&req); err != nil {
+		respondError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.SQL == "" {
+		respondError(w, "SQL command is required", http.StatusBadRequest)
+		return
+	}
+
+	args := make([]interface{}, len(req.Params))
+	for i, param := range req.Params {
+		arg, err := jsonParam(param)
+		if err != nil {
+			respondError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		args[i] = arg
+	}
+
+	cmd, params, err := parser.NewParser(req.SQL).Prepare()
+	if err != nil {
+		respondError(w, fmt.Sprintf("Parse error: %v", err), http.StatusBadRequest)
+		return
+	}
+	cmd, err = h.bind(cmd, params, args)
+	if err != nil {
+		respondError(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	result, err := executor.ExecuteCommand(h.db, cmd)
+	if err != nil {
+		respondError(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	if result.IsQuery() {
+		rows := result.Rows
+		if rows == nil {
+			rows = []engine.Row{}
+		}
+		respondJSON(w, QueryResponse{Columns: result.Columns, Rows: rows})
+		return
+	}
+	respondJSON(w, ExecResponse{Message: result.Message, RowsAffected: result.RowsAffected})
+}
+
+// errorStatus picks the HTTP status for an error returned by the engine
+func errorStatus(err error) int {
+	var (
+		tableNotFound  engine.ErrTableNotFound
+		columnNotFound engine.ErrColumnNotFound
+		tableExists    engine.ErrTableAlreadyExists
+		primaryKey     engine.ErrPrimaryKeyViolation
+		unique         engine.ErrUniqueViolation
+		foreignKey     engine.ErrForeignKeyViolation
+		timeout        engine.ErrQueryTimeout
+	)
+	switch {
+	case errors.As(err, &tableNotFound), errors.As(err, &columnNotFound):
+		return http.StatusNotFound
+	case errors.As(err, &tableExists), errors.As(err, &primaryKey),
+		errors.As(err, &unique), errors.As(err, &foreignKey):
+		return http.StatusConflict
+	case errors.As(err, &timeout):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusBadRequest
+}